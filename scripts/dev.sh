@@ -12,6 +12,7 @@ sleep 5
 # 启动后端（开发模式）
 echo "🔧 启动后端服务..."
 cd server
+go run ./cmd/migrate up || exit 1
 go run main.go &
 BACKEND_PID=$!
 
@@ -22,6 +22,25 @@ if [ ! -f "$CONFIG_FILE" ]; then
     exit 1
 fi
 
+# 主密钥为空或仍是示例占位值时生成随机密钥写回配置文件，否则后端拒绝启动
+if ! python3 - "$CONFIG_FILE" <<'EOF'
+import json, secrets, sys
+path = sys.argv[1]
+with open(path, encoding="utf-8") as f:
+    config = json.load(f)
+security = config.setdefault("security", {})
+if security.get("jwt_secret", "") in ("", "your-jwt-secret-key"):
+    security["jwt_secret"] = secrets.token_hex(32)
+    with open(path, "w", encoding="utf-8") as f:
+        json.dump(config, f, ensure_ascii=False, indent=2)
+        f.write("\n")
+    print("已为 security.jwt_secret 生成随机密钥")
+EOF
+then
+    echo -e "${RED}错误: 无法生成 security.jwt_secret，请手动设置（可用 openssl rand -hex 32 生成）${NC}"
+    exit 1
+fi
+
 # 检查可执行文件是否存在
 if [ ! -f "$BINARY_NAME" ]; then
     echo -e "${RED}错误: 可执行文件 $BINARY_NAME 不存在${NC}"
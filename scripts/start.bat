@@ -64,6 +64,13 @@ if !errorlevel! equ 0 (
 )
 
 cd server
+REM 启动前先执行数据库迁移
+go run ./cmd/migrate up >> ..\%LOG_DIR%\backend.log 2>&1
+if !errorlevel! neq 0 (
+    echo %RED%数据库迁移失败，请查看 %LOG_DIR%\backend.log%NC%
+    cd ..
+    goto :eof
+)
 start /B go run main.go > ..\%LOG_DIR%\backend.log 2>&1
 for /f "tokens=2" %%i in ('tasklist /FI "IMAGENAME eq go.exe" /FO CSV ^| find "go.exe"') do (
     echo %%i > ..\%PID_DIR%\backend.pid
@@ -0,0 +1,148 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 批量导入工具
+ */
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"negaihoshi/server/config"
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "config/config.json", "后端配置文件路径")
+		format     = flag.String("format", "", "导入格式: negaihoshi/csv/twitter")
+		user       = flag.String("user", "", "所有记录都导入到该用户名下")
+		userMap    = flag.String("map", "", "来源用户名到本站用户名的映射，如 old=new,old2=new2")
+		dryRun     = flag.Bool("dry-run", false, "只检查不写入，报告将导入的数量和冲突")
+		help       = flag.Bool("help", false, "显示帮助信息")
+	)
+	flag.Parse()
+
+	if *help || flag.NArg() == 0 {
+		showHelp()
+		return
+	}
+
+	mapping, err := service.ParseImportUserMap(*userMap)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("读取导入文件失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	serverConfig := config.ConfigFunction{}
+	if err := serverConfig.ReadConfiguration(*configPath); err != nil {
+		fmt.Printf("读取配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	db, _, err := storage.Open(serverConfig.GetDatabaseConfig())
+	if err != nil {
+		fmt.Printf("数据库连接失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	svc := service.NewImportService(
+		repository.NewImportRepository(dao.NewImportDAO(db), openCache(&serverConfig)),
+		repository.NewUserRepository(dao.NewUserDAO(db)),
+		nil,
+	)
+	report, err := svc.Import(context.Background(), domain.ImportOptions{
+		Format:   *format,
+		DryRun:   *dryRun,
+		Username: *user,
+		UserMap:  mapping,
+	}, data)
+	printReport(report)
+	if err != nil {
+		fmt.Printf("导入失败: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printReport(report domain.ImportReport) {
+	if report.DryRun {
+		fmt.Println("dry-run 模式，未写入任何数据")
+	}
+	fmt.Printf("读取记录: %d\n", report.Total)
+	for _, kind := range []string{dao.ImportTargetTreeHole, dao.ImportTargetStatus, dao.ImportTargetPost} {
+		if n := report.Created[kind]; n > 0 {
+			fmt.Printf("导入 %s: %d\n", kind, n)
+		}
+	}
+	fmt.Printf("跳过: %d\n", report.Skipped)
+	fmt.Printf("冲突: %d\n", report.ConflictCount)
+	for _, c := range report.Conflicts {
+		line, _ := json.Marshal(c)
+		fmt.Printf("  %s\n", line)
+	}
+	if report.ConflictCount > len(report.Conflicts) {
+		fmt.Printf("  ... 其余 %d 条冲突未列出\n", report.ConflictCount-len(report.Conflicts))
+	}
+}
+
+func showHelp() {
+	fmt.Println("Negaihoshi 批量导入工具")
+	fmt.Println()
+	fmt.Println("用法:")
+	fmt.Println("  import [选项] <文件>")
+	fmt.Println()
+	fmt.Println("格式:")
+	fmt.Println("  negaihoshi      本站数据导出的 ZIP 文件，导入树洞、动态和文章")
+	fmt.Println("  csv             树洞 CSV，表头包含 content，可选 id/username/email/ctime")
+	fmt.Println("  twitter         Twitter/X 存档 ZIP 或其中的 tweets.js，推文导入为动态")
+	fmt.Println()
+	fmt.Println("选项:")
+	fmt.Println("  -config string")
+	fmt.Println("        后端配置文件路径 (默认: config/config.json)")
+	fmt.Println("  -format string")
+	fmt.Println("        导入格式: negaihoshi/csv/twitter")
+	fmt.Println("  -user string")
+	fmt.Println("        所有记录都导入到该用户名下")
+	fmt.Println("  -map string")
+	fmt.Println("        来源用户名到本站用户名的映射，如 old=new,old2=new2")
+	fmt.Println("  -dry-run")
+	fmt.Println("        只检查不写入，报告将导入的数量和冲突")
+	fmt.Println("  -help")
+	fmt.Println("        显示帮助信息")
+	fmt.Println()
+	fmt.Println("示例:")
+	fmt.Println("  # 先检查再导入")
+	fmt.Println("  import -format csv -dry-run treeholes.csv")
+	fmt.Println("  import -format csv treeholes.csv")
+	fmt.Println()
+	fmt.Println("  # 把 Twitter 存档导入为 alice 的动态")
+	fmt.Println("  import -format twitter -user alice twitter-archive.zip")
+}
+
+// openCache 配置了 Redis 时导入后使服务端的树洞列表缓存失效；
+// 未配置时服务端使用进程内缓存，列表缓存在过期后自然更新
+func openCache(serverConfig *config.ConfigFunction) *cache.Cache {
+	host, port, password := serverConfig.GetRedisConfig()
+	if host == "" {
+		return nil
+	}
+	return cache.New(cache.NewRedisStore(redis.NewClient(&redis.Options{
+		Addr:     host + ":" + port,
+		Password: password,
+	})))
+}
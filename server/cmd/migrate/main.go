@@ -0,0 +1,157 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 09:00:00
+ * @Description: 数据库迁移工具
+ */
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/config"
+	"negaihoshi/server/src/migration"
+	"negaihoshi/server/src/storage"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "config/config.json", "后端配置文件路径")
+		dir        = flag.String("dir", "src/migration/sql", "create 命令生成迁移文件的根目录，每种数据库各生成一份")
+		help       = flag.Bool("help", false, "显示帮助信息")
+	)
+	flag.Parse()
+
+	if *help || flag.NArg() == 0 {
+		showHelp()
+		return
+	}
+
+	command := flag.Arg(0)
+	if command == "create" {
+		if flag.NArg() < 2 {
+			fmt.Println("请指定迁移名称，例如: migrate create add_user_avatar")
+			os.Exit(1)
+		}
+		files, err := migration.Create(*dir, flag.Arg(1))
+		if err != nil {
+			fmt.Printf("创建迁移文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		for _, file := range files {
+			fmt.Printf("已创建: %s\n", file)
+		}
+		return
+	}
+
+	serverConfig := config.ConfigFunction{}
+	if err := serverConfig.ReadConfiguration(*configPath); err != nil {
+		fmt.Printf("读取配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	db, driver, err := storage.Open(serverConfig.GetDatabaseConfig())
+	if err != nil {
+		fmt.Printf("数据库连接失败: %v\n", err)
+		os.Exit(1)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fmt.Printf("数据库连接失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migration.NewMigrator(sqlDB, driver)
+	if err != nil {
+		fmt.Printf("加载迁移文件失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("已执行: %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("迁移失败: %v\n", err)
+			os.Exit(1)
+		}
+		if len(done) == 0 {
+			fmt.Println("数据库结构已是最新")
+		}
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps <= 0 {
+				fmt.Println("回滚步数必须是正整数")
+				os.Exit(1)
+			}
+		}
+		done, err := migrator.Down(ctx, steps)
+		for _, m := range done {
+			fmt.Printf("已回滚: %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("回滚失败: %v\n", err)
+			os.Exit(1)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Printf("查询迁移状态失败: %v\n", err)
+			os.Exit(1)
+		}
+		for _, s := range statuses {
+			state := "未执行"
+			if s.Applied {
+				state = "已执行 " + time.UnixMilli(s.AppliedAt).Format("2006-01-02 15:04:05")
+			}
+			if s.Unknown {
+				state += " (程序中不存在该版本)"
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Printf("未知命令: %s\n\n", command)
+		showHelp()
+		os.Exit(1)
+	}
+}
+
+func showHelp() {
+	fmt.Println("Negaihoshi 数据库迁移工具")
+	fmt.Println()
+	fmt.Println("用法:")
+	fmt.Println("  migrate [选项] <命令> [参数]")
+	fmt.Println()
+	fmt.Println("命令:")
+	fmt.Println("  up              执行所有未执行的迁移")
+	fmt.Println("  down [步数]     回滚最近的迁移 (默认: 1)")
+	fmt.Println("  status          查看迁移执行情况")
+	fmt.Println("  create <名称>   生成新的 up/down 迁移文件")
+	fmt.Println()
+	fmt.Println("选项:")
+	fmt.Println("  -config string")
+	fmt.Println("        后端配置文件路径 (默认: config/config.json)")
+	fmt.Println("  -dir string")
+	fmt.Println("        create 命令生成迁移文件的根目录，每种数据库各生成一份 (默认: src/migration/sql)")
+	fmt.Println("  -help")
+	fmt.Println("        显示帮助信息")
+	fmt.Println()
+	fmt.Println("示例:")
+	fmt.Println("  # 升级到最新版本")
+	fmt.Println("  migrate up")
+	fmt.Println()
+	fmt.Println("  # 回滚最近两个迁移")
+	fmt.Println("  migrate down 2")
+	fmt.Println()
+	fmt.Println("  # 新建迁移")
+	fmt.Println("  migrate create add_post_tags")
+}
@@ -97,6 +97,12 @@ func main() {
 	for _, user := range users {
 		fmt.Printf("🔄 处理用户: %s (ID: %d)\n", user.Username, user.ID)
 
+		// 外部账号自动注册的用户没有密码，保持为空
+		if user.Password == "" {
+			fmt.Printf("   ⚠️  未设置密码，跳过\n")
+			continue
+		}
+
 		// 检查密码是否已经是加密的（简单判断）
 		if len(user.Password) > 50 && len(user.Password) < 200 {
 			fmt.Printf("   ⚠️  密码可能已经是加密的，跳过\n")
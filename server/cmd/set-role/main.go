@@ -0,0 +1,93 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 20:00:00
+ * @Description: 设置用户角色，用于指定管理员
+ */
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"negaihoshi/server/config"
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/storage"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "config/config.json", "后端配置文件路径")
+		role       = flag.String("role", domain.RoleAdmin, "要设置的角色: admin/user")
+		help       = flag.Bool("help", false, "显示帮助信息")
+	)
+	flag.Parse()
+
+	if *help || flag.NArg() == 0 {
+		showHelp()
+		return
+	}
+	if *role != domain.RoleAdmin && *role != domain.RoleUser {
+		fmt.Printf("不支持的角色: %s\n", *role)
+		os.Exit(1)
+	}
+
+	serverConfig := config.ConfigFunction{}
+	if err := serverConfig.ReadConfiguration(*configPath); err != nil {
+		fmt.Printf("读取配置失败: %v\n", err)
+		os.Exit(1)
+	}
+	db, _, err := storage.Open(serverConfig.GetDatabaseConfig())
+	if err != nil {
+		fmt.Printf("数据库连接失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	users := repository.NewUserRepository(dao.NewUserDAO(db))
+	user, err := users.FindByUsername(ctx, flag.Arg(0))
+	if errors.Is(err, dao.ErrUserNotFound) {
+		fmt.Printf("用户 %s 不存在\n", flag.Arg(0))
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("查询用户失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := users.UpdateRole(ctx, user.Id, *role); err != nil {
+		fmt.Printf("设置角色失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	audit := service.NewAuditService(repository.NewAuditLogRepository(dao.NewAuditLogDAO(db)), nil)
+	audit.Record(ctx, user.Id, domain.AuditActionRoleChange, "",
+		fmt.Sprintf("通过命令行把用户 %s 的角色从 %s 改为 %s", user.Username, user.Role, *role))
+	fmt.Printf("已把用户 %s 的角色设置为 %s\n", user.Username, *role)
+}
+
+func showHelp() {
+	fmt.Println("Negaihoshi 用户角色设置工具")
+	fmt.Println()
+	fmt.Println("用法:")
+	fmt.Println("  set-role [选项] <用户名>")
+	fmt.Println()
+	fmt.Println("选项:")
+	fmt.Println("  -config string")
+	fmt.Println("        后端配置文件路径 (默认: config/config.json)")
+	fmt.Println("  -role string")
+	fmt.Println("        要设置的角色: admin/user (默认: admin)")
+	fmt.Println("  -help")
+	fmt.Println("        显示帮助信息")
+	fmt.Println()
+	fmt.Println("示例:")
+	fmt.Println("  # 指定 alice 为管理员")
+	fmt.Println("  set-role alice")
+	fmt.Println()
+	fmt.Println("  # 撤销 alice 的管理员角色")
+	fmt.Println("  set-role -role user alice")
+}
@@ -165,3 +165,108 @@ func (c *ConfigFunction) IsApiDocsEnabled() bool {
 	}
 	return c.Config.ApiDocs.Enabled
 }
+
+func (c *ConfigFunction) GetRateLimitConfig() (int, int, map[string]RateLimitRule) {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return 0, 0, nil
+	}
+	return c.Config.RateLimit.RequestsPerMinute, c.Config.RateLimit.Burst, c.Config.RateLimit.Routes
+}
+
+func (c *ConfigFunction) GetJwtSecret() string {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return ""
+	}
+	return c.Config.Security.JwtSecret
+}
+
+func (c *ConfigFunction) GetTotpIssuer() string {
+	if IsZero(c.Config) || c.Config.Security.TotpIssuer == "" {
+		return "Negaihoshi"
+	}
+	return c.Config.Security.TotpIssuer
+}
+
+// GetAuthTokenTTL 返回访问令牌有效期（分钟）和刷新令牌有效期（天）
+func (c *ConfigFunction) GetAuthTokenTTL() (int, int) {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return 0, 0
+	}
+	return c.Config.Security.AccessTokenTTLMinutes, c.Config.Security.RefreshTokenTTLDays
+}
+
+func (c *ConfigFunction) GetMailConfig() MailConfig {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return MailConfig{}
+	}
+	return c.Config.Mail
+}
+
+func (c *ConfigFunction) IsUnverifiedPostAllowed() bool {
+	if IsZero(c.Config) {
+		return true
+	}
+	return c.Config.Account.AllowUnverifiedPost
+}
+
+func (c *ConfigFunction) GetLoginProtectionConfig() LoginProtectionConfig {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return LoginProtectionConfig{}
+	}
+	return c.Config.LoginProtection
+}
+
+func (c *ConfigFunction) GetReportHideThreshold() int {
+	if IsZero(c.Config) {
+		return 0
+	}
+	return c.Config.Moderation.ReportHideThreshold
+}
+
+func (c *ConfigFunction) GetBlockedWords() []string {
+	if IsZero(c.Config) {
+		return nil
+	}
+	return c.Config.Moderation.BlockedWords
+}
+
+func (c *ConfigFunction) GetTrashRetentionDays() int {
+	if IsZero(c.Config) {
+		return 0
+	}
+	return c.Config.Moderation.TrashRetentionDays
+}
+
+func (c *ConfigFunction) GetExportConfig() ExportConfig {
+	if IsZero(c.Config) {
+		return ExportConfig{}
+	}
+	return c.Config.Export
+}
+
+func (c *ConfigFunction) GetCacheConfig() CacheConfig {
+	if IsZero(c.Config) {
+		return CacheConfig{}
+	}
+	return c.Config.Cache
+}
+
+func (c *ConfigFunction) GetMetricsToken() string {
+	if IsZero(c.Config) {
+		return ""
+	}
+	return c.Config.Metrics.Token
+}
+
+func (c *ConfigFunction) GetOIDCConfig() OIDCConfig {
+	if IsZero(c.Config) {
+		fmt.Println("config 未被赋值")
+		return OIDCConfig{}
+	}
+	return c.Config.OIDC
+}
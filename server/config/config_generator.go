@@ -9,6 +9,8 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -76,14 +78,29 @@ type GlobalConfig struct {
 		ApiDocs              bool `json:"api_docs"`
 		AdminPanel           bool `json:"admin_panel"`
 		WordpressIntegration bool `json:"wordpress_integration"`
+		AllowUnverifiedPost  bool `json:"allow_unverified_post"`
 	} `json:"features"`
+	Mail struct {
+		Driver      string `json:"driver"`
+		Host        string `json:"host"`
+		Port        int    `json:"port"`
+		Username    string `json:"username"`
+		Password    string `json:"password"`
+		From        string `json:"from"`
+		FileDir     string `json:"file_dir"`
+		LinkBaseURL string `json:"link_base_url"`
+	} `json:"mail"`
 	Limits struct {
-		MaxPostLength     int `json:"max_post_length"`
-		MaxUsernameLength int `json:"max_username_length"`
-		MaxEmailLength    int `json:"max_email_length"`
-		RateLimit         struct {
-			RequestsPerMinute int `json:"requests_per_minute"`
-			Burst             int `json:"burst"`
+		MaxPostLength      int      `json:"max_post_length"`
+		MaxUsernameLength  int      `json:"max_username_length"`
+		MaxEmailLength     int      `json:"max_email_length"`
+		ReportThreshold    int      `json:"report_threshold"`
+		BlockedWords       []string `json:"blocked_words"`
+		TrashRetentionDays int      `json:"trash_retention_days"`
+		RateLimit          struct {
+			RequestsPerMinute int                       `json:"requests_per_minute"`
+			Burst             int                       `json:"burst"`
+			Routes            map[string]GlobalRateRule `json:"routes"`
 		} `json:"rate_limit"`
 	} `json:"limits"`
 	Logging struct {
@@ -98,7 +115,56 @@ type GlobalConfig struct {
 		RequireSpecialChars bool   `json:"require_special_chars"`
 		JwtSecret           string `json:"jwt_secret"`
 		BcryptCost          int    `json:"bcrypt_cost"`
+		TotpIssuer          string `json:"totp_issuer"`
+		AccessTokenTTL      int    `json:"access_token_ttl_minutes"`
+		RefreshTokenTTL     int    `json:"refresh_token_ttl_days"`
+		LoginProtection     struct {
+			CaptchaThreshold     int `json:"captcha_threshold"`
+			LockThreshold        int `json:"lock_threshold"`
+			IPLockThreshold      int `json:"ip_lock_threshold"`
+			FailureWindowSeconds int `json:"failure_window_seconds"`
+			BaseLockSeconds      int `json:"base_lock_seconds"`
+			MaxLockSeconds       int `json:"max_lock_seconds"`
+			Captcha              struct {
+				VerifyURL string `json:"verify_url"`
+				Secret    string `json:"secret"`
+			} `json:"captcha"`
+		} `json:"login_protection"`
 	} `json:"security"`
+	OIDC struct {
+		AutoProvision    bool                 `json:"auto_provision"`
+		FrontendRedirect string               `json:"frontend_redirect"`
+		Providers        []GlobalOIDCProvider `json:"providers"`
+	} `json:"oidc"`
+	Export struct {
+		Dir          string `json:"dir"`
+		LinkTTLHours int    `json:"link_ttl_hours"`
+	} `json:"export"`
+	Cache struct {
+		MemoryEntries  int `json:"memory_entries"`
+		ItemTTLSeconds int `json:"item_ttl_seconds"`
+		ListTTLSeconds int `json:"list_ttl_seconds"`
+	} `json:"cache"`
+	Metrics struct {
+		Token string `json:"token"`
+	} `json:"metrics"`
+}
+
+// GlobalOIDCProvider 全局配置中的 OIDC 登录提供方
+type GlobalOIDCProvider struct {
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"display_name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+}
+
+// GlobalRateRule 全局配置中按路径覆盖的限流规则
+type GlobalRateRule struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
 }
 
 // ConfigGenerator 配置文件生成器
@@ -200,6 +266,78 @@ func (cg *ConfigGenerator) convertToBackendConfig(global *GlobalConfig) *Config
 	backend.Redis.Port = fmt.Sprintf("%d", global.Redis.Port)
 	backend.Redis.Password = global.Redis.Password
 
+	// 转换限流配置
+	backend.RateLimit.RequestsPerMinute = global.Limits.RateLimit.RequestsPerMinute
+	backend.RateLimit.Burst = global.Limits.RateLimit.Burst
+	backend.RateLimit.Routes = make(map[string]RateLimitRule)
+	for path, rule := range global.Limits.RateLimit.Routes {
+		backend.RateLimit.Routes[path] = RateLimitRule{
+			RequestsPerMinute: rule.RequestsPerMinute,
+			Burst:             rule.Burst,
+		}
+	}
+
+	// 转换安全配置
+	backend.Security.JwtSecret = global.Security.JwtSecret
+	backend.Security.TotpIssuer = global.Security.TotpIssuer
+	backend.Security.AccessTokenTTLMinutes = global.Security.AccessTokenTTL
+	backend.Security.RefreshTokenTTLDays = global.Security.RefreshTokenTTL
+
+	// 转换邮件配置
+	backend.Mail.Driver = global.Mail.Driver
+	backend.Mail.Host = global.Mail.Host
+	backend.Mail.Port = fmt.Sprintf("%d", global.Mail.Port)
+	backend.Mail.Username = global.Mail.Username
+	backend.Mail.Password = global.Mail.Password
+	backend.Mail.From = global.Mail.From
+	backend.Mail.FileDir = global.Mail.FileDir
+	backend.Mail.LinkBaseURL = global.Mail.LinkBaseURL
+	backend.Account.AllowUnverifiedPost = global.Features.AllowUnverifiedPost
+
+	// 转换登录防护配置
+	loginProtection := global.Security.LoginProtection
+	backend.LoginProtection.CaptchaThreshold = loginProtection.CaptchaThreshold
+	backend.LoginProtection.LockThreshold = loginProtection.LockThreshold
+	backend.LoginProtection.IPLockThreshold = loginProtection.IPLockThreshold
+	backend.LoginProtection.FailureWindowSeconds = loginProtection.FailureWindowSeconds
+	backend.LoginProtection.BaseLockSeconds = loginProtection.BaseLockSeconds
+	backend.LoginProtection.MaxLockSeconds = loginProtection.MaxLockSeconds
+	backend.LoginProtection.Captcha.VerifyURL = loginProtection.Captcha.VerifyURL
+	backend.LoginProtection.Captcha.Secret = loginProtection.Captcha.Secret
+
+	// 转换内容审核配置
+	backend.Moderation.ReportHideThreshold = global.Limits.ReportThreshold
+	backend.Moderation.BlockedWords = global.Limits.BlockedWords
+	backend.Moderation.TrashRetentionDays = global.Limits.TrashRetentionDays
+
+	// 转换第三方登录配置
+	backend.OIDC.AutoProvision = global.OIDC.AutoProvision
+	backend.OIDC.FrontendRedirect = global.OIDC.FrontendRedirect
+	backend.OIDC.Providers = make([]OIDCProviderConfig, 0, len(global.OIDC.Providers))
+	for _, provider := range global.OIDC.Providers {
+		backend.OIDC.Providers = append(backend.OIDC.Providers, OIDCProviderConfig{
+			Name:         provider.Name,
+			DisplayName:  provider.DisplayName,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		})
+	}
+
+	// 转换数据导出配置
+	backend.Export.Dir = global.Export.Dir
+	backend.Export.LinkTTLHours = global.Export.LinkTTLHours
+
+	// 转换缓存配置
+	backend.Cache.MemoryEntries = global.Cache.MemoryEntries
+	backend.Cache.ItemTTLSeconds = global.Cache.ItemTTLSeconds
+	backend.Cache.ListTTLSeconds = global.Cache.ListTTLSeconds
+
+	// 转换监控指标配置
+	backend.Metrics.Token = global.Metrics.Token
+
 	return backend
 }
 
@@ -282,12 +420,27 @@ func (cg *ConfigGenerator) generateDefaultConfig() error {
 	defaultGlobalConfig.Features.ApiDocs = true
 	defaultGlobalConfig.Features.AdminPanel = true
 	defaultGlobalConfig.Features.WordpressIntegration = true
+	defaultGlobalConfig.Features.AllowUnverifiedPost = true
+
+	defaultGlobalConfig.Mail.Driver = "log"
+	defaultGlobalConfig.Mail.Port = 587
+	defaultGlobalConfig.Mail.From = "noreply@negaihoshi.com"
+	defaultGlobalConfig.Mail.FileDir = "logs/mail"
+	defaultGlobalConfig.Mail.LinkBaseURL = "http://localhost:3000"
 
 	defaultGlobalConfig.Limits.MaxPostLength = 1000
 	defaultGlobalConfig.Limits.MaxUsernameLength = 50
 	defaultGlobalConfig.Limits.MaxEmailLength = 100
+	defaultGlobalConfig.Limits.ReportThreshold = 5
+	defaultGlobalConfig.Limits.BlockedWords = []string{}
+	defaultGlobalConfig.Limits.TrashRetentionDays = 30
 	defaultGlobalConfig.Limits.RateLimit.RequestsPerMinute = 60
 	defaultGlobalConfig.Limits.RateLimit.Burst = 10
+	defaultGlobalConfig.Limits.RateLimit.Routes = map[string]GlobalRateRule{
+		"/api/users/login":  {RequestsPerMinute: 10, Burst: 5},
+		"/api/users/signup": {RequestsPerMinute: 5, Burst: 3},
+		"/api/auth/token":   {RequestsPerMinute: 10, Burst: 5},
+	}
 
 	defaultGlobalConfig.Logging.Level = "info"
 	defaultGlobalConfig.Logging.File = "logs/app.log"
@@ -297,8 +450,33 @@ func (cg *ConfigGenerator) generateDefaultConfig() error {
 
 	defaultGlobalConfig.Security.PasswordMinLength = 8
 	defaultGlobalConfig.Security.RequireSpecialChars = true
-	defaultGlobalConfig.Security.JwtSecret = "your-jwt-secret-key"
+	// 每次生成随机密钥，后端拒绝使用过短或示例中的占位密钥启动
+	jwtSecret, err := randomSecret()
+	if err != nil {
+		return err
+	}
+	defaultGlobalConfig.Security.JwtSecret = jwtSecret
 	defaultGlobalConfig.Security.BcryptCost = 12
+	defaultGlobalConfig.Security.TotpIssuer = "Negaihoshi"
+	defaultGlobalConfig.Security.AccessTokenTTL = 15
+	defaultGlobalConfig.Security.RefreshTokenTTL = 30
+	defaultGlobalConfig.Security.LoginProtection.CaptchaThreshold = 3
+	defaultGlobalConfig.Security.LoginProtection.LockThreshold = 5
+	defaultGlobalConfig.Security.LoginProtection.IPLockThreshold = 20
+	defaultGlobalConfig.Security.LoginProtection.FailureWindowSeconds = 900
+	defaultGlobalConfig.Security.LoginProtection.BaseLockSeconds = 60
+	defaultGlobalConfig.Security.LoginProtection.MaxLockSeconds = 3600
+
+	defaultGlobalConfig.OIDC.AutoProvision = true
+	defaultGlobalConfig.OIDC.FrontendRedirect = "http://localhost:3000/oauth/callback"
+	defaultGlobalConfig.OIDC.Providers = []GlobalOIDCProvider{}
+
+	defaultGlobalConfig.Export.Dir = "data/exports"
+	defaultGlobalConfig.Export.LinkTTLHours = 72
+
+	defaultGlobalConfig.Cache.MemoryEntries = 10000
+	defaultGlobalConfig.Cache.ItemTTLSeconds = 300
+	defaultGlobalConfig.Cache.ListTTLSeconds = 30
 
 	// 确保全局配置文件目录存在
 	globalConfigDir := filepath.Dir(cg.globalConfigPath)
@@ -321,3 +499,12 @@ func (cg *ConfigGenerator) generateDefaultConfig() error {
 	// 生成后端配置文件
 	return cg.GenerateConfig()
 }
+
+// randomSecret 生成 32 字节随机数的十六进制表示
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机密钥失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -34,4 +34,108 @@ type Config struct {
 		Port     string `json:"port"`
 		Password string `json:"password"`
 	} `json:"redis"`
+	RateLimit struct {
+		RequestsPerMinute int `json:"requests-per-minute"`
+		Burst             int `json:"burst"`
+		// 按路径覆盖的限流规则，如对登录接口设置更严格的限制
+		Routes map[string]RateLimitRule `json:"routes"`
+	} `json:"rate-limit"`
+	Security struct {
+		JwtSecret string `json:"jwt-secret"`
+		// 两步验证认证器中显示的发行方名称
+		TotpIssuer string `json:"totp-issuer"`
+		// API 访问令牌和刷新令牌有效期
+		AccessTokenTTLMinutes int `json:"access-token-ttl-minutes"`
+		RefreshTokenTTLDays   int `json:"refresh-token-ttl-days"`
+	} `json:"security"`
+	Mail    MailConfig `json:"mail"`
+	Account struct {
+		// 是否允许未验证邮箱的用户发布树洞
+		AllowUnverifiedPost bool `json:"allow-unverified-post"`
+	} `json:"account"`
+	LoginProtection LoginProtectionConfig `json:"login-protection"`
+	Moderation      struct {
+		ReportHideThreshold int `json:"report-hide-threshold"`
+		// 树洞和私信中不允许出现的词语，匹配时忽略大小写和空白
+		BlockedWords []string `json:"blocked-words"`
+		// 回收站中内容的保留天数，超过后永久删除，未配置时为 30 天
+		TrashRetentionDays int `json:"trash-retention-days"`
+	} `json:"moderation"`
+	OIDC    OIDCConfig    `json:"oidc"`
+	Export  ExportConfig  `json:"export"`
+	Cache   CacheConfig   `json:"cache"`
+	Metrics MetricsConfig `json:"metrics"`
+}
+
+type RateLimitRule struct {
+	RequestsPerMinute int `json:"requests-per-minute"`
+	Burst             int `json:"burst"`
+}
+
+type LoginProtectionConfig struct {
+	CaptchaThreshold     int `json:"captcha-threshold"`
+	LockThreshold        int `json:"lock-threshold"`
+	IPLockThreshold      int `json:"ip-lock-threshold"`
+	FailureWindowSeconds int `json:"failure-window-seconds"`
+	BaseLockSeconds      int `json:"base-lock-seconds"`
+	MaxLockSeconds       int `json:"max-lock-seconds"`
+	Captcha              struct {
+		// 兼容 reCAPTCHA / hCaptcha / Turnstile 的 siteverify 地址，为空时不启用
+		VerifyURL string `json:"verify-url"`
+		Secret    string `json:"secret"`
+	} `json:"captcha"`
+}
+
+type MailConfig struct {
+	// smtp | file | log，未配置时为 log
+	Driver   string `json:"driver"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+	// driver 为 file 时邮件写入的目录
+	FileDir string `json:"file-dir"`
+	// 邮件中验证和重置链接指向的前端地址
+	LinkBaseURL string `json:"link-base-url"`
+}
+
+type ExportConfig struct {
+	// 导出文件保存的目录，未配置时为 data/exports
+	Dir string `json:"dir"`
+	// 下载链接的有效小时数，过期后删除导出文件，未配置时为 72 小时
+	LinkTTLHours int `json:"link-ttl-hours"`
+}
+
+type CacheConfig struct {
+	// 未配置 Redis 时进程内 LRU 缓存的最大条数，未配置时为 10000
+	MemoryEntries int `json:"memory-entries"`
+	// 单条树洞、动态、文章和用户资料的缓存秒数，未配置时为 300 秒
+	ItemTTLSeconds int `json:"item-ttl-seconds"`
+	// 树洞列表分页的缓存秒数，未配置时为 30 秒
+	ListTTLSeconds int `json:"list-ttl-seconds"`
+}
+
+type MetricsConfig struct {
+	// 不为空时采集 /metrics 需要携带 Authorization: Bearer <token>，为空时不校验
+	Token string `json:"token"`
+}
+
+type OIDCConfig struct {
+	// 首次使用外部账号登录时是否自动注册
+	AutoProvision bool `json:"auto-provision"`
+	// 登录回调完成后跳转的前端地址，为空时直接返回 JSON
+	FrontendRedirect string               `json:"frontend-redirect"`
+	Providers        []OIDCProviderConfig `json:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	// 提供方标识，出现在登录和回调路径中
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"display-name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client-id"`
+	ClientSecret string   `json:"client-secret"`
+	RedirectURL  string   `json:"redirect-url"`
+	Scopes       []string `json:"scopes"`
 }
@@ -9,14 +9,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"negaihoshi/server/config"
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/mail"
+	"negaihoshi/server/src/metrics"
+	"negaihoshi/server/src/migration"
+	"negaihoshi/server/src/oidc"
+	"negaihoshi/server/src/ratelimit"
+	"negaihoshi/server/src/realtime"
 	"negaihoshi/server/src/repository"
 	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/request"
+	"negaihoshi/server/src/security"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/storage"
+	"negaihoshi/server/src/timeline"
 	"negaihoshi/server/src/util"
 	"negaihoshi/server/src/web"
 	"negaihoshi/server/src/web/middleware"
+	"net/http"
 	"strings"
 	"time"
 
@@ -24,7 +39,7 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/mysql"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
@@ -34,20 +49,82 @@ func main() {
 		panic(err)
 	}
 
+	keys := initKeys(&serverConfig)
 	db := initDB(&serverConfig)
-	u, userService := initUser(db)
-	t, treeholeService := initTreeHole(db)
-	s, statusService := initPersonalTextStatus(db)
+	redisClient := initRedis(&serverConfig)
+	appCache := initCache(&serverConfig, redisClient)
+	treeholeRepo, contentRepo := initContentRepositories(db, &serverConfig, appCache)
+	appMetrics := initMetrics(db, appCache)
+	notificationService := initNotification(db)
+	hub := initRealtime(redisClient)
+	auditService := initAudit(db, notificationService)
+	mailer := initMailer(&serverConfig)
+	sessionService := initSession(db)
+	authTokenService := initAuthTokens(db, &serverConfig, keys, auditService)
+	twoFactorService := initTwoFactor(db, &serverConfig, keys, notificationService)
+	personalTokenService := initPersonalToken(db)
+	webhookService := initWebhook(db, keys)
+	// 业务事件先计数再交给 webhook 投递
+	events := service.NewCountingPublisher(webhookService, appMetrics)
+	u, userService := initUser(db, &serverConfig, keys, redisClient, appCache, appMetrics, auditService, mailer, sessionService, twoFactorService, events, notificationService)
+	tf := web.NewTwoFactorHandler(userService, twoFactorService, sessionService)
+	auth := web.NewAuthHandler(userService, authTokenService)
+	oidcHandler := initOIDC(db, &serverConfig, userService, sessionService, auditService)
+	pat := web.NewPersonalTokenHandler(personalTokenService)
+	contentFilter := service.NewContentFilter(serverConfig.GetBlockedWords())
+	visibility := initVisibility(db)
+	t, treeholeService := initTreeHole(db, &serverConfig, treeholeRepo, events, notificationService, hub, contentFilter, visibility)
+	timelineService := initTimeline(db, redisClient, contentRepo, visibility)
+	bh, blockService := initBlock(db, timelineService, hub)
+	crossPoster := initCrossPost(db, treeholeRepo, contentRepo, notificationService, appMetrics)
+	s, statusService := initPersonalTextStatus(contentRepo, events, notificationService, timelineService, visibility, crossPoster, appMetrics)
+	wp := web.NewWordPressHandler(userService, notificationService, crossPoster)
+	fh := initFollow(db, blockService, timelineService)
+	rp, reportService := initReport(db, &serverConfig, appCache, events, notificationService)
+	wh := web.NewWebhookHandler(webhookService)
+	nh := web.NewNotificationHandler(notificationService)
+	rt := web.NewRealtimeHandler(hub, visibility, allowOrigin(&serverConfig))
+	mh := initMessage(db, blockService, contentFilter, hub)
 	apiDocs := initAPIDocsHandler(&serverConfig)
-	admin := initAdminHandler(userService, treeholeService, statusService)
-	r := initWebServer(&serverConfig)
+	th, trashService := initTrash(&serverConfig, treeholeRepo, contentRepo, auditService)
+	eh, exportService := initDataExport(db, &serverConfig, keys, treeholeRepo, contentRepo, notificationService)
+	admin := initAdminHandler(userService, treeholeService, statusService, reportService, auditService, twoFactorService, trashService, initImport(db, appCache, auditService), appCache)
+	health := initHealth(db, redisClient)
+	mt := web.NewMetricsHandler(appMetrics, serverConfig.GetMetricsToken())
+	r := initWebServer(&serverConfig, appMetrics, initRateLimiter(redisClient), sessionService, authTokenService, personalTokenService, userService)
 
 	// 注册路由
 	u.RegisterUserRoutes(r)
+	tf.RegisterTwoFactorRoutes(r)
+	auth.RegisterAuthRoutes(r)
+	pat.RegisterPersonalTokenRoutes(r)
+	oidcHandler.RegisterOIDCRoutes(r)
 	t.RegisterTreeHoleRoutes(r)
 	s.RegisterStatusAndPostsRoutes(r)
+	s.RegisterRevisionRoutes(r)
+	rp.RegisterReportRoutes(r)
 	apiDocs.RegisterAPIDocsRoutes(r)
 	admin.RegisterAdminRoutes(r)
+	wh.RegisterWebhookRoutes(r)
+	nh.RegisterNotificationRoutes(r)
+	rt.RegisterRealtimeRoutes(r)
+	bh.RegisterBlockRoutes(r)
+	mh.RegisterMessageRoutes(r)
+	fh.RegisterFollowRoutes(r)
+	th.RegisterTrashRoutes(r)
+	wp.RegisterWordPressRoutes(r)
+	eh.RegisterDataExportRoutes(r)
+	health.RegisterHealthRoutes(r)
+	mt.RegisterMetricsRoutes(r)
+
+	// 后台投递 webhook，失败的投递按退避时间重试
+	go webhookService.Run(context.Background())
+	// 定期永久删除回收站中超过保留期的内容
+	go trashService.Run(context.Background())
+	// 定时发布到期的文章
+	go statusService.RunScheduler(context.Background())
+	// 生成用户申请的数据导出并删除过期的导出文件
+	go exportService.Run(context.Background())
 
 	r.Static("/assets", "./assets")
 	r.StaticFile("/favicon.ico", "./assets/favicon.ico")
@@ -70,102 +147,434 @@ func initConfig() (config.ConfigFunction, error) {
 	return serverConfig, nil
 }
 
-func initWebServer(config *config.ConfigFunction) *gin.Engine {
+func initWebServer(config *config.ConfigFunction, appMetrics *metrics.Metrics, limiter ratelimit.Limiter, sessionService *service.SessionService, authTokenService *service.AuthTokenService, personalTokenService *service.PersonalTokenService, userService *service.UserService) *gin.Engine {
 	r := gin.Default()
-	frontendPrefix := config.GetFrontendPrefix()
+	// 放在最前面，被限流和未登录拦截的请求同样计入
+	r.Use(middleware.NewMetricsMiddleware(appMetrics, web.RealtimeRoutes()...))
 	r.Use(cors.New(cors.Config{
 		AllowHeaders:     []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
-		AllowOriginFunc: func(origin string) bool {
-			if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
-				return true
-			}
-			return strings.HasPrefix(origin, frontendPrefix[0])
-		},
-		MaxAge: 12 * time.Hour,
+		AllowOriginFunc:  allowOrigin(config),
+		MaxAge:           12 * time.Hour,
 	}))
 	store := cookie.NewStore([]byte("secret"))
 	r.Use(sessions.Sessions("ssid", store))
-	r.Use(middleware.NewLoginMiddlewareBuilder().
+	r.Use(middleware.NewLoginMiddlewareBuilder(sessionService, authTokenService).
 		IgnorePaths("/api/users/signup").
 		IgnorePaths("/api/users/login").
+		IgnorePaths("/api/users/verify-email").
+		IgnorePaths("/api/users/forgot-password").
+		IgnorePaths("/api/users/reset-password").
+		IgnorePaths("/api/auth/token").
+		IgnorePaths("/api/auth/revoke").
+		IgnorePaths("/api/auth/oidc/*").
 		IgnorePaths("/").
 		IgnorePaths("/favicon.ico").
 		IgnorePaths("/api/treehole/list").
 		IgnorePaths("/api/treehole/list/*").
+		IgnorePaths("/api/treehole/stream").
+		IgnorePaths("/api/treehole/ws").
 		IgnorePaths("/api/docs").
 		IgnorePaths("/api/test").
 		IgnorePaths("/api/docs/json").
 		IgnorePaths("/api/test/execute").
 		IgnorePaths("/admin").
 		IgnorePaths("/admin/*").
+		IgnorePaths("/api/exports/download/*").
+		IgnorePaths("/api/health").
+		IgnorePaths("/api/ready").
+		IgnorePaths("/metrics").
+		AllowPending("/api/users/logout").
+		AllowPending("/api/users/2fa").
+		AllowPending("/api/users/2fa/setup").
+		AllowPending("/api/users/2fa/enable").
+		AllowPending("/api/users/2fa/verify").
+		// 个人访问令牌只能访问下面声明了权限范围的接口
+		BearerVerifier(service.PersonalTokenPrefix, personalTokenService).
+		RouteScope(http.MethodPost, "/api/treehole/create", domain.ScopeTreeholeWrite).
+		RouteScope(http.MethodDelete, "/api/treehole/:id", domain.ScopeTreeholeWrite).
+		RouteScope(http.MethodGet, "/api/posts/view/:id", domain.ScopeStatusRead).
+		RouteScope(http.MethodGet, "/api/posts/:uid", domain.ScopeStatusRead).
+		RouteScope(http.MethodGet, "/api/posts/listAll", domain.ScopeStatusRead).
+		RouteScope(http.MethodGet, "/api/timeline/home", domain.ScopeStatusRead).
+		RouteScope(http.MethodPost, "/api/wordpress/transfer", domain.ScopeWordpressTransfer).
+		PrefixScope("/api/admin/", domain.ScopeAdminAll).
 		Build())
+	// 管理后台接口只允许管理员访问，个人访问令牌还需要 admin:* 权限范围
+	r.Use(middleware.NewAdminMiddleware("/api/admin/", userService))
+	// 放在登录中间件之后，按认证后的用户限流；登录、注册等接口在忽略列表中，仍会按IP限流
+	r.Use(initRateLimitMiddleware(config, limiter))
 	return r
 }
 
-func initDB(config *config.ConfigFunction) *gorm.DB {
-	_, dbHost, dbPort, dbUser, dbPassword, dbDatabaseName := config.GetDatabaseConfig()
-	db, err := gorm.Open(mysql.Open(dbUser + ":" + dbPassword + "@tcp(" + dbHost + ":" + dbPort + ")/" + dbDatabaseName + "?charset=utf8mb4&parseTime=True&loc=Local"))
+// allowOrigin 跨域请求和 WebSocket 连接允许的来源
+func allowOrigin(config *config.ConfigFunction) func(origin string) bool {
+	frontendPrefix := config.GetFrontendPrefix()
+	return func(origin string) bool {
+		if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
+			return true
+		}
+		return strings.HasPrefix(origin, frontendPrefix[0])
+	}
+}
+
+func initRateLimitMiddleware(config *config.ConfigFunction, limiter ratelimit.Limiter) gin.HandlerFunc {
+	requestsPerMinute, burst, routes := config.GetRateLimitConfig()
+	builder := middleware.NewRateLimitMiddlewareBuilder(limiter, ratelimit.Rate{
+		RequestsPerMinute: requestsPerMinute,
+		Burst:             burst,
+	})
+	for path, rule := range routes {
+		builder.RouteRate(path, ratelimit.Rate{
+			RequestsPerMinute: rule.RequestsPerMinute,
+			Burst:             rule.Burst,
+		})
+	}
+	return builder.Build()
+}
+
+// 未配置Redis时返回nil，各组件退化为内存实现
+func initRedis(config *config.ConfigFunction) redis.UniversalClient {
+	host, port, password := config.GetRedisConfig()
+	if host == "" {
+		return nil
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:     host + ":" + port,
+		Password: password,
+	})
+}
+
+// 配置了 Redis 时通过 Redis 发布订阅，多个实例的订阅方都能收到推送
+func initRealtime(redisClient redis.UniversalClient) realtime.Hub {
+	if redisClient == nil {
+		return realtime.NewMemoryHub()
+	}
+	hub := realtime.NewRedisHub(redisClient)
+	go hub.Run(context.Background())
+	return hub
+}
+
+// 配置了 Redis 时缓存在 Redis 中，多个实例共享，写入时的失效对所有实例生效
+func initCache(config *config.ConfigFunction, redisClient redis.UniversalClient) *cache.Cache {
+	if redisClient == nil {
+		return cache.New(cache.NewMemoryStore(config.GetCacheConfig().MemoryEntries))
+	}
+	return cache.New(cache.NewRedisStore(redisClient))
+}
+
+// 各服务共用同一份带缓存的树洞、动态和文章仓储，任何服务的修改都会删除对应的缓存
+func initContentRepositories(db *gorm.DB, config *config.ConfigFunction, c *cache.Cache) (repository.TreeHoleRepository, repository.StatusAndPostsRepository) {
+	cacheConfig := config.GetCacheConfig()
+	itemTTL := cacheTTL(cacheConfig.ItemTTLSeconds, 5*time.Minute)
+	treeholes := repository.NewCachedTreeHoleRepository(repository.NewTreeHoleRepository(dao.NewTreeHoleDAO(db)),
+		c, itemTTL, cacheTTL(cacheConfig.ListTTLSeconds, 30*time.Second))
+	content := repository.NewCachedStatusAndPostsRepository(
+		repository.NewStatusAndPostsRepository(dao.NewStatusDAO(db), dao.NewPostsDAO(db), dao.NewRevisionDAO(db)), c, itemTTL)
+	return treeholes, content
+}
+
+// cacheTTL 未配置缓存时间时使用默认值
+func cacheTTL(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// 导出数据库连接池和缓存命中统计，其余指标由各服务记录
+func initMetrics(db *gorm.DB, c *cache.Cache) *metrics.Metrics {
+	m := metrics.New()
+	sqlDB, err := db.DB()
 	if err != nil {
-		// panic相当于整个goroutine结束
 		panic(err)
 	}
+	m.RegisterDB(sqlDB, "main")
+	m.RegisterCache(c)
+	return m
+}
 
-	// 初始化用户表（包含新的个人资料字段）
-	err = dao.InitUserTable(db)
+// 就绪检查数据库和已配置的 Redis
+func initHealth(db *gorm.DB, redisClient redis.UniversalClient) *web.HealthHandler {
+	sqlDB, err := db.DB()
 	if err != nil {
 		panic(err)
 	}
+	checks := []web.ReadinessCheck{{Name: "database", Check: sqlDB.PingContext}}
+	if redisClient != nil {
+		checks = append(checks, web.ReadinessCheck{Name: "redis", Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}})
+	}
+	return web.NewHealthHandler(checks...)
+}
+
+func initRateLimiter(redisClient redis.UniversalClient) ratelimit.Limiter {
+	if redisClient == nil {
+		return ratelimit.NewMemoryLimiter()
+	}
+	return ratelimit.NewRedisLimiter(redisClient)
+}
 
-	// 初始化其他表
-	err = dao.InitUserWordpressInfoTable(db)
+// 主密钥不安全时拒绝启动，各类令牌使用从主密钥派生的独立密钥签名
+func initKeys(config *config.ConfigFunction) *security.KeyRing {
+	keys, err := security.NewKeyRing(config.GetJwtSecret())
 	if err != nil {
 		panic(err)
 	}
-	err = dao.InitTreeHoleTable(db)
+	return keys
+}
+
+func initDB(config *config.ConfigFunction) *gorm.DB {
+	db, driver, err := storage.Open(config.GetDatabaseConfig())
 	if err != nil {
+		// panic相当于整个goroutine结束
 		panic(err)
 	}
-	return db
-}
 
-func initUser(db *gorm.DB) (*web.UserHandler, *service.UserService) {
-	// 从gorm.DB获取底层的sql.DB
+	// 表结构由 cmd/migrate 管理，存在未执行的迁移时拒绝启动
 	sqlDB, err := db.DB()
 	if err != nil {
 		panic(err)
 	}
+	migrator, err := migration.NewMigrator(sqlDB, driver)
+	if err != nil {
+		panic(err)
+	}
+	_, _, _, _, _, dbName := config.GetDatabaseConfig()
+	if driver == storage.SQLite && storage.IsMemory(dbName) {
+		// 内存数据库每次启动都是空库，直接执行迁移
+		_, err = migrator.Up(context.Background())
+	} else {
+		err = migrator.Check(context.Background())
+	}
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
 
-	// 初始化密码加密工具（使用配置中的密钥）
-	// 这里使用一个默认密钥，实际生产环境应该从配置文件读取
+func initNotification(db *gorm.DB) *service.NotificationService {
+	repo := repository.NewNotificationRepository(dao.NewNotificationDAO(db))
+	return service.NewNotificationService(repo)
+}
+
+func initAudit(db *gorm.DB, notifications *service.NotificationService) *service.AuditService {
+	repo := repository.NewAuditLogRepository(dao.NewAuditLogDAO(db))
+	return service.NewAuditService(repo, notifications)
+}
+
+// 未配置邮件驱动时只把邮件写入日志
+func initMailer(config *config.ConfigFunction) mail.Mailer {
+	mailConfig := config.GetMailConfig()
+	switch mailConfig.Driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mailConfig.Host, mailConfig.Port, mailConfig.Username, mailConfig.Password, mailConfig.From)
+	case "file":
+		return mail.NewFileMailer(mailConfig.FileDir, mailConfig.From)
+	default:
+		return mail.LogMailer{}
+	}
+}
+
+func initLoginGuard(config *config.ConfigFunction, redisClient redis.UniversalClient, audit *service.AuditService, mailer mail.Mailer) *service.LoginGuard {
+	protection := config.GetLoginProtectionConfig()
+
+	var store security.AttemptStore
+	if redisClient != nil {
+		store = security.NewRedisAttemptStore(redisClient)
+	} else {
+		memoryStore := security.NewMemoryAttemptStore()
+		go memoryStore.Run(context.Background())
+		store = memoryStore
+	}
+	// 未配置验证码服务时不要求人机验证
+	var captcha security.CaptchaVerifier
+	if protection.Captcha.VerifyURL != "" {
+		captcha = security.NewSiteVerifyCaptcha(protection.Captcha.VerifyURL, protection.Captcha.Secret)
+	}
+
+	return service.NewLoginGuard(store, captcha, service.NewMailSecurityNotifier(mailer), audit, service.LoginProtectionPolicy{
+		CaptchaThreshold: protection.CaptchaThreshold,
+		LockThreshold:    protection.LockThreshold,
+		IPLockThreshold:  protection.IPLockThreshold,
+		FailureWindow:    time.Duration(protection.FailureWindowSeconds) * time.Second,
+		BaseLockDuration: time.Duration(protection.BaseLockSeconds) * time.Second,
+		MaxLockDuration:  time.Duration(protection.MaxLockSeconds) * time.Second,
+	})
+}
+
+func initSession(db *gorm.DB) *service.SessionService {
+	repo := repository.NewUserSessionRepository(dao.NewUserSessionDAO(db))
+	refreshRepo := repository.NewRefreshTokenRepository(dao.NewRefreshTokenDAO(db))
+	tokenRepo := repository.NewPersonalTokenRepository(dao.NewPersonalTokenDAO(db))
+	return service.NewSessionService(repo, refreshRepo, tokenRepo)
+}
+
+func initAuthTokens(db *gorm.DB, config *config.ConfigFunction, keys *security.KeyRing, audit *service.AuditService) *service.AuthTokenService {
+	accessMinutes, refreshDays := config.GetAuthTokenTTL()
+	repo := repository.NewRefreshTokenRepository(dao.NewRefreshTokenDAO(db))
+	return service.NewAuthTokenService(
+		security.NewJWTSigner(keys.Derive(security.KeyPurposeJWT)),
+		repo,
+		audit,
+		time.Duration(accessMinutes)*time.Minute,
+		time.Duration(refreshDays)*24*time.Hour,
+	)
+}
+
+func initPersonalToken(db *gorm.DB) *service.PersonalTokenService {
+	repo := repository.NewPersonalTokenRepository(dao.NewPersonalTokenDAO(db))
+	return service.NewPersonalTokenService(repo, repository.NewUserRepository(dao.NewUserDAO(db)))
+}
+
+// 注册配置中的 OIDC 登录提供方，服务配置在首次登录时才拉取
+func initOIDC(db *gorm.DB, config *config.ConfigFunction, userService *service.UserService, sessionService *service.SessionService, audit *service.AuditService) *web.OIDCHandler {
+	oidcConfig := config.GetOIDCConfig()
+	identities := repository.NewUserIdentityRepository(dao.NewUserIdentityDAO(db))
+	userRepo := repository.NewUserRepository(dao.NewUserDAO(db))
+	oidcService := service.NewOIDCService(identities, userRepo, userService, audit, oidcConfig.AutoProvision)
+	for _, provider := range oidcConfig.Providers {
+		if provider.Name == "" || provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("OIDC 提供方 %q 配置不完整，已跳过", provider.Name)
+			continue
+		}
+		oidcService.Register(oidc.NewProvider(oidc.Config{
+			Name:         provider.Name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}), provider.DisplayName)
+	}
+	return web.NewOIDCHandler(oidcService, userService, sessionService, oidcConfig.FrontendRedirect)
+}
+
+func initWebhook(db *gorm.DB, keys *security.KeyRing) *service.WebhookService {
+	// 签名密钥使用主密钥派生的加密密钥，旧版本用固定密钥加密的数据在读取时迁移
+	crypto := util.NewPasswordCrypto(keys.Derive(security.KeyPurposeWebhook))
+	repo := repository.NewWebhookRepository(dao.NewWebhookDAO(db), crypto, initCrypto())
+	return service.NewWebhookService(repo)
+}
+
+// 初始化密码加密工具（使用配置中的密钥）
+// 这里使用一个默认密钥，实际生产环境应该从配置文件读取
+func initCrypto() *util.PasswordCrypto {
 	cryptoKey := []byte("negaihoshi-password-encryption-key-32bytes")
-	crypto := util.NewPasswordCrypto(cryptoKey)
+	return util.NewPasswordCrypto(cryptoKey)
+}
 
-	ud := dao.NewUserDAO(sqlDB)
+func initTwoFactor(db *gorm.DB, config *config.ConfigFunction, keys *security.KeyRing, notifications *service.NotificationService) *service.TwoFactorService {
+	// 认证器密钥使用主密钥派生的加密密钥，旧版本用固定密钥加密的数据在读取时迁移
+	crypto := util.NewPasswordCrypto(keys.Derive(security.KeyPurposeTOTP))
+	repo := repository.NewTwoFactorRepository(dao.NewTwoFactorDAO(db), crypto, initCrypto())
+	settings := repository.NewSettingRepository(dao.NewSettingDAO(db))
+	return service.NewTwoFactorService(repo, settings, config.GetTotpIssuer(), notifications)
+}
+
+func initUser(db *gorm.DB, config *config.ConfigFunction, keys *security.KeyRing, redisClient redis.UniversalClient, profiles *cache.Cache, appMetrics *metrics.Metrics, audit *service.AuditService, mailer mail.Mailer, sessionService *service.SessionService, twoFactorService *service.TwoFactorService, events service.EventPublisher, notifications *service.NotificationService) (*web.UserHandler, *service.UserService) {
+	crypto := initCrypto()
+
+	ud := dao.NewUserDAO(db)
 	repo := repository.NewUserRepository(ud)
-	svc := service.NewUserService(repo, crypto)
-	return web.NewUserHandler(svc), svc
+	tokens := service.NewUserTokenService(
+		security.NewTokenSigner(keys.Derive(security.KeyPurposeUserToken)),
+		repository.NewUserTokenRepository(dao.NewUserTokenDAO(db)),
+	)
+	guard := initLoginGuard(config, redisClient, audit, mailer)
+	svc := service.NewUserService(repo, crypto, guard, tokens, sessionService, twoFactorService, mailer, config.GetMailConfig().LinkBaseURL, events, notifications,
+		profiles, cacheTTL(config.GetCacheConfig().ItemTTLSeconds, 5*time.Minute), appMetrics)
+	return web.NewUserHandler(svc, sessionService), svc
 }
 
-func initTreeHole(db *gorm.DB) (*web.TreeHoleHandler, *service.TreeHoleService) {
-	td := dao.NewTreeHoleDAO(db)
-	repo := repository.NewTreeHoleRepository(td)
-	svc := service.NewTreeHoleService(repo)
+func initTreeHole(db *gorm.DB, config *config.ConfigFunction, repo repository.TreeHoleRepository, events service.EventPublisher, notifications *service.NotificationService, hub realtime.Hub, filter *service.ContentFilter, visibility *service.VisibilityFilter) (*web.TreeHoleHandler, *service.TreeHoleService) {
+	userRepo := repository.NewUserRepository(dao.NewUserDAO(db))
+	svc := service.NewTreeHoleService(repo, userRepo, config.IsUnverifiedPostAllowed(), events, notifications, hub, filter, visibility)
 	return web.NewTreeHoleHandler(svc), svc
 }
 
-func initPersonalTextStatus(db *gorm.DB) (*web.StatusAndPostsHandler, *service.StatusAndPostsService) {
-	sd := dao.NewStatusDAO(db)
-	pd := dao.NewPostsDAO(db)
-	repo := repository.NewStatusAndPostsRepository(sd, pd)
-	svc := service.NewStatusAndPostsService(repo)
-	return web.NewStatusAndPostsHandler(svc), svc
+// 屏蔽和静音关系的过滤在各内容服务中共用
+func initVisibility(db *gorm.DB) *service.VisibilityFilter {
+	return service.NewVisibilityFilter(repository.NewUserBlockRepository(dao.NewUserBlockDAO(db)))
+}
+
+func initBlock(db *gorm.DB, timeline *service.TimelineService, hub realtime.Hub) (*web.BlockHandler, *service.BlockService) {
+	repo := repository.NewUserBlockRepository(dao.NewUserBlockDAO(db))
+	userRepo := repository.NewUserRepository(dao.NewUserDAO(db))
+	follows := repository.NewFollowRepository(dao.NewFollowDAO(db))
+	svc := service.NewBlockService(repo, userRepo, follows, timeline, hub)
+	return web.NewBlockHandler(svc), svc
+}
+
+func initMessage(db *gorm.DB, blocks *service.BlockService, filter *service.ContentFilter, hub realtime.Hub) *web.MessageHandler {
+	repo := repository.NewMessageRepository(dao.NewMessageDAO(db))
+	userRepo := repository.NewUserRepository(dao.NewUserDAO(db))
+	svc := service.NewMessageService(repo, userRepo, blocks, filter, hub)
+	return web.NewMessageHandler(svc)
+}
+
+// 配置了 Redis 时时间线缓存在 Redis 中，多个实例共享
+func initTimeline(db *gorm.DB, redisClient redis.UniversalClient, content repository.StatusAndPostsRepository, visibility *service.VisibilityFilter) *service.TimelineService {
+	var store timeline.Store = timeline.NewMemoryStore()
+	if redisClient != nil {
+		store = timeline.NewRedisStore(redisClient)
+	}
+	follows := repository.NewFollowRepository(dao.NewFollowDAO(db))
+	return service.NewTimelineService(follows, content, store, visibility)
+}
+
+func initFollow(db *gorm.DB, blocks *service.BlockService, timeline *service.TimelineService) *web.FollowHandler {
+	repo := repository.NewFollowRepository(dao.NewFollowDAO(db))
+	userRepo := repository.NewUserRepository(dao.NewUserDAO(db))
+	svc := service.NewFollowService(repo, userRepo, blocks, timeline)
+	return web.NewFollowHandler(svc, timeline)
+}
+
+func initPersonalTextStatus(repo repository.StatusAndPostsRepository, events service.EventPublisher, notifications *service.NotificationService, timeline *service.TimelineService, visibility *service.VisibilityFilter, crossPoster *service.WordPressCrossPoster, appMetrics *metrics.Metrics) (*web.StatusAndPostsHandler, *service.StatusAndPostsService) {
+	svc := service.NewStatusAndPostsService(repo, events, notifications, timeline, visibility, crossPoster)
+	return web.NewStatusAndPostsHandler(svc, appMetrics), svc
+}
+
+// 转发到作者绑定的 WordPress 站点，定时发布的文章也通过它转发
+func initCrossPost(db *gorm.DB, treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, notifications *service.NotificationService, appMetrics *metrics.Metrics) *service.WordPressCrossPoster {
+	bindings := repository.NewUserWordpressInfoRepository(dao.NewUserWordpressInfoDAO(db))
+	return service.NewWordPressCrossPoster(treeholes, content, bindings, request.NewWpRequest(), notifications, appMetrics)
+}
+
+// 举报达到阈值自动隐藏内容时删除对应的缓存
+func initReport(db *gorm.DB, config *config.ConfigFunction, c *cache.Cache, events service.EventPublisher, notifications *service.NotificationService) (*web.ReportHandler, *service.ReportService) {
+	repo := repository.NewReportRepository(dao.NewReportDAO(db), dao.NewTreeHoleDAO(db), dao.NewStatusDAO(db), dao.NewPostsDAO(db), c)
+	svc := service.NewReportService(repo, config.GetReportHideThreshold(), events, notifications)
+	return web.NewReportHandler(svc), svc
+}
+
+func initTrash(config *config.ConfigFunction, treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, audit *service.AuditService) (*web.TrashHandler, *service.TrashService) {
+	svc := service.NewTrashService(treeholes, content, audit, config.GetTrashRetentionDays())
+	return web.NewTrashHandler(svc), svc
+}
+
+// 下载链接使用单独派生的签名密钥
+func initDataExport(db *gorm.DB, config *config.ConfigFunction, keys *security.KeyRing, treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, notifications *service.NotificationService) (*web.DataExportHandler, *service.DataExportService) {
+	repo := repository.NewDataExportRepository(dao.NewDataExportDAO(db))
+	users := repository.NewUserRepository(dao.NewUserDAO(db))
+	bindings := repository.NewUserWordpressInfoRepository(dao.NewUserWordpressInfoDAO(db))
+	signer := security.NewTokenSigner(keys.Derive(security.KeyPurposeExportLink))
+	exportConfig := config.GetExportConfig()
+	svc := service.NewDataExportService(repo, users, treeholes, content, bindings, signer, notifications, exportConfig.Dir, exportConfig.LinkTTLHours)
+	return web.NewDataExportHandler(svc), svc
 }
 
 func initAPIDocsHandler(config *config.ConfigFunction) *web.APIDocsHandler {
 	return web.NewAPIDocsHandler(config)
 }
 
-func initAdminHandler(userService *service.UserService, treeholeService *service.TreeHoleService, statusService *service.StatusAndPostsService) *web.AdminHandler {
-	return web.NewAdminHandler(userService, treeholeService, statusService)
+func initAdminHandler(userService *service.UserService, treeholeService *service.TreeHoleService, statusService *service.StatusAndPostsService, reportService *service.ReportService, auditService *service.AuditService, twoFactorService *service.TwoFactorService, trashService *service.TrashService, importService *service.ImportService, appCache *cache.Cache) *web.AdminHandler {
+	return web.NewAdminHandler(userService, treeholeService, statusService, reportService, auditService, twoFactorService, trashService, importService, appCache)
+}
+
+func initImport(db *gorm.DB, c *cache.Cache, audit *service.AuditService) *service.ImportService {
+	repo := repository.NewImportRepository(dao.NewImportDAO(db), c)
+	users := repository.NewUserRepository(dao.NewUserDAO(db))
+	return service.NewImportService(repo, users, audit)
 }
@@ -0,0 +1,175 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 17:00:00
+ * @Description: 读穿缓存：未命中时回源并写入缓存，写入数据后删除对应的缓存
+ */
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 删除缓存后再次删除的延迟，清除删除前已开始的回源在删除后写入的旧数据
+const redeleteDelay = 500 * time.Millisecond
+
+// Cache 为 nil 时所有读取直接回源，失效操作不做任何事，便于在不需要缓存的场景中使用。
+// 缓存后端出错时只记录日志并回源，不影响业务
+type Cache struct {
+	store Store
+	group group
+	mu    sync.Mutex
+	stats map[string]*counters
+}
+
+type counters struct {
+	hits, misses, shared, errors atomic.Int64
+}
+
+// Stats 某一类缓存的命中统计，Name 为键中第一个冒号之前的部分
+type Stats struct {
+	Name   string `json:"name"`
+	Hits   int64  `json:"hits"`
+	Misses int64  `json:"misses"`
+	// 未命中但与其他请求合并回源的次数，计入 Misses
+	Shared int64 `json:"shared"`
+	Errors int64 `json:"errors"`
+}
+
+func New(store Store) *Cache {
+	return &Cache{store: store, stats: make(map[string]*counters)}
+}
+
+// Fetch 从缓存读取 key，未命中时调用 load 回源并缓存 ttl 时间。
+// 同一个 key 并发未命中时只有一个请求回源；load 返回错误时不缓存
+func Fetch[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	if c == nil {
+		return load(ctx)
+	}
+	st := c.counters(key)
+	var v T
+	data, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		st.errors.Add(1)
+		log.Printf("读取缓存 %s 失败: %v", key, err)
+	} else if ok {
+		if err := json.Unmarshal(data, &v); err == nil {
+			st.hits.Add(1)
+			return v, nil
+		}
+		st.errors.Add(1)
+	}
+
+	st.misses.Add(1)
+	val, err, shared := c.group.do(key, func() (any, error) {
+		// 回源结果由所有等待的请求共享，不因发起请求的取消而中断
+		loadCtx := context.WithoutCancel(ctx)
+		v, err := load(loadCtx)
+		if err != nil {
+			return v, err
+		}
+		if data, err := json.Marshal(v); err == nil {
+			if err := c.store.Set(loadCtx, key, data, ttl); err != nil {
+				st.errors.Add(1)
+				log.Printf("写入缓存 %s 失败: %v", key, err)
+			}
+		}
+		return v, nil
+	})
+	if shared {
+		st.shared.Add(1)
+	}
+	if err != nil {
+		return v, err
+	}
+	v, _ = val.(T)
+	return v, nil
+}
+
+// Delete 数据写入后删除对应的缓存，并在稍后再删除一次
+func (c *Cache) Delete(ctx context.Context, keys ...string) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		log.Printf("删除缓存 %v 失败: %v", keys, err)
+	}
+	time.AfterFunc(redeleteDelay, func() {
+		if err := c.store.Delete(ctx, keys...); err != nil {
+			log.Printf("删除缓存 %v 失败: %v", keys, err)
+		}
+	})
+}
+
+// Version 返回一组列表缓存的当前版本，列表的键中带上版本号，
+// 数据变化时调用 Bump 使该组的所有分页同时失效，不需要逐个删除
+func (c *Cache) Version(ctx context.Context, name string) string {
+	if c == nil {
+		return "0"
+	}
+	data, ok, err := c.store.Get(ctx, versionKey(name))
+	if err == nil && ok {
+		return string(data)
+	}
+	// 版本号丢失时生成新的版本号，旧版本的分页不会再被读取
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err == nil {
+		if err := c.store.Set(ctx, versionKey(name), []byte(version), versionTTL); err != nil {
+			log.Printf("写入缓存版本 %s 失败: %v", name, err)
+		}
+	}
+	return version
+}
+
+// Bump 使 name 这一组列表缓存全部失效
+func (c *Cache) Bump(ctx context.Context, name string) {
+	c.Delete(ctx, versionKey(name))
+}
+
+// Stats 返回各类缓存的命中统计，按名称排序
+func (c *Cache) Stats() []Stats {
+	if c == nil {
+		return []Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]Stats, 0, len(c.stats))
+	for name, st := range c.stats {
+		stats = append(stats, Stats{
+			Name:   name,
+			Hits:   st.hits.Load(),
+			Misses: st.misses.Load(),
+			Shared: st.shared.Load(),
+			Errors: st.errors.Load(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+func (c *Cache) counters(key string) *counters {
+	name, _, _ := strings.Cut(key, ":")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.stats[name]
+	if !ok {
+		st = &counters{}
+		c.stats[name] = st
+	}
+	return st
+}
+
+// 版本号的有效期比列表缓存长得多，过期后生成新的版本号即可
+const versionTTL = 24 * time.Hour
+
+func versionKey(name string) string {
+	return "version:" + name
+}
@@ -0,0 +1,51 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 17:00:00
+ * @Description: 合并同一个键上并发的缓存未命中，只有一个请求回源
+ */
+package cache
+
+import (
+	"errors"
+	"sync"
+)
+
+// fn 发生 panic 时等待中的调用收到该错误
+var errLoadPanicked = errors.New("cache: 回源时发生 panic")
+
+type call struct {
+	wg  sync.WaitGroup
+	val any
+	err error
+}
+
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do 同一个键同时只执行一次 fn，其余调用等待并共享结果，shared 表示结果来自其他调用
+func (g *group) do(key string, fn func() (any, error)) (val any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call{err: errLoadPanicked}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
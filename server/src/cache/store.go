@@ -0,0 +1,18 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 17:00:00
+ * @Description: 热点数据缓存的存储后端
+ */
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store 缓存存储后端，保存序列化后的数据。Get 未命中时返回 false 和 nil 错误
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
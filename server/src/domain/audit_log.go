@@ -0,0 +1,31 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 安全审计日志
+ */
+package domain
+
+import "time"
+
+// 审计事件类型
+const (
+	AuditActionLoginFailed     = "login_failed"
+	AuditActionAccountLocked   = "account_locked"
+	AuditActionIPLocked        = "ip_locked"
+	AuditActionTwoFactorPolicy = "two_factor_policy"   // 管理员修改两步验证策略
+	AuditActionTokenReuse      = "refresh_token_reuse" // 检测到刷新令牌被重复使用
+	AuditActionIdentityLink    = "identity_link"       // 绑定外部登录账号
+	AuditActionIdentityUnlink  = "identity_unlink"     // 解绑外部登录账号
+	AuditActionContentRestore  = "content_restore"     // 管理员恢复已删除的内容
+	AuditActionContentImport   = "content_import"      // 管理员批量导入内容
+	AuditActionRoleChange      = "role_change"         // 修改用户角色
+)
+
+type AuditLog struct {
+	Id      int64     `json:"id"`
+	UserId  int64     `json:"user_id"`
+	Action  string    `json:"action"`
+	Ip      string    `json:"ip"`
+	Message string    `json:"message"`
+	Ctime   time.Time `json:"ctime"`
+}
@@ -0,0 +1,26 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: 令牌接口返回的访问令牌和刷新令牌
+ */
+package domain
+
+import "time"
+
+type TokenPair struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// 访问令牌有效期（秒）
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshToken struct {
+	Id     int64
+	UserId int64
+	// 同一次登录轮换出的刷新令牌属于同一个家族，检测到重用时整个家族作废
+	FamilyId string
+	Used     bool
+	Revoked  bool
+	ExpireAt time.Time
+}
@@ -0,0 +1,22 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户屏蔽和静音
+ */
+package domain
+
+import "time"
+
+// UserBlock 用户屏蔽了另一个用户，双方都无法再给对方发私信
+type UserBlock struct {
+	UserId    int64     `json:"user_id"`
+	BlockedId int64     `json:"blocked_id"`
+	Ctime     time.Time `json:"ctime"`
+}
+
+// UserMute 用户静音了另一个用户，对方的内容只是不出现在自己的信息流中
+type UserMute struct {
+	UserId  int64     `json:"user_id"`
+	MutedId int64     `json:"muted_id"`
+	Ctime   time.Time `json:"ctime"`
+}
@@ -0,0 +1,34 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 15:00:00
+ * @Description: 用户数据导出
+ */
+package domain
+
+import "time"
+
+// 导出任务状态
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+type DataExport struct {
+	Id     int64  `json:"id"`
+	UserId int64  `json:"user_id"`
+	Status string `json:"status"`
+	// 导出文件的字节数
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+	// 下载链接的过期时间，生成完成前为零值
+	ExpireAt time.Time `json:"expire_at"`
+	Ctime    time.Time `json:"ctime"`
+	FileName string    `json:"-"`
+	// 下载链接中必须携带的随机令牌，生成完成前和过期后为空
+	DownloadToken string `json:"-"`
+	// 带签名的下载链接，只在生成完成且未过期时返回
+	DownloadURL string `json:"download_url,omitempty"`
+}
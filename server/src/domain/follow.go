@@ -0,0 +1,21 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 用户关注关系
+ */
+package domain
+
+import "time"
+
+// FollowUser 关注或粉丝列表中的一个用户
+type FollowUser struct {
+	UserId   int64     `json:"user_id"`
+	Nickname string    `json:"nickname"`
+	Avatar   string    `json:"avatar"`
+	Ctime    time.Time `json:"ctime"`
+}
+
+type FollowStats struct {
+	Followers int64 `json:"followers"`
+	Following int64 `json:"following"`
+}
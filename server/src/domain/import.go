@@ -0,0 +1,44 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 从本站导出文件、CSV 和 Twitter/X 存档批量导入内容
+ */
+package domain
+
+// 支持的导入格式
+const (
+	ImportFormatNegaihoshi = "negaihoshi" // 本站数据导出的 ZIP 文件
+	ImportFormatCSV        = "csv"        // 树洞 CSV
+	ImportFormatTwitter    = "twitter"    // Twitter/X 存档，导入为动态
+)
+
+type ImportOptions struct {
+	Format string
+	// 只检查不写入，报告将导入的数量和冲突
+	DryRun bool
+	// 不为空时所有记录都导入到该用户名下
+	Username string
+	// 来源用户名到本站用户名的映射，未映射的按同名用户或相同邮箱匹配
+	UserMap map[string]string
+}
+
+// ImportConflict 无法导入的记录及原因
+type ImportConflict struct {
+	// 记录在文件中的位置，如 treeholes.json[3]、第 5 行
+	Ref        string `json:"ref"`
+	ExternalId string `json:"external_id,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+type ImportReport struct {
+	Format string `json:"format"`
+	DryRun bool   `json:"dry_run"`
+	// 文件中读取到的记录数
+	Total int `json:"total"`
+	// 按内容类型统计导入的条数，dry-run 时为将导入的条数
+	Created map[string]int `json:"created"`
+	// 之前已导入过或不需要导入的记录数
+	Skipped       int              `json:"skipped"`
+	ConflictCount int              `json:"conflict_count"`
+	Conflicts     []ImportConflict `json:"conflicts"`
+}
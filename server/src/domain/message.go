@@ -0,0 +1,30 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户之间的私信
+ */
+package domain
+
+import "time"
+
+// 私信的实时推送事件
+const EventMessageCreated = "message.created"
+
+// Conversation 某个用户视角下的一个会话
+type Conversation struct {
+	Id            int64     `json:"id"`
+	PeerId        int64     `json:"peer_id"`
+	PeerNickname  string    `json:"peer_nickname"`
+	PeerAvatar    string    `json:"peer_avatar"`
+	LastMessage   string    `json:"last_message"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	Unread        int64     `json:"unread"`
+}
+
+type DirectMessage struct {
+	Id             int64     `json:"id"`
+	ConversationId int64     `json:"conversation_id"`
+	SenderId       int64     `json:"sender_id"`
+	Content        string    `json:"content"`
+	Ctime          time.Time `json:"ctime"`
+}
@@ -0,0 +1,47 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 20:00:00
+ * @Description: 站内通知
+ */
+package domain
+
+import "time"
+
+// 通知类型
+const (
+	NotificationContentApproved = "content_approved" // 内容审核通过
+	NotificationContentRejected = "content_rejected" // 内容审核未通过
+	NotificationContentHidden   = "content_hidden"   // 内容被多次举报后自动隐藏
+	NotificationTransferFailed  = "transfer_failed"  // 转发到 WordPress 失败
+	NotificationDataExport      = "data_export"      // 数据导出完成或失败
+	NotificationSecurity        = "security"         // 账户安全提醒，不能关闭
+)
+
+// AllNotificationTypes 用户可以在偏好设置中看到的通知类型
+var AllNotificationTypes = []string{
+	NotificationContentApproved,
+	NotificationContentRejected,
+	NotificationContentHidden,
+	NotificationTransferFailed,
+	NotificationDataExport,
+	NotificationSecurity,
+}
+
+type Notification struct {
+	Id      int64  `json:"id"`
+	UserId  int64  `json:"user_id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// 关联的内容，如 treehole/status/post，可为空
+	TargetType string    `json:"target_type,omitempty"`
+	TargetId   int64     `json:"target_id,omitempty"`
+	Read       bool      `json:"read"`
+	Ctime      time.Time `json:"ctime"`
+}
+
+// NotificationPreference 用户对某类通知的开关，未设置的类型默认开启
+type NotificationPreference struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
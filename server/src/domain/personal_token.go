@@ -0,0 +1,37 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 17:00:00
+ * @Description: 个人访问令牌
+ */
+package domain
+
+import "time"
+
+// 个人访问令牌权限范围
+const (
+	ScopeTreeholeWrite     = "treehole:write"     // 发布和删除树洞
+	ScopeStatusRead        = "status:read"        // 读取动态和文章
+	ScopeWordpressTransfer = "wordpress:transfer" // 转发内容到 WordPress
+	ScopeAdminAll          = "admin:*"            // 管理后台全部接口
+)
+
+// AllScopes 可授予的全部权限范围
+var AllScopes = []string{
+	ScopeTreeholeWrite,
+	ScopeStatusRead,
+	ScopeWordpressTransfer,
+	ScopeAdminAll,
+}
+
+type PersonalAccessToken struct {
+	Id     int64    `json:"id"`
+	UserId int64    `json:"user_id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// 令牌前几位，便于用户辨认
+	Prefix string `json:"prefix"`
+	// 为空表示永不过期
+	ExpireAt   *time.Time `json:"expire_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Ctime      time.Time  `json:"ctime"`
+}
@@ -2,10 +2,53 @@ package domain
 
 import "time"
 
+// 文章状态，草稿和定时发布的文章只有作者可见，私密文章只有作者可见，
+// 不公开的文章持有链接即可查看但不出现在列表和时间线中
+const (
+	PostStateDraft     = "draft"
+	PostStateScheduled = "scheduled"
+	PostStatePublished = "published"
+	PostStatePrivate   = "private"
+	PostStateUnlisted  = "unlisted"
+)
+
 type Posts struct {
 	Id      int64
 	Title   string
 	Content string
 	UserId  int64
 	Ctime   time.Time
+	// 编辑过的内容在前台显示“已编辑”和最后编辑时间
+	Edited   bool
+	EditedAt time.Time
+	State    string
+	// 发布时间，定时发布的文章为计划发布时间，草稿为零值
+	PublishAt time.Time
+	// 发布时是否转发到作者绑定的 WordPress 站点，转发后清除
+	CrossPost bool
+}
+
+// ValidPostState 是否为合法的文章状态
+func ValidPostState(state string) bool {
+	switch state {
+	case PostStateDraft, PostStateScheduled, PostStatePublished, PostStatePrivate, PostStateUnlisted:
+		return true
+	}
+	return false
+}
+
+// VisibleTo 查看者能否通过 id 查看文章，viewerId 为 0 表示未登录
+func (p Posts) VisibleTo(viewerId int64) bool {
+	if viewerId != 0 && viewerId == p.UserId {
+		return true
+	}
+	return p.State == PostStatePublished || p.State == PostStateUnlisted
+}
+
+// ListedFor 文章能否出现在查看者看到的列表中
+func (p Posts) ListedFor(viewerId int64) bool {
+	if viewerId != 0 && viewerId == p.UserId {
+		return true
+	}
+	return p.State == PostStatePublished
 }
@@ -0,0 +1,43 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: 已认证的请求主体
+ */
+package domain
+
+import "strings"
+
+// 认证方式
+const (
+	AuthMethodSession = "session" // Cookie 会话
+	AuthMethodBearer  = "bearer"  // JWT 访问令牌
+	AuthMethodToken   = "token"   // 个人访问令牌
+)
+
+// Principal 登录中间件认证通过后放入 gin 上下文，handler 统一从这里获取当前用户
+type Principal struct {
+	UserId int64
+	Method string
+	// Cookie 会话登录时的会话ID
+	SessionId string
+	// 密码已验证但两步验证尚未完成
+	Pending bool
+	// 个人访问令牌的权限范围，为 nil 时不限制
+	Scopes []string
+}
+
+// HasScope 判断是否拥有指定权限，"admin:*" 这样的通配权限匹配同一前缀下的全部权限
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+		if strings.HasSuffix(s, ":*") && strings.HasPrefix(scope, strings.TrimSuffix(s, "*")) {
+			return true
+		}
+	}
+	return false
+}
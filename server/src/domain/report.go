@@ -0,0 +1,45 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 10:00:00
+ * @Description: 内容举报
+ */
+package domain
+
+import "time"
+
+// 举报目标类型
+const (
+	ReportTargetTreeHole = "treehole"
+	ReportTargetStatus   = "status"
+	ReportTargetPost     = "post"
+)
+
+// 举报原因分类
+const (
+	ReportReasonSpam       = "spam"       // 垃圾广告
+	ReportReasonHarassment = "harassment" // 骚扰辱骂
+	ReportReasonIllegal    = "illegal"    // 违法违规
+	ReportReasonSexual     = "sexual"     // 色情低俗
+	ReportReasonOther      = "other"      // 其他
+)
+
+// 举报处理状态
+const (
+	ReportStatusPending   = "pending"   // 待处理
+	ReportStatusResolved  = "resolved"  // 已处理（内容被拒绝）
+	ReportStatusDismissed = "dismissed" // 已驳回（内容被保留）
+)
+
+type Report struct {
+	Id         int64     `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetId   int64     `json:"target_id"`
+	ReporterId int64     `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Detail     string    `json:"detail"`
+	Status     string    `json:"status"`
+	HandlerId  int64     `json:"handler_id"`
+	HandleNote string    `json:"handle_note"`
+	Ctime      time.Time `json:"ctime"`
+	Utime      time.Time `json:"utime"`
+}
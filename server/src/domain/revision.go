@@ -0,0 +1,47 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 13:00:00
+ * @Description: 动态和文章的编辑历史
+ */
+package domain
+
+import "time"
+
+// 编辑历史的内容类型，与举报目标类型一致
+const (
+	RevisionStatus = ReportTargetStatus
+	RevisionPost   = ReportTargetPost
+)
+
+// ContentRevision 动态或文章的一个历史版本，Version 从 1 开始，1 为首次编辑前的原始版本
+type ContentRevision struct {
+	Id       int64     `json:"id"`
+	Version  int       `json:"version"`
+	Type     string    `json:"type"`
+	TargetId int64     `json:"target_id"`
+	EditorId int64     `json:"editor_id"`
+	Title    string    `json:"title,omitempty"`
+	Content  string    `json:"content"`
+	Ctime    time.Time `json:"ctime"`
+}
+
+// 逐行比较的操作类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine 两个版本逐行比较的一行结果
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff 两个版本之间的差异，文章的标题和正文分别比较
+type RevisionDiff struct {
+	From    ContentRevision `json:"from"`
+	To      ContentRevision `json:"to"`
+	Title   []DiffLine      `json:"title,omitempty"`
+	Content []DiffLine      `json:"content"`
+}
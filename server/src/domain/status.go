@@ -7,4 +7,7 @@ type Status struct {
 	Content string
 	UserId  int64
 	Ctime   time.Time
+	// 编辑过的内容在前台显示“已编辑”和最后编辑时间
+	Edited   bool
+	EditedAt time.Time
 }
@@ -0,0 +1,50 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 首页时间线
+ */
+package domain
+
+import "time"
+
+// 时间线条目类型
+const (
+	TimelineStatus = "status"
+	TimelinePost   = "post"
+)
+
+// TimelineEntry 时间线中的一条引用，缓存中只保存引用，读取时再加载内容
+type TimelineEntry struct {
+	Kind string
+	Id   int64
+	// 毫秒时间戳
+	Ctime int64
+}
+
+// Before 按时间倒序排列时 e 是否排在 o 前面，时间相同时依次比较类型和 id，保证顺序稳定
+func (e TimelineEntry) Before(o TimelineEntry) bool {
+	if e.Ctime != o.Ctime {
+		return e.Ctime > o.Ctime
+	}
+	if e.Kind != o.Kind {
+		return e.Kind > o.Kind
+	}
+	return e.Id > o.Id
+}
+
+func (e TimelineEntry) IsZero() bool {
+	return e.Kind == "" && e.Id == 0 && e.Ctime == 0
+}
+
+// TimelineItem 时间线中返回给前端的一条动态或文章
+type TimelineItem struct {
+	Type    string    `json:"type"`
+	Id      int64     `json:"id"`
+	UserId  int64     `json:"user_id"`
+	Title   string    `json:"title,omitempty"`
+	Content string    `json:"content"`
+	Ctime   time.Time `json:"ctime"`
+	// 编辑过的内容显示“已编辑”和最后编辑时间
+	Edited   bool      `json:"edited"`
+	EditedAt time.Time `json:"edited_at"`
+}
@@ -0,0 +1,35 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 12:00:00
+ * @Description: 回收站
+ */
+package domain
+
+import "time"
+
+// 回收站内容类型，与举报目标类型一致
+const (
+	TrashTreeHole = ReportTargetTreeHole
+	TrashStatus   = ReportTargetStatus
+	TrashPost     = ReportTargetPost
+)
+
+// TrashItem 回收站中一条已删除的树洞、动态或文章
+type TrashItem struct {
+	Type    string    `json:"type"`
+	Id      int64     `json:"id"`
+	UserId  int64     `json:"user_id"`
+	Title   string    `json:"title,omitempty"`
+	Content string    `json:"content"`
+	Ctime   time.Time `json:"ctime"`
+	// 删除操作人，不是作者本人时表示被管理员删除，作者不能自行恢复
+	DeletedBy int64     `json:"deleted_by"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// 超过该时间后内容会被永久删除
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// DeletedByAuthor 是否由作者本人删除
+func (t TrashItem) DeletedByAuthor() bool {
+	return t.DeletedBy == t.UserId
+}
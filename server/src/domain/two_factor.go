@@ -0,0 +1,29 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 两步验证
+ */
+package domain
+
+type TwoFactor struct {
+	UserId int64
+	// 解密后的 TOTP 密钥
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// 两步验证状态
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// 站点是否要求所有用户开启两步验证
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// 开启两步验证时返回给用户的绑定信息
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	// otpauth:// 地址，前端据此生成二维码
+	URI string `json:"uri"`
+}
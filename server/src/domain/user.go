@@ -2,6 +2,12 @@ package domain
 
 import "time"
 
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	Id       int64
 	Username string
@@ -13,8 +19,22 @@ type User struct {
 	Phone    string
 	Location string
 	Website  string
-	Ctime    time.Time
-	Utime    time.Time
+	// 邮箱是否已验证
+	EmailVerified bool
+	// 用户角色，RoleUser 或 RoleAdmin
+	Role  string
+	Ctime time.Time
+	Utime time.Time
+}
+
+// HasPassword 是否设置过密码，外部账号自动注册的用户在找回密码前没有密码
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
 // 个人资料更新请求
@@ -38,6 +58,8 @@ type ProfileResponse struct {
 	Phone    string `json:"phone"`
 	Location string `json:"location"`
 	Website  string `json:"website"`
-	Ctime    string `json:"ctime"`
-	Utime    string `json:"utime"`
+	// 邮箱是否已验证
+	EmailVerified bool   `json:"email_verified"`
+	Ctime         string `json:"ctime"`
+	Utime         string `json:"utime"`
 }
@@ -0,0 +1,34 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 18:00:00
+ * @Description: 绑定的外部登录账号
+ */
+package domain
+
+import "time"
+
+type UserIdentity struct {
+	Id       int64     `json:"id"`
+	UserId   int64     `json:"user_id"`
+	Provider string    `json:"provider"`
+	Subject  string    `json:"-"`
+	Email    string    `json:"email"`
+	Ctime    time.Time `json:"ctime"`
+}
+
+// OIDCAuthState 跳转到提供方前保存在会话中的授权请求，回调时校验
+type OIDCAuthState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// 非 0 时表示为该用户绑定外部账号，而不是登录
+	LinkUserId int64 `json:"link_user_id"`
+	Ctime      int64 `json:"ctime"`
+}
+
+// OIDCProviderInfo 前端展示的登录方式
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
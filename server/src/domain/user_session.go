@@ -0,0 +1,22 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 14:00:00
+ * @Description: 用户登录会话
+ */
+package domain
+
+import "time"
+
+type UserSession struct {
+	Id        int64  `json:"id"`
+	UserId    int64  `json:"user_id"`
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	Ip        string `json:"ip"`
+	// 密码已验证但两步验证尚未完成
+	Pending bool `json:"pending"`
+	// 是否为发起请求的当前会话
+	Current  bool      `json:"current"`
+	LastSeen time.Time `json:"last_seen"`
+	Ctime    time.Time `json:"ctime"`
+}
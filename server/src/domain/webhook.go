@@ -0,0 +1,89 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: 对外推送的 webhook 和投递记录
+ */
+package domain
+
+import "time"
+
+// webhook 事件类型
+const (
+	EventTreeholeCreated   = "treehole.created"
+	EventTreeholeDeleted   = "treehole.deleted"
+	EventTreeholeModerated = "treehole.moderated"
+	EventStatusCreated     = "status.created"
+	EventStatusUpdated     = "status.updated"
+	EventStatusDeleted     = "status.deleted"
+	EventStatusModerated   = "status.moderated"
+	EventPostCreated       = "post.created"
+	EventPostUpdated       = "post.updated"
+	EventPostDeleted       = "post.deleted"
+	EventPostModerated     = "post.moderated"
+	EventUserSignedUp      = "user.signed_up"
+	// 管理后台测试按钮发送的事件，只投递给指定的 webhook
+	EventPing = "ping"
+)
+
+// AllWebhookEvents 可订阅的全部事件
+var AllWebhookEvents = []string{
+	EventTreeholeCreated, EventTreeholeDeleted, EventTreeholeModerated,
+	EventStatusCreated, EventStatusUpdated, EventStatusDeleted, EventStatusModerated,
+	EventPostCreated, EventPostUpdated, EventPostDeleted, EventPostModerated,
+	EventUserSignedUp,
+}
+
+// 投递状态
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
+
+type Webhook struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// 签名密钥，只在创建和重置时返回
+	Secret string `json:"secret,omitempty"`
+	// 订阅的事件，"*" 表示全部
+	Events  []string  `json:"events"`
+	Enabled bool      `json:"enabled"`
+	Ctime   time.Time `json:"ctime"`
+	Utime   time.Time `json:"utime"`
+}
+
+// Subscribes 是否订阅了该事件
+func (w Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent 推送给订阅方的请求体
+type WebhookEvent struct {
+	Id         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+type WebhookDelivery struct {
+	Id        int64  `json:"id"`
+	WebhookId int64  `json:"webhook_id"`
+	EventId   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Payload   string `json:"payload"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	// 最近一次投递的结果
+	ResponseCode int        `json:"response_code"`
+	Error        string     `json:"error"`
+	DurationMs   int64      `json:"duration_ms"`
+	NextRetryAt  *time.Time `json:"next_retry_at"`
+	Ctime        time.Time  `json:"ctime"`
+	Utime        time.Time  `json:"utime"`
+}
@@ -0,0 +1,15 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 14:00:00
+ * @Description: 转发内容到 WordPress 的结果
+ */
+package domain
+
+// WordPressTransferResult 转发到一个站点的结果，失败时 Error 为原因
+type WordPressTransferResult struct {
+	SiteId    int64  `json:"site_id"`
+	Success   bool   `json:"success"`
+	WPPostId  int64  `json:"wp_post_id,omitempty"`
+	WPPostURL string `json:"wp_post_url,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
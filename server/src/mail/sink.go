@@ -0,0 +1,51 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 13:00:00
+ * @Description: 开发和测试环境使用的邮件输出，不真正发送邮件
+ */
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogMailer 将邮件内容打印到日志
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("[mail] To: %s Subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer 将邮件以 .eml 文件写入目录，便于测试时检查
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitizeFileName(msg.To))
+	return os.WriteFile(filepath.Join(f.dir, name), buildMIME(f.from, msg), 0644)
+}
+
+func sanitizeFileName(s string) string {
+	out := []rune(s)
+	for i, r := range out {
+		if r == '/' || r == '\\' || r == ':' {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
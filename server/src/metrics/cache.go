@@ -0,0 +1,52 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 18:00:00
+ * @Description: 把缓存的命中统计转换为 Prometheus 指标
+ */
+package metrics
+
+import (
+	"negaihoshi/server/src/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCollector 每次采集时读取 cache.Stats，计数由缓存自己维护
+type cacheCollector struct {
+	cache  *cache.Cache
+	hits   *prometheus.Desc
+	misses *prometheus.Desc
+	shared *prometheus.Desc
+	errors *prometheus.Desc
+}
+
+func newCacheCollector(c *cache.Cache) *cacheCollector {
+	labels := []string{"cache"}
+	return &cacheCollector{
+		cache: c,
+		hits: prometheus.NewDesc(namespace+"_cache_hits_total",
+			"缓存命中次数，cache 为缓存键的类别", labels, nil),
+		misses: prometheus.NewDesc(namespace+"_cache_misses_total",
+			"缓存未命中次数", labels, nil),
+		shared: prometheus.NewDesc(namespace+"_cache_shared_loads_total",
+			"未命中但与其他并发请求合并回源的次数，计入未命中次数", labels, nil),
+		errors: prometheus.NewDesc(namespace+"_cache_errors_total",
+			"缓存后端出错后直接回源的次数", labels, nil),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.shared
+	ch <- c.errors
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, st := range c.cache.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(st.Hits), st.Name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(st.Misses), st.Name)
+		ch <- prometheus.MustNewConstMetric(c.shared, prometheus.CounterValue, float64(st.Shared), st.Name)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(st.Errors), st.Name)
+	}
+}
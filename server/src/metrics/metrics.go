@@ -0,0 +1,153 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 18:00:00
+ * @Description: Prometheus 监控指标：HTTP 请求耗时、数据库连接池、缓存命中、WordPress 转发结果和业务计数
+ */
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"negaihoshi/server/src/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "negaihoshi"
+
+// 登录方式
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodTwoFactor = "two_factor"
+	LoginMethodOIDC      = "oidc"
+)
+
+// 登录结果
+const (
+	LoginResultSuccess = "success"
+	LoginResultFailure = "failure"
+	// 账户或 IP 被锁定，或需要人机验证
+	LoginResultBlocked = "blocked"
+	// 密码正确，等待输入两步验证码
+	LoginResultTwoFactor = "two_factor_required"
+)
+
+// WordPress 转发方式
+const (
+	WordPressTransfer  = "transfer"
+	WordPressCrossPost = "crosspost"
+	// 发布或编辑时按请求中填写的站点直接转发
+	WordPressInline = "inline"
+)
+
+// WordPress 转发结果
+const (
+	WordPressSuccess  = "success"
+	WordPressFailure  = "failure"
+	WordPressNotBound = "not_bound"
+)
+
+// HTTP 请求耗时的分桶，单位秒
+var httpBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Metrics 为 nil 时所有记录操作不做任何事，命令行工具和测试中可以不创建
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpDuration *prometheus.HistogramVec
+	events       *prometheus.CounterVec
+	logins       *prometheus.CounterVec
+	wordpress    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP 请求耗时，route 为注册的路由，未匹配任何路由的请求为 unmatched",
+			Buckets:   httpBuckets,
+		}, []string{"method", "route", "status"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_total",
+			Help:      "业务事件次数，type 与 webhook 事件类型相同，如 post.created、treehole.created、user.signed_up",
+		}, []string{"type"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "登录次数，method 为 password/two_factor/oidc，result 为 success/failure/blocked/two_factor_required",
+		}, []string{"method", "result"}),
+		wordpress: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "wordpress_deliveries_total",
+			Help:      "转发到 WordPress 的次数，kind 为 transfer（手动转发）、crosspost（发布时自动转发）或 inline（发布时按请求中的站点转发），result 为 success/failure/not_bound",
+		}, []string{"kind", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.events,
+		m.logins,
+		m.wordpress,
+	)
+	return m
+}
+
+// RegisterDB 导出数据库连接池状态，指标名为 go_sql_*，name 作为 db_name 标签
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCache 导出缓存的命中统计
+func (m *Metrics) RegisterCache(c *cache.Cache) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(newCacheCollector(c))
+}
+
+// Handler 以 Prometheus 文本格式输出全部指标
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP 记录一次 HTTP 请求的耗时
+func (m *Metrics) ObserveHTTP(method, route, status string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpDuration.WithLabelValues(method, route, status).Observe(elapsed.Seconds())
+}
+
+// Event 记录一次业务事件
+func (m *Metrics) Event(eventType string) {
+	if m == nil {
+		return
+	}
+	m.events.WithLabelValues(eventType).Inc()
+}
+
+// Login 记录一次登录尝试
+func (m *Metrics) Login(method, result string) {
+	if m == nil {
+		return
+	}
+	m.logins.WithLabelValues(method, result).Inc()
+}
+
+// WordPressDelivery 记录一次转发到 WordPress 的结果
+func (m *Metrics) WordPressDelivery(kind, result string) {
+	if m == nil {
+		return
+	}
+	m.wordpress.WithLabelValues(kind, result).Inc()
+}
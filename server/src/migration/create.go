@@ -0,0 +1,79 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 09:00:00
+ * @Description: 生成新的迁移文件
+ */
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create 在 root 下每个数据库目录中生成同一版本号的 up/down 空文件，返回生成的文件路径
+func Create(root, name string) ([]string, error) {
+	name = strings.Trim(nameSanitizer.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, ErrNameInvalid
+	}
+
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	var latest int64
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, d.Name())
+		targets = append(targets, dir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			match := fileNamePattern.FindStringSubmatch(entry.Name())
+			if match == nil {
+				continue
+			}
+			version, err := strconv.ParseInt(match[1], 10, 64)
+			if err == nil && version > latest {
+				latest = version
+			}
+		}
+	}
+
+	base := fmt.Sprintf("%04d_%s", latest+1, name)
+	var files []string
+	for _, dir := range targets {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, base+"."+direction+".sql")
+			if err := writeNew(file, fmt.Sprintf("-- %s %s\n-- 每条语句以行尾分号结束\n", base, direction)); err != nil {
+				return files, err
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// writeNew 使用 O_EXCL 防止覆盖已有文件
+func writeNew(file, content string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 09:00:00
+ * @Description: 不同数据库的迁移记录表与迁移锁
+ */
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"time"
+)
+
+// 迁移锁名称，同一数据库上的所有实例共用
+const lockName = "negaihoshi_schema_migrations"
+
+type dialect struct {
+	createTable   string
+	insertVersion string
+	deleteVersion string
+	// DDL 支持事务时每个迁移在单独的事务中执行，失败不会留下半成品
+	transactional bool
+	lock          func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error
+	// commit 为 false 表示迁移失败，需要持有事务的锁应回滚
+	unlock func(ctx context.Context, conn *sql.Conn, commit bool) error
+}
+
+var dialects = map[string]*dialect{
+	"mysql": {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			applied_at BIGINT NOT NULL,
+			PRIMARY KEY (version)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`,
+		insertVersion: "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		deleteVersion: "DELETE FROM schema_migrations WHERE version = ?",
+		lock:          mysqlLock,
+		unlock:        mysqlUnlock,
+	},
+	"postgres": {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at BIGINT NOT NULL
+		)`,
+		insertVersion: "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+		deleteVersion: "DELETE FROM schema_migrations WHERE version = $1",
+		transactional: true,
+		lock:          postgresLock,
+		unlock:        postgresUnlock,
+	},
+	"sqlite": {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER NOT NULL PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at INTEGER NOT NULL
+		)`,
+		insertVersion: "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		deleteVersion: "DELETE FROM schema_migrations WHERE version = ?",
+		lock:          sqliteLock,
+		unlock:        sqliteUnlock,
+	},
+}
+
+// mysqlLock 使用 GET_LOCK 获取会话级命名锁，必须在同一连接上释放
+func mysqlLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	var got sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(timeout.Seconds())).Scan(&got)
+	if err != nil {
+		return err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return ErrLockTimeout
+	}
+	return nil
+}
+
+func mysqlUnlock(ctx context.Context, conn *sql.Conn, commit bool) error {
+	_, err := conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", lockName)
+	return err
+}
+
+// postgresLock 轮询会话级 advisory lock，直到获取成功或超时
+func postgresLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var got bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", postgresLockKey()).Scan(&got)
+		if err != nil {
+			return err
+		}
+		if got {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func postgresUnlock(ctx context.Context, conn *sql.Conn, commit bool) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockKey())
+	return err
+}
+
+func postgresLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(lockName))
+	return int64(h.Sum64())
+}
+
+// sqliteLock 开启写事务获取数据库写锁，整次迁移在这个事务中完成，等待时间由 busy_timeout 控制
+func sqliteLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	_, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+	return err
+}
+
+func sqliteUnlock(ctx context.Context, conn *sql.Conn, commit bool) error {
+	stmt := "ROLLBACK"
+	if commit {
+		stmt = "COMMIT"
+	}
+	_, err := conn.ExecContext(ctx, stmt)
+	return err
+}
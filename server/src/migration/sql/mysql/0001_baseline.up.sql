@@ -0,0 +1,348 @@
+-- 0001_baseline up
+-- 基线结构，与原先 GORM AutoMigrate 生成的表一致，已存在的表会被直接沿用，缺少的列由 0009_legacy_columns 补上
+
+-- 早期 scripts/init.sql 建的 users 表以 password_hash 保存密码，结构与程序不兼容，无法沿用。
+-- MySQL 不能在存储过程外主动报错，这里通过查询一个不存在的表中止迁移，错误信息中的表名即原因
+SET @sql = (SELECT IF(COUNT(*) > 0,
+  'SELECT 1 FROM `unsupported_legacy_init_sql_users_table`',
+  'DO 0')
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'users' AND COLUMN_NAME = 'password_hash');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint AUTO_INCREMENT,
+  `username` varchar(191) NOT NULL,
+  `email` varchar(191) NOT NULL,
+  `password` longtext NOT NULL,
+  `nickname` varchar(100),
+  `bio` text,
+  `avatar` varchar(500),
+  `phone` varchar(20),
+  `location` varchar(200),
+  `website` varchar(500),
+  `email_verified` boolean DEFAULT false,
+  `ctime` datetime(3) NULL,
+  `utime` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_users_username` (`username`),
+  UNIQUE INDEX `uni_users_email` (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `wordpress_whitelists` (
+  `id` bigint AUTO_INCREMENT,
+  `wp_site_url` varchar(191),
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_wordpress_whitelists_wp_site_url` (`wp_site_url`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_wordpress_infos` (
+  `id` bigint AUTO_INCREMENT,
+  `uid` bigint,
+  `w_puname` longtext,
+  `wp_api_key` longtext,
+  `ctime` bigint,
+  `utime` bigint,
+  `site_white_list_id` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_user_wordpress_infos_uid` (`uid`),
+  CONSTRAINT `fk_user_wordpress_infos_site_white_list` FOREIGN KEY (`site_white_list_id`) REFERENCES `wordpress_whitelists` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `tree_holes` (
+  `id` bigint AUTO_INCREMENT,
+  `content` longtext,
+  `user_id` bigint,
+  `hidden` boolean DEFAULT false,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `statuses` (
+  `id` bigint AUTO_INCREMENT,
+  `content` longtext,
+  `user_id` bigint,
+  `hidden` boolean DEFAULT false,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_ctime` (`user_id`, `ctime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `posts` (
+  `id` bigint AUTO_INCREMENT,
+  `title` longtext,
+  `content` longtext,
+  `user_id` bigint,
+  `hidden` boolean DEFAULT false,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_ctime` (`user_id`, `ctime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `reports` (
+  `id` bigint AUTO_INCREMENT,
+  `reporter_id` bigint,
+  `target_type` varchar(20),
+  `target_id` bigint,
+  `reason` varchar(20),
+  `detail` text,
+  `status` varchar(20),
+  `handler_id` bigint,
+  `handle_note` varchar(500),
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_reporter_target` (`reporter_id`, `target_type`, `target_id`),
+  INDEX `idx_target` (`target_type`, `target_id`),
+  INDEX `idx_reports_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `action` varchar(50),
+  `ip` varchar(64),
+  `message` varchar(500),
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_user_id` (`user_id`),
+  INDEX `idx_audit_logs_action` (`action`),
+  INDEX `idx_audit_logs_ctime` (`ctime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_tokens` (
+  `id` bigint AUTO_INCREMENT,
+  `nonce` varchar(64),
+  `user_id` bigint,
+  `purpose` varchar(30),
+  `expire_at` bigint,
+  `used_at` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_user_tokens_nonce` (`nonce`),
+  INDEX `idx_user_tokens_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_sessions` (
+  `id` bigint AUTO_INCREMENT,
+  `sid` varchar(64),
+  `user_id` bigint,
+  `device` varchar(100),
+  `user_agent` varchar(500),
+  `ip` varchar(64),
+  `pending` boolean,
+  `last_seen` bigint,
+  `expire_at` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_user_sessions_sid` (`sid`),
+  INDEX `idx_user_sessions_user_id` (`user_id`),
+  INDEX `idx_user_sessions_expire_at` (`expire_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `two_factors` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `secret` varchar(255),
+  `enabled` boolean,
+  `last_step` bigint,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_two_factors_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `recovery_codes` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `code_hash` varchar(64),
+  `used_at` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_recovery_codes_user_id` (`user_id`),
+  INDEX `idx_recovery_codes_code_hash` (`code_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `settings` (
+  `key` varchar(100),
+  `value` text,
+  `utime` bigint,
+  PRIMARY KEY (`key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+  `id` bigint AUTO_INCREMENT,
+  `token_hash` varchar(64),
+  `user_id` bigint,
+  `family_id` varchar(64),
+  `parent_id` bigint,
+  `used_at` bigint,
+  `revoked_at` bigint,
+  `expire_at` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_refresh_tokens_token_hash` (`token_hash`),
+  INDEX `idx_refresh_tokens_user_id` (`user_id`),
+  INDEX `idx_refresh_tokens_family_id` (`family_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `personal_tokens` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `name` varchar(100),
+  `token_hash` varchar(64),
+  `prefix` varchar(16),
+  `scopes` varchar(500),
+  `expire_at` bigint,
+  `last_used_at` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uni_personal_tokens_token_hash` (`token_hash`),
+  INDEX `idx_personal_tokens_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_identities` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `provider` varchar(50),
+  `subject` varchar(255),
+  `email` varchar(255),
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user_provider` (`user_id`, `provider`),
+  UNIQUE INDEX `idx_provider_subject` (`provider`, `subject`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `webhooks` (
+  `id` bigint AUTO_INCREMENT,
+  `name` varchar(100),
+  `url` varchar(1000),
+  `secret` varchar(255),
+  `events` varchar(1000),
+  `enabled` boolean DEFAULT true,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` bigint AUTO_INCREMENT,
+  `webhook_id` bigint,
+  `event_id` varchar(64),
+  `event_type` varchar(50),
+  `payload` mediumtext,
+  `status` varchar(20),
+  `attempts` bigint,
+  `response_code` bigint,
+  `response_body` text,
+  `error` varchar(1000),
+  `duration_ms` bigint,
+  `next_retry_at` bigint,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),
+  INDEX `idx_webhook_deliveries_event_id` (`event_id`),
+  INDEX `idx_status_next_retry` (`status`, `next_retry_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `notifications` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `type` varchar(32),
+  `title` varchar(100),
+  `content` varchar(1000),
+  `target_type` varchar(20),
+  `target_id` bigint,
+  `is_read` boolean DEFAULT false,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_read` (`user_id`, `is_read`),
+  INDEX `idx_notifications_ctime` (`ctime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `type` varchar(32),
+  `enabled` boolean,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user_type` (`user_id`, `type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_blocks` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `blocked_id` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user_blocked` (`user_id`, `blocked_id`),
+  INDEX `idx_user_blocks_blocked_id` (`blocked_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user_mutes` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint,
+  `muted_id` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user_muted` (`user_id`, `muted_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `conversations` (
+  `id` bigint AUTO_INCREMENT,
+  `user_low` bigint,
+  `user_high` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user_pair` (`user_low`, `user_high`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `conversation_members` (
+  `id` bigint AUTO_INCREMENT,
+  `conversation_id` bigint,
+  `user_id` bigint,
+  `peer_id` bigint,
+  `unread_count` bigint,
+  `last_read_id` bigint,
+  `last_message_id` bigint,
+  `last_message_preview` varchar(100),
+  `last_message_at` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_conversation_user` (`conversation_id`, `user_id`),
+  INDEX `idx_user_last` (`user_id`, `last_message_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `direct_messages` (
+  `id` bigint AUTO_INCREMENT,
+  `conversation_id` bigint,
+  `sender_id` bigint,
+  `content` varchar(2000),
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_conversation_id` (`conversation_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `follows` (
+  `id` bigint AUTO_INCREMENT,
+  `follower_id` bigint,
+  `followee_id` bigint,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_follower_followee` (`follower_id`, `followee_id`),
+  INDEX `idx_follows_followee_id` (`followee_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `follow_counts` (
+  `user_id` bigint,
+  `followers` bigint,
+  `following` bigint,
+  PRIMARY KEY (`user_id`),
+  INDEX `idx_follow_counts_followers` (`followers`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,17 @@
+-- 0002_soft_delete down
+-- 回滚前已软删除的内容会重新出现
+
+ALTER TABLE `posts`
+  DROP INDEX `idx_deleted_at`,
+  DROP COLUMN `deleted_by`,
+  DROP COLUMN `deleted_at`;
+
+ALTER TABLE `statuses`
+  DROP INDEX `idx_deleted_at`,
+  DROP COLUMN `deleted_by`,
+  DROP COLUMN `deleted_at`;
+
+ALTER TABLE `tree_holes`
+  DROP INDEX `idx_deleted_at`,
+  DROP COLUMN `deleted_by`,
+  DROP COLUMN `deleted_at`;
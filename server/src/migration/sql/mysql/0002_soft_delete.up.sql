@@ -0,0 +1,17 @@
+-- 0002_soft_delete up
+-- 树洞、动态和文章改为软删除，deleted_at 为 0 表示未删除，超过保留期后由清理任务物理删除
+
+ALTER TABLE `tree_holes`
+  ADD COLUMN `deleted_at` bigint NOT NULL DEFAULT 0,
+  ADD COLUMN `deleted_by` bigint NOT NULL DEFAULT 0,
+  ADD INDEX `idx_deleted_at` (`deleted_at`);
+
+ALTER TABLE `statuses`
+  ADD COLUMN `deleted_at` bigint NOT NULL DEFAULT 0,
+  ADD COLUMN `deleted_by` bigint NOT NULL DEFAULT 0,
+  ADD INDEX `idx_deleted_at` (`deleted_at`);
+
+ALTER TABLE `posts`
+  ADD COLUMN `deleted_at` bigint NOT NULL DEFAULT 0,
+  ADD COLUMN `deleted_by` bigint NOT NULL DEFAULT 0,
+  ADD INDEX `idx_deleted_at` (`deleted_at`);
@@ -0,0 +1,8 @@
+-- 0003_content_revisions down
+-- 回滚会丢失全部编辑历史
+
+ALTER TABLE `posts` DROP COLUMN `edited_at`;
+
+ALTER TABLE `statuses` DROP COLUMN `edited_at`;
+
+DROP TABLE IF EXISTS `content_revisions`;
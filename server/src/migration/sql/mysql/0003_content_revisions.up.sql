@@ -0,0 +1,18 @@
+-- 0003_content_revisions up
+-- 动态和文章的编辑历史，只追加不修改；edited_at 为 0 表示未编辑过
+
+CREATE TABLE IF NOT EXISTS `content_revisions` (
+  `id` bigint AUTO_INCREMENT,
+  `target_type` varchar(20) NOT NULL,
+  `target_id` bigint NOT NULL,
+  `editor_id` bigint NOT NULL,
+  `title` longtext,
+  `content` longtext,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_target` (`target_type`, `target_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `statuses` ADD COLUMN `edited_at` bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE `posts` ADD COLUMN `edited_at` bigint NOT NULL DEFAULT 0;
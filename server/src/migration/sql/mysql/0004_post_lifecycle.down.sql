@@ -0,0 +1,9 @@
+-- 0004_post_lifecycle down
+-- 回滚后草稿、定时和私密文章会按已发布显示
+
+ALTER TABLE `posts`
+  DROP INDEX `idx_user_publish_at`,
+  DROP INDEX `idx_state_publish_at`,
+  DROP COLUMN `cross_post`,
+  DROP COLUMN `publish_at`,
+  DROP COLUMN `state`;
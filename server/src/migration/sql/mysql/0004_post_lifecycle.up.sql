@@ -0,0 +1,11 @@
+-- 0004_post_lifecycle up
+-- 文章增加草稿、定时发布、私密和不公开状态，已有文章视为已发布，发布时间取创建时间
+
+ALTER TABLE `posts`
+  ADD COLUMN `state` varchar(20) NOT NULL DEFAULT 'published',
+  ADD COLUMN `publish_at` bigint NOT NULL DEFAULT 0,
+  ADD COLUMN `cross_post` boolean NOT NULL DEFAULT false,
+  ADD INDEX `idx_state_publish_at` (`state`, `publish_at`),
+  ADD INDEX `idx_user_publish_at` (`user_id`, `publish_at`);
+
+UPDATE `posts` SET `publish_at` = `ctime`;
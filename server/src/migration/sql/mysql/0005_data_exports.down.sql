@@ -0,0 +1,4 @@
+-- 0005_data_exports down
+-- 回滚不会删除磁盘上已生成的导出文件
+
+DROP TABLE IF EXISTS `data_exports`;
@@ -0,0 +1,17 @@
+-- 0005_data_exports up
+-- 用户数据导出任务，导出文件保存在磁盘上，超过 expire_at 后删除
+
+CREATE TABLE IF NOT EXISTS `data_exports` (
+  `id` bigint AUTO_INCREMENT,
+  `user_id` bigint NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `file_name` varchar(255),
+  `size` bigint NOT NULL DEFAULT 0,
+  `error` varchar(500),
+  `expire_at` bigint NOT NULL DEFAULT 0,
+  `ctime` bigint,
+  `utime` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_ctime` (`user_id`, `ctime`),
+  INDEX `idx_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+-- 0006_import_records down
+-- 回滚不会删除已导入的内容，重新执行导入会产生重复内容
+
+DROP TABLE IF EXISTS `import_records`;
@@ -0,0 +1,13 @@
+-- 0006_import_records up
+-- 记录已导入的外部内容，重复导入同一来源的记录时跳过
+
+CREATE TABLE IF NOT EXISTS `import_records` (
+  `id` bigint AUTO_INCREMENT,
+  `source` varchar(50) NOT NULL,
+  `external_id` varchar(128) NOT NULL,
+  `target_type` varchar(20) NOT NULL,
+  `target_id` bigint NOT NULL,
+  `ctime` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_source_external` (`source`, `external_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+-- 0007_user_roles up
+-- 用户角色，管理后台接口只允许 admin 访问。
+-- 早期 scripts/init.sql 建的 users 表已有 role 列（取值同为 user/admin），此时保留原列
+
+SET @sql = (SELECT IF(COUNT(*) = 0,
+  'ALTER TABLE `users` ADD COLUMN `role` varchar(20) NOT NULL DEFAULT ''user''',
+  'DO 0')
+  FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'users' AND COLUMN_NAME = 'role');
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,4 @@
+-- 0008_webhook_response_body up
+-- 投递记录不再保存订阅方的响应体，避免通过 webhook 读取内网服务的响应
+
+ALTER TABLE `webhook_deliveries` DROP COLUMN `response_body`;
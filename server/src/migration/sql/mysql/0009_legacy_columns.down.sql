@@ -0,0 +1,2 @@
+-- 0009_legacy_columns down
+-- 补上的列和索引属于基线结构，回滚时保留
@@ -0,0 +1,4 @@
+-- 0010_export_download_token up
+-- 导出任务的下载令牌，下载链接必须携带与之一致的令牌；已生成的导出没有令牌，需要重新申请
+
+ALTER TABLE `data_exports` ADD COLUMN `download_token` varchar(64) NOT NULL DEFAULT '';
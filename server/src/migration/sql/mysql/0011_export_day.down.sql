@@ -0,0 +1,4 @@
+-- 0011_export_day down
+
+DROP INDEX `idx_user_export_day` ON `data_exports`;
+ALTER TABLE `data_exports` DROP COLUMN `export_day`;
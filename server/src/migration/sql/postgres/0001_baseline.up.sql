@@ -0,0 +1,337 @@
+-- 0001_baseline up
+-- 基线结构，与 MySQL 基线对应；PostgreSQL/SQLite 的索引名在整个库内唯一，同名索引加了表名前缀
+
+CREATE TABLE IF NOT EXISTS users (
+  id bigserial,
+  username varchar(191) NOT NULL,
+  email varchar(191) NOT NULL,
+  password text NOT NULL,
+  nickname varchar(100),
+  bio text,
+  avatar varchar(500),
+  phone varchar(20),
+  location varchar(200),
+  website varchar(500),
+  email_verified boolean DEFAULT false,
+  ctime timestamptz,
+  utime timestamptz,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_users_username UNIQUE (username),
+  CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS wordpress_whitelists (
+  id bigserial,
+  wp_site_url varchar(191),
+  PRIMARY KEY (id),
+  CONSTRAINT uni_wordpress_whitelists_wp_site_url UNIQUE (wp_site_url)
+);
+
+CREATE TABLE IF NOT EXISTS user_wordpress_infos (
+  id bigserial,
+  uid bigint,
+  w_puname text,
+  wp_api_key text,
+  ctime bigint,
+  utime bigint,
+  site_white_list_id bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_user_wordpress_infos_uid UNIQUE (uid),
+  CONSTRAINT fk_user_wordpress_infos_site_white_list FOREIGN KEY (site_white_list_id) REFERENCES wordpress_whitelists (id)
+);
+
+CREATE TABLE IF NOT EXISTS tree_holes (
+  id bigserial,
+  content text,
+  user_id bigint,
+  hidden boolean DEFAULT false,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS statuses (
+  id bigserial,
+  content text,
+  user_id bigint,
+  hidden boolean DEFAULT false,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_statuses_user_ctime ON statuses (user_id, ctime);
+
+CREATE TABLE IF NOT EXISTS posts (
+  id bigserial,
+  title text,
+  content text,
+  user_id bigint,
+  hidden boolean DEFAULT false,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_posts_user_ctime ON posts (user_id, ctime);
+
+CREATE TABLE IF NOT EXISTS reports (
+  id bigserial,
+  reporter_id bigint,
+  target_type varchar(20),
+  target_id bigint,
+  reason varchar(20),
+  detail text,
+  status varchar(20),
+  handler_id bigint,
+  handle_note varchar(500),
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_reporter_target UNIQUE (reporter_id, target_type, target_id)
+);
+CREATE INDEX IF NOT EXISTS idx_target ON reports (target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports (status);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id bigserial,
+  user_id bigint,
+  action varchar(50),
+  ip varchar(64),
+  message varchar(500),
+  ctime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_ctime ON audit_logs (ctime);
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id bigserial,
+  nonce varchar(64),
+  user_id bigint,
+  purpose varchar(30),
+  expire_at bigint,
+  used_at bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_user_tokens_nonce UNIQUE (nonce)
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id bigserial,
+  sid varchar(64),
+  user_id bigint,
+  device varchar(100),
+  user_agent varchar(500),
+  ip varchar(64),
+  pending boolean,
+  last_seen bigint,
+  expire_at bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_user_sessions_sid UNIQUE (sid)
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expire_at ON user_sessions (expire_at);
+
+CREATE TABLE IF NOT EXISTS two_factors (
+  id bigserial,
+  user_id bigint,
+  secret varchar(255),
+  enabled boolean,
+  last_step bigint,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_two_factors_user_id UNIQUE (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id bigserial,
+  user_id bigint,
+  code_hash varchar(64),
+  used_at bigint,
+  ctime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS settings (
+  key varchar(100) NOT NULL,
+  value text,
+  utime bigint,
+  PRIMARY KEY (key)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id bigserial,
+  token_hash varchar(64),
+  user_id bigint,
+  family_id varchar(64),
+  parent_id bigint,
+  used_at bigint,
+  revoked_at bigint,
+  expire_at bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_refresh_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS personal_tokens (
+  id bigserial,
+  user_id bigint,
+  name varchar(100),
+  token_hash varchar(64),
+  prefix varchar(16),
+  scopes varchar(500),
+  expire_at bigint,
+  last_used_at bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_personal_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_personal_tokens_user_id ON personal_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+  id bigserial,
+  user_id bigint,
+  provider varchar(50),
+  subject varchar(255),
+  email varchar(255),
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_provider UNIQUE (user_id, provider),
+  CONSTRAINT idx_provider_subject UNIQUE (provider, subject)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+  id bigserial,
+  name varchar(100),
+  url varchar(1000),
+  secret varchar(255),
+  events varchar(1000),
+  enabled boolean DEFAULT true,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id bigserial,
+  webhook_id bigint,
+  event_id varchar(64),
+  event_type varchar(50),
+  payload text,
+  status varchar(20),
+  attempts bigint,
+  response_code bigint,
+  response_body text,
+  error varchar(1000),
+  duration_ms bigint,
+  next_retry_at bigint,
+  ctime bigint,
+  utime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_status_next_retry ON webhook_deliveries (status, next_retry_at);
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id bigserial,
+  user_id bigint,
+  type varchar(32),
+  title varchar(100),
+  content varchar(1000),
+  target_type varchar(20),
+  target_id bigint,
+  is_read boolean DEFAULT false,
+  ctime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_read ON notifications (user_id, is_read);
+CREATE INDEX IF NOT EXISTS idx_notifications_ctime ON notifications (ctime);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  id bigserial,
+  user_id bigint,
+  type varchar(32),
+  enabled boolean,
+  utime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_type UNIQUE (user_id, type)
+);
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+  id bigserial,
+  user_id bigint,
+  blocked_id bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_blocked UNIQUE (user_id, blocked_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+CREATE TABLE IF NOT EXISTS user_mutes (
+  id bigserial,
+  user_id bigint,
+  muted_id bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_muted UNIQUE (user_id, muted_id)
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+  id bigserial,
+  user_low bigint,
+  user_high bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_user_pair UNIQUE (user_low, user_high)
+);
+
+CREATE TABLE IF NOT EXISTS conversation_members (
+  id bigserial,
+  conversation_id bigint,
+  user_id bigint,
+  peer_id bigint,
+  unread_count bigint,
+  last_read_id bigint,
+  last_message_id bigint,
+  last_message_preview varchar(100),
+  last_message_at bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_conversation_user UNIQUE (conversation_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_last ON conversation_members (user_id, last_message_at);
+
+CREATE TABLE IF NOT EXISTS direct_messages (
+  id bigserial,
+  conversation_id bigint,
+  sender_id bigint,
+  content varchar(2000),
+  ctime bigint,
+  PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_id ON direct_messages (conversation_id);
+
+CREATE TABLE IF NOT EXISTS follows (
+  id bigserial,
+  follower_id bigint,
+  followee_id bigint,
+  ctime bigint,
+  PRIMARY KEY (id),
+  CONSTRAINT idx_follower_followee UNIQUE (follower_id, followee_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows (followee_id);
+
+CREATE TABLE IF NOT EXISTS follow_counts (
+  user_id bigint NOT NULL,
+  followers bigint,
+  following bigint,
+  PRIMARY KEY (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follow_counts_followers ON follow_counts (followers);
@@ -0,0 +1,14 @@
+-- 0002_soft_delete down
+-- 回滚前已软删除的内容会重新出现
+
+DROP INDEX IF EXISTS idx_posts_deleted_at;
+ALTER TABLE posts DROP COLUMN deleted_by;
+ALTER TABLE posts DROP COLUMN deleted_at;
+
+DROP INDEX IF EXISTS idx_statuses_deleted_at;
+ALTER TABLE statuses DROP COLUMN deleted_by;
+ALTER TABLE statuses DROP COLUMN deleted_at;
+
+DROP INDEX IF EXISTS idx_tree_holes_deleted_at;
+ALTER TABLE tree_holes DROP COLUMN deleted_by;
+ALTER TABLE tree_holes DROP COLUMN deleted_at;
@@ -0,0 +1,14 @@
+-- 0002_soft_delete up
+-- 树洞、动态和文章改为软删除，deleted_at 为 0 表示未删除，超过保留期后由清理任务物理删除
+
+ALTER TABLE tree_holes ADD COLUMN deleted_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE tree_holes ADD COLUMN deleted_by bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_tree_holes_deleted_at ON tree_holes (deleted_at);
+
+ALTER TABLE statuses ADD COLUMN deleted_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE statuses ADD COLUMN deleted_by bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_statuses_deleted_at ON statuses (deleted_at);
+
+ALTER TABLE posts ADD COLUMN deleted_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN deleted_by bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
@@ -0,0 +1,8 @@
+-- 0004_post_lifecycle down
+-- 回滚后草稿、定时和私密文章会按已发布显示
+
+DROP INDEX IF EXISTS idx_posts_user_publish_at;
+DROP INDEX IF EXISTS idx_posts_state_publish_at;
+ALTER TABLE posts DROP COLUMN cross_post;
+ALTER TABLE posts DROP COLUMN publish_at;
+ALTER TABLE posts DROP COLUMN state;
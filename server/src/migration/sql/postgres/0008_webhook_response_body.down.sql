@@ -0,0 +1,3 @@
+-- 0008_webhook_response_body down
+
+ALTER TABLE webhook_deliveries ADD COLUMN response_body text;
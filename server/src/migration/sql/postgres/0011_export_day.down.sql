@@ -0,0 +1,4 @@
+-- 0011_export_day down
+
+DROP INDEX IF EXISTS idx_data_exports_user_export_day;
+ALTER TABLE data_exports DROP COLUMN export_day;
@@ -0,0 +1,29 @@
+-- 0001_baseline down
+
+DROP TABLE IF EXISTS follow_counts;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS direct_messages;
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
+DROP TABLE IF EXISTS user_mutes;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS personal_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factors;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS statuses;
+DROP TABLE IF EXISTS tree_holes;
+DROP TABLE IF EXISTS user_wordpress_infos;
+DROP TABLE IF EXISTS wordpress_whitelists;
+DROP TABLE IF EXISTS users;
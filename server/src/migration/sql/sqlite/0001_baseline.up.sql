@@ -0,0 +1,312 @@
+-- 0001_baseline up
+-- 基线结构，与 MySQL 基线对应；PostgreSQL/SQLite 的索引名在整个库内唯一，同名索引加了表名前缀
+
+CREATE TABLE IF NOT EXISTS users (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  username text NOT NULL,
+  email text NOT NULL,
+  password text NOT NULL,
+  nickname text,
+  bio text,
+  avatar text,
+  phone text,
+  location text,
+  website text,
+  email_verified numeric DEFAULT false,
+  ctime datetime,
+  utime datetime,
+  CONSTRAINT uni_users_username UNIQUE (username),
+  CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS wordpress_whitelists (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  wp_site_url text,
+  CONSTRAINT uni_wordpress_whitelists_wp_site_url UNIQUE (wp_site_url)
+);
+
+CREATE TABLE IF NOT EXISTS user_wordpress_infos (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  uid integer,
+  w_puname text,
+  wp_api_key text,
+  ctime integer,
+  utime integer,
+  site_white_list_id integer,
+  CONSTRAINT uni_user_wordpress_infos_uid UNIQUE (uid),
+  CONSTRAINT fk_user_wordpress_infos_site_white_list FOREIGN KEY (site_white_list_id) REFERENCES wordpress_whitelists (id)
+);
+
+CREATE TABLE IF NOT EXISTS tree_holes (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  content text,
+  user_id integer,
+  hidden numeric DEFAULT false,
+  ctime integer,
+  utime integer
+);
+
+CREATE TABLE IF NOT EXISTS statuses (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  content text,
+  user_id integer,
+  hidden numeric DEFAULT false,
+  ctime integer,
+  utime integer
+);
+CREATE INDEX IF NOT EXISTS idx_statuses_user_ctime ON statuses (user_id, ctime);
+
+CREATE TABLE IF NOT EXISTS posts (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  title text,
+  content text,
+  user_id integer,
+  hidden numeric DEFAULT false,
+  ctime integer,
+  utime integer
+);
+CREATE INDEX IF NOT EXISTS idx_posts_user_ctime ON posts (user_id, ctime);
+
+CREATE TABLE IF NOT EXISTS reports (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  reporter_id integer,
+  target_type text,
+  target_id integer,
+  reason text,
+  detail text,
+  status text,
+  handler_id integer,
+  handle_note text,
+  ctime integer,
+  utime integer,
+  CONSTRAINT idx_reporter_target UNIQUE (reporter_id, target_type, target_id)
+);
+CREATE INDEX IF NOT EXISTS idx_target ON reports (target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports (status);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  action text,
+  ip text,
+  message text,
+  ctime integer
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_ctime ON audit_logs (ctime);
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  nonce text,
+  user_id integer,
+  purpose text,
+  expire_at integer,
+  used_at integer,
+  ctime integer,
+  CONSTRAINT uni_user_tokens_nonce UNIQUE (nonce)
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  sid text,
+  user_id integer,
+  device text,
+  user_agent text,
+  ip text,
+  pending numeric,
+  last_seen integer,
+  expire_at integer,
+  ctime integer,
+  CONSTRAINT uni_user_sessions_sid UNIQUE (sid)
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expire_at ON user_sessions (expire_at);
+
+CREATE TABLE IF NOT EXISTS two_factors (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  secret text,
+  enabled numeric,
+  last_step integer,
+  ctime integer,
+  utime integer,
+  CONSTRAINT uni_two_factors_user_id UNIQUE (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  code_hash text,
+  used_at integer,
+  ctime integer
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS settings (
+  key text NOT NULL,
+  value text,
+  utime integer,
+  PRIMARY KEY (key)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  token_hash text,
+  user_id integer,
+  family_id text,
+  parent_id integer,
+  used_at integer,
+  revoked_at integer,
+  expire_at integer,
+  ctime integer,
+  CONSTRAINT uni_refresh_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS personal_tokens (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  name text,
+  token_hash text,
+  prefix text,
+  scopes text,
+  expire_at integer,
+  last_used_at integer,
+  ctime integer,
+  CONSTRAINT uni_personal_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_personal_tokens_user_id ON personal_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  provider text,
+  subject text,
+  email text,
+  ctime integer,
+  CONSTRAINT idx_user_provider UNIQUE (user_id, provider),
+  CONSTRAINT idx_provider_subject UNIQUE (provider, subject)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name text,
+  url text,
+  secret text,
+  events text,
+  enabled numeric DEFAULT true,
+  ctime integer,
+  utime integer
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  webhook_id integer,
+  event_id text,
+  event_type text,
+  payload text,
+  status text,
+  attempts integer,
+  response_code integer,
+  response_body text,
+  error text,
+  duration_ms integer,
+  next_retry_at integer,
+  ctime integer,
+  utime integer
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_status_next_retry ON webhook_deliveries (status, next_retry_at);
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  type text,
+  title text,
+  content text,
+  target_type text,
+  target_id integer,
+  is_read numeric DEFAULT false,
+  ctime integer
+);
+CREATE INDEX IF NOT EXISTS idx_user_read ON notifications (user_id, is_read);
+CREATE INDEX IF NOT EXISTS idx_notifications_ctime ON notifications (ctime);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  type text,
+  enabled numeric,
+  utime integer,
+  CONSTRAINT idx_user_type UNIQUE (user_id, type)
+);
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  blocked_id integer,
+  ctime integer,
+  CONSTRAINT idx_user_blocked UNIQUE (user_id, blocked_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+CREATE TABLE IF NOT EXISTS user_mutes (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  muted_id integer,
+  ctime integer,
+  CONSTRAINT idx_user_muted UNIQUE (user_id, muted_id)
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_low integer,
+  user_high integer,
+  ctime integer,
+  CONSTRAINT idx_user_pair UNIQUE (user_low, user_high)
+);
+
+CREATE TABLE IF NOT EXISTS conversation_members (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  conversation_id integer,
+  user_id integer,
+  peer_id integer,
+  unread_count integer,
+  last_read_id integer,
+  last_message_id integer,
+  last_message_preview text,
+  last_message_at integer,
+  CONSTRAINT idx_conversation_user UNIQUE (conversation_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_last ON conversation_members (user_id, last_message_at);
+
+CREATE TABLE IF NOT EXISTS direct_messages (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  conversation_id integer,
+  sender_id integer,
+  content text,
+  ctime integer
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_id ON direct_messages (conversation_id);
+
+CREATE TABLE IF NOT EXISTS follows (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  follower_id integer,
+  followee_id integer,
+  ctime integer,
+  CONSTRAINT idx_follower_followee UNIQUE (follower_id, followee_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows (followee_id);
+
+CREATE TABLE IF NOT EXISTS follow_counts (
+  user_id integer NOT NULL,
+  followers integer,
+  following integer,
+  PRIMARY KEY (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follow_counts_followers ON follow_counts (followers);
@@ -0,0 +1,10 @@
+-- 0004_post_lifecycle up
+-- 文章增加草稿、定时发布、私密和不公开状态，已有文章视为已发布，发布时间取创建时间
+
+ALTER TABLE posts ADD COLUMN state text NOT NULL DEFAULT 'published';
+ALTER TABLE posts ADD COLUMN publish_at integer NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN cross_post numeric NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_posts_state_publish_at ON posts (state, publish_at);
+CREATE INDEX IF NOT EXISTS idx_posts_user_publish_at ON posts (user_id, publish_at);
+
+UPDATE posts SET publish_at = ctime;
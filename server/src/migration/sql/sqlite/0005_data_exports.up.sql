@@ -0,0 +1,16 @@
+-- 0005_data_exports up
+-- 用户数据导出任务，导出文件保存在磁盘上，超过 expire_at 后删除
+
+CREATE TABLE IF NOT EXISTS data_exports (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer NOT NULL,
+  status text NOT NULL,
+  file_name text,
+  size integer NOT NULL DEFAULT 0,
+  error text,
+  expire_at integer NOT NULL DEFAULT 0,
+  ctime integer,
+  utime integer
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_ctime ON data_exports (user_id, ctime);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status);
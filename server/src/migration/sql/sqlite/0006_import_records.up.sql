@@ -0,0 +1,12 @@
+-- 0006_import_records up
+-- 记录已导入的外部内容，重复导入同一来源的记录时跳过
+
+CREATE TABLE IF NOT EXISTS import_records (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  source text NOT NULL,
+  external_id text NOT NULL,
+  target_type text NOT NULL,
+  target_id integer NOT NULL,
+  ctime integer,
+  CONSTRAINT idx_import_records_source_external UNIQUE (source, external_id)
+);
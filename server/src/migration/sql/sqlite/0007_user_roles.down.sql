@@ -0,0 +1,3 @@
+-- 0007_user_roles down
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,4 @@
+-- 0007_user_roles up
+-- 用户角色，管理后台接口只允许 admin 访问
+
+ALTER TABLE users ADD COLUMN role text NOT NULL DEFAULT 'user';
@@ -0,0 +1 @@
+-- 0009_legacy_columns down
@@ -0,0 +1,2 @@
+-- 0009_legacy_columns up
+-- 只有 MySQL 存在旧版本 AutoMigrate 建的表，其他数据库无需处理
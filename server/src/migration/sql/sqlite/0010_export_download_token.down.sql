@@ -0,0 +1,3 @@
+-- 0010_export_download_token down
+
+ALTER TABLE data_exports DROP COLUMN download_token;
@@ -0,0 +1,6 @@
+-- 0011_export_day up
+-- 申请导出的日期，与 user_id 组成唯一索引，由数据库保证每个用户每天只有一个未失败的导出任务；
+-- 已有任务的日期为空，不受限制
+
+ALTER TABLE data_exports ADD COLUMN export_day text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_user_export_day ON data_exports (user_id, export_day);
@@ -0,0 +1,47 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 11:00:00
+ * @Description: 令牌桶限流器
+ */
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rate 限流规则：每分钟补充 RequestsPerMinute 个令牌，桶容量为 Burst
+type Rate struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// Capacity 令牌桶容量，未配置 Burst 时等于每分钟请求数
+func (r Rate) Capacity() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.RequestsPerMinute
+}
+
+// Interval 补充一个令牌所需时间
+func (r Rate) Interval() time.Duration {
+	if r.RequestsPerMinute <= 0 {
+		return 0
+	}
+	return time.Minute / time.Duration(r.RequestsPerMinute)
+}
+
+// Result 单次限流判断结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被限流时距离下一个可用令牌的时间
+	ResetAfter time.Duration // 令牌桶补满所需时间
+}
+
+// Limiter 限流器后端，key 由调用方组合（如 ip:127.0.0.1、user:1）
+type Limiter interface {
+	Allow(ctx context.Context, key string, rate Rate) (Result, error)
+}
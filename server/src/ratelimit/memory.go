@@ -0,0 +1,91 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 11:00:00
+ * @Description: 内存令牌桶限流器，适用于单实例部署
+ */
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 空闲令牌桶清理周期
+const memoryCleanupInterval = 5 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // 令牌桶补满的时间点，之后即可清理
+}
+
+type MemoryLimiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	// 当前时间，测试时替换
+	now func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, rate Rate) (Result, error) {
+	capacity := rate.Capacity()
+	interval := rate.Interval()
+	if capacity <= 0 || interval <= 0 {
+		// 未配置限流规则时直接放行
+		return Result{Allowed: true}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.cleanup(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(capacity), last: now}
+		m.buckets[key] = b
+	}
+
+	// 按流逝时间补充令牌
+	elapsed := now.Sub(b.last)
+	b.tokens += float64(elapsed) / float64(interval)
+	if b.tokens > float64(capacity) {
+		b.tokens = float64(capacity)
+	}
+	b.last = now
+
+	res := Result{Limit: capacity}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	res.Remaining = int(b.tokens)
+	res.ResetAfter = time.Duration((float64(capacity) - b.tokens) * float64(interval))
+	b.full = now.Add(res.ResetAfter)
+	return res, nil
+}
+
+// cleanup 定期清理已补满的令牌桶，避免内存无限增长
+func (m *MemoryLimiter) cleanup(now time.Time) {
+	if now.Sub(m.lastCleanup) < memoryCleanupInterval {
+		return
+	}
+	m.lastCleanup = now
+	for key, b := range m.buckets {
+		if now.After(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
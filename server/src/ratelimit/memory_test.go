@@ -0,0 +1,112 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 11:00:00
+ * @Description: 内存令牌桶限流器的测试
+ */
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	// 每分钟 60 个，即每秒补充一个令牌，桶容量 3
+	rate := Rate{RequestsPerMinute: 60, Burst: 3}
+
+	type step struct {
+		advance       time.Duration // 本次请求前经过的时间
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		rate  Rate
+		steps []step
+	}{
+		{
+			name: "Burst",
+			rate: rate,
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+		{
+			name: "Refill",
+			rate: rate,
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+				{500 * time.Millisecond, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+		{
+			name: "RefillCappedAtBurst",
+			rate: rate,
+			steps: []step{
+				{0, true, 2, 0},
+				{time.Hour, true, 2, 0},
+				{0, true, 1, 0},
+			},
+		},
+		{
+			name: "BurstDefaultsToRate",
+			rate: Rate{RequestsPerMinute: 2},
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, 30 * time.Second},
+				{30 * time.Second, true, 0, 0},
+			},
+		},
+		{
+			name: "Unlimited",
+			rate: Rate{},
+			steps: []step{
+				{0, true, 0, 0},
+				{0, true, 0, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)
+			m := NewMemoryLimiter()
+			m.now = func() time.Time { return now }
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				res, err := m.Allow(context.Background(), "ip:127.0.0.1", tt.rate)
+				if err != nil {
+					t.Fatalf("第 %d 次请求出错: %v", i+1, err)
+				}
+				if res.Allowed != s.wantAllowed || res.Remaining != s.wantRemaining || res.RetryAfter != s.wantRetry {
+					t.Fatalf("第 %d 次请求得到 allowed=%t remaining=%d retry=%s，期望 allowed=%t remaining=%d retry=%s",
+						i+1, res.Allowed, res.Remaining, res.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterKeysIndependent(t *testing.T) {
+	m := NewMemoryLimiter()
+	rate := Rate{RequestsPerMinute: 1}
+	ctx := context.Background()
+	if res, _ := m.Allow(ctx, "ip:1", rate); !res.Allowed {
+		t.Fatal("第一个请求应放行")
+	}
+	if res, _ := m.Allow(ctx, "ip:1", rate); res.Allowed {
+		t.Fatal("同一个 key 超出限额后应被限流")
+	}
+	if res, _ := m.Allow(ctx, "ip:2", rate); !res.Allowed {
+		t.Fatal("不同 key 的令牌桶应互不影响")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 11:00:00
+ * @Description: Redis令牌桶限流器，多实例部署时共享限流状态
+ */
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript 在 Redis 中原子地完成令牌补充和扣减
+// KEYS[1] 令牌桶key
+// ARGV[1] 桶容量 ARGV[2] 补充一个令牌的毫秒数 ARGV[3] 当前毫秒时间戳
+// 返回 {是否放行, 剩余令牌*1000, 重试等待毫秒, 补满等待毫秒}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	last = now
+end
+
+tokens = math.min(capacity, tokens + (now - last) / interval)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * interval)
+end
+
+local reset = math.ceil((capacity - tokens) * interval)
+redis.call("HSET", KEYS[1], "tokens", tokens, "last", now)
+redis.call("PEXPIRE", KEYS[1], reset + interval)
+
+return {allowed, math.floor(tokens * 1000), retry, reset}
+`)
+
+type RedisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisLimiter(client redis.UniversalClient) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: "negaihoshi:ratelimit:",
+	}
+}
+
+func (r *RedisLimiter) Allow(ctx context.Context, key string, rate Rate) (Result, error) {
+	capacity := rate.Capacity()
+	interval := rate.Interval()
+	if capacity <= 0 || interval <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	values, err := tokenBucketScript.Run(ctx, r.client, []string{r.prefix + key},
+		capacity, interval.Milliseconds(), time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      capacity,
+		Remaining:  int(values[1] / 1000),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,176 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 21:00:00
+ * @Description: 实时推送的发布订阅中心
+ */
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrSlowConsumer 订阅方消费太慢，缓冲区写满后被断开，客户端应带上 Last-Event-ID 重连
+	ErrSlowConsumer = errors.New("realtime: subscriber too slow")
+	ErrClosed       = errors.New("realtime: subscription closed")
+)
+
+const (
+	// 每个订阅的缓冲事件数
+	subscriberBuffer = 64
+	// 每个主题保留的历史事件数，用于断线重连后补发
+	historySize = 256
+)
+
+// Event 推送事件，Id 在同一主题内单调递增，作为 SSE 的 id
+type Event struct {
+	Id    uint64          `json:"id"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// IdString SSE 的 id 字段
+func (e Event) IdString() string {
+	return strconv.FormatUint(e.Id, 10)
+}
+
+// Hub 发布订阅中心，有进程内和 Redis 两种实现
+type Hub interface {
+	Publish(ctx context.Context, topic, eventType string, data interface{}) error
+	// Subscribe 订阅主题，lastEventId 非 0 时先补发之后的历史事件
+	Subscribe(ctx context.Context, topic string, lastEventId uint64) (*Subscription, error)
+}
+
+// Subscription 一个连接的订阅
+type Subscription struct {
+	topic string
+	ch    chan Event
+	done  chan struct{}
+	once  sync.Once
+	err   error
+	// 补发的历史事件，在实时事件之前返回
+	replay []Event
+	// 已返回的最大事件 id，用于跳过补发和实时事件之间重复的部分
+	lastId uint64
+	// Resync 为 true 表示断开期间的事件已超出历史保留范围，客户端需要重新拉取列表
+	Resync bool
+	cancel func(*Subscription)
+}
+
+func newSubscription(topic string, lastEventId uint64) *Subscription {
+	return &Subscription{
+		topic:  topic,
+		ch:     make(chan Event, subscriberBuffer),
+		done:   make(chan struct{}),
+		lastId: lastEventId,
+	}
+}
+
+// Next 阻塞等待下一个事件
+func (s *Subscription) Next(ctx context.Context) (Event, error) {
+	if len(s.replay) > 0 {
+		event := s.replay[0]
+		s.replay = s.replay[1:]
+		s.lastId = event.Id
+		return event, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-s.done:
+			return Event{}, s.err
+		case event := <-s.ch:
+			if event.Id <= s.lastId {
+				continue
+			}
+			s.lastId = event.Id
+			return event, nil
+		}
+	}
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.stop(ErrClosed)
+	if s.cancel != nil {
+		s.cancel(s)
+	}
+}
+
+func (s *Subscription) stop(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// offer 非阻塞投递，缓冲区满时断开该订阅，避免拖慢发布方
+func (s *Subscription) offer(event Event) {
+	select {
+	case s.ch <- event:
+	default:
+		s.stop(ErrSlowConsumer)
+	}
+}
+
+// attachReplay 设置补发事件，history 为 lastId 之后的历史事件（按 id 升序），latest 为主题当前最大 id
+func (s *Subscription) attachReplay(history []Event, latest uint64) {
+	if s.lastId == 0 {
+		return
+	}
+	if s.lastId > latest {
+		// 事件 id 比服务端记录的还新，说明服务端重启过，id 已重新计数
+		s.Resync = true
+		s.lastId = latest
+		return
+	}
+	if len(history) > 0 {
+		s.Resync = history[0].Id > s.lastId+1
+	} else {
+		s.Resync = latest > s.lastId
+	}
+	s.replay = history
+}
+
+// broker 本实例内的订阅管理和分发
+type broker struct {
+	mu   sync.RWMutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+func (b *broker) add(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[sub.topic] == nil {
+		b.subs[sub.topic] = make(map[*Subscription]struct{})
+	}
+	b.subs[sub.topic][sub] = struct{}{}
+	sub.cancel = b.remove
+}
+
+func (b *broker) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs[sub.topic], sub)
+	if len(b.subs[sub.topic]) == 0 {
+		delete(b.subs, sub.topic)
+	}
+}
+
+func (b *broker) dispatch(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs[event.Topic] {
+		sub.offer(event)
+	}
+}
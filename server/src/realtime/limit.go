@@ -0,0 +1,49 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 21:00:00
+ * @Description: 长连接数限制
+ */
+package realtime
+
+import "sync"
+
+// ConnLimiter 限制单个客户端和本实例的长连接总数
+type ConnLimiter struct {
+	mu       sync.Mutex
+	perKey   int
+	total    int
+	counts   map[string]int
+	inflight int
+}
+
+func NewConnLimiter(perKey, total int) *ConnLimiter {
+	return &ConnLimiter{
+		perKey: perKey,
+		total:  total,
+		counts: make(map[string]int),
+	}
+}
+
+// Acquire 占用一个连接名额，成功时返回释放函数
+func (l *ConnLimiter) Acquire(key string) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= l.total || l.counts[key] >= l.perKey {
+		return nil, false
+	}
+	l.inflight++
+	l.counts[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.inflight--
+			l.counts[key]--
+			if l.counts[key] <= 0 {
+				delete(l.counts, key)
+			}
+		})
+	}, true
+}
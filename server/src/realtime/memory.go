@@ -0,0 +1,68 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 21:00:00
+ * @Description: 进程内发布订阅，单实例部署使用
+ */
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type MemoryHub struct {
+	*broker
+	mu      sync.Mutex
+	seq     map[string]uint64
+	history map[string][]Event
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		broker:  newBroker(),
+		seq:     make(map[string]uint64),
+		history: make(map[string][]Event),
+	}
+}
+
+func (h *MemoryHub) Publish(ctx context.Context, topic, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// 分配 id 和分发在同一把锁内完成，保证订阅方按 id 顺序收到事件
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq[topic]++
+	event := Event{
+		Id:    h.seq[topic],
+		Topic: topic,
+		Type:  eventType,
+		Data:  payload,
+	}
+	history := append(h.history[topic], event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	h.history[topic] = history
+	h.dispatch(event)
+	return nil
+}
+
+func (h *MemoryHub) Subscribe(ctx context.Context, topic string, lastEventId uint64) (*Subscription, error) {
+	sub := newSubscription(topic, lastEventId)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.add(sub)
+	var replay []Event
+	for _, event := range h.history[topic] {
+		if event.Id > lastEventId {
+			replay = append(replay, event)
+		}
+	}
+	sub.attachReplay(replay, h.seq[topic])
+	return sub, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 13:00:00
+ * @Description: 进程内发布订阅按 Last-Event-ID 补发的测试
+ */
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testTopic = "treehole"
+
+func publishN(t *testing.T, hub Hub, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := hub.Publish(context.Background(), testTopic, "treehole.created", map[string]int{"n": i}); err != nil {
+			t.Fatalf("发布失败: %v", err)
+		}
+	}
+}
+
+// nextIds 依次读取 n 个事件的 id
+func nextIds(t *testing.T, sub *Subscription, n int) []uint64 {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ids := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		event, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatalf("读取第 %d 个事件失败: %v", i+1, err)
+		}
+		ids = append(ids, event.Id)
+	}
+	return ids
+}
+
+func TestMemoryHubReplay(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   int
+		lastEventId uint64
+		wantResync  bool
+		// 订阅后再发布一个事件，期望依次读到的事件 id
+		wantIds []uint64
+	}{
+		{"NoLastEventId", 5, 0, false, []uint64{6}},
+		{"ReplayMissed", 5, 3, false, []uint64{4, 5, 6}},
+		{"UpToDate", 5, 5, false, []uint64{6}},
+		// id 比服务端记录的还新，说明服务端重启过
+		{"AheadOfServer", 5, 10, true, []uint64{6}},
+		{"EmptyTopicAfterRestart", 0, 3, true, []uint64{1}},
+		// 断开期间的事件超出历史保留范围，只补发保留的部分
+		{"HistoryOverflow", historySize + 10, 1, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewMemoryHub()
+			publishN(t, hub, tt.published)
+			sub, err := hub.Subscribe(context.Background(), testTopic, tt.lastEventId)
+			if err != nil {
+				t.Fatalf("订阅失败: %v", err)
+			}
+			defer sub.Close()
+			if sub.Resync != tt.wantResync {
+				t.Fatalf("Resync 为 %t，期望 %t", sub.Resync, tt.wantResync)
+			}
+			publishN(t, hub, 1)
+
+			want := tt.wantIds
+			if want == nil {
+				// 保留最近 historySize 个事件，加上订阅后发布的一个
+				for id := uint64(tt.published - historySize + 1); id <= uint64(tt.published+1); id++ {
+					want = append(want, id)
+				}
+			}
+			got := nextIds(t, sub, len(want))
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("读到的事件 id 为 %v，期望 %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryHubTopicsIndependent(t *testing.T) {
+	hub := NewMemoryHub()
+	ctx := context.Background()
+	if err := hub.Publish(ctx, "other", "x", nil); err != nil {
+		t.Fatalf("发布失败: %v", err)
+	}
+	sub, err := hub.Subscribe(ctx, testTopic, 1)
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	defer sub.Close()
+	// 其他主题的事件不计入本主题的 id，本主题从未发布过，订阅方的 id 比服务端新
+	if !sub.Resync {
+		t.Fatal("主题的事件 id 应独立计数")
+	}
+	publishN(t, hub, 1)
+	if got := nextIds(t, sub, 1); got[0] != 1 {
+		t.Fatalf("读到的事件 id 为 %v，期望 [1]", got)
+	}
+}
+
+func TestMemoryHubSlowConsumer(t *testing.T) {
+	hub := NewMemoryHub()
+	sub, err := hub.Subscribe(context.Background(), testTopic, 0)
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	defer sub.Close()
+	publishN(t, hub, subscriberBuffer+1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; ; i++ {
+		_, err := sub.Next(ctx)
+		if errors.Is(err, ErrSlowConsumer) {
+			return
+		}
+		if err != nil || i > subscriberBuffer {
+			t.Fatalf("缓冲区写满后应返回 ErrSlowConsumer，得到 %v", err)
+		}
+	}
+}
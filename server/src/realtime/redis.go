@@ -0,0 +1,137 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 21:00:00
+ * @Description: 基于 Redis 发布订阅的实现，多实例部署时各实例的订阅方都能收到事件
+ */
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "realtime:"
+
+// 长时间没有新事件的主题自动清理，私信按用户分主题，避免不活跃用户的键一直留在 Redis 中。
+// 序号过期后重新计数，客户端带着旧 id 重连时会收到 resync
+const redisTopicTTL = 24 * time.Hour
+
+// 在一个脚本内分配 id、写入历史并发布，多个实例同时发布时事件也按 id 顺序到达
+// KEYS[1] 序号 KEYS[2] 历史 ARGV[1] 主题 ARGV[2] 类型 ARGV[3] 数据 ARGV[4] 历史保留数 ARGV[5] 频道 ARGV[6] 过期秒数
+const publishScript = `
+local id = redis.call('INCR', KEYS[1])
+local event = '{"id":' .. id .. ',"topic":' .. cjson.encode(ARGV[1]) .. ',"type":' .. cjson.encode(ARGV[2]) .. ',"data":' .. ARGV[3] .. '}'
+redis.call('ZADD', KEYS[2], id, event)
+redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -(tonumber(ARGV[4]) + 1))
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+redis.call('EXPIRE', KEYS[2], ARGV[6])
+redis.call('PUBLISH', ARGV[5], event)
+return id
+`
+
+type RedisHub struct {
+	*broker
+	client redis.UniversalClient
+}
+
+func NewRedisHub(client redis.UniversalClient) *RedisHub {
+	return &RedisHub{
+		broker: newBroker(),
+		client: client,
+	}
+}
+
+func (h *RedisHub) Publish(ctx context.Context, topic, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.client.Eval(ctx, publishScript,
+		[]string{h.seqKey(topic), h.historyKey(topic)},
+		topic, eventType, string(payload), historySize, h.channel(), int(redisTopicTTL.Seconds()),
+	).Err()
+}
+
+func (h *RedisHub) Subscribe(ctx context.Context, topic string, lastEventId uint64) (*Subscription, error) {
+	sub := newSubscription(topic, lastEventId)
+	h.add(sub)
+	if lastEventId == 0 {
+		return sub, nil
+	}
+
+	// 先读序号再读历史，期间新发布的事件只会出现在历史里，不会被误判为缺失
+	latest, err := h.client.Get(ctx, h.seqKey(topic)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		sub.Close()
+		return nil, err
+	}
+	members, err := h.client.ZRangeByScore(ctx, h.historyKey(topic), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatUint(lastEventId, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	replay := make([]Event, 0, len(members))
+	for _, member := range members {
+		var event Event
+		if err := json.Unmarshal([]byte(member), &event); err == nil {
+			replay = append(replay, event)
+		}
+	}
+	sub.attachReplay(replay, uint64(latest))
+	return sub, nil
+}
+
+// Run 订阅 Redis 频道并分发给本实例的订阅方，连接断开后自动重试，ctx 取消时退出
+func (h *RedisHub) Run(ctx context.Context) {
+	for {
+		h.listen(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *RedisHub) listen(ctx context.Context) {
+	pubsub := h.client.Subscribe(ctx, h.channel())
+	defer pubsub.Close()
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("解析实时事件失败: %v", err)
+				continue
+			}
+			h.dispatch(event)
+		}
+	}
+}
+
+func (h *RedisHub) seqKey(topic string) string {
+	return redisKeyPrefix + topic + ":seq"
+}
+
+func (h *RedisHub) historyKey(topic string) string {
+	return redisKeyPrefix + topic + ":history"
+}
+
+func (h *RedisHub) channel() string {
+	return redisKeyPrefix + "events"
+}
@@ -0,0 +1,52 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 安全审计日志仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type AuditLogRepository struct {
+	dao *dao.AuditLogDAO
+}
+
+func NewAuditLogRepository(dao *dao.AuditLogDAO) *AuditLogRepository {
+	return &AuditLogRepository{
+		dao: dao,
+	}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, log domain.AuditLog) error {
+	return r.dao.Insert(ctx, dao.AuditLog{
+		UserId:  log.UserId,
+		Action:  log.Action,
+		Ip:      log.Ip,
+		Message: log.Message,
+	})
+}
+
+func (r *AuditLogRepository) GetList(ctx context.Context, action string, offset, limit int) ([]domain.AuditLog, int64, error) {
+	logs, total, err := r.dao.FindByPage(ctx, action, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.AuditLog, 0, len(logs))
+	for _, l := range logs {
+		results = append(results, domain.AuditLog{
+			Id:      l.Id,
+			UserId:  l.UserId,
+			Action:  l.Action,
+			Ip:      l.Ip,
+			Message: l.Message,
+			Ctime:   time.UnixMilli(l.Ctime),
+		})
+	}
+	return results, total, nil
+}
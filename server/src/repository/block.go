@@ -0,0 +1,85 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户屏蔽和静音仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type UserBlockRepository struct {
+	dao *dao.UserBlockDAO
+}
+
+func NewUserBlockRepository(dao *dao.UserBlockDAO) *UserBlockRepository {
+	return &UserBlockRepository{
+		dao: dao,
+	}
+}
+
+func (r *UserBlockRepository) Create(ctx context.Context, userId, blockedId int64) error {
+	return r.dao.Insert(ctx, userId, blockedId)
+}
+
+func (r *UserBlockRepository) Delete(ctx context.Context, userId, blockedId int64) error {
+	return r.dao.Delete(ctx, userId, blockedId)
+}
+
+func (r *UserBlockRepository) GetList(ctx context.Context, userId int64, offset, limit int) ([]domain.UserBlock, int64, error) {
+	list, total, err := r.dao.FindByUser(ctx, userId, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.UserBlock, 0, len(list))
+	for _, b := range list {
+		results = append(results, domain.UserBlock{
+			UserId:    b.UserId,
+			BlockedId: b.BlockedId,
+			Ctime:     time.UnixMilli(b.Ctime),
+		})
+	}
+	return results, total, nil
+}
+
+func (r *UserBlockRepository) ExistsEither(ctx context.Context, a, b int64) (bool, error) {
+	return r.dao.ExistsEither(ctx, a, b)
+}
+
+func (r *UserBlockRepository) CreateMute(ctx context.Context, userId, mutedId int64) error {
+	return r.dao.InsertMute(ctx, userId, mutedId)
+}
+
+func (r *UserBlockRepository) DeleteMute(ctx context.Context, userId, mutedId int64) error {
+	return r.dao.DeleteMute(ctx, userId, mutedId)
+}
+
+func (r *UserBlockRepository) GetMuteList(ctx context.Context, userId int64, offset, limit int) ([]domain.UserMute, int64, error) {
+	list, total, err := r.dao.FindMutesByUser(ctx, userId, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.UserMute, 0, len(list))
+	for _, m := range list {
+		results = append(results, domain.UserMute{
+			UserId:  m.UserId,
+			MutedId: m.MutedId,
+			Ctime:   time.UnixMilli(m.Ctime),
+		})
+	}
+	return results, total, nil
+}
+
+// BlockedIds 与用户存在双向屏蔽关系的用户
+func (r *UserBlockRepository) BlockedIds(ctx context.Context, userId int64) ([]int64, error) {
+	return r.dao.FindBlockedIds(ctx, userId)
+}
+
+func (r *UserBlockRepository) MutedIds(ctx context.Context, userId int64) ([]int64, error) {
+	return r.dao.FindMutedIds(ctx, userId)
+}
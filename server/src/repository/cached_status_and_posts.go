@@ -0,0 +1,97 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 17:00:00
+ * @Description: 带缓存的动态和文章仓储，缓存单条动态和文章
+ */
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+)
+
+// CachedStatusAndPostsRepository 包装另一个实现，未覆盖的方法直接调用被包装的实现。
+// 修改动态或文章的方法都必须删除对应的缓存
+type CachedStatusAndPostsRepository struct {
+	StatusAndPostsRepository
+	cache *cache.Cache
+	ttl   time.Duration
+}
+
+func NewCachedStatusAndPostsRepository(repo StatusAndPostsRepository, c *cache.Cache, ttl time.Duration) *CachedStatusAndPostsRepository {
+	return &CachedStatusAndPostsRepository{StatusAndPostsRepository: repo, cache: c, ttl: ttl}
+}
+
+func (r *CachedStatusAndPostsRepository) GetStatus(ctx context.Context, id int64) (domain.Status, error) {
+	return cache.Fetch(ctx, r.cache, statusCacheKey(id), r.ttl, func(ctx context.Context) (domain.Status, error) {
+		return r.StatusAndPostsRepository.GetStatus(ctx, id)
+	})
+}
+
+func (r *CachedStatusAndPostsRepository) GetPosts(ctx context.Context, id int64) (domain.Posts, error) {
+	return cache.Fetch(ctx, r.cache, postsCacheKey(id), r.ttl, func(ctx context.Context) (domain.Posts, error) {
+		return r.StatusAndPostsRepository.GetPosts(ctx, id)
+	})
+}
+
+func (r *CachedStatusAndPostsRepository) EditStatus(ctx context.Context, status domain.Status, editorId int64) error {
+	defer r.cache.Delete(ctx, statusCacheKey(status.Id))
+	return r.StatusAndPostsRepository.EditStatus(ctx, status, editorId)
+}
+
+func (r *CachedStatusAndPostsRepository) EditPosts(ctx context.Context, posts domain.Posts, editorId int64) error {
+	defer r.cache.Delete(ctx, postsCacheKey(posts.Id))
+	return r.StatusAndPostsRepository.EditPosts(ctx, posts, editorId)
+}
+
+func (r *CachedStatusAndPostsRepository) DeleteStatus(ctx context.Context, id, deletedBy int64) error {
+	defer r.cache.Delete(ctx, statusCacheKey(id))
+	return r.StatusAndPostsRepository.DeleteStatus(ctx, id, deletedBy)
+}
+
+func (r *CachedStatusAndPostsRepository) DeletePosts(ctx context.Context, id, deletedBy int64) error {
+	defer r.cache.Delete(ctx, postsCacheKey(id))
+	return r.StatusAndPostsRepository.DeletePosts(ctx, id, deletedBy)
+}
+
+func (r *CachedStatusAndPostsRepository) SetStatusHidden(ctx context.Context, id int64, hidden bool) error {
+	defer r.cache.Delete(ctx, statusCacheKey(id))
+	return r.StatusAndPostsRepository.SetStatusHidden(ctx, id, hidden)
+}
+
+func (r *CachedStatusAndPostsRepository) SetPostsHidden(ctx context.Context, id int64, hidden bool) error {
+	defer r.cache.Delete(ctx, postsCacheKey(id))
+	return r.StatusAndPostsRepository.SetPostsHidden(ctx, id, hidden)
+}
+
+func (r *CachedStatusAndPostsRepository) RestoreStatus(ctx context.Context, id int64) error {
+	defer r.cache.Delete(ctx, statusCacheKey(id))
+	return r.StatusAndPostsRepository.RestoreStatus(ctx, id)
+}
+
+func (r *CachedStatusAndPostsRepository) RestorePosts(ctx context.Context, id int64) error {
+	defer r.cache.Delete(ctx, postsCacheKey(id))
+	return r.StatusAndPostsRepository.RestorePosts(ctx, id)
+}
+
+func (r *CachedStatusAndPostsRepository) ChangePostsState(ctx context.Context, id int64, state string, publishAt time.Time, crossPost bool) error {
+	defer r.cache.Delete(ctx, postsCacheKey(id))
+	return r.StatusAndPostsRepository.ChangePostsState(ctx, id, state, publishAt, crossPost)
+}
+
+func (r *CachedStatusAndPostsRepository) PublishScheduledPosts(ctx context.Context, id int64, now time.Time) (bool, error) {
+	defer r.cache.Delete(ctx, postsCacheKey(id))
+	return r.StatusAndPostsRepository.PublishScheduledPosts(ctx, id, now)
+}
+
+func statusCacheKey(id int64) string {
+	return "status:" + strconv.FormatInt(id, 10)
+}
+
+func postsCacheKey(id int64) string {
+	return "posts:" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,110 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 17:00:00
+ * @Description: 带缓存的树洞仓储，缓存单条树洞和公共列表的分页
+ */
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+)
+
+// 树洞列表缓存组，新增、删除、隐藏和恢复树洞时整组失效
+const treeHoleListGroup = "treehole_list"
+
+// CachedTreeHoleRepository 包装另一个实现，未覆盖的方法直接调用被包装的实现。
+// 修改树洞的方法都必须删除对应的缓存
+type CachedTreeHoleRepository struct {
+	TreeHoleRepository
+	cache   *cache.Cache
+	itemTTL time.Duration
+	listTTL time.Duration
+}
+
+func NewCachedTreeHoleRepository(repo TreeHoleRepository, c *cache.Cache, itemTTL, listTTL time.Duration) *CachedTreeHoleRepository {
+	return &CachedTreeHoleRepository{TreeHoleRepository: repo, cache: c, itemTTL: itemTTL, listTTL: listTTL}
+}
+
+func (r *CachedTreeHoleRepository) GetById(ctx context.Context, id int64) (domain.TreeHole, error) {
+	return cache.Fetch(ctx, r.cache, treeHoleCacheKey(id), r.itemTTL, func(ctx context.Context) (domain.TreeHole, error) {
+		return r.TreeHoleRepository.GetById(ctx, id)
+	})
+}
+
+func (r *CachedTreeHoleRepository) GetList(ctx context.Context, offset, limit int, excludeUserIds []int64) ([]domain.TreeHole, error) {
+	key := fmt.Sprintf("%s:%s:%d:%d:%s", treeHoleListGroup, r.cache.Version(ctx, treeHoleListGroup), offset, limit, userIdsKey(excludeUserIds))
+	return cache.Fetch(ctx, r.cache, key, r.listTTL, func(ctx context.Context) ([]domain.TreeHole, error) {
+		return r.TreeHoleRepository.GetList(ctx, offset, limit, excludeUserIds)
+	})
+}
+
+func (r *CachedTreeHoleRepository) Create(ctx context.Context, treeHole domain.TreeHole) (domain.TreeHole, error) {
+	created, err := r.TreeHoleRepository.Create(ctx, treeHole)
+	if err == nil {
+		r.cache.Bump(ctx, treeHoleListGroup)
+	}
+	return created, err
+}
+
+func (r *CachedTreeHoleRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	defer r.invalidate(ctx, id)
+	return r.TreeHoleRepository.Delete(ctx, id, deletedBy)
+}
+
+func (r *CachedTreeHoleRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	defer r.invalidate(ctx, id)
+	return r.TreeHoleRepository.SetHidden(ctx, id, hidden)
+}
+
+func (r *CachedTreeHoleRepository) Restore(ctx context.Context, id int64) error {
+	defer r.invalidate(ctx, id)
+	return r.TreeHoleRepository.Restore(ctx, id)
+}
+
+// invalidate 写入失败时同样删除缓存，数据库可能已部分修改
+func (r *CachedTreeHoleRepository) invalidate(ctx context.Context, id int64) {
+	InvalidateTreeHoleCache(ctx, r.cache, id)
+}
+
+// InvalidateTreeHoleCache 删除单条树洞的缓存并使列表缓存失效，供不经过该仓储修改树洞的地方调用
+func InvalidateTreeHoleCache(ctx context.Context, c *cache.Cache, ids ...int64) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, treeHoleCacheKey(id))
+	}
+	c.Delete(ctx, keys...)
+	c.Bump(ctx, treeHoleListGroup)
+}
+
+func treeHoleCacheKey(id int64) string {
+	return "treehole:" + strconv.FormatInt(id, 10)
+}
+
+// userIdsKey 把排除的用户列表转为缓存键的一部分，顺序不同的相同列表得到相同的键
+func userIdsKey(ids []int64) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	joined := strings.Join(parts, ",")
+	if len(joined) <= 64 {
+		return joined
+	}
+	sum := sha256.Sum256([]byte(joined))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,50 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 安全审计日志数据访问
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLog struct {
+	Id      int64  `gorm:"primaryKey;autoIncrement"`
+	UserId  int64  `gorm:"index"`
+	Action  string `gorm:"size:50;index"`
+	Ip      string `gorm:"size:64"`
+	Message string `gorm:"size:500"`
+	Ctime   int64  `gorm:"index"`
+}
+
+type AuditLogDAO struct {
+	db *gorm.DB
+}
+
+func NewAuditLogDAO(db *gorm.DB) *AuditLogDAO {
+	return &AuditLogDAO{db: db}
+}
+
+func (dao *AuditLogDAO) Insert(ctx context.Context, log AuditLog) error {
+	log.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&log).Error
+}
+
+// FindByPage 按事件类型分页查询，action 为空时查询全部
+func (dao *AuditLogDAO) FindByPage(ctx context.Context, action string, offset, limit int) ([]AuditLog, int64, error) {
+	var logs []AuditLog
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&AuditLog{})
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
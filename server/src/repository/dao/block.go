@@ -0,0 +1,110 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户屏蔽和静音数据访问
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserBlock struct {
+	Id        int64 `gorm:"primaryKey;autoIncrement"`
+	UserId    int64 `gorm:"uniqueIndex:idx_user_blocked,priority:1"`
+	BlockedId int64 `gorm:"uniqueIndex:idx_user_blocked,priority:2;index"`
+	Ctime     int64
+}
+
+type UserMute struct {
+	Id      int64 `gorm:"primaryKey;autoIncrement"`
+	UserId  int64 `gorm:"uniqueIndex:idx_user_muted,priority:1"`
+	MutedId int64 `gorm:"uniqueIndex:idx_user_muted,priority:2"`
+	Ctime   int64
+}
+
+type UserBlockDAO struct {
+	db *gorm.DB
+}
+
+func NewUserBlockDAO(db *gorm.DB) *UserBlockDAO {
+	return &UserBlockDAO{db: db}
+}
+
+// Insert 重复屏蔽时忽略
+func (dao *UserBlockDAO) Insert(ctx context.Context, userId, blockedId int64) error {
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&UserBlock{
+		UserId:    userId,
+		BlockedId: blockedId,
+		Ctime:     time.Now().UnixMilli(),
+	}).Error
+}
+
+func (dao *UserBlockDAO) Delete(ctx context.Context, userId, blockedId int64) error {
+	return dao.db.WithContext(ctx).Where("user_id = ? AND blocked_id = ?", userId, blockedId).Delete(&UserBlock{}).Error
+}
+
+func (dao *UserBlockDAO) FindByUser(ctx context.Context, userId int64, offset, limit int) ([]UserBlock, int64, error) {
+	var list []UserBlock
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&UserBlock{}).Where("user_id = ?", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+// ExistsEither 任意一方屏蔽了另一方
+func (dao *UserBlockDAO) ExistsEither(ctx context.Context, a, b int64) (bool, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&UserBlock{}).
+		Where("(user_id = ? AND blocked_id = ?) OR (user_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// InsertMute 重复静音时忽略
+func (dao *UserBlockDAO) InsertMute(ctx context.Context, userId, mutedId int64) error {
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&UserMute{
+		UserId:  userId,
+		MutedId: mutedId,
+		Ctime:   time.Now().UnixMilli(),
+	}).Error
+}
+
+func (dao *UserBlockDAO) DeleteMute(ctx context.Context, userId, mutedId int64) error {
+	return dao.db.WithContext(ctx).Where("user_id = ? AND muted_id = ?", userId, mutedId).Delete(&UserMute{}).Error
+}
+
+func (dao *UserBlockDAO) FindMutesByUser(ctx context.Context, userId int64, offset, limit int) ([]UserMute, int64, error) {
+	var list []UserMute
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&UserMute{}).Where("user_id = ?", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+// FindBlockedIds 与用户存在屏蔽关系的全部用户，包括用户屏蔽的和屏蔽了用户的
+func (dao *UserBlockDAO) FindBlockedIds(ctx context.Context, userId int64) ([]int64, error) {
+	var blocked, blockedBy []int64
+	err := dao.db.WithContext(ctx).Model(&UserBlock{}).Where("user_id = ?", userId).Pluck("blocked_id", &blocked).Error
+	if err != nil {
+		return nil, err
+	}
+	err = dao.db.WithContext(ctx).Model(&UserBlock{}).Where("blocked_id = ?", userId).Pluck("user_id", &blockedBy).Error
+	return append(blocked, blockedBy...), err
+}
+
+func (dao *UserBlockDAO) FindMutedIds(ctx context.Context, userId int64) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&UserMute{}).Where("user_id = ?", userId).Pluck("muted_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,151 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 15:00:00
+ * @Description: 用户数据导出任务
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDataExportNotFound = gorm.ErrRecordNotFound
+	// ErrDataExportDuplicate 用户当天已有未失败的导出任务
+	ErrDataExportDuplicate = errors.New("当天已申请过导出")
+)
+
+// 导出日期的格式，按服务器时区划分
+const exportDayLayout = "2006-01-02"
+
+// 导出任务状态
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+type DataExport struct {
+	Id     int64
+	UserId int64  `gorm:"index:idx_user_ctime,priority:1;uniqueIndex:idx_user_export_day,priority:1"`
+	Status string `gorm:"size:20;index:idx_status"`
+	// 导出目录中的文件名，生成完成后才有值
+	FileName string `gorm:"size:255"`
+	// 下载链接中携带的随机令牌，生成完成时写入，过期后清空
+	DownloadToken string `gorm:"size:64"`
+	Size          int64
+	Error         string `gorm:"size:500"`
+	// 申请导出的日期，与 user_id 组成唯一索引限制每天一次；任务失败后清空，允许当天重新申请
+	ExportDay *string `gorm:"size:10;uniqueIndex:idx_user_export_day,priority:2"`
+	// 下载链接的过期时间，过期后删除文件
+	ExpireAt int64
+	Ctime    int64 `gorm:"index:idx_user_ctime,priority:2"`
+	Utime    int64
+}
+
+type DataExportDAO struct {
+	db *gorm.DB
+}
+
+func NewDataExportDAO(db *gorm.DB) *DataExportDAO {
+	return &DataExportDAO{db: db}
+}
+
+// Insert 创建导出任务，用户当天已有未失败的任务时返回 ErrDataExportDuplicate
+func (dao *DataExportDAO) Insert(ctx context.Context, export DataExport) (DataExport, error) {
+	now := time.Now()
+	day := now.Format(exportDayLayout)
+	export.Status = DataExportPending
+	export.ExportDay = &day
+	export.Ctime = now.UnixMilli()
+	export.Utime = now.UnixMilli()
+	err := dao.db.WithContext(ctx).Create(&export).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return DataExport{}, ErrDataExportDuplicate
+	}
+	return export, err
+}
+
+func (dao *DataExportDAO) FindById(ctx context.Context, id int64) (DataExport, error) {
+	var export DataExport
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&export).Error
+	return export, err
+}
+
+// FindByUser 按创建时间倒序查询用户最近的导出任务
+func (dao *DataExportDAO) FindByUser(ctx context.Context, userId int64, limit int) ([]DataExport, error) {
+	var exports []DataExport
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).
+		Order("ctime DESC, id DESC").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// FindPending 按创建先后查询等待执行的任务
+func (dao *DataExportDAO) FindPending(ctx context.Context, limit int) ([]DataExport, error) {
+	var exports []DataExport
+	err := dao.db.WithContext(ctx).Where("status = ?", DataExportPending).
+		Order("id").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// Claim 把等待中的任务标记为执行中，多个实例同时执行时只有一个会成功
+func (dao *DataExportDAO) Claim(ctx context.Context, id int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&DataExport{}).Where("id = ? AND status = ?", id, DataExportPending).
+		Updates(map[string]interface{}{
+			"status": DataExportRunning,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ResetStale 把 before 之前开始执行仍未结束的任务重新放回队列，用于实例中途退出的情况
+func (dao *DataExportDAO) ResetStale(ctx context.Context, before int64) error {
+	return dao.db.WithContext(ctx).Model(&DataExport{}).Where("status = ? AND utime < ?", DataExportRunning, before).
+		Updates(map[string]interface{}{
+			"status": DataExportPending,
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *DataExportDAO) Complete(ctx context.Context, id int64, fileName, downloadToken string, size, expireAt int64) error {
+	return dao.db.WithContext(ctx).Model(&DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         DataExportReady,
+		"file_name":      fileName,
+		"download_token": downloadToken,
+		"size":           size,
+		"expire_at":      expireAt,
+		"utime":          time.Now().UnixMilli(),
+	}).Error
+}
+
+// Fail 标记任务失败并清空导出日期，失败的任务不占用当天的导出次数
+func (dao *DataExportDAO) Fail(ctx context.Context, id int64, reason string) error {
+	return dao.db.WithContext(ctx).Model(&DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     DataExportFailed,
+		"error":      reason,
+		"export_day": nil,
+		"utime":      time.Now().UnixMilli(),
+	}).Error
+}
+
+// FindExpired 查询下载链接已过期但文件还未删除的任务
+func (dao *DataExportDAO) FindExpired(ctx context.Context, now int64, limit int) ([]DataExport, error) {
+	var exports []DataExport
+	err := dao.db.WithContext(ctx).Where("status = ? AND expire_at < ?", DataExportReady, now).
+		Order("expire_at").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+func (dao *DataExportDAO) MarkExpired(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         DataExportExpired,
+		"download_token": "",
+		"utime":          time.Now().UnixMilli(),
+	}).Error
+}
@@ -0,0 +1,155 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 用户关注关系数据访问
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Follow struct {
+	Id         int64 `gorm:"primaryKey;autoIncrement"`
+	FollowerId int64 `gorm:"uniqueIndex:idx_follower_followee,priority:1"`
+	FolloweeId int64 `gorm:"uniqueIndex:idx_follower_followee,priority:2;index"`
+	Ctime      int64
+}
+
+// FollowCount 关注数和粉丝数，关注和取消关注时在同一事务中更新
+type FollowCount struct {
+	UserId    int64 `gorm:"primaryKey;autoIncrement:false"`
+	Followers int64 `gorm:"index"`
+	Following int64
+}
+
+type FollowDAO struct {
+	db *gorm.DB
+}
+
+func NewFollowDAO(db *gorm.DB) *FollowDAO {
+	return &FollowDAO{db: db}
+}
+
+// Insert 已关注时返回 false
+func (dao *FollowDAO) Insert(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	inserted := false
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Follow{
+			FollowerId: followerId,
+			FolloweeId: followeeId,
+			Ctime:      time.Now().UnixMilli(),
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		inserted = true
+		if err := incrFollowCount(tx, followeeId, "followers", 1); err != nil {
+			return err
+		}
+		return incrFollowCount(tx, followerId, "following", 1)
+	})
+	return inserted, err
+}
+
+// Delete 未关注时返回 false
+func (dao *FollowDAO) Delete(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	deleted := false
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("follower_id = ? AND followee_id = ?", followerId, followeeId).Delete(&Follow{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = true
+		if err := incrFollowCount(tx, followeeId, "followers", -1); err != nil {
+			return err
+		}
+		return incrFollowCount(tx, followerId, "following", -1)
+	})
+	return deleted, err
+}
+
+func incrFollowCount(tx *gorm.DB, userId int64, column string, delta int64) error {
+	count := FollowCount{UserId: userId}
+	if delta > 0 {
+		if column == "followers" {
+			count.Followers = delta
+		} else {
+			count.Following = delta
+		}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		// PostgreSQL 中未加表名的列会与 EXCLUDED 产生歧义
+		DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr("follow_counts."+column+" + ?", delta)}),
+	}).Create(&count).Error
+}
+
+func (dao *FollowDAO) Exists(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&Follow{}).
+		Where("follower_id = ? AND followee_id = ?", followerId, followeeId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindCount 没有任何关注关系的用户返回零值
+func (dao *FollowDAO) FindCount(ctx context.Context, userId int64) (FollowCount, error) {
+	var count FollowCount
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).First(&count).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return FollowCount{UserId: userId}, nil
+	}
+	return count, err
+}
+
+// FindFollowers 按关注时间倒序分页，beforeId 为上一页最后一条记录的 id
+func (dao *FollowDAO) FindFollowers(ctx context.Context, followeeId, beforeId int64, limit int) ([]Follow, error) {
+	return dao.findPage(ctx, "followee_id", followeeId, beforeId, limit)
+}
+
+func (dao *FollowDAO) FindFollowing(ctx context.Context, followerId, beforeId int64, limit int) ([]Follow, error) {
+	return dao.findPage(ctx, "follower_id", followerId, beforeId, limit)
+}
+
+func (dao *FollowDAO) findPage(ctx context.Context, column string, userId, beforeId int64, limit int) ([]Follow, error) {
+	var list []Follow
+	query := dao.db.WithContext(ctx).Where(column+" = ?", userId)
+	if beforeId > 0 {
+		query = query.Where("id < ?", beforeId)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// FindFolloweeIds 用户关注的全部用户
+func (dao *FollowDAO) FindFolloweeIds(ctx context.Context, followerId int64) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Follow{}).Where("follower_id = ?", followerId).Pluck("followee_id", &ids).Error
+	return ids, err
+}
+
+// FindFollowerIds 按粉丝 id 升序分批读取，afterId 为上一批最后一个粉丝的 id
+func (dao *FollowDAO) FindFollowerIds(ctx context.Context, followeeId, afterId int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Follow{}).
+		Where("followee_id = ? AND follower_id > ?", followeeId, afterId).
+		Order("follower_id").Limit(limit).
+		Pluck("follower_id", &ids).Error
+	return ids, err
+}
+
+// FindPopularFolloweeIds 用户关注的人中粉丝数不少于 minFollowers 的用户
+func (dao *FollowDAO) FindPopularFolloweeIds(ctx context.Context, followerId, minFollowers int64) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Follow{}).
+		Joins("JOIN follow_counts ON follow_counts.user_id = follows.followee_id").
+		Where("follows.follower_id = ? AND follow_counts.followers >= ?", followerId, minFollowers).
+		Pluck("follows.followee_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,103 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 批量导入外部内容，并记录来源用于重复导入时跳过
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrImportDuplicate 同一来源的记录已经导入过
+var ErrImportDuplicate = errors.New("该记录已导入")
+
+// 导入内容的类型
+const (
+	ImportTargetTreeHole = "treehole"
+	ImportTargetStatus   = "status"
+	ImportTargetPost     = "post"
+)
+
+type ImportRecord struct {
+	Id int64
+	// 来源格式，如 negaihoshi/csv/twitter
+	Source string `gorm:"size:50;uniqueIndex:idx_source_external,priority:1"`
+	// 记录在来源中的唯一标识
+	ExternalId string `gorm:"size:128;uniqueIndex:idx_source_external,priority:2"`
+	TargetType string `gorm:"size:20"`
+	TargetId   int64
+	Ctime      int64
+}
+
+type ImportDAO struct {
+	db *gorm.DB
+}
+
+func NewImportDAO(db *gorm.DB) *ImportDAO {
+	return &ImportDAO{db: db}
+}
+
+// FindImported 返回 externalIds 中已经导入过的记录
+func (dao *ImportDAO) FindImported(ctx context.Context, source string, externalIds []string) (map[string]bool, error) {
+	imported := make(map[string]bool)
+	if len(externalIds) == 0 {
+		return imported, nil
+	}
+	var ids []string
+	err := dao.db.WithContext(ctx).Model(&ImportRecord{}).
+		Where("source = ? AND external_id IN ?", source, externalIds).
+		Pluck("external_id", &ids).Error
+	for _, id := range ids {
+		imported[id] = true
+	}
+	return imported, err
+}
+
+// InsertTreeHole 保留原始创建时间写入树洞，与导入记录在同一事务中写入
+func (dao *ImportDAO) InsertTreeHole(ctx context.Context, source, externalId string, treeHole TreeHole) (TreeHole, error) {
+	treeHole.Utime = treeHole.Ctime
+	err := dao.insert(ctx, source, externalId, ImportTargetTreeHole, &treeHole, func() int64 { return treeHole.Id })
+	return treeHole, err
+}
+
+// InsertStatus 保留原始创建时间写入动态
+func (dao *ImportDAO) InsertStatus(ctx context.Context, source, externalId string, status Status) (Status, error) {
+	status.Utime = status.Ctime
+	err := dao.insert(ctx, source, externalId, ImportTargetStatus, &status, func() int64 { return status.Id })
+	return status, err
+}
+
+// InsertPosts 保留原始创建时间、状态和发布时间写入文章
+func (dao *ImportDAO) InsertPosts(ctx context.Context, source, externalId string, posts Posts) (Posts, error) {
+	posts.Utime = posts.Ctime
+	if posts.State == "" {
+		posts.State = PostStatePublished
+	}
+	err := dao.insert(ctx, source, externalId, ImportTargetPost, &posts, func() int64 { return posts.Id })
+	return posts, err
+}
+
+// insert 先写入内容再写入导入记录，导入记录冲突时整个事务回滚，返回 ErrImportDuplicate
+func (dao *ImportDAO) insert(ctx context.Context, source, externalId, targetType string, content any, id func() int64) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(content).Error; err != nil {
+			return err
+		}
+		return tx.Create(&ImportRecord{
+			Source:     source,
+			ExternalId: externalId,
+			TargetType: targetType,
+			TargetId:   id(),
+			Ctime:      time.Now().UnixMilli(),
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrImportDuplicate
+	}
+	return err
+}
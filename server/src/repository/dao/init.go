@@ -1,31 +0,0 @@
-/*
- * @Author: Aii 如樱如月 morikawa@kimisui56.work
- * @Date: 2025-04-22 15:07:23
- * @LastEditors: Aiikisaraki morikawa@kimisui56.work
- * @LastEditTime: 2025-05-11 11:19:05
- * @FilePath: \nekaihoshi\server\src\repository\dao\init.go
- * @Description: 这是默认设置,请设置`customMade`, 打开koroFileHeader查看配置 进行设置: https://github.com/OBKoro1/koro1FileHeader/wiki/%E9%85%8D%E7%BD%AEc
- */
-package dao
-
-import "gorm.io/gorm"
-
-func InitUserTable(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&User{})
-}
-
-func InitUserWordpressInfoTable(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&UserWordpressInfo{})
-}
-
-func InitTreeHoleTable(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&TreeHole{})
-}
-
-func InitStatusTable(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&Status{})
-}
-
-func InitPostsTable(db *gorm.DB) error {
-	return db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci").AutoMigrate(&Posts{})
-}
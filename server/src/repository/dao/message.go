@@ -0,0 +1,160 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 私信会话和消息数据访问
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrConversationNotFound = gorm.ErrRecordNotFound
+
+// Conversation 两个用户之间唯一的会话，UserLow 为较小的用户 id
+type Conversation struct {
+	Id       int64 `gorm:"primaryKey;autoIncrement"`
+	UserLow  int64 `gorm:"uniqueIndex:idx_user_pair,priority:1"`
+	UserHigh int64 `gorm:"uniqueIndex:idx_user_pair,priority:2"`
+	Ctime    int64
+}
+
+// ConversationMember 每个参与者一行，保存会话列表需要的最后一条消息和未读数
+type ConversationMember struct {
+	Id                 int64 `gorm:"primaryKey;autoIncrement"`
+	ConversationId     int64 `gorm:"uniqueIndex:idx_conversation_user,priority:1"`
+	UserId             int64 `gorm:"uniqueIndex:idx_conversation_user,priority:2;index:idx_user_last,priority:1"`
+	PeerId             int64
+	UnreadCount        int64
+	LastReadId         int64
+	LastMessageId      int64
+	LastMessagePreview string `gorm:"size:100"`
+	LastMessageAt      int64  `gorm:"index:idx_user_last,priority:2"`
+}
+
+type DirectMessage struct {
+	Id             int64 `gorm:"primaryKey;autoIncrement"`
+	ConversationId int64 `gorm:"index:idx_conversation_id,priority:1"`
+	SenderId       int64
+	Content        string `gorm:"size:2000"`
+	Ctime          int64
+}
+
+type MessageDAO struct {
+	db *gorm.DB
+}
+
+func NewMessageDAO(db *gorm.DB) *MessageDAO {
+	return &MessageDAO{db: db}
+}
+
+// FindOrCreateConversation 查找两个用户的会话，不存在时创建会话和双方的成员记录
+func (dao *MessageDAO) FindOrCreateConversation(ctx context.Context, a, b int64) (Conversation, error) {
+	low, high := a, b
+	if low > high {
+		low, high = high, low
+	}
+	var conv Conversation
+	err := dao.db.WithContext(ctx).Where("user_low = ? AND user_high = ?", low, high).First(&conv).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return conv, err
+	}
+
+	now := time.Now().UnixMilli()
+	err = dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conv = Conversation{UserLow: low, UserHigh: high, Ctime: now}
+		if err := tx.Create(&conv).Error; err != nil {
+			return err
+		}
+		members := []ConversationMember{
+			{ConversationId: conv.Id, UserId: low, PeerId: high, LastMessageAt: now},
+			{ConversationId: conv.Id, UserId: high, PeerId: low, LastMessageAt: now},
+		}
+		return tx.Create(&members).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 对方同时发起了会话，直接使用已创建的
+		conv = Conversation{}
+		err = dao.db.WithContext(ctx).Where("user_low = ? AND user_high = ?", low, high).First(&conv).Error
+	}
+	return conv, err
+}
+
+// FindMember 用户不是会话成员时返回 ErrConversationNotFound
+func (dao *MessageDAO) FindMember(ctx context.Context, conversationId, userId int64) (ConversationMember, error) {
+	var member ConversationMember
+	err := dao.db.WithContext(ctx).Where("conversation_id = ? AND user_id = ?", conversationId, userId).First(&member).Error
+	return member, err
+}
+
+// Insert 写入消息，同时更新双方的最后一条消息和接收方的未读数
+func (dao *MessageDAO) Insert(ctx context.Context, msg DirectMessage, preview string) (DirectMessage, error) {
+	msg.Ctime = time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&msg).Error; err != nil {
+			return err
+		}
+		last := map[string]interface{}{
+			"last_message_id":      msg.Id,
+			"last_message_preview": preview,
+			"last_message_at":      msg.Ctime,
+		}
+		err := tx.Model(&ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", msg.ConversationId, msg.SenderId).
+			Updates(last).Error
+		if err != nil {
+			return err
+		}
+		last["unread_count"] = gorm.Expr("unread_count + 1")
+		return tx.Model(&ConversationMember{}).
+			Where("conversation_id = ? AND user_id <> ?", msg.ConversationId, msg.SenderId).
+			Updates(last).Error
+	})
+	return msg, err
+}
+
+// FindMembersByUser 按最后一条消息时间倒序返回用户的会话，未发过消息的会话不显示
+func (dao *MessageDAO) FindMembersByUser(ctx context.Context, userId int64, offset, limit int) ([]ConversationMember, int64, error) {
+	var list []ConversationMember
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&ConversationMember{}).Where("user_id = ? AND last_message_id > 0", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("last_message_at DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+// FindMessages 按 id 倒序分页，beforeId 为 0 时从最新的消息开始
+func (dao *MessageDAO) FindMessages(ctx context.Context, conversationId, beforeId int64, limit int) ([]DirectMessage, error) {
+	var list []DirectMessage
+	query := dao.db.WithContext(ctx).Where("conversation_id = ?", conversationId)
+	if beforeId > 0 {
+		query = query.Where("id < ?", beforeId)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// MarkRead 清空未读数并记录已读到的消息
+func (dao *MessageDAO) MarkRead(ctx context.Context, conversationId, userId int64) error {
+	return dao.db.WithContext(ctx).Model(&ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ?", conversationId, userId).
+		Updates(map[string]interface{}{
+			"unread_count": 0,
+			"last_read_id": gorm.Expr("last_message_id"),
+		}).Error
+}
+
+func (dao *MessageDAO) SumUnread(ctx context.Context, userId int64) (int64, error) {
+	var total int64
+	err := dao.db.WithContext(ctx).Model(&ConversationMember{}).
+		Select("COALESCE(SUM(unread_count), 0)").
+		Where("user_id = ?", userId).
+		Scan(&total).Error
+	return total, err
+}
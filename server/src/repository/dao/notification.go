@@ -0,0 +1,124 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 20:00:00
+ * @Description: 站内通知数据访问
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotificationPreferenceNotFound = gorm.ErrRecordNotFound
+
+type Notification struct {
+	Id         int64  `gorm:"primaryKey;autoIncrement"`
+	UserId     int64  `gorm:"index:idx_user_read,priority:1"`
+	Type       string `gorm:"size:32"`
+	Title      string `gorm:"size:100"`
+	Content    string `gorm:"size:1000"`
+	TargetType string `gorm:"size:20"`
+	TargetId   int64
+	IsRead     bool  `gorm:"index:idx_user_read,priority:2;default:false"`
+	Ctime      int64 `gorm:"index"`
+}
+
+type NotificationPreference struct {
+	Id      int64  `gorm:"primaryKey;autoIncrement"`
+	UserId  int64  `gorm:"uniqueIndex:idx_user_type"`
+	Type    string `gorm:"size:32;uniqueIndex:idx_user_type"`
+	Enabled bool
+	Utime   int64
+}
+
+// NotificationFilter 通知列表的查询条件
+type NotificationFilter struct {
+	UserId     int64
+	Type       string
+	UnreadOnly bool
+}
+
+type NotificationDAO struct {
+	db *gorm.DB
+}
+
+func NewNotificationDAO(db *gorm.DB) *NotificationDAO {
+	return &NotificationDAO{db: db}
+}
+
+func (dao *NotificationDAO) Insert(ctx context.Context, n Notification) error {
+	n.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&n).Error
+}
+
+func (dao *NotificationDAO) FindByPage(ctx context.Context, filter NotificationFilter, offset, limit int) ([]Notification, int64, error) {
+	var list []Notification
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&Notification{}).Where("user_id = ?", filter.UserId)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.UnreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
+}
+
+// CountUnreadByType 按类型统计未读数
+func (dao *NotificationDAO) CountUnreadByType(ctx context.Context, userId int64) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	err := dao.db.WithContext(ctx).Model(&Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", userId, false).
+		Group("type").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkRead 将用户的指定通知标记为已读，ids 为空时标记全部，返回实际更新的条数
+func (dao *NotificationDAO) MarkRead(ctx context.Context, userId int64, ids []int64) (int64, error) {
+	query := dao.db.WithContext(ctx).Model(&Notification{}).Where("user_id = ? AND is_read = ?", userId, false)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	res := query.Update("is_read", true)
+	return res.RowsAffected, res.Error
+}
+
+func (dao *NotificationDAO) FindPreferences(ctx context.Context, userId int64) ([]NotificationPreference, error) {
+	var prefs []NotificationPreference
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).Find(&prefs).Error
+	return prefs, err
+}
+
+// FindPreference 未设置过时返回 ErrNotificationPreferenceNotFound
+func (dao *NotificationDAO) FindPreference(ctx context.Context, userId int64, notificationType string) (NotificationPreference, error) {
+	var pref NotificationPreference
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND type = ?", userId, notificationType).First(&pref).Error
+	return pref, err
+}
+
+func (dao *NotificationDAO) UpsertPreference(ctx context.Context, pref NotificationPreference) error {
+	pref.Utime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "utime"}),
+	}).Create(&pref).Error
+}
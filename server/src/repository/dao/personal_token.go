@@ -0,0 +1,83 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 17:00:00
+ * @Description: 个人访问令牌，只保存摘要
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrPersonalTokenNotFound = gorm.ErrRecordNotFound
+
+type PersonalToken struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement"`
+	UserId    int64  `gorm:"index"`
+	Name      string `gorm:"size:100"`
+	TokenHash string `gorm:"size:64;unique"`
+	Prefix    string `gorm:"size:16"`
+	// 逗号分隔的权限范围
+	Scopes string `gorm:"size:500"`
+	// 0 表示永不过期
+	ExpireAt   int64
+	LastUsedAt int64
+	Ctime      int64
+}
+
+type PersonalTokenDAO struct {
+	db *gorm.DB
+}
+
+func NewPersonalTokenDAO(db *gorm.DB) *PersonalTokenDAO {
+	return &PersonalTokenDAO{db: db}
+}
+
+func (dao *PersonalTokenDAO) Insert(ctx context.Context, token PersonalToken) (PersonalToken, error) {
+	token.Ctime = time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Create(&token).Error
+	return token, err
+}
+
+func (dao *PersonalTokenDAO) FindByHash(ctx context.Context, hash string) (PersonalToken, error) {
+	var token PersonalToken
+	err := dao.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	return token, err
+}
+
+func (dao *PersonalTokenDAO) FindByUser(ctx context.Context, userId int64) ([]PersonalToken, error) {
+	var tokens []PersonalToken
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (dao *PersonalTokenDAO) CountByUser(ctx context.Context, userId int64) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&PersonalToken{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+func (dao *PersonalTokenDAO) UpdateLastUsed(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&PersonalToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now().UnixMilli()).Error
+}
+
+// DeleteByUser 删除用户的全部个人访问令牌
+func (dao *PersonalTokenDAO) DeleteByUser(ctx context.Context, userId int64) error {
+	return dao.db.WithContext(ctx).Where("user_id = ?", userId).Delete(&PersonalToken{}).Error
+}
+
+func (dao *PersonalTokenDAO) Delete(ctx context.Context, userId, id int64) error {
+	res := dao.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&PersonalToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
@@ -15,13 +15,38 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrPostsNotFound = gorm.ErrRecordNotFound
+
+// 文章状态，与 domain.PostState* 一致
+const (
+	PostStateDraft     = "draft"
+	PostStateScheduled = "scheduled"
+	PostStatePublished = "published"
+	PostStatePrivate   = "private"
+	PostStateUnlisted  = "unlisted"
+)
+
 type Posts struct {
 	Id      int64
 	Title   string
 	Content string
-	UserId  int64
-	Ctime   int64
-	Utime   int64
+	UserId  int64 `gorm:"index:idx_user_ctime,priority:1"`
+	// 被举报达到阈值或审核拒绝后隐藏
+	Hidden bool  `gorm:"default:false"`
+	Ctime  int64 `gorm:"index:idx_user_ctime,priority:2"`
+	Utime  int64
+	// 最后一次编辑的时间，0 表示未编辑过
+	EditedAt int64
+	// 软删除时间，0 表示未删除
+	DeletedAt int64
+	// 删除操作人，作者本人删除时为作者 id，管理员删除时为管理员 id
+	DeletedBy int64
+	// 只有已发布的文章出现在列表和时间线中
+	State string `gorm:"size:20;default:published"`
+	// 发布时间，时间线按发布时间排序，草稿为 0
+	PublishAt int64
+	// 发布时转发到作者绑定的 WordPress 站点，转发后清除
+	CrossPost bool `gorm:"default:false"`
 }
 
 type PostsDAO struct {
@@ -32,40 +57,211 @@ func NewPostsDAO(db *gorm.DB) *PostsDAO {
 	return &PostsDAO{db: db}
 }
 
-func (dao *PostsDAO) Insert(ctx context.Context, posts Posts) error {
+func (dao *PostsDAO) Insert(ctx context.Context, posts Posts) (Posts, error) {
 	// 存毫秒数
 	now := time.Now().UnixMilli()
 	posts.Utime = now
 	posts.Ctime = now
+	if posts.State == "" {
+		posts.State = PostStatePublished
+	}
+	if posts.State != PostStateDraft && posts.State != PostStateScheduled {
+		posts.PublishAt = now
+	}
 	err := dao.db.WithContext(ctx).Create(&posts).Error
-	return err
+	return posts, err
 }
 
+// FindById 返回任意状态的文章，由调用方按查看者过滤
 func (dao *PostsDAO) FindById(ctx context.Context, id int64) (Posts, error) {
 	var posts Posts
-	err := dao.db.Where("id =?", id).First(&posts).Error
+	err := dao.db.WithContext(ctx).Where("id = ? AND hidden = ? AND deleted_at = ?", id, false, 0).First(&posts).Error
 	return posts, err
 }
 
+// FindByUid 返回用户任意状态的文章，由调用方按查看者过滤
 func (dao *PostsDAO) FindByUid(ctx context.Context, uid int64) ([]Posts, error) {
 	var posts []Posts
-	err := dao.db.Where("user_id =?", uid).Find(&posts).Error
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND hidden = ? AND deleted_at = ?", uid, false, 0).Order("id").Find(&posts).Error
 	return posts, err
 }
 
 func (dao *PostsDAO) GetAllRecord(ctx context.Context) ([]Posts, error) {
 	var posts []Posts
-	err := dao.db.Find(&posts).Error
+	err := dao.db.WithContext(ctx).Where("hidden = ? AND deleted_at = ? AND state = ?", false, 0, PostStatePublished).Order("id").Find(&posts).Error
+	return posts, err
+}
+
+// Update 编辑内容并在同一事务中追加编辑历史，不影响隐藏状态和创建时间，
+// 记录不存在或已删除时返回 ErrPostsNotFound
+func (dao *PostsDAO) Update(ctx context.Context, posts Posts, editorId int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old Posts
+		if err := tx.Where("id = ? AND deleted_at = ?", posts.Id, 0).First(&old).Error; err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		err := appendRevision(tx, ContentRevision{
+			TargetType: RevisionTargetPost,
+			TargetId:   old.Id,
+			EditorId:   old.UserId,
+			Title:      old.Title,
+			Content:    old.Content,
+			Ctime:      old.Ctime,
+		}, ContentRevision{
+			TargetType: RevisionTargetPost,
+			TargetId:   old.Id,
+			EditorId:   editorId,
+			Title:      posts.Title,
+			Content:    posts.Content,
+			Ctime:      now,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Posts{}).Where("id = ?", old.Id).Updates(map[string]interface{}{
+			"title":     posts.Title,
+			"content":   posts.Content,
+			"utime":     now,
+			"edited_at": now,
+		}).Error
+	})
+}
+
+// SoftDelete 标记删除，记录不存在或已删除时返回 ErrPostsNotFound
+func (dao *PostsDAO) SoftDelete(ctx context.Context, id, deletedBy int64) error {
+	res := dao.db.WithContext(ctx).Model(&Posts{}).Where("id = ? AND deleted_at = ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": time.Now().UnixMilli(),
+		"deleted_by": deletedBy,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPostsNotFound
+	}
+	return nil
+}
+
+// Restore 恢复已删除的记录，记录不存在或未删除时返回 ErrPostsNotFound
+func (dao *PostsDAO) Restore(ctx context.Context, id int64) error {
+	res := dao.db.WithContext(ctx).Model(&Posts{}).Where("id = ? AND deleted_at > ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": 0,
+		"deleted_by": 0,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPostsNotFound
+	}
+	return nil
+}
+
+func (dao *PostsDAO) FindDeletedById(ctx context.Context, id int64) (Posts, error) {
+	var posts Posts
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at > ?", id, 0).First(&posts).Error
+	return posts, err
+}
+
+// FindDeletedByUser 按删除时间倒序查询用户已删除的记录
+func (dao *PostsDAO) FindDeletedByUser(ctx context.Context, userId int64, limit int) ([]Posts, error) {
+	var posts []Posts
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND deleted_at > ?", userId, 0).
+		Order("deleted_at DESC, id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// PurgeDeletedBefore 物理删除 before 之前删除的记录及其编辑历史，每次最多 limit 条，返回删除的条数
+func (dao *PostsDAO) PurgeDeletedBefore(ctx context.Context, before int64, limit int) (int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Posts{}).Where("deleted_at > ? AND deleted_at < ?", 0, before).
+		Order("deleted_at").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	var purged int64
+	err = dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id IN ? AND deleted_at > ?", ids, 0).Delete(&Posts{})
+		if res.Error != nil {
+			return res.Error
+		}
+		purged = res.RowsAffected
+		return deleteRevisions(tx, RevisionTargetPost, ids)
+	})
+	return purged, err
+}
+
+// FindByIdUnscoped 查询包括已隐藏在内的记录，供审核使用，已删除的记录不会返回
+func (dao *PostsDAO) FindByIdUnscoped(ctx context.Context, id int64) (Posts, error) {
+	var posts Posts
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at = ?", id, 0).First(&posts).Error
+	return posts, err
+}
+
+func (dao *PostsDAO) UpdateHidden(ctx context.Context, id int64, hidden bool) error {
+	return dao.db.WithContext(ctx).Model(&Posts{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"hidden": hidden,
+		"utime":  time.Now().UnixMilli(),
+	}).Error
+}
+
+// FindTimeline 按发布时间倒序读取多个用户已发布的文章，只返回 id 和发布时间，
+// 只包含 publish_at 小于 beforeCtime 或 publish_at 等于 beforeCtime 且 id 小于 beforeId 的记录
+func (dao *PostsDAO) FindTimeline(ctx context.Context, uids []int64, beforeCtime, beforeId int64, limit int) ([]Posts, error) {
+	var posts []Posts
+	err := dao.db.WithContext(ctx).Select("id, user_id, publish_at").
+		Where("user_id IN ? AND hidden = ? AND deleted_at = ? AND state = ?", uids, false, 0, PostStatePublished).
+		Where("publish_at < ? OR (publish_at = ? AND id < ?)", beforeCtime, beforeCtime, beforeId).
+		Order("publish_at DESC, id DESC").Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// FindByIds 只返回已发布的文章
+func (dao *PostsDAO) FindByIds(ctx context.Context, ids []int64) ([]Posts, error) {
+	var posts []Posts
+	err := dao.db.WithContext(ctx).Where("id IN ? AND hidden = ? AND deleted_at = ? AND state = ?", ids, false, 0, PostStatePublished).Find(&posts).Error
 	return posts, err
 }
 
-func (dao *PostsDAO) Update(ctx context.Context, posts Posts) error {
-	posts.Utime = time.Now().UnixMilli()
-	err := dao.db.WithContext(ctx).Save(&posts).Error
-	return err
+// UpdateState 修改文章状态、发布时间和是否转发，记录不存在或已删除时返回 ErrPostsNotFound
+func (dao *PostsDAO) UpdateState(ctx context.Context, id int64, state string, publishAt int64, crossPost bool) error {
+	res := dao.db.WithContext(ctx).Model(&Posts{}).Where("id = ? AND deleted_at = ?", id, 0).Updates(map[string]interface{}{
+		"state":      state,
+		"publish_at": publishAt,
+		"cross_post": crossPost,
+		"utime":      time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPostsNotFound
+	}
+	return nil
+}
+
+// FindDueScheduled 查询发布时间不晚于 now 的定时文章，按发布时间先后排列
+func (dao *PostsDAO) FindDueScheduled(ctx context.Context, now int64, limit int) ([]Posts, error) {
+	var posts []Posts
+	err := dao.db.WithContext(ctx).Where("state = ? AND publish_at <= ? AND deleted_at = ?", PostStateScheduled, now, 0).
+		Order("publish_at, id").Limit(limit).Find(&posts).Error
+	return posts, err
 }
 
-func (dao *PostsDAO) Delete(ctx context.Context, id int64) error {
-	err := dao.db.WithContext(ctx).Where("id = ?", id).Delete(&Posts{}).Error
-	return err
+// PublishScheduled 发布到期的定时文章并清除转发标记，文章已被发布、改为其他状态或已删除时返回 false，
+// 多个实例同时执行时只有一个会成功
+func (dao *PostsDAO) PublishScheduled(ctx context.Context, id, now int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&Posts{}).
+		Where("id = ? AND state = ? AND publish_at <= ? AND deleted_at = ?", id, PostStateScheduled, now, 0).
+		Updates(map[string]interface{}{
+			"state":      PostStatePublished,
+			"cross_post": false,
+			"utime":      now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
 }
@@ -0,0 +1,78 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: 刷新令牌，只保存摘要
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenNotFound = gorm.ErrRecordNotFound
+	ErrRefreshTokenUsed     = errors.New("刷新令牌已被使用")
+)
+
+type RefreshToken struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement"`
+	TokenHash string `gorm:"size:64;unique"`
+	UserId    int64  `gorm:"index"`
+	FamilyId  string `gorm:"size:64;index"`
+	// 轮换前的上一个令牌ID，家族中第一个令牌为 0
+	ParentId int64
+	// 0 表示未使用
+	UsedAt    int64
+	RevokedAt int64
+	ExpireAt  int64
+	Ctime     int64
+}
+
+type RefreshTokenDAO struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenDAO(db *gorm.DB) *RefreshTokenDAO {
+	return &RefreshTokenDAO{db: db}
+}
+
+func (dao *RefreshTokenDAO) Insert(ctx context.Context, token RefreshToken) error {
+	token.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&token).Error
+}
+
+func (dao *RefreshTokenDAO) FindByHash(ctx context.Context, hash string) (RefreshToken, error) {
+	var token RefreshToken
+	err := dao.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	return token, err
+}
+
+// MarkUsed 原子地将令牌标记为已使用，并发轮换时只有一个请求能成功
+func (dao *RefreshTokenDAO) MarkUsed(ctx context.Context, id int64) error {
+	res := dao.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("id = ? AND used_at = ?", id, 0).
+		Update("used_at", time.Now().UnixMilli())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRefreshTokenUsed
+	}
+	return nil
+}
+
+func (dao *RefreshTokenDAO) RevokeFamily(ctx context.Context, familyId string) error {
+	return dao.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at = ?", familyId, 0).
+		Update("revoked_at", time.Now().UnixMilli()).Error
+}
+
+func (dao *RefreshTokenDAO) RevokeByUser(ctx context.Context, userId int64) error {
+	return dao.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at = ?", userId, 0).
+		Update("revoked_at", time.Now().UnixMilli()).Error
+}
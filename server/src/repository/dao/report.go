@@ -0,0 +1,95 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 10:00:00
+ * @Description: 内容举报数据访问
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReportDuplicate = errors.New("已举报过该内容")
+	ErrReportNotFound  = gorm.ErrRecordNotFound
+)
+
+type Report struct {
+	Id int64 `gorm:"primaryKey;autoIncrement"`
+	// 同一用户对同一内容只能举报一次
+	ReporterId int64  `gorm:"uniqueIndex:idx_reporter_target"`
+	TargetType string `gorm:"size:20;uniqueIndex:idx_reporter_target;index:idx_target"`
+	TargetId   int64  `gorm:"uniqueIndex:idx_reporter_target;index:idx_target"`
+	Reason     string `gorm:"size:20"`
+	Detail     string `gorm:"type:text"`
+	Status     string `gorm:"size:20;index"`
+	HandlerId  int64
+	HandleNote string `gorm:"size:500"`
+	Ctime      int64
+	Utime      int64
+}
+
+type ReportDAO struct {
+	db *gorm.DB
+}
+
+func NewReportDAO(db *gorm.DB) *ReportDAO {
+	return &ReportDAO{db: db}
+}
+
+func (dao *ReportDAO) Insert(ctx context.Context, report Report) error {
+	now := time.Now().UnixMilli()
+	report.Ctime = now
+	report.Utime = now
+	err := dao.db.WithContext(ctx).Create(&report).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrReportDuplicate
+	}
+	return err
+}
+
+func (dao *ReportDAO) FindById(ctx context.Context, id int64) (Report, error) {
+	var report Report
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&report).Error
+	return report, err
+}
+
+// FindByPage 按处理状态分页查询，status 为空时查询全部
+func (dao *ReportDAO) FindByPage(ctx context.Context, status string, offset, limit int) ([]Report, int64, error) {
+	var reports []Report
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&Report{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, total, err
+}
+
+// CountByTarget 统计某条内容处于指定状态的举报数
+func (dao *ReportDAO) CountByTarget(ctx context.Context, targetType string, targetId int64, status string) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetId, status).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateStatusByTarget 批量处理某条内容的待处理举报
+func (dao *ReportDAO) UpdateStatusByTarget(ctx context.Context, targetType string, targetId int64, fromStatus, toStatus string, handlerId int64, note string) error {
+	return dao.db.WithContext(ctx).Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetId, fromStatus).
+		Updates(map[string]interface{}{
+			"status":      toStatus,
+			"handler_id":  handlerId,
+			"handle_note": note,
+			"utime":       time.Now().UnixMilli(),
+		}).Error
+}
@@ -0,0 +1,75 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 13:00:00
+ * @Description: 动态和文章的编辑历史
+ */
+package dao
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound = gorm.ErrRecordNotFound
+
+// 编辑历史的内容类型，与举报目标类型一致
+const (
+	RevisionTargetStatus = "status"
+	RevisionTargetPost   = "post"
+)
+
+// ContentRevision 只追加不修改，每条记录是内容的一个完整版本
+type ContentRevision struct {
+	Id         int64
+	TargetType string `gorm:"size:20;index:idx_target,priority:1"`
+	TargetId   int64  `gorm:"index:idx_target,priority:2"`
+	// 本版本的编辑人，首次编辑时补记的原始版本为作者
+	EditorId int64
+	Title    string
+	Content  string
+	Ctime    int64
+}
+
+type RevisionDAO struct {
+	db *gorm.DB
+}
+
+func NewRevisionDAO(db *gorm.DB) *RevisionDAO {
+	return &RevisionDAO{db: db}
+}
+
+// FindByTarget 按版本先后返回内容的全部编辑历史
+func (dao *RevisionDAO) FindByTarget(ctx context.Context, targetType string, targetId int64) ([]ContentRevision, error) {
+	var revisions []ContentRevision
+	err := dao.db.WithContext(ctx).Where("target_type = ? AND target_id = ?", targetType, targetId).
+		Order("id").Find(&revisions).Error
+	return revisions, err
+}
+
+func (dao *RevisionDAO) FindById(ctx context.Context, targetType string, targetId, id int64) (ContentRevision, error) {
+	var revision ContentRevision
+	err := dao.db.WithContext(ctx).Where("id = ? AND target_type = ? AND target_id = ?", id, targetType, targetId).
+		First(&revision).Error
+	return revision, err
+}
+
+// appendRevision 在编辑内容的事务中追加新版本，内容第一次被编辑时先补记编辑前的原始版本
+func appendRevision(tx *gorm.DB, original, next ContentRevision) error {
+	var count int64
+	err := tx.Model(&ContentRevision{}).Where("target_type = ? AND target_id = ?", next.TargetType, next.TargetId).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := tx.Create(&original).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&next).Error
+}
+
+func deleteRevisions(tx *gorm.DB, targetType string, targetIds []int64) error {
+	return tx.Where("target_type = ? AND target_id IN ?", targetType, targetIds).Delete(&ContentRevision{}).Error
+}
@@ -0,0 +1,43 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 站点运行时设置，键值存储
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSettingNotFound = gorm.ErrRecordNotFound
+
+type Setting struct {
+	Key   string `gorm:"primaryKey;size:100"`
+	Value string `gorm:"type:text"`
+	Utime int64
+}
+
+type SettingDAO struct {
+	db *gorm.DB
+}
+
+func NewSettingDAO(db *gorm.DB) *SettingDAO {
+	return &SettingDAO{db: db}
+}
+
+func (dao *SettingDAO) Get(ctx context.Context, key string) (string, error) {
+	var setting Setting
+	err := dao.db.WithContext(ctx).Where(&Setting{Key: key}).First(&setting).Error
+	return setting.Value, err
+}
+
+func (dao *SettingDAO) Set(ctx context.Context, key, value string) error {
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "utime"}),
+	}).Create(&Setting{Key: key, Value: value, Utime: time.Now().UnixMilli()}).Error
+}
@@ -15,12 +15,22 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrStatusNotFound = gorm.ErrRecordNotFound
+
 type Status struct {
 	Id      int64
 	Content string
-	UserId  int64
-	Ctime   int64
-	Utime   int64
+	UserId  int64 `gorm:"index:idx_user_ctime,priority:1"`
+	// 被举报达到阈值或审核拒绝后隐藏
+	Hidden bool  `gorm:"default:false"`
+	Ctime  int64 `gorm:"index:idx_user_ctime,priority:2"`
+	Utime  int64
+	// 最后一次编辑的时间，0 表示未编辑过
+	EditedAt int64
+	// 软删除时间，0 表示未删除
+	DeletedAt int64
+	// 删除操作人，作者本人删除时为作者 id，管理员删除时为管理员 id
+	DeletedBy int64
 }
 
 type StatusDAO struct {
@@ -31,40 +41,158 @@ func NewStatusDAO(db *gorm.DB) *StatusDAO {
 	return &StatusDAO{db: db}
 }
 
-func (dao *StatusDAO) Insert(ctx context.Context, status Status) error {
+func (dao *StatusDAO) Insert(ctx context.Context, status Status) (Status, error) {
 	// 存毫秒数
 	now := time.Now().UnixMilli()
 	status.Utime = now
 	status.Ctime = now
 	err := dao.db.WithContext(ctx).Create(&status).Error
-	return err
+	return status, err
 }
 
 func (dao *StatusDAO) FindById(ctx context.Context, id int64) (Status, error) {
 	var status Status
-	err := dao.db.Where("id =?", id).First(&status).Error
+	err := dao.db.WithContext(ctx).Where("id = ? AND hidden = ? AND deleted_at = ?", id, false, 0).First(&status).Error
 	return status, err
 }
 
 func (dao *StatusDAO) FindByUid(ctx context.Context, uid int64) ([]Status, error) {
 	var status []Status
-	err := dao.db.Where("user_id =?", uid).Find(&status).Error
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND hidden = ? AND deleted_at = ?", uid, false, 0).Order("id").Find(&status).Error
 	return status, err
 }
 
 func (dao *StatusDAO) GetAllRecord(ctx context.Context) ([]Status, error) {
 	var status []Status
-	err := dao.db.Find(&status).Error
+	err := dao.db.WithContext(ctx).Where("hidden = ? AND deleted_at = ?", false, 0).Order("id").Find(&status).Error
+	return status, err
+}
+
+// Update 编辑内容并在同一事务中追加编辑历史，不影响隐藏状态和创建时间，
+// 记录不存在或已删除时返回 ErrStatusNotFound
+func (dao *StatusDAO) Update(ctx context.Context, status Status, editorId int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old Status
+		if err := tx.Where("id = ? AND deleted_at = ?", status.Id, 0).First(&old).Error; err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		err := appendRevision(tx, ContentRevision{
+			TargetType: RevisionTargetStatus,
+			TargetId:   old.Id,
+			EditorId:   old.UserId,
+			Content:    old.Content,
+			Ctime:      old.Ctime,
+		}, ContentRevision{
+			TargetType: RevisionTargetStatus,
+			TargetId:   old.Id,
+			EditorId:   editorId,
+			Content:    status.Content,
+			Ctime:      now,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Status{}).Where("id = ?", old.Id).Updates(map[string]interface{}{
+			"content":   status.Content,
+			"utime":     now,
+			"edited_at": now,
+		}).Error
+	})
+}
+
+// SoftDelete 标记删除，记录不存在或已删除时返回 ErrStatusNotFound
+func (dao *StatusDAO) SoftDelete(ctx context.Context, id, deletedBy int64) error {
+	res := dao.db.WithContext(ctx).Model(&Status{}).Where("id = ? AND deleted_at = ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": time.Now().UnixMilli(),
+		"deleted_by": deletedBy,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrStatusNotFound
+	}
+	return nil
+}
+
+// Restore 恢复已删除的记录，记录不存在或未删除时返回 ErrStatusNotFound
+func (dao *StatusDAO) Restore(ctx context.Context, id int64) error {
+	res := dao.db.WithContext(ctx).Model(&Status{}).Where("id = ? AND deleted_at > ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": 0,
+		"deleted_by": 0,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrStatusNotFound
+	}
+	return nil
+}
+
+func (dao *StatusDAO) FindDeletedById(ctx context.Context, id int64) (Status, error) {
+	var status Status
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at > ?", id, 0).First(&status).Error
+	return status, err
+}
+
+// FindDeletedByUser 按删除时间倒序查询用户已删除的记录
+func (dao *StatusDAO) FindDeletedByUser(ctx context.Context, userId int64, limit int) ([]Status, error) {
+	var status []Status
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND deleted_at > ?", userId, 0).
+		Order("deleted_at DESC, id DESC").Limit(limit).Find(&status).Error
+	return status, err
+}
+
+// PurgeDeletedBefore 物理删除 before 之前删除的记录及其编辑历史，每次最多 limit 条，返回删除的条数
+func (dao *StatusDAO) PurgeDeletedBefore(ctx context.Context, before int64, limit int) (int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&Status{}).Where("deleted_at > ? AND deleted_at < ?", 0, before).
+		Order("deleted_at").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	var purged int64
+	err = dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id IN ? AND deleted_at > ?", ids, 0).Delete(&Status{})
+		if res.Error != nil {
+			return res.Error
+		}
+		purged = res.RowsAffected
+		return deleteRevisions(tx, RevisionTargetStatus, ids)
+	})
+	return purged, err
+}
+
+// FindByIdUnscoped 查询包括已隐藏在内的记录，供审核使用，已删除的记录不会返回
+func (dao *StatusDAO) FindByIdUnscoped(ctx context.Context, id int64) (Status, error) {
+	var status Status
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at = ?", id, 0).First(&status).Error
 	return status, err
 }
 
-func (dao *StatusDAO) Update(ctx context.Context, status Status) error {
-	status.Utime = time.Now().UnixMilli()
-	err := dao.db.WithContext(ctx).Save(&status).Error
-	return err
+func (dao *StatusDAO) UpdateHidden(ctx context.Context, id int64, hidden bool) error {
+	return dao.db.WithContext(ctx).Model(&Status{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"hidden": hidden,
+		"utime":  time.Now().UnixMilli(),
+	}).Error
 }
 
-func (dao *StatusDAO) Delete(ctx context.Context, id int64) error {
-	err := dao.db.WithContext(ctx).Delete(&Status{}, id).Error
-	return err
+// FindTimeline 按时间倒序读取多个用户的动态，只返回 id 和创建时间，
+// 只包含 ctime 小于 beforeCtime 或 ctime 等于 beforeCtime 且 id 小于 beforeId 的记录
+func (dao *StatusDAO) FindTimeline(ctx context.Context, uids []int64, beforeCtime, beforeId int64, limit int) ([]Status, error) {
+	var status []Status
+	err := dao.db.WithContext(ctx).Select("id, user_id, ctime").
+		Where("user_id IN ? AND hidden = ? AND deleted_at = ?", uids, false, 0).
+		Where("ctime < ? OR (ctime = ? AND id < ?)", beforeCtime, beforeCtime, beforeId).
+		Order("ctime DESC, id DESC").Limit(limit).
+		Find(&status).Error
+	return status, err
+}
+
+func (dao *StatusDAO) FindByIds(ctx context.Context, ids []int64) ([]Status, error) {
+	var status []Status
+	err := dao.db.WithContext(ctx).Where("id IN ? AND hidden = ? AND deleted_at = ?", ids, false, 0).Find(&status).Error
+	return status, err
 }
@@ -7,12 +7,20 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrTreeHoleNotFound = gorm.ErrRecordNotFound
+
 type TreeHole struct {
 	Id      int64
 	Content string
 	UserId  int64
-	Ctime   int64
-	Utime   int64
+	// 被举报达到阈值或审核拒绝后隐藏
+	Hidden bool `gorm:"default:false"`
+	Ctime  int64
+	Utime  int64
+	// 软删除时间，0 表示未删除
+	DeletedAt int64
+	// 删除操作人，作者本人删除时为作者 id，管理员删除时为管理员 id
+	DeletedBy int64
 }
 
 type TreeHoleDAO struct {
@@ -23,34 +31,104 @@ func NewTreeHoleDAO(db *gorm.DB) *TreeHoleDAO {
 	return &TreeHoleDAO{db: db}
 }
 
-func (dao *TreeHoleDAO) Insert(ctx context.Context, treeHole TreeHole) error {
+func (dao *TreeHoleDAO) Insert(ctx context.Context, treeHole TreeHole) (TreeHole, error) {
 	// 存毫秒数
 	now := time.Now().UnixMilli()
 	treeHole.Utime = now
 	treeHole.Ctime = now
 	err := dao.db.WithContext(ctx).Create(&treeHole).Error
-	return err
+	return treeHole, err
 }
 
-func (dao *TreeHoleDAO) FindByPage(ctx context.Context, offset, limit int) ([]TreeHole, error) {
+// FindByPage excludeUserIds 中用户发布的树洞不会返回
+func (dao *TreeHoleDAO) FindByPage(ctx context.Context, offset, limit int, excludeUserIds []int64) ([]TreeHole, error) {
 	var treeHoles []TreeHole
-	err := dao.db.Offset(offset).Limit(limit).Find(&treeHoles).Error
+	query := dao.db.WithContext(ctx).Where("hidden = ? AND deleted_at = ?", false, 0)
+	if len(excludeUserIds) > 0 {
+		query = query.Where("user_id NOT IN ?", excludeUserIds)
+	}
+	err := query.Order("id").Offset(offset).Limit(limit).Find(&treeHoles).Error
 	return treeHoles, err
 }
 
 func (dao *TreeHoleDAO) FindByUserAndPage(ctx context.Context, userId int64, offset, limit int) ([]TreeHole, error) {
 	var treeHoles []TreeHole
-	err := dao.db.Offset(offset).Limit(limit).Where("user_id = ?", userId).Find(&treeHoles).Error
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND hidden = ? AND deleted_at = ?", userId, false, 0).Order("id").Offset(offset).Limit(limit).Find(&treeHoles).Error
 	return treeHoles, err
 }
 
 func (dao *TreeHoleDAO) FindById(ctx context.Context, id int64) (TreeHole, error) {
 	var treeHole TreeHole
-	err := dao.db.Where("id =?", id).First(&treeHole).Error
+	err := dao.db.WithContext(ctx).Where("id = ? AND hidden = ? AND deleted_at = ?", id, false, 0).First(&treeHole).Error
+	return treeHole, err
+}
+
+// SoftDelete 标记删除，记录不存在或已删除时返回 ErrTreeHoleNotFound
+func (dao *TreeHoleDAO) SoftDelete(ctx context.Context, id, deletedBy int64) error {
+	res := dao.db.WithContext(ctx).Model(&TreeHole{}).Where("id = ? AND deleted_at = ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": time.Now().UnixMilli(),
+		"deleted_by": deletedBy,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTreeHoleNotFound
+	}
+	return nil
+}
+
+// Restore 恢复已删除的记录，记录不存在或未删除时返回 ErrTreeHoleNotFound
+func (dao *TreeHoleDAO) Restore(ctx context.Context, id int64) error {
+	res := dao.db.WithContext(ctx).Model(&TreeHole{}).Where("id = ? AND deleted_at > ?", id, 0).Updates(map[string]interface{}{
+		"deleted_at": 0,
+		"deleted_by": 0,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTreeHoleNotFound
+	}
+	return nil
+}
+
+func (dao *TreeHoleDAO) FindDeletedById(ctx context.Context, id int64) (TreeHole, error) {
+	var treeHole TreeHole
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at > ?", id, 0).First(&treeHole).Error
+	return treeHole, err
+}
+
+// FindDeletedByUser 按删除时间倒序查询用户已删除的记录
+func (dao *TreeHoleDAO) FindDeletedByUser(ctx context.Context, userId int64, limit int) ([]TreeHole, error) {
+	var treeHoles []TreeHole
+	err := dao.db.WithContext(ctx).Where("user_id = ? AND deleted_at > ?", userId, 0).
+		Order("deleted_at DESC, id DESC").Limit(limit).Find(&treeHoles).Error
+	return treeHoles, err
+}
+
+// PurgeDeletedBefore 物理删除 before 之前删除的记录，每次最多 limit 条，返回删除的条数
+func (dao *TreeHoleDAO) PurgeDeletedBefore(ctx context.Context, before int64, limit int) (int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Model(&TreeHole{}).Where("deleted_at > ? AND deleted_at < ?", 0, before).
+		Order("deleted_at").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	res := dao.db.WithContext(ctx).Where("id IN ? AND deleted_at > ?", ids, 0).Delete(&TreeHole{})
+	return res.RowsAffected, res.Error
+}
+
+// FindByIdUnscoped 查询包括已隐藏在内的树洞，供审核使用，已删除的树洞不会返回
+func (dao *TreeHoleDAO) FindByIdUnscoped(ctx context.Context, id int64) (TreeHole, error) {
+	var treeHole TreeHole
+	err := dao.db.WithContext(ctx).Where("id = ? AND deleted_at = ?", id, 0).First(&treeHole).Error
 	return treeHole, err
 }
 
-func (dao *TreeHoleDAO) DeleteById(ctx context.Context, id int64) error {
-	err := dao.db.Where("id = ?", id).Delete(&TreeHole{}).Error
-	return err
+func (dao *TreeHoleDAO) UpdateHidden(ctx context.Context, id int64, hidden bool) error {
+	return dao.db.WithContext(ctx).Model(&TreeHole{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"hidden": hidden,
+		"utime":  time.Now().UnixMilli(),
+	}).Error
 }
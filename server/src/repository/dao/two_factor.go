@@ -0,0 +1,159 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 两步验证密钥和恢复码
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTwoFactorNotFound = gorm.ErrRecordNotFound
+	ErrTOTPStepUsed      = errors.New("验证码已被使用")
+	ErrRecoveryCodeUsed  = errors.New("恢复码不存在或已被使用")
+)
+
+type TwoFactor struct {
+	Id     int64 `gorm:"primaryKey;autoIncrement"`
+	UserId int64 `gorm:"unique"`
+	// 加密后的 TOTP 密钥
+	Secret  string `gorm:"size:255"`
+	Enabled bool
+	// 最近一次验证成功的时间步，用于防止验证码重放
+	LastStep int64
+	Ctime    int64
+	Utime    int64
+}
+
+type RecoveryCode struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	UserId   int64  `gorm:"index"`
+	CodeHash string `gorm:"size:64;index"`
+	// 0 表示未使用
+	UsedAt int64
+	Ctime  int64
+}
+
+type TwoFactorDAO struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorDAO(db *gorm.DB) *TwoFactorDAO {
+	return &TwoFactorDAO{db: db}
+}
+
+func (dao *TwoFactorDAO) FindByUser(ctx context.Context, userId int64) (TwoFactor, error) {
+	var tf TwoFactor
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).First(&tf).Error
+	return tf, err
+}
+
+// Upsert 写入新的待启用密钥，已有记录时覆盖并重置为未启用
+func (dao *TwoFactorDAO) Upsert(ctx context.Context, userId int64, secret string) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&TwoFactor{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+			"secret":    secret,
+			"enabled":   false,
+			"last_step": 0,
+			"utime":     now,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+		return tx.Create(&TwoFactor{
+			UserId: userId,
+			Secret: secret,
+			Ctime:  now,
+			Utime:  now,
+		}).Error
+	})
+}
+
+// UpdateSecret 只替换加密保存的密钥，不改变启用状态
+func (dao *TwoFactorDAO) UpdateSecret(ctx context.Context, userId int64, secret string) error {
+	return dao.db.WithContext(ctx).Model(&TwoFactor{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"secret": secret,
+		"utime":  time.Now().UnixMilli(),
+	}).Error
+}
+
+// UseStep 原子地记录验证成功的时间步，不大于上次时间步时返回 ErrTOTPStepUsed
+func (dao *TwoFactorDAO) UseStep(ctx context.Context, userId, step int64) error {
+	res := dao.db.WithContext(ctx).Model(&TwoFactor{}).
+		Where("user_id = ? AND last_step < ?", userId, step).
+		Updates(map[string]interface{}{
+			"last_step": step,
+			"utime":     time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrTOTPStepUsed
+	}
+	return nil
+}
+
+func (dao *TwoFactorDAO) UpdateEnabled(ctx context.Context, userId int64, enabled bool) error {
+	return dao.db.WithContext(ctx).Model(&TwoFactor{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"enabled": enabled,
+		"utime":   time.Now().UnixMilli(),
+	}).Error
+}
+
+// DeleteByUser 关闭两步验证，同时删除密钥和恢复码
+func (dao *TwoFactorDAO) DeleteByUser(ctx context.Context, userId int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&TwoFactor{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes 用新的恢复码替换用户原有的全部恢复码
+func (dao *TwoFactorDAO) ReplaceRecoveryCodes(ctx context.Context, userId int64, hashes []string) error {
+	now := time.Now().UnixMilli()
+	codes := make([]RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, RecoveryCode{UserId: userId, CodeHash: hash, Ctime: now})
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode 原子地核销一个恢复码
+func (dao *TwoFactorDAO) UseRecoveryCode(ctx context.Context, userId int64, hash string) error {
+	res := dao.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at = ?", userId, hash, 0).
+		Update("used_at", time.Now().UnixMilli())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecoveryCodeUsed
+	}
+	return nil
+}
+
+func (dao *TwoFactorDAO) CountUnusedRecoveryCodes(ctx context.Context, userId int64) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND used_at = ?", userId, 0).
+		Count(&count).Error
+	return count, err
+}
@@ -9,176 +9,137 @@
 package dao
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"time"
 
 	"negaihoshi/server/src/domain"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	Id       int64     `gorm:"primaryKey;autoIncrement"`
-	Username string    `gorm:"unique;not null"`
-	Email    string    `gorm:"unique;not null"`
-	Password string    `gorm:"not null"`
-	Nickname string    `gorm:"size:100"`
-	Bio      string    `gorm:"type:text"`
-	Avatar   string    `gorm:"size:500"`
-	Phone    string    `gorm:"size:20"`
-	Location string    `gorm:"size:200"`
-	Website  string    `gorm:"size:500"`
-	Ctime    time.Time `gorm:"autoCreateTime"`
-	Utime    time.Time `gorm:"autoUpdateTime"`
+	Id            int64     `gorm:"primaryKey;autoIncrement"`
+	Username      string    `gorm:"unique;not null"`
+	Email         string    `gorm:"unique;not null"`
+	Password      string    `gorm:"not null"`
+	Nickname      string    `gorm:"size:100"`
+	Bio           string    `gorm:"type:text"`
+	Avatar        string    `gorm:"size:500"`
+	Phone         string    `gorm:"size:20"`
+	Location      string    `gorm:"size:200"`
+	Website       string    `gorm:"size:500"`
+	EmailVerified bool      `gorm:"default:false"`
+	Role          string    `gorm:"size:20;not null;default:user"`
+	Ctime         time.Time `gorm:"autoCreateTime"`
+	Utime         time.Time `gorm:"autoUpdateTime"`
 }
 
+var (
+	ErrUserNotFound          = gorm.ErrRecordNotFound
+	ErrUserDuplicateUsername = errors.New("用户名已被使用")
+	ErrUserDuplicateEmail    = errors.New("邮箱已被使用")
+)
+
 type UserDAO struct {
-	db *sql.DB
+	db *gorm.DB
 }
 
-func NewUserDAO(db *sql.DB) *UserDAO {
+func NewUserDAO(db *gorm.DB) *UserDAO {
 	return &UserDAO{db: db}
 }
 
-func (dao *UserDAO) Insert(user *User) error {
-	query := `
-		INSERT INTO users (username, email, password, nickname, bio, avatar, phone, location, website, ctime, utime)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
+func (dao *UserDAO) Insert(ctx context.Context, user *User) error {
 	now := time.Now()
-	_, err := dao.db.Exec(query,
-		user.Username,
-		user.Email,
-		user.Password,
-		user.Nickname,
-		user.Bio,
-		user.Avatar,
-		user.Phone,
-		user.Location,
-		user.Website,
-		now,
-		now,
-	)
-
-	if err != nil {
-		// 检查是否是唯一性约束冲突
-		if err.Error() == "UNIQUE constraint failed: users.username" {
-			return errors.New("username already exists")
-		}
-		if err.Error() == "UNIQUE constraint failed: users.email" {
-			return errors.New("email already exists")
+	user.Ctime = now
+	user.Utime = now
+	err := dao.db.WithContext(ctx).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 各数据库的冲突错误中索引名格式不同，冲突后再查一次确定是哪个字段
+		var count int64
+		if dao.db.WithContext(ctx).Model(&User{}).Where("username = ?", user.Username).Count(&count).Error == nil && count > 0 {
+			return ErrUserDuplicateUsername
 		}
-		return err
+		return ErrUserDuplicateEmail
 	}
-
-	return nil
+	return err
 }
 
-func (dao *UserDAO) FindById(id int64) (*User, error) {
-	query := `
-		SELECT id, username, email, password, nickname, bio, avatar, phone, location, website, ctime, utime
-		FROM users WHERE id = ?
-	`
-
+func (dao *UserDAO) FindById(ctx context.Context, id int64) (*User, error) {
 	user := &User{}
-	err := dao.db.QueryRow(query, id).Scan(
-		&user.Id,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.Nickname,
-		&user.Bio,
-		&user.Avatar,
-		&user.Phone,
-		&user.Location,
-		&user.Website,
-		&user.Ctime,
-		&user.Utime,
-	)
-
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(user).Error
 	if err != nil {
 		return nil, err
 	}
-
 	return user, nil
 }
 
-func (dao *UserDAO) FindByEmail(email string) (*User, error) {
-	query := `
-		SELECT id, username, email, password, nickname, bio, avatar, phone, location, website, ctime, utime
-		FROM users WHERE email = ?
-	`
-
+func (dao *UserDAO) FindByEmail(ctx context.Context, email string) (*User, error) {
 	user := &User{}
-	err := dao.db.QueryRow(query, email).Scan(
-		&user.Id,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.Nickname,
-		&user.Bio,
-		&user.Avatar,
-		&user.Phone,
-		&user.Location,
-		&user.Website,
-		&user.Ctime,
-		&user.Utime,
-	)
-
+	err := dao.db.WithContext(ctx).Where("email = ?", email).First(user).Error
 	if err != nil {
 		return nil, err
 	}
-
 	return user, nil
 }
 
-func (dao *UserDAO) FindByUsername(username string) (*User, error) {
-	query := `
-		SELECT id, username, email, password, nickname, bio, avatar, phone, location, website, ctime, utime
-		FROM users WHERE username = ?
-	`
-
+func (dao *UserDAO) FindByUsername(ctx context.Context, username string) (*User, error) {
 	user := &User{}
-	err := dao.db.QueryRow(query, username).Scan(
-		&user.Id,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.Nickname,
-		&user.Bio,
-		&user.Avatar,
-		&user.Phone,
-		&user.Location,
-		&user.Website,
-		&user.Ctime,
-		&user.Utime,
-	)
-
+	err := dao.db.WithContext(ctx).Where("username = ?", username).First(user).Error
 	if err != nil {
 		return nil, err
 	}
-
 	return user, nil
 }
 
-func (dao *UserDAO) UpdateProfile(id int64, profile *domain.ProfileUpdateRequest) error {
-	query := `
-		UPDATE users 
-		SET nickname = ?, bio = ?, avatar = ?, phone = ?, location = ?, website = ?, utime = ?
-		WHERE id = ?
-	`
+func (dao *UserDAO) UpdateProfile(ctx context.Context, id int64, profile *domain.ProfileUpdateRequest) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"nickname": profile.Nickname,
+		"bio":      profile.Bio,
+		"avatar":   profile.Avatar,
+		"phone":    profile.Phone,
+		"location": profile.Location,
+		"website":  profile.Website,
+		"utime":    time.Now(),
+	}).Error
+}
 
-	now := time.Now()
-	_, err := dao.db.Exec(query,
-		profile.Nickname,
-		profile.Bio,
-		profile.Avatar,
-		profile.Phone,
-		profile.Location,
-		profile.Website,
-		now,
-		id,
-	)
+func (dao *UserDAO) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"password": password,
+		"utime":    time.Now(),
+	}).Error
+}
 
+func (dao *UserDAO) UpdateEmailVerified(ctx context.Context, id int64, verified bool) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"email_verified": verified,
+		"utime":          time.Now(),
+	}).Error
+}
+
+func (dao *UserDAO) UpdateRole(ctx context.Context, id int64, role string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"role":  role,
+		"utime": time.Now(),
+	}).Error
+}
+
+// UpdateEmail 修改邮箱，新邮箱需要重新验证
+func (dao *UserDAO) UpdateEmail(ctx context.Context, id int64, email string) error {
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+		"email":          email,
+		"email_verified": false,
+		"utime":          time.Now(),
+	}).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrUserDuplicateEmail
+	}
 	return err
 }
+
+func (dao *UserDAO) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := dao.db.WithContext(ctx).Model(&User{}).Count(&count).Error
+	return count, err
+}
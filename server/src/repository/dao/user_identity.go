@@ -0,0 +1,61 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 18:00:00
+ * @Description: 用户绑定的外部登录账号
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserIdentityNotFound = gorm.ErrRecordNotFound
+
+type UserIdentity struct {
+	Id int64 `gorm:"primaryKey;autoIncrement"`
+	// 每个用户在同一提供方只能绑定一个账号
+	UserId   int64  `gorm:"uniqueIndex:idx_user_provider"`
+	Provider string `gorm:"size:50;uniqueIndex:idx_user_provider;uniqueIndex:idx_provider_subject"`
+	Subject  string `gorm:"size:255;uniqueIndex:idx_provider_subject"`
+	Email    string `gorm:"size:255"`
+	Ctime    int64
+}
+
+type UserIdentityDAO struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityDAO(db *gorm.DB) *UserIdentityDAO {
+	return &UserIdentityDAO{db: db}
+}
+
+func (dao *UserIdentityDAO) Insert(ctx context.Context, identity UserIdentity) error {
+	identity.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&identity).Error
+}
+
+func (dao *UserIdentityDAO) FindBySubject(ctx context.Context, provider, subject string) (UserIdentity, error) {
+	var identity UserIdentity
+	err := dao.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	return identity, err
+}
+
+func (dao *UserIdentityDAO) FindByUser(ctx context.Context, userId int64) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := dao.db.WithContext(ctx).Where("user_id = ?", userId).Order("id ASC").Find(&identities).Error
+	return identities, err
+}
+
+func (dao *UserIdentityDAO) Delete(ctx context.Context, userId int64, provider string) error {
+	res := dao.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userId, provider).Delete(&UserIdentity{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserIdentityNotFound
+	}
+	return nil
+}
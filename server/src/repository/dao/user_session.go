@@ -0,0 +1,106 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 14:00:00
+ * @Description: 服务端会话登记表
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserSessionNotFound = gorm.ErrRecordNotFound
+
+type UserSession struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement"`
+	Sid       string `gorm:"size:64;unique"`
+	UserId    int64  `gorm:"index"`
+	Device    string `gorm:"size:100"`
+	UserAgent string `gorm:"size:500"`
+	Ip        string `gorm:"size:64"`
+	// 密码已验证但两步验证尚未完成
+	Pending  bool
+	LastSeen int64
+	ExpireAt int64 `gorm:"index"`
+	Ctime    int64
+}
+
+type UserSessionDAO struct {
+	db *gorm.DB
+}
+
+func NewUserSessionDAO(db *gorm.DB) *UserSessionDAO {
+	return &UserSessionDAO{db: db}
+}
+
+func (dao *UserSessionDAO) Insert(ctx context.Context, session UserSession) error {
+	now := time.Now().UnixMilli()
+	session.Ctime = now
+	session.LastSeen = now
+	return dao.db.WithContext(ctx).Create(&session).Error
+}
+
+// FindBySid 查询未过期的会话
+func (dao *UserSessionDAO) FindBySid(ctx context.Context, sid string) (UserSession, error) {
+	var session UserSession
+	err := dao.db.WithContext(ctx).
+		Where("sid = ? AND expire_at > ?", sid, time.Now().UnixMilli()).
+		First(&session).Error
+	return session, err
+}
+
+func (dao *UserSessionDAO) FindByUser(ctx context.Context, userId int64) ([]UserSession, error) {
+	var sessions []UserSession
+	err := dao.db.WithContext(ctx).
+		Where("user_id = ? AND expire_at > ?", userId, time.Now().UnixMilli()).
+		Order("last_seen DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (dao *UserSessionDAO) UpdateLastSeen(ctx context.Context, sid, ip string) error {
+	return dao.db.WithContext(ctx).Model(&UserSession{}).
+		Where("sid = ?", sid).
+		Updates(map[string]interface{}{
+			"last_seen": time.Now().UnixMilli(),
+			"ip":        ip,
+		}).Error
+}
+
+// Promote 两步验证完成后将会话转为正式会话
+func (dao *UserSessionDAO) Promote(ctx context.Context, sid string, expireAt int64) error {
+	return dao.db.WithContext(ctx).Model(&UserSession{}).
+		Where("sid = ?", sid).
+		Updates(map[string]interface{}{
+			"pending":   false,
+			"expire_at": expireAt,
+			"last_seen": time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *UserSessionDAO) DeleteById(ctx context.Context, userId, id int64) error {
+	res := dao.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&UserSession{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserSessionNotFound
+	}
+	return nil
+}
+
+func (dao *UserSessionDAO) DeleteBySid(ctx context.Context, sid string) error {
+	return dao.db.WithContext(ctx).Where("sid = ?", sid).Delete(&UserSession{}).Error
+}
+
+// DeleteByUserExcept 删除用户除 exceptSid 以外的全部会话，exceptSid 为空时全部删除
+func (dao *UserSessionDAO) DeleteByUserExcept(ctx context.Context, userId int64, exceptSid string) error {
+	query := dao.db.WithContext(ctx).Where("user_id = ?", userId)
+	if exceptSid != "" {
+		query = query.Where("sid <> ?", exceptSid)
+	}
+	return query.Delete(&UserSession{}).Error
+}
@@ -0,0 +1,61 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 13:00:00
+ * @Description: 一次性令牌使用记录
+ */
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserTokenUsed = errors.New("令牌不存在或已被使用")
+
+type UserToken struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Nonce    string `gorm:"size:64;unique"`
+	UserId   int64  `gorm:"index"`
+	Purpose  string `gorm:"size:30"`
+	ExpireAt int64
+	// 0 表示未使用
+	UsedAt int64
+	Ctime  int64
+}
+
+type UserTokenDAO struct {
+	db *gorm.DB
+}
+
+func NewUserTokenDAO(db *gorm.DB) *UserTokenDAO {
+	return &UserTokenDAO{db: db}
+}
+
+func (dao *UserTokenDAO) Insert(ctx context.Context, token UserToken) error {
+	token.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&token).Error
+}
+
+// Consume 原子地将令牌标记为已使用，保证令牌只能使用一次
+func (dao *UserTokenDAO) Consume(ctx context.Context, nonce, purpose string) error {
+	res := dao.db.WithContext(ctx).Model(&UserToken{}).
+		Where("nonce = ? AND purpose = ? AND used_at = ?", nonce, purpose, 0).
+		Update("used_at", time.Now().UnixMilli())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserTokenUsed
+	}
+	return nil
+}
+
+// InvalidateByUser 作废用户某一用途的全部未使用令牌
+func (dao *UserTokenDAO) InvalidateByUser(ctx context.Context, userId int64, purpose string) error {
+	return dao.db.WithContext(ctx).Model(&UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at = ?", userId, purpose, 0).
+		Update("used_at", time.Now().UnixMilli()).Error
+}
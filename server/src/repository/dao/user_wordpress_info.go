@@ -13,7 +13,6 @@ import (
 	"errors"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
@@ -54,12 +53,9 @@ func (dao *UserWordpressInfoDAO) Insert(ctx context.Context, wpui UserWordpressI
 	wpui.Utime = now
 	wpui.Ctime = now
 	err := dao.db.WithContext(ctx).Create(&wpui).Error
-	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-		const uniqueConflictsErrNo uint16 = 1062
-		if mysqlErr.Number == uniqueConflictsErrNo {
-			// 用户记录冲突
-			return ErrUserDuplicateUid
-		}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 用户记录冲突
+		return ErrUserDuplicateUid
 	}
 	return err
 }
@@ -0,0 +1,182 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: webhook 配置和投递记录
+ */
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound         = gorm.ErrRecordNotFound
+	ErrWebhookDeliveryNotFound = gorm.ErrRecordNotFound
+)
+
+type Webhook struct {
+	Id   int64  `gorm:"primaryKey;autoIncrement"`
+	Name string `gorm:"size:100"`
+	URL  string `gorm:"size:1000"`
+	// 加密保存的签名密钥
+	Secret string `gorm:"size:255"`
+	// 逗号分隔的事件类型
+	Events  string `gorm:"size:1000"`
+	Enabled bool   `gorm:"default:true"`
+	Ctime   int64
+	Utime   int64
+}
+
+type WebhookDelivery struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement"`
+	WebhookId    int64  `gorm:"index"`
+	EventId      string `gorm:"size:64;index"`
+	EventType    string `gorm:"size:50"`
+	Payload      string `gorm:"type:mediumtext"`
+	Status       string `gorm:"size:20;index:idx_status_next_retry"`
+	Attempts     int
+	ResponseCode int
+	Error        string `gorm:"size:1000"`
+	DurationMs   int64
+	// 待投递记录的下次投递时间，0 表示不再投递
+	NextRetryAt int64 `gorm:"index:idx_status_next_retry"`
+	Ctime       int64
+	Utime       int64
+}
+
+type WebhookDAO struct {
+	db *gorm.DB
+}
+
+func NewWebhookDAO(db *gorm.DB) *WebhookDAO {
+	return &WebhookDAO{db: db}
+}
+
+func (dao *WebhookDAO) Insert(ctx context.Context, hook Webhook) (Webhook, error) {
+	now := time.Now().UnixMilli()
+	hook.Ctime = now
+	hook.Utime = now
+	err := dao.db.WithContext(ctx).Create(&hook).Error
+	return hook, err
+}
+
+func (dao *WebhookDAO) Update(ctx context.Context, hook Webhook) error {
+	res := dao.db.WithContext(ctx).Model(&Webhook{}).Where("id = ?", hook.Id).Updates(map[string]interface{}{
+		"name":    hook.Name,
+		"url":     hook.URL,
+		"events":  hook.Events,
+		"enabled": hook.Enabled,
+		"utime":   time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (dao *WebhookDAO) UpdateSecret(ctx context.Context, id int64, secret string) error {
+	res := dao.db.WithContext(ctx).Model(&Webhook{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"secret": secret,
+		"utime":  time.Now().UnixMilli(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (dao *WebhookDAO) Delete(ctx context.Context, id int64) error {
+	res := dao.db.WithContext(ctx).Where("id = ?", id).Delete(&Webhook{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (dao *WebhookDAO) FindById(ctx context.Context, id int64) (Webhook, error) {
+	var hook Webhook
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&hook).Error
+	return hook, err
+}
+
+func (dao *WebhookDAO) FindAll(ctx context.Context) ([]Webhook, error) {
+	var hooks []Webhook
+	err := dao.db.WithContext(ctx).Order("id ASC").Find(&hooks).Error
+	return hooks, err
+}
+
+func (dao *WebhookDAO) FindEnabled(ctx context.Context) ([]Webhook, error) {
+	var hooks []Webhook
+	err := dao.db.WithContext(ctx).Where("enabled = ?", true).Find(&hooks).Error
+	return hooks, err
+}
+
+func (dao *WebhookDAO) InsertDelivery(ctx context.Context, delivery WebhookDelivery) (WebhookDelivery, error) {
+	now := time.Now().UnixMilli()
+	delivery.Ctime = now
+	delivery.Utime = now
+	err := dao.db.WithContext(ctx).Create(&delivery).Error
+	return delivery, err
+}
+
+func (dao *WebhookDAO) FindDeliveryById(ctx context.Context, id int64) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+	return delivery, err
+}
+
+func (dao *WebhookDAO) FindDeliveriesByWebhook(ctx context.Context, webhookId int64, offset, limit int) ([]WebhookDelivery, int64, error) {
+	var deliveries []WebhookDelivery
+	var total int64
+	query := dao.db.WithContext(ctx).Model(&WebhookDelivery{}).Where("webhook_id = ?", webhookId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// FindDue 查询已到投递时间的待投递记录
+func (dao *WebhookDAO) FindDue(ctx context.Context, now int64, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := dao.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at > 0 AND next_retry_at <= ?", "pending", now).
+		Order("next_retry_at ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Claim 把下次投递时间推后作为租约，多个实例同时扫描时只有一个能领取成功
+func (dao *WebhookDAO) Claim(ctx context.Context, id, expectNextRetryAt, leaseUntil int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_retry_at = ?", id, "pending", expectNextRetryAt).
+		Update("next_retry_at", leaseUntil)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// UpdateResult 记录一次投递的结果
+func (dao *WebhookDAO) UpdateResult(ctx context.Context, delivery WebhookDelivery) error {
+	return dao.db.WithContext(ctx).Model(&WebhookDelivery{}).Where("id = ?", delivery.Id).Updates(map[string]interface{}{
+		"status":        delivery.Status,
+		"attempts":      delivery.Attempts,
+		"response_code": delivery.ResponseCode,
+		"error":         delivery.Error,
+		"duration_ms":   delivery.DurationMs,
+		"next_retry_at": delivery.NextRetryAt,
+		"utime":         time.Now().UnixMilli(),
+	}).Error
+}
@@ -0,0 +1,93 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 15:00:00
+ * @Description: 用户数据导出任务仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type DataExportRepository struct {
+	dao *dao.DataExportDAO
+}
+
+func NewDataExportRepository(dao *dao.DataExportDAO) *DataExportRepository {
+	return &DataExportRepository{
+		dao: dao,
+	}
+}
+
+// Create 创建等待执行的导出任务，用户当天已有未失败的任务时返回 dao.ErrDataExportDuplicate
+func (r *DataExportRepository) Create(ctx context.Context, userId int64) (domain.DataExport, error) {
+	export, err := r.dao.Insert(ctx, dao.DataExport{UserId: userId})
+	return toDomainDataExport(export), err
+}
+
+// FindById 查询不到时返回 dao.ErrDataExportNotFound
+func (r *DataExportRepository) FindById(ctx context.Context, id int64) (domain.DataExport, error) {
+	export, err := r.dao.FindById(ctx, id)
+	return toDomainDataExport(export), err
+}
+
+func (r *DataExportRepository) FindByUser(ctx context.Context, userId int64, limit int) ([]domain.DataExport, error) {
+	list, err := r.dao.FindByUser(ctx, userId, limit)
+	return toDomainDataExports(list), err
+}
+
+func (r *DataExportRepository) FindPending(ctx context.Context, limit int) ([]domain.DataExport, error) {
+	list, err := r.dao.FindPending(ctx, limit)
+	return toDomainDataExports(list), err
+}
+
+func (r *DataExportRepository) Claim(ctx context.Context, id int64) (bool, error) {
+	return r.dao.Claim(ctx, id)
+}
+
+func (r *DataExportRepository) ResetStale(ctx context.Context, before time.Time) error {
+	return r.dao.ResetStale(ctx, before.UnixMilli())
+}
+
+func (r *DataExportRepository) Complete(ctx context.Context, id int64, fileName, downloadToken string, size int64, expireAt time.Time) error {
+	return r.dao.Complete(ctx, id, fileName, downloadToken, size, expireAt.UnixMilli())
+}
+
+func (r *DataExportRepository) Fail(ctx context.Context, id int64, reason string) error {
+	return r.dao.Fail(ctx, id, reason)
+}
+
+func (r *DataExportRepository) FindExpired(ctx context.Context, now time.Time, limit int) ([]domain.DataExport, error) {
+	list, err := r.dao.FindExpired(ctx, now.UnixMilli(), limit)
+	return toDomainDataExports(list), err
+}
+
+func (r *DataExportRepository) MarkExpired(ctx context.Context, id int64) error {
+	return r.dao.MarkExpired(ctx, id)
+}
+
+func toDomainDataExport(e dao.DataExport) domain.DataExport {
+	return domain.DataExport{
+		Id:            e.Id,
+		UserId:        e.UserId,
+		Status:        e.Status,
+		Size:          e.Size,
+		Error:         e.Error,
+		ExpireAt:      editedAt(e.ExpireAt),
+		Ctime:         time.UnixMilli(e.Ctime),
+		FileName:      e.FileName,
+		DownloadToken: e.DownloadToken,
+	}
+}
+
+func toDomainDataExports(list []dao.DataExport) []domain.DataExport {
+	exports := make([]domain.DataExport, 0, len(list))
+	for _, e := range list {
+		exports = append(exports, toDomainDataExport(e))
+	}
+	return exports
+}
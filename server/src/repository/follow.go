@@ -0,0 +1,91 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 用户关注关系仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type FollowRepository struct {
+	dao *dao.FollowDAO
+}
+
+func NewFollowRepository(dao *dao.FollowDAO) *FollowRepository {
+	return &FollowRepository{
+		dao: dao,
+	}
+}
+
+func (r *FollowRepository) Create(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	return r.dao.Insert(ctx, followerId, followeeId)
+}
+
+func (r *FollowRepository) Delete(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	return r.dao.Delete(ctx, followerId, followeeId)
+}
+
+func (r *FollowRepository) Exists(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	return r.dao.Exists(ctx, followerId, followeeId)
+}
+
+func (r *FollowRepository) Stats(ctx context.Context, userId int64) (domain.FollowStats, error) {
+	count, err := r.dao.FindCount(ctx, userId)
+	if err != nil {
+		return domain.FollowStats{}, err
+	}
+	return domain.FollowStats{
+		Followers: count.Followers,
+		Following: count.Following,
+	}, nil
+}
+
+// GetFollowers 返回粉丝列表和下一页的游标，没有更多时游标为 0，昵称头像由服务层补充
+func (r *FollowRepository) GetFollowers(ctx context.Context, userId, beforeId int64, limit int) ([]domain.FollowUser, int64, error) {
+	list, err := r.dao.FindFollowers(ctx, userId, beforeId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := make([]domain.FollowUser, 0, len(list))
+	for _, f := range list {
+		users = append(users, domain.FollowUser{UserId: f.FollowerId, Ctime: time.UnixMilli(f.Ctime)})
+	}
+	return users, nextFollowCursor(list, limit), nil
+}
+
+func (r *FollowRepository) GetFollowing(ctx context.Context, userId, beforeId int64, limit int) ([]domain.FollowUser, int64, error) {
+	list, err := r.dao.FindFollowing(ctx, userId, beforeId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := make([]domain.FollowUser, 0, len(list))
+	for _, f := range list {
+		users = append(users, domain.FollowUser{UserId: f.FolloweeId, Ctime: time.UnixMilli(f.Ctime)})
+	}
+	return users, nextFollowCursor(list, limit), nil
+}
+
+func nextFollowCursor(list []dao.Follow, limit int) int64 {
+	if len(list) < limit {
+		return 0
+	}
+	return list[len(list)-1].Id
+}
+
+func (r *FollowRepository) FolloweeIds(ctx context.Context, userId int64) ([]int64, error) {
+	return r.dao.FindFolloweeIds(ctx, userId)
+}
+
+func (r *FollowRepository) FollowerIds(ctx context.Context, userId, afterId int64, limit int) ([]int64, error) {
+	return r.dao.FindFollowerIds(ctx, userId, afterId, limit)
+}
+
+func (r *FollowRepository) PopularFolloweeIds(ctx context.Context, userId, minFollowers int64) ([]int64, error) {
+	return r.dao.FindPopularFolloweeIds(ctx, userId, minFollowers)
+}
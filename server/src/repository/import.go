@@ -0,0 +1,85 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 批量导入仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type ImportRepository struct {
+	dao *dao.ImportDAO
+	// 导入完成后使树洞列表缓存失效，可以为 nil
+	cache *cache.Cache
+}
+
+func NewImportRepository(dao *dao.ImportDAO, c *cache.Cache) *ImportRepository {
+	return &ImportRepository{
+		dao:   dao,
+		cache: c,
+	}
+}
+
+// FindImported 返回 externalIds 中已经导入过的记录
+func (r *ImportRepository) FindImported(ctx context.Context, source string, externalIds []string) (map[string]bool, error) {
+	return r.dao.FindImported(ctx, source, externalIds)
+}
+
+// InvalidateTreeHoleLists 导入树洞后使树洞列表缓存失效，整批导入完成后调用一次
+func (r *ImportRepository) InvalidateTreeHoleLists(ctx context.Context) {
+	r.cache.Bump(ctx, treeHoleListGroup)
+}
+
+// ImportTreeHole 记录已导入过时返回 dao.ErrImportDuplicate，下同
+func (r *ImportRepository) ImportTreeHole(ctx context.Context, source, externalId string, th domain.TreeHole) (domain.TreeHole, error) {
+	res, err := r.dao.InsertTreeHole(ctx, source, externalId, dao.TreeHole{
+		Content: th.Content,
+		UserId:  th.UserId,
+		Ctime:   th.Ctime.UnixMilli(),
+	})
+	if err != nil {
+		return domain.TreeHole{}, err
+	}
+	return domain.TreeHole{
+		Id:      res.Id,
+		Content: res.Content,
+		UserId:  res.UserId,
+		Ctime:   time.UnixMilli(res.Ctime),
+	}, nil
+}
+
+func (r *ImportRepository) ImportStatus(ctx context.Context, source, externalId string, status domain.Status) (domain.Status, error) {
+	res, err := r.dao.InsertStatus(ctx, source, externalId, dao.Status{
+		Content:  status.Content,
+		UserId:   status.UserId,
+		Ctime:    status.Ctime.UnixMilli(),
+		EditedAt: unixMilli(status.EditedAt),
+	})
+	if err != nil {
+		return domain.Status{}, err
+	}
+	return toDomainStatus(res), nil
+}
+
+func (r *ImportRepository) ImportPosts(ctx context.Context, source, externalId string, posts domain.Posts) (domain.Posts, error) {
+	res, err := r.dao.InsertPosts(ctx, source, externalId, dao.Posts{
+		Title:     posts.Title,
+		Content:   posts.Content,
+		UserId:    posts.UserId,
+		Ctime:     posts.Ctime.UnixMilli(),
+		EditedAt:  unixMilli(posts.EditedAt),
+		State:     posts.State,
+		PublishAt: unixMilli(posts.PublishAt),
+	})
+	if err != nil {
+		return domain.Posts{}, err
+	}
+	return toDomainPosts(res), nil
+}
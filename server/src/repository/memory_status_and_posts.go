@@ -0,0 +1,544 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: 进程内动态和文章仓储，供测试和无数据库的本地开发使用
+ */
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+// memoryContent 动态和文章共用的存储结构，动态的 Title 为空
+type memoryContent struct {
+	Id      int64
+	Title   string
+	Content string
+	UserId  int64
+	Hidden  bool
+	// 毫秒时间戳
+	Ctime int64
+	// 软删除时间，0 表示未删除
+	DeletedAt int64
+	DeletedBy int64
+	// 最后一次编辑的时间，0 表示未编辑过
+	EditedAt int64
+	// 动态始终为已发布，发布时间与创建时间相同
+	State     string
+	PublishAt int64
+	CrossPost bool
+}
+
+type MemoryStatusAndPostsRepository struct {
+	mu           sync.RWMutex
+	nextStatusId int64
+	nextPostsId  int64
+	status       map[int64]*memoryContent
+	posts        map[int64]*memoryContent
+	// 编辑历史按追加顺序保存，Version 在读取时计算
+	revisions      []domain.ContentRevision
+	nextRevisionId int64
+}
+
+func NewMemoryStatusAndPostsRepository() *MemoryStatusAndPostsRepository {
+	return &MemoryStatusAndPostsRepository{
+		status: make(map[int64]*memoryContent),
+		posts:  make(map[int64]*memoryContent),
+	}
+}
+
+func (s *MemoryStatusAndPostsRepository) CreateStatus(ctx context.Context, status domain.Status) (domain.Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextStatusId++
+	now := time.Now().UnixMilli()
+	c := &memoryContent{Id: s.nextStatusId, Content: status.Content, UserId: status.UserId, Ctime: now, State: domain.PostStatePublished, PublishAt: now}
+	s.status[c.Id] = c
+	return c.toStatus(), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) CreatePosts(ctx context.Context, posts domain.Posts) (domain.Posts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextPostsId++
+	now := time.Now().UnixMilli()
+	c := &memoryContent{
+		Id:        s.nextPostsId,
+		Title:     posts.Title,
+		Content:   posts.Content,
+		UserId:    posts.UserId,
+		Ctime:     now,
+		State:     posts.State,
+		PublishAt: unixMilli(posts.PublishAt),
+		CrossPost: posts.CrossPost,
+	}
+	// 与 SQL 实现一致，未指定状态时为已发布，立即可见的文章发布时间为创建时间
+	if c.State == "" {
+		c.State = domain.PostStatePublished
+	}
+	if c.State != domain.PostStateDraft && c.State != domain.PostStateScheduled {
+		c.PublishAt = now
+	}
+	s.posts[c.Id] = c
+	return c.toPosts(), nil
+}
+
+// EditStatus 编辑不影响隐藏状态和创建时间，已删除的内容不能编辑
+func (s *MemoryStatusAndPostsRepository) EditStatus(ctx context.Context, status domain.Status, editorId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.status[status.Id]
+	if !ok || c.DeletedAt > 0 {
+		return dao.ErrStatusNotFound
+	}
+	s.edit(c, domain.RevisionStatus, "", status.Content, editorId)
+	return nil
+}
+
+func (s *MemoryStatusAndPostsRepository) EditPosts(ctx context.Context, posts domain.Posts, editorId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.posts[posts.Id]
+	if !ok || c.DeletedAt > 0 {
+		return dao.ErrPostsNotFound
+	}
+	s.edit(c, domain.RevisionPost, posts.Title, posts.Content, editorId)
+	return nil
+}
+
+func (s *MemoryStatusAndPostsRepository) GetPosts(ctx context.Context, id int64) (domain.Posts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.posts[id]
+	if !ok || c.Hidden || c.DeletedAt > 0 {
+		return domain.Posts{}, dao.ErrPostsNotFound
+	}
+	return c.toPosts(), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) GetStatus(ctx context.Context, id int64) (domain.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.status[id]
+	if !ok || c.Hidden || c.DeletedAt > 0 {
+		return domain.Status{}, dao.ErrStatusNotFound
+	}
+	return c.toStatus(), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindStatusByUser(ctx context.Context, uid int64) ([]domain.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var status []domain.Status
+	for _, c := range visibleContents(s.status, func(c *memoryContent) bool { return c.UserId == uid }) {
+		status = append(status, c.toStatus())
+	}
+	return status, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindPostsByUser(ctx context.Context, uid int64) ([]domain.Posts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var posts []domain.Posts
+	for _, c := range visibleContents(s.posts, func(c *memoryContent) bool { return c.UserId == uid }) {
+		posts = append(posts, c.toPosts())
+	}
+	return posts, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) GetAllStatus(ctx context.Context) ([]domain.Status, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var status []domain.Status
+	for _, c := range visibleContents(s.status, nil) {
+		status = append(status, c.toStatus())
+	}
+	return status, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) GetAllPosts(ctx context.Context) ([]domain.Posts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var posts []domain.Posts
+	for _, c := range visibleContents(s.posts, func(c *memoryContent) bool { return c.State == domain.PostStatePublished }) {
+		posts = append(posts, c.toPosts())
+	}
+	return posts, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) DeleteStatus(ctx context.Context, id, deletedBy int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return softDelete(s.status, id, deletedBy, dao.ErrStatusNotFound)
+}
+
+func (s *MemoryStatusAndPostsRepository) DeletePosts(ctx context.Context, id, deletedBy int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return softDelete(s.posts, id, deletedBy, dao.ErrPostsNotFound)
+}
+
+// FindStatusOwner 查询动态作者，包括已隐藏的动态
+func (s *MemoryStatusAndPostsRepository) FindStatusOwner(ctx context.Context, id int64) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.status[id]
+	if !ok || c.DeletedAt > 0 {
+		return 0, dao.ErrStatusNotFound
+	}
+	return c.UserId, nil
+}
+
+// FindPostsOwner 查询文章作者，包括已隐藏的文章
+func (s *MemoryStatusAndPostsRepository) FindPostsOwner(ctx context.Context, id int64) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.posts[id]
+	if !ok || c.DeletedAt > 0 {
+		return 0, dao.ErrPostsNotFound
+	}
+	return c.UserId, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) SetStatusHidden(ctx context.Context, id int64, hidden bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.status[id]; ok {
+		c.Hidden = hidden
+	}
+	return nil
+}
+
+func (s *MemoryStatusAndPostsRepository) SetPostsHidden(ctx context.Context, id int64, hidden bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.posts[id]; ok {
+		c.Hidden = hidden
+	}
+	return nil
+}
+
+// TimelineEntries 按时间倒序读取多个用户的动态和文章引用，after 为上一页的最后一条，为零值时从最新开始
+func (s *MemoryStatusAndPostsRepository) TimelineEntries(ctx context.Context, uids []int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var entries []domain.TimelineEntry
+	collect := func(kind string, contents map[int64]*memoryContent) {
+		for _, c := range contents {
+			if c.Hidden || c.DeletedAt > 0 || c.State != domain.PostStatePublished || !slices.Contains(uids, c.UserId) {
+				continue
+			}
+			e := domain.TimelineEntry{Kind: kind, Id: c.Id, Ctime: c.PublishAt}
+			if after.IsZero() || after.Before(e) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	collect(domain.TimelineStatus, s.status)
+	collect(domain.TimelinePost, s.posts)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Before(entries[j])
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// FindTimelineItems 加载时间线条目的内容，结果以只含类型和 id 的条目为键，已删除或已隐藏的条目不会返回
+func (s *MemoryStatusAndPostsRepository) FindTimelineItems(ctx context.Context, entries []domain.TimelineEntry) (map[domain.TimelineEntry]domain.TimelineItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make(map[domain.TimelineEntry]domain.TimelineItem, len(entries))
+	for _, e := range entries {
+		contents := s.posts
+		if e.Kind == domain.TimelineStatus {
+			contents = s.status
+		}
+		c, ok := contents[e.Id]
+		if !ok || c.Hidden || c.DeletedAt > 0 || c.State != domain.PostStatePublished {
+			continue
+		}
+		items[domain.TimelineEntry{Kind: e.Kind, Id: e.Id}] = domain.TimelineItem{
+			Type:     e.Kind,
+			Id:       c.Id,
+			UserId:   c.UserId,
+			Title:    c.Title,
+			Content:  c.Content,
+			Ctime:    time.UnixMilli(c.PublishAt),
+			Edited:   c.EditedAt > 0,
+			EditedAt: editedAt(c.EditedAt),
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindDeletedStatus(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return deletedContents(s.status, domain.TrashStatus, userId, limit), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindDeletedPosts(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return deletedContents(s.posts, domain.TrashPost, userId, limit), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindDeletedStatusById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.status[id]
+	if !ok || c.DeletedAt == 0 {
+		return domain.TrashItem{}, dao.ErrStatusNotFound
+	}
+	return c.trashItem(domain.TrashStatus), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindDeletedPostsById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.posts[id]
+	if !ok || c.DeletedAt == 0 {
+		return domain.TrashItem{}, dao.ErrPostsNotFound
+	}
+	return c.trashItem(domain.TrashPost), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) RestoreStatus(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return restore(s.status, id, dao.ErrStatusNotFound)
+}
+
+func (s *MemoryStatusAndPostsRepository) RestorePosts(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return restore(s.posts, id, dao.ErrPostsNotFound)
+}
+
+func (s *MemoryStatusAndPostsRepository) PurgeDeletedStatus(ctx context.Context, before time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.purge(s.status, domain.RevisionStatus, before, limit), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) PurgeDeletedPosts(ctx context.Context, before time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.purge(s.posts, domain.RevisionPost, before, limit), nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindRevisions(ctx context.Context, kind string, id int64) ([]domain.ContentRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revisions := []domain.ContentRevision{}
+	for _, r := range s.revisions {
+		if r.Type == kind && r.TargetId == id {
+			r.Version = len(revisions) + 1
+			revisions = append(revisions, r)
+		}
+	}
+	return revisions, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindRevision(ctx context.Context, kind string, id, revisionId int64) (domain.ContentRevision, error) {
+	revisions, _ := s.FindRevisions(ctx, kind, id)
+	for _, r := range revisions {
+		if r.Id == revisionId {
+			return r, nil
+		}
+	}
+	return domain.ContentRevision{}, dao.ErrRevisionNotFound
+}
+
+func (s *MemoryStatusAndPostsRepository) ChangePostsState(ctx context.Context, id int64, state string, publishAt time.Time, crossPost bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.posts[id]
+	if !ok || c.DeletedAt > 0 {
+		return dao.ErrPostsNotFound
+	}
+	c.State = state
+	c.PublishAt = unixMilli(publishAt)
+	c.CrossPost = crossPost
+	return nil
+}
+
+func (s *MemoryStatusAndPostsRepository) FindDueScheduledPosts(ctx context.Context, now time.Time, limit int) ([]domain.Posts, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var due []*memoryContent
+	for _, c := range s.posts {
+		if c.State == domain.PostStateScheduled && c.PublishAt <= now.UnixMilli() && c.DeletedAt == 0 {
+			due = append(due, c)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].PublishAt != due[j].PublishAt {
+			return due[i].PublishAt < due[j].PublishAt
+		}
+		return due[i].Id < due[j].Id
+	})
+	posts := make([]domain.Posts, 0, min(len(due), limit))
+	for _, c := range due[:min(len(due), limit)] {
+		posts = append(posts, c.toPosts())
+	}
+	return posts, nil
+}
+
+func (s *MemoryStatusAndPostsRepository) PublishScheduledPosts(ctx context.Context, id int64, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.posts[id]
+	if !ok || c.State != domain.PostStateScheduled || c.PublishAt > now.UnixMilli() || c.DeletedAt > 0 {
+		return false, nil
+	}
+	c.State = domain.PostStatePublished
+	c.CrossPost = false
+	return true, nil
+}
+
+// 以下函数和方法调用方需持有锁
+
+// edit 与 SQL 实现一致，内容第一次被编辑时先补记编辑前的原始版本
+func (s *MemoryStatusAndPostsRepository) edit(c *memoryContent, kind, title, content string, editorId int64) {
+	if !slices.ContainsFunc(s.revisions, func(r domain.ContentRevision) bool { return r.Type == kind && r.TargetId == c.Id }) {
+		s.appendRevision(kind, c.Id, c.UserId, c.Title, c.Content, c.Ctime)
+	}
+	now := time.Now().UnixMilli()
+	s.appendRevision(kind, c.Id, editorId, title, content, now)
+	c.Title = title
+	c.Content = content
+	c.EditedAt = now
+}
+
+func (s *MemoryStatusAndPostsRepository) appendRevision(kind string, id, editorId int64, title, content string, ctime int64) {
+	s.nextRevisionId++
+	s.revisions = append(s.revisions, domain.ContentRevision{
+		Id:       s.nextRevisionId,
+		Type:     kind,
+		TargetId: id,
+		EditorId: editorId,
+		Title:    title,
+		Content:  content,
+		Ctime:    time.UnixMilli(ctime),
+	})
+}
+
+// purge 与 SQL 实现一致，先清理删除时间最早的，同时清理编辑历史
+func (s *MemoryStatusAndPostsRepository) purge(contents map[int64]*memoryContent, kind string, before time.Time, limit int) int64 {
+	purged := purge(contents, before, limit)
+	s.revisions = slices.DeleteFunc(s.revisions, func(r domain.ContentRevision) bool {
+		return r.Type == kind && slices.Contains(purged, r.TargetId)
+	})
+	return int64(len(purged))
+}
+
+func softDelete(contents map[int64]*memoryContent, id, deletedBy int64, notFound error) error {
+	c, ok := contents[id]
+	if !ok || c.DeletedAt > 0 {
+		return notFound
+	}
+	c.DeletedAt = time.Now().UnixMilli()
+	c.DeletedBy = deletedBy
+	return nil
+}
+
+func restore(contents map[int64]*memoryContent, id int64, notFound error) error {
+	c, ok := contents[id]
+	if !ok || c.DeletedAt == 0 {
+		return notFound
+	}
+	c.DeletedAt = 0
+	c.DeletedBy = 0
+	return nil
+}
+
+// purge 返回清理的内容 id
+func purge(contents map[int64]*memoryContent, before time.Time, limit int) []int64 {
+	var expired []*memoryContent
+	for _, c := range contents {
+		if c.DeletedAt > 0 && c.DeletedAt < before.UnixMilli() {
+			expired = append(expired, c)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].DeletedAt < expired[j].DeletedAt
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	ids := make([]int64, 0, len(expired))
+	for _, c := range expired {
+		delete(contents, c.Id)
+		ids = append(ids, c.Id)
+	}
+	return ids
+}
+
+func deletedContents(contents map[int64]*memoryContent, kind string, userId int64, limit int) []domain.TrashItem {
+	var items []domain.TrashItem
+	for _, c := range contents {
+		if c.DeletedAt > 0 && c.UserId == userId {
+			items = append(items, c.trashItem(kind))
+		}
+	}
+	return latestDeleted(items, limit)
+}
+
+// visibleContents 按 id 升序返回未隐藏、未删除且满足 match 的内容，match 为 nil 时不过滤
+func visibleContents(contents map[int64]*memoryContent, match func(c *memoryContent) bool) []*memoryContent {
+	var results []*memoryContent
+	for _, c := range contents {
+		if !c.Hidden && c.DeletedAt == 0 && (match == nil || match(c)) {
+			results = append(results, c)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Id < results[j].Id
+	})
+	return results
+}
+
+func (c *memoryContent) toStatus() domain.Status {
+	return domain.Status{Id: c.Id, Content: c.Content, UserId: c.UserId, Ctime: time.UnixMilli(c.Ctime), Edited: c.EditedAt > 0, EditedAt: editedAt(c.EditedAt)}
+}
+
+func (c *memoryContent) toPosts() domain.Posts {
+	return domain.Posts{
+		Id:        c.Id,
+		Title:     c.Title,
+		Content:   c.Content,
+		UserId:    c.UserId,
+		Ctime:     time.UnixMilli(c.Ctime),
+		Edited:    c.EditedAt > 0,
+		EditedAt:  editedAt(c.EditedAt),
+		State:     c.State,
+		PublishAt: editedAt(c.PublishAt),
+		CrossPost: c.CrossPost,
+	}
+}
+
+func (c *memoryContent) trashItem(kind string) domain.TrashItem {
+	return domain.TrashItem{
+		Type:      kind,
+		Id:        c.Id,
+		UserId:    c.UserId,
+		Title:     c.Title,
+		Content:   c.Content,
+		Ctime:     time.UnixMilli(c.Ctime),
+		DeletedBy: c.DeletedBy,
+		DeletedAt: time.UnixMilli(c.DeletedAt),
+	}
+}
@@ -0,0 +1,223 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: 进程内树洞仓储，供测试和无数据库的本地开发使用
+ */
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type memoryTreeHole struct {
+	domain.TreeHole
+	hidden bool
+	// 软删除时间，为零值表示未删除
+	deletedAt time.Time
+	deletedBy int64
+}
+
+func (t *memoryTreeHole) deleted() bool {
+	return !t.deletedAt.IsZero()
+}
+
+type MemoryTreeHoleRepository struct {
+	mu     sync.RWMutex
+	nextId int64
+	// 按 id 升序
+	items []*memoryTreeHole
+}
+
+func NewMemoryTreeHoleRepository() *MemoryTreeHoleRepository {
+	return &MemoryTreeHoleRepository{}
+}
+
+func (r *MemoryTreeHoleRepository) Create(ctx context.Context, treeHole domain.TreeHole) (domain.TreeHole, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	created := domain.TreeHole{
+		Id:      r.nextId,
+		Content: treeHole.Content,
+		UserId:  treeHole.UserId,
+		Ctime:   time.UnixMilli(time.Now().UnixMilli()),
+	}
+	r.items = append(r.items, &memoryTreeHole{TreeHole: created})
+	return created, nil
+}
+
+func (r *MemoryTreeHoleRepository) GetList(ctx context.Context, offset, limit int, excludeUserIds []int64) ([]domain.TreeHole, error) {
+	return r.page(offset, limit, func(t *memoryTreeHole) bool {
+		return !slices.Contains(excludeUserIds, t.UserId)
+	}), nil
+}
+
+func (r *MemoryTreeHoleRepository) GetListByUser(ctx context.Context, userId int64, offset, limit int) ([]domain.TreeHole, error) {
+	return r.page(offset, limit, func(t *memoryTreeHole) bool {
+		return t.UserId == userId
+	}), nil
+}
+
+func (r *MemoryTreeHoleRepository) GetById(ctx context.Context, id int64) (domain.TreeHole, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t := r.find(id)
+	if t == nil || t.hidden || t.deleted() {
+		return domain.TreeHole{}, dao.ErrTreeHoleNotFound
+	}
+	return t.TreeHole, nil
+}
+
+func (r *MemoryTreeHoleRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.find(id)
+	if t == nil || t.deleted() {
+		return dao.ErrTreeHoleNotFound
+	}
+	t.deletedAt = time.UnixMilli(time.Now().UnixMilli())
+	t.deletedBy = deletedBy
+	return nil
+}
+
+// FindOwner 查询树洞作者，包括已隐藏的树洞
+func (r *MemoryTreeHoleRepository) FindOwner(ctx context.Context, id int64) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t := r.find(id)
+	if t == nil || t.deleted() {
+		return 0, dao.ErrTreeHoleNotFound
+	}
+	return t.UserId, nil
+}
+
+func (r *MemoryTreeHoleRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t := r.find(id); t != nil {
+		t.hidden = hidden
+	}
+	return nil
+}
+
+func (r *MemoryTreeHoleRepository) FindDeleted(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var items []domain.TrashItem
+	for _, t := range r.items {
+		if t.deleted() && t.UserId == userId {
+			items = append(items, t.trashItem())
+		}
+	}
+	return latestDeleted(items, limit), nil
+}
+
+func (r *MemoryTreeHoleRepository) FindDeletedById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t := r.find(id)
+	if t == nil || !t.deleted() {
+		return domain.TrashItem{}, dao.ErrTreeHoleNotFound
+	}
+	return t.trashItem(), nil
+}
+
+func (r *MemoryTreeHoleRepository) Restore(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.find(id)
+	if t == nil || !t.deleted() {
+		return dao.ErrTreeHoleNotFound
+	}
+	t.deletedAt = time.Time{}
+	t.deletedBy = 0
+	return nil
+}
+
+func (r *MemoryTreeHoleRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []*memoryTreeHole
+	for _, t := range r.items {
+		if t.deleted() && t.deletedAt.Before(before) {
+			expired = append(expired, t)
+		}
+	}
+	// 与 SQL 实现一致，先清理删除时间最早的
+	slices.SortFunc(expired, func(a, b *memoryTreeHole) int {
+		return a.deletedAt.Compare(b.deletedAt)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	r.items = slices.DeleteFunc(r.items, func(t *memoryTreeHole) bool {
+		return slices.Contains(expired, t)
+	})
+	return int64(len(expired)), nil
+}
+
+func (t *memoryTreeHole) trashItem() domain.TrashItem {
+	return domain.TrashItem{
+		Type:      domain.TrashTreeHole,
+		Id:        t.Id,
+		UserId:    t.UserId,
+		Content:   t.Content,
+		Ctime:     t.Ctime,
+		DeletedBy: t.deletedBy,
+		DeletedAt: t.deletedAt,
+	}
+}
+
+// page 按 id 升序分页，已隐藏和已删除的树洞不计入
+func (r *MemoryTreeHoleRepository) page(offset, limit int, match func(t *memoryTreeHole) bool) []domain.TreeHole {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := []domain.TreeHole{}
+	for _, t := range r.items {
+		if len(results) >= limit {
+			break
+		}
+		if t.hidden || t.deleted() || !match(t) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		results = append(results, t.TreeHole)
+	}
+	return results
+}
+
+// find 调用方需持有锁
+func (r *MemoryTreeHoleRepository) find(id int64) *memoryTreeHole {
+	i, ok := slices.BinarySearchFunc(r.items, id, func(t *memoryTreeHole, id int64) int {
+		return cmp.Compare(t.Id, id)
+	})
+	if !ok {
+		return nil
+	}
+	return r.items[i]
+}
+
+// latestDeleted 按删除时间倒序排列并截取前 limit 条，删除时间相同时 id 大的在前
+func latestDeleted(items []domain.TrashItem, limit int) []domain.TrashItem {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].DeletedAt.After(items[j].DeletedAt)
+		}
+		return items[i].Id > items[j].Id
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
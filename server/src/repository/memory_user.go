@@ -0,0 +1,162 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: 进程内用户仓储，供测试和无数据库的本地开发使用
+ */
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	nextId int64
+	users  map[int64]domain.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[int64]domain.User),
+	}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.findBy(func(u domain.User) bool { return u.Username == user.Username }); ok {
+		return dao.ErrUserDuplicateUsername
+	}
+	if _, ok := r.findBy(func(u domain.User) bool { return u.Email == user.Email }); ok {
+		return dao.ErrUserDuplicateEmail
+	}
+
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+	now := time.Now()
+	r.nextId++
+	stored := *user
+	stored.Id = r.nextId
+	stored.EmailVerified = false
+	stored.Ctime = now
+	stored.Utime = now
+	r.users[stored.Id] = stored
+
+	user.Id = stored.Id
+	user.Ctime = now
+	user.Utime = now
+	return nil
+}
+
+func (r *MemoryUserRepository) FindById(ctx context.Context, id int64) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, dao.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.findBy(func(u domain.User) bool { return u.Email == email })
+	if !ok {
+		return nil, dao.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *MemoryUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.findBy(func(u domain.User) bool { return u.Username == username })
+	if !ok {
+		return nil, dao.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, id int64, profile *domain.ProfileUpdateRequest) error {
+	return r.update(id, func(u *domain.User) error {
+		u.Nickname = profile.Nickname
+		u.Bio = profile.Bio
+		u.Avatar = profile.Avatar
+		u.Phone = profile.Phone
+		u.Location = profile.Location
+		u.Website = profile.Website
+		return nil
+	})
+}
+
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return r.update(id, func(u *domain.User) error {
+		u.Password = password
+		return nil
+	})
+}
+
+// UpdateEmail 修改邮箱，新邮箱需要重新验证
+func (r *MemoryUserRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	return r.update(id, func(u *domain.User) error {
+		if other, ok := r.findBy(func(o domain.User) bool { return o.Email == email }); ok && other.Id != u.Id {
+			return dao.ErrUserDuplicateEmail
+		}
+		u.Email = email
+		u.EmailVerified = false
+		return nil
+	})
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	return r.update(id, func(u *domain.User) error {
+		u.EmailVerified = true
+		return nil
+	})
+}
+
+func (r *MemoryUserRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	return r.update(id, func(u *domain.User) error {
+		u.Role = role
+		return nil
+	})
+}
+
+func (r *MemoryUserRepository) GetTotalUserCount(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.users)), nil
+}
+
+// update 与 SQL 实现一致，用户不存在时不报错
+func (r *MemoryUserRepository) update(id int64, fn func(u *domain.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	if err := fn(&u); err != nil {
+		return err
+	}
+	u.Utime = time.Now()
+	r.users[id] = u
+	return nil
+}
+
+// findBy 调用方需持有锁
+func (r *MemoryUserRepository) findBy(match func(u domain.User) bool) (domain.User, bool) {
+	for _, u := range r.users {
+		if match(u) {
+			return u, true
+		}
+	}
+	return domain.User{}, false
+}
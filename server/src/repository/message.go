@@ -0,0 +1,97 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 私信仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type MessageRepository struct {
+	dao *dao.MessageDAO
+}
+
+func NewMessageRepository(dao *dao.MessageDAO) *MessageRepository {
+	return &MessageRepository{
+		dao: dao,
+	}
+}
+
+// FindOrCreateConversation 返回两个用户之间的会话 id
+func (r *MessageRepository) FindOrCreateConversation(ctx context.Context, a, b int64) (int64, error) {
+	conv, err := r.dao.FindOrCreateConversation(ctx, a, b)
+	return conv.Id, err
+}
+
+// FindPeer 返回会话中的另一方，用户不是会话成员时返回 dao.ErrConversationNotFound
+func (r *MessageRepository) FindPeer(ctx context.Context, conversationId, userId int64) (int64, error) {
+	member, err := r.dao.FindMember(ctx, conversationId, userId)
+	return member.PeerId, err
+}
+
+func (r *MessageRepository) Create(ctx context.Context, msg domain.DirectMessage, preview string) (domain.DirectMessage, error) {
+	created, err := r.dao.Insert(ctx, dao.DirectMessage{
+		ConversationId: msg.ConversationId,
+		SenderId:       msg.SenderId,
+		Content:        msg.Content,
+	}, preview)
+	if err != nil {
+		return domain.DirectMessage{}, err
+	}
+	return toDomainMessage(created), nil
+}
+
+// GetConversations 会话列表，只填充会话本身的字段，对方的昵称头像由服务层补充
+func (r *MessageRepository) GetConversations(ctx context.Context, userId int64, offset, limit int) ([]domain.Conversation, int64, error) {
+	list, total, err := r.dao.FindMembersByUser(ctx, userId, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.Conversation, 0, len(list))
+	for _, m := range list {
+		results = append(results, domain.Conversation{
+			Id:            m.ConversationId,
+			PeerId:        m.PeerId,
+			LastMessage:   m.LastMessagePreview,
+			LastMessageAt: time.UnixMilli(m.LastMessageAt),
+			Unread:        m.UnreadCount,
+		})
+	}
+	return results, total, nil
+}
+
+func (r *MessageRepository) GetMessages(ctx context.Context, conversationId, beforeId int64, limit int) ([]domain.DirectMessage, error) {
+	list, err := r.dao.FindMessages(ctx, conversationId, beforeId, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.DirectMessage, 0, len(list))
+	for _, m := range list {
+		results = append(results, toDomainMessage(m))
+	}
+	return results, nil
+}
+
+func (r *MessageRepository) MarkRead(ctx context.Context, conversationId, userId int64) error {
+	return r.dao.MarkRead(ctx, conversationId, userId)
+}
+
+func (r *MessageRepository) UnreadCount(ctx context.Context, userId int64) (int64, error) {
+	return r.dao.SumUnread(ctx, userId)
+}
+
+func toDomainMessage(m dao.DirectMessage) domain.DirectMessage {
+	return domain.DirectMessage{
+		Id:             m.Id,
+		ConversationId: m.ConversationId,
+		SenderId:       m.SenderId,
+		Content:        m.Content,
+		Ctime:          time.UnixMilli(m.Ctime),
+	}
+}
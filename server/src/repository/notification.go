@@ -0,0 +1,103 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 20:00:00
+ * @Description: 站内通知仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type NotificationRepository struct {
+	dao *dao.NotificationDAO
+}
+
+func NewNotificationRepository(dao *dao.NotificationDAO) *NotificationRepository {
+	return &NotificationRepository{
+		dao: dao,
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n domain.Notification) error {
+	return r.dao.Insert(ctx, dao.Notification{
+		UserId:     n.UserId,
+		Type:       n.Type,
+		Title:      n.Title,
+		Content:    n.Content,
+		TargetType: n.TargetType,
+		TargetId:   n.TargetId,
+	})
+}
+
+func (r *NotificationRepository) GetList(ctx context.Context, userId int64, notificationType string, unreadOnly bool, offset, limit int) ([]domain.Notification, int64, error) {
+	list, total, err := r.dao.FindByPage(ctx, dao.NotificationFilter{
+		UserId:     userId,
+		Type:       notificationType,
+		UnreadOnly: unreadOnly,
+	}, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.Notification, 0, len(list))
+	for _, n := range list {
+		results = append(results, domain.Notification{
+			Id:         n.Id,
+			UserId:     n.UserId,
+			Type:       n.Type,
+			Title:      n.Title,
+			Content:    n.Content,
+			TargetType: n.TargetType,
+			TargetId:   n.TargetId,
+			Read:       n.IsRead,
+			Ctime:      time.UnixMilli(n.Ctime),
+		})
+	}
+	return results, total, nil
+}
+
+func (r *NotificationRepository) CountUnreadByType(ctx context.Context, userId int64) (map[string]int64, error) {
+	return r.dao.CountUnreadByType(ctx, userId)
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userId int64, ids []int64) (int64, error) {
+	return r.dao.MarkRead(ctx, userId, ids)
+}
+
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userId int64) ([]domain.NotificationPreference, error) {
+	prefs, err := r.dao.FindPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.NotificationPreference, 0, len(prefs))
+	for _, p := range prefs {
+		results = append(results, domain.NotificationPreference{
+			Type:    p.Type,
+			Enabled: p.Enabled,
+		})
+	}
+	return results, nil
+}
+
+func (r *NotificationRepository) GetPreference(ctx context.Context, userId int64, notificationType string) (domain.NotificationPreference, error) {
+	p, err := r.dao.FindPreference(ctx, userId, notificationType)
+	if err != nil {
+		return domain.NotificationPreference{}, err
+	}
+	return domain.NotificationPreference{
+		Type:    p.Type,
+		Enabled: p.Enabled,
+	}, nil
+}
+
+func (r *NotificationRepository) SavePreference(ctx context.Context, userId int64, pref domain.NotificationPreference) error {
+	return r.dao.UpsertPreference(ctx, dao.NotificationPreference{
+		UserId:  userId,
+		Type:    pref.Type,
+		Enabled: pref.Enabled,
+	})
+}
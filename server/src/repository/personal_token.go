@@ -0,0 +1,103 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 17:00:00
+ * @Description: 个人访问令牌仓库
+ */
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type PersonalTokenRepository struct {
+	dao *dao.PersonalTokenDAO
+}
+
+func NewPersonalTokenRepository(dao *dao.PersonalTokenDAO) *PersonalTokenRepository {
+	return &PersonalTokenRepository{
+		dao: dao,
+	}
+}
+
+func (r *PersonalTokenRepository) Create(ctx context.Context, hash string, token domain.PersonalAccessToken) (domain.PersonalAccessToken, error) {
+	var expireAt int64
+	if token.ExpireAt != nil {
+		expireAt = token.ExpireAt.UnixMilli()
+	}
+	created, err := r.dao.Insert(ctx, dao.PersonalToken{
+		UserId:    token.UserId,
+		Name:      token.Name,
+		TokenHash: hash,
+		Prefix:    token.Prefix,
+		Scopes:    strings.Join(token.Scopes, ","),
+		ExpireAt:  expireAt,
+	})
+	if err != nil {
+		return domain.PersonalAccessToken{}, err
+	}
+	return r.toDomain(created), nil
+}
+
+func (r *PersonalTokenRepository) FindByHash(ctx context.Context, hash string) (domain.PersonalAccessToken, error) {
+	token, err := r.dao.FindByHash(ctx, hash)
+	if err != nil {
+		return domain.PersonalAccessToken{}, err
+	}
+	return r.toDomain(token), nil
+}
+
+func (r *PersonalTokenRepository) FindByUser(ctx context.Context, userId int64) ([]domain.PersonalAccessToken, error) {
+	tokens, err := r.dao.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.PersonalAccessToken, 0, len(tokens))
+	for _, token := range tokens {
+		results = append(results, r.toDomain(token))
+	}
+	return results, nil
+}
+
+func (r *PersonalTokenRepository) CountByUser(ctx context.Context, userId int64) (int64, error) {
+	return r.dao.CountByUser(ctx, userId)
+}
+
+func (r *PersonalTokenRepository) Touch(ctx context.Context, id int64) error {
+	return r.dao.UpdateLastUsed(ctx, id)
+}
+
+func (r *PersonalTokenRepository) Delete(ctx context.Context, userId, id int64) error {
+	return r.dao.Delete(ctx, userId, id)
+}
+
+func (r *PersonalTokenRepository) DeleteByUser(ctx context.Context, userId int64) error {
+	return r.dao.DeleteByUser(ctx, userId)
+}
+
+func (r *PersonalTokenRepository) toDomain(token dao.PersonalToken) domain.PersonalAccessToken {
+	result := domain.PersonalAccessToken{
+		Id:     token.Id,
+		UserId: token.UserId,
+		Name:   token.Name,
+		Scopes: []string{},
+		Prefix: token.Prefix,
+		Ctime:  time.UnixMilli(token.Ctime),
+	}
+	if token.Scopes != "" {
+		result.Scopes = strings.Split(token.Scopes, ",")
+	}
+	if token.ExpireAt != 0 {
+		expireAt := time.UnixMilli(token.ExpireAt)
+		result.ExpireAt = &expireAt
+	}
+	if token.LastUsedAt != 0 {
+		lastUsedAt := time.UnixMilli(token.LastUsedAt)
+		result.LastUsedAt = &lastUsedAt
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: 刷新令牌仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type RefreshTokenRepository struct {
+	dao *dao.RefreshTokenDAO
+}
+
+func NewRefreshTokenRepository(dao *dao.RefreshTokenDAO) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		dao: dao,
+	}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, hash string, userId int64, familyId string, parentId int64, expireAt time.Time) error {
+	return r.dao.Insert(ctx, dao.RefreshToken{
+		TokenHash: hash,
+		UserId:    userId,
+		FamilyId:  familyId,
+		ParentId:  parentId,
+		ExpireAt:  expireAt.UnixMilli(),
+	})
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	token, err := r.dao.FindByHash(ctx, hash)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	return domain.RefreshToken{
+		Id:       token.Id,
+		UserId:   token.UserId,
+		FamilyId: token.FamilyId,
+		Used:     token.UsedAt != 0,
+		Revoked:  token.RevokedAt != 0,
+		ExpireAt: time.UnixMilli(token.ExpireAt),
+	}, nil
+}
+
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id int64) error {
+	return r.dao.MarkUsed(ctx, id)
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyId string) error {
+	return r.dao.RevokeFamily(ctx, familyId)
+}
+
+func (r *RefreshTokenRepository) RevokeByUser(ctx context.Context, userId int64) error {
+	return r.dao.RevokeByUser(ctx, userId)
+}
@@ -0,0 +1,149 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 10:00:00
+ * @Description: 内容举报仓库
+ */
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var ErrReportTargetType = errors.New("不支持的举报类型")
+
+type ReportRepository struct {
+	rdao *dao.ReportDAO
+	tdao *dao.TreeHoleDAO
+	sdao *dao.StatusDAO
+	pdao *dao.PostsDAO
+	// 隐藏内容时删除内容的缓存，可以为 nil
+	cache *cache.Cache
+}
+
+func NewReportRepository(rdao *dao.ReportDAO, tdao *dao.TreeHoleDAO, sdao *dao.StatusDAO, pdao *dao.PostsDAO, c *cache.Cache) *ReportRepository {
+	return &ReportRepository{
+		rdao:  rdao,
+		tdao:  tdao,
+		sdao:  sdao,
+		pdao:  pdao,
+		cache: c,
+	}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report domain.Report) error {
+	return r.rdao.Insert(ctx, dao.Report{
+		ReporterId: report.ReporterId,
+		TargetType: report.TargetType,
+		TargetId:   report.TargetId,
+		Reason:     report.Reason,
+		Detail:     report.Detail,
+		Status:     domain.ReportStatusPending,
+	})
+}
+
+func (r *ReportRepository) GetById(ctx context.Context, id int64) (domain.Report, error) {
+	report, err := r.rdao.FindById(ctx, id)
+	if err != nil {
+		return domain.Report{}, err
+	}
+	return r.toDomain(report), nil
+}
+
+func (r *ReportRepository) GetList(ctx context.Context, status string, offset, limit int) ([]domain.Report, int64, error) {
+	reports, total, err := r.rdao.FindByPage(ctx, status, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.Report, 0, len(reports))
+	for _, report := range reports {
+		results = append(results, r.toDomain(report))
+	}
+	return results, total, nil
+}
+
+func (r *ReportRepository) CountPendingByTarget(ctx context.Context, targetType string, targetId int64) (int64, error) {
+	return r.rdao.CountByTarget(ctx, targetType, targetId, domain.ReportStatusPending)
+}
+
+func (r *ReportRepository) ResolveByTarget(ctx context.Context, targetType string, targetId int64, status string, handlerId int64, note string) error {
+	return r.rdao.UpdateStatusByTarget(ctx, targetType, targetId, domain.ReportStatusPending, status, handlerId, note)
+}
+
+// TargetExists 判断被举报内容是否存在（包括已隐藏的内容），只有作者可见的文章视为不存在
+func (r *ReportRepository) TargetExists(ctx context.Context, targetType string, targetId int64) (bool, error) {
+	var err error
+	switch targetType {
+	case domain.ReportTargetTreeHole:
+		_, err = r.tdao.FindByIdUnscoped(ctx, targetId)
+	case domain.ReportTargetStatus:
+		_, err = r.sdao.FindByIdUnscoped(ctx, targetId)
+	case domain.ReportTargetPost:
+		var p dao.Posts
+		p, err = r.pdao.FindByIdUnscoped(ctx, targetId)
+		if err == nil && p.State != dao.PostStatePublished && p.State != dao.PostStateUnlisted {
+			return false, nil
+		}
+	default:
+		return false, ErrReportTargetType
+	}
+	if errors.Is(err, dao.ErrReportNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// TargetOwner 查询被举报内容的作者
+func (r *ReportRepository) TargetOwner(ctx context.Context, targetType string, targetId int64) (int64, error) {
+	switch targetType {
+	case domain.ReportTargetTreeHole:
+		t, err := r.tdao.FindByIdUnscoped(ctx, targetId)
+		return t.UserId, err
+	case domain.ReportTargetStatus:
+		s, err := r.sdao.FindByIdUnscoped(ctx, targetId)
+		return s.UserId, err
+	case domain.ReportTargetPost:
+		p, err := r.pdao.FindByIdUnscoped(ctx, targetId)
+		return p.UserId, err
+	default:
+		return 0, ErrReportTargetType
+	}
+}
+
+// SetTargetHidden 隐藏或恢复被举报的内容
+func (r *ReportRepository) SetTargetHidden(ctx context.Context, targetType string, targetId int64, hidden bool) error {
+	switch targetType {
+	case domain.ReportTargetTreeHole:
+		defer InvalidateTreeHoleCache(ctx, r.cache, targetId)
+		return r.tdao.UpdateHidden(ctx, targetId, hidden)
+	case domain.ReportTargetStatus:
+		defer r.cache.Delete(ctx, statusCacheKey(targetId))
+		return r.sdao.UpdateHidden(ctx, targetId, hidden)
+	case domain.ReportTargetPost:
+		defer r.cache.Delete(ctx, postsCacheKey(targetId))
+		return r.pdao.UpdateHidden(ctx, targetId, hidden)
+	default:
+		return ErrReportTargetType
+	}
+}
+
+func (r *ReportRepository) toDomain(report dao.Report) domain.Report {
+	return domain.Report{
+		Id:         report.Id,
+		TargetType: report.TargetType,
+		TargetId:   report.TargetId,
+		ReporterId: report.ReporterId,
+		Reason:     report.Reason,
+		Detail:     report.Detail,
+		Status:     report.Status,
+		HandlerId:  report.HandlerId,
+		HandleNote: report.HandleNote,
+		Ctime:      time.UnixMilli(report.Ctime),
+		Utime:      time.UnixMilli(report.Utime),
+	}
+}
@@ -0,0 +1,16 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 21:00:00
+ * @Description: 对内存实现和 SQL 实现运行仓储契约用例
+ */
+package repository_test
+
+import (
+	"testing"
+
+	"negaihoshi/server/src/repository/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.All(t)
+}
@@ -0,0 +1,88 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: 仓储契约用例，内存实现和 SQL 实现运行同一套用例保证行为一致
+ */
+
+// Package repotest 提供仓储接口的契约用例。在测试中调用 All 即可对内存实现和 SQLite 上的 SQL 实现运行全部用例：
+//
+//	func TestRepositories(t *testing.T) {
+//		repotest.All(t)
+//	}
+//
+// 新增实现时调用对应的 XRepositoryContract 并传入构造函数
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/migration"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/storage"
+
+	"gorm.io/gorm"
+)
+
+// All 对内存和 SQLite 两种后端以及带缓存的包装运行全部契约用例
+func All(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		UserRepositoryContract(t, func(t *testing.T) repository.UserRepository {
+			return repository.NewMemoryUserRepository()
+		})
+		TreeHoleRepositoryContract(t, func(t *testing.T) repository.TreeHoleRepository {
+			return repository.NewMemoryTreeHoleRepository()
+		})
+		StatusAndPostsRepositoryContract(t, func(t *testing.T) repository.StatusAndPostsRepository {
+			return repository.NewMemoryStatusAndPostsRepository()
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		UserRepositoryContract(t, func(t *testing.T) repository.UserRepository {
+			return repository.NewUserRepository(dao.NewUserDAO(OpenSQLite(t)))
+		})
+		TreeHoleRepositoryContract(t, func(t *testing.T) repository.TreeHoleRepository {
+			return repository.NewTreeHoleRepository(dao.NewTreeHoleDAO(OpenSQLite(t)))
+		})
+		StatusAndPostsRepositoryContract(t, func(t *testing.T) repository.StatusAndPostsRepository {
+			db := OpenSQLite(t)
+			return repository.NewStatusAndPostsRepository(dao.NewStatusDAO(db), dao.NewPostsDAO(db), dao.NewRevisionDAO(db))
+		})
+	})
+	t.Run("cached", func(t *testing.T) {
+		TreeHoleRepositoryContract(t, func(t *testing.T) repository.TreeHoleRepository {
+			c := cache.New(cache.NewMemoryStore(0))
+			return repository.NewCachedTreeHoleRepository(repository.NewMemoryTreeHoleRepository(), c, time.Minute, time.Minute)
+		})
+		StatusAndPostsRepositoryContract(t, func(t *testing.T) repository.StatusAndPostsRepository {
+			c := cache.New(cache.NewMemoryStore(0))
+			return repository.NewCachedStatusAndPostsRepository(repository.NewMemoryStatusAndPostsRepository(), c, time.Minute)
+		})
+	})
+}
+
+// OpenSQLite 打开一个执行过全部迁移的 SQLite 内存数据库，每次调用相互独立，测试结束后自动关闭
+func OpenSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, driver, err := storage.Open(storage.SQLite, "", "", "", "", ":memory:")
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migration.NewMigrator(sqlDB, driver)
+	if err != nil {
+		t.Fatalf("加载迁移文件失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
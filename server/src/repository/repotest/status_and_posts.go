@@ -0,0 +1,549 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: StatusAndPostsRepository 契约用例
+ */
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+// StatusAndPostsRepositoryContract newRepo 每次调用需返回一个空仓储
+func StatusAndPostsRepositoryContract(t *testing.T, newRepo func(t *testing.T) repository.StatusAndPostsRepository) {
+	ctx := context.Background()
+	createStatus := func(t *testing.T, repo repository.StatusAndPostsRepository, userId int64, content string) domain.Status {
+		t.Helper()
+		created, err := repo.CreateStatus(ctx, domain.Status{UserId: userId, Content: content})
+		if err != nil {
+			t.Fatalf("创建动态失败: %v", err)
+		}
+		return created
+	}
+	createPosts := func(t *testing.T, repo repository.StatusAndPostsRepository, userId int64, title string) domain.Posts {
+		t.Helper()
+		created, err := repo.CreatePosts(ctx, domain.Posts{UserId: userId, Title: title, Content: title + "的正文"})
+		if err != nil {
+			t.Fatalf("创建文章失败: %v", err)
+		}
+		return created
+	}
+
+	t.Run("StatusCreateEditDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		created := createStatus(t, repo, 1, "今天天气不错")
+		if created.Id == 0 || created.Ctime.IsZero() {
+			t.Fatalf("创建后未返回 Id 和时间: %+v", created)
+		}
+		got, err := repo.GetStatus(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetStatus 失败: %v", err)
+		}
+		if got != created {
+			t.Fatalf("GetStatus 返回的动态不一致: %+v, 创建时: %+v", got, created)
+		}
+
+		if err := repo.EditStatus(ctx, domain.Status{Id: created.Id, UserId: 1, Content: "改过了"}, 1); err != nil {
+			t.Fatalf("EditStatus 失败: %v", err)
+		}
+		got, err = repo.GetStatus(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetStatus 失败: %v", err)
+		}
+		if got.Content != "改过了" || !got.Ctime.Equal(created.Ctime) {
+			t.Fatalf("编辑后内容应更新且创建时间不变: %+v", got)
+		}
+
+		if err := repo.DeleteStatus(ctx, created.Id, 1); err != nil {
+			t.Fatalf("DeleteStatus 失败: %v", err)
+		}
+		if _, err := repo.GetStatus(ctx, created.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("已删除的动态应返回 ErrStatusNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindStatusOwner(ctx, created.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("已删除的动态 FindStatusOwner 应返回 ErrStatusNotFound，实际: %v", err)
+		}
+	})
+
+	t.Run("PostsCreateEditDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		created := createPosts(t, repo, 1, "标题")
+		if created.Id == 0 || created.Ctime.IsZero() {
+			t.Fatalf("创建后未返回 Id 和时间: %+v", created)
+		}
+		got, err := repo.GetPosts(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetPosts 失败: %v", err)
+		}
+		if got != created {
+			t.Fatalf("GetPosts 返回的文章不一致: %+v, 创建时: %+v", got, created)
+		}
+
+		if err := repo.EditPosts(ctx, domain.Posts{Id: created.Id, UserId: 1, Title: "新标题", Content: "新正文"}, 1); err != nil {
+			t.Fatalf("EditPosts 失败: %v", err)
+		}
+		got, err = repo.GetPosts(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetPosts 失败: %v", err)
+		}
+		if got.Title != "新标题" || got.Content != "新正文" || !got.Ctime.Equal(created.Ctime) {
+			t.Fatalf("编辑后内容应更新且创建时间不变: %+v", got)
+		}
+
+		if err := repo.DeletePosts(ctx, created.Id, 1); err != nil {
+			t.Fatalf("DeletePosts 失败: %v", err)
+		}
+		if _, err := repo.GetPosts(ctx, created.Id); !errors.Is(err, dao.ErrPostsNotFound) {
+			t.Fatalf("已删除的文章应返回 ErrPostsNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindPostsOwner(ctx, created.Id); !errors.Is(err, dao.ErrPostsNotFound) {
+			t.Fatalf("已删除的文章 FindPostsOwner 应返回 ErrPostsNotFound，实际: %v", err)
+		}
+	})
+
+	t.Run("StatusAndPostsList", func(t *testing.T) {
+		repo := newRepo(t)
+		var status []domain.Status
+		var posts []domain.Posts
+		for i := 0; i < 4; i++ {
+			status = append(status, createStatus(t, repo, int64(i%2+1), fmt.Sprintf("动态%d", i)))
+			posts = append(posts, createPosts(t, repo, int64(i%2+1), fmt.Sprintf("文章%d", i)))
+		}
+
+		byUser, err := repo.FindStatusByUser(ctx, 2)
+		if err != nil {
+			t.Fatalf("FindStatusByUser 失败: %v", err)
+		}
+		assertStatus(t, "FindStatusByUser", byUser, status[1], status[3])
+		allStatus, err := repo.GetAllStatus(ctx)
+		if err != nil {
+			t.Fatalf("GetAllStatus 失败: %v", err)
+		}
+		assertStatus(t, "GetAllStatus", allStatus, status...)
+
+		postsByUser, err := repo.FindPostsByUser(ctx, 1)
+		if err != nil {
+			t.Fatalf("FindPostsByUser 失败: %v", err)
+		}
+		assertPosts(t, "FindPostsByUser", postsByUser, posts[0], posts[2])
+		allPosts, err := repo.GetAllPosts(ctx)
+		if err != nil {
+			t.Fatalf("GetAllPosts 失败: %v", err)
+		}
+		assertPosts(t, "GetAllPosts", allPosts, posts...)
+
+		if empty, err := repo.FindStatusByUser(ctx, 404); err != nil || len(empty) != 0 {
+			t.Fatalf("没有动态的用户应返回空列表: %+v, %v", empty, err)
+		}
+	})
+
+	t.Run("StatusAndPostsHidden", func(t *testing.T) {
+		repo := newRepo(t)
+		hiddenStatus := createStatus(t, repo, 1, "会被隐藏")
+		visibleStatus := createStatus(t, repo, 1, "正常")
+		hiddenPosts := createPosts(t, repo, 1, "会被隐藏")
+		if err := repo.SetStatusHidden(ctx, hiddenStatus.Id, true); err != nil {
+			t.Fatalf("SetStatusHidden 失败: %v", err)
+		}
+		if err := repo.SetPostsHidden(ctx, hiddenPosts.Id, true); err != nil {
+			t.Fatalf("SetPostsHidden 失败: %v", err)
+		}
+
+		if _, err := repo.GetStatus(ctx, hiddenStatus.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("已隐藏的动态应返回 ErrStatusNotFound，实际: %v", err)
+		}
+		if _, err := repo.GetPosts(ctx, hiddenPosts.Id); !errors.Is(err, dao.ErrPostsNotFound) {
+			t.Fatalf("已隐藏的文章应返回 ErrPostsNotFound，实际: %v", err)
+		}
+		byUser, err := repo.FindStatusByUser(ctx, 1)
+		if err != nil {
+			t.Fatalf("FindStatusByUser 失败: %v", err)
+		}
+		assertStatus(t, "隐藏后 FindStatusByUser", byUser, visibleStatus)
+		if allPosts, err := repo.GetAllPosts(ctx); err != nil || len(allPosts) != 0 {
+			t.Fatalf("隐藏后 GetAllPosts 应为空: %+v, %v", allPosts, err)
+		}
+		if owner, err := repo.FindStatusOwner(ctx, hiddenStatus.Id); err != nil || owner != 1 {
+			t.Fatalf("FindStatusOwner 应包括已隐藏的动态，实际: %d, %v", owner, err)
+		}
+		if owner, err := repo.FindPostsOwner(ctx, hiddenPosts.Id); err != nil || owner != 1 {
+			t.Fatalf("FindPostsOwner 应包括已隐藏的文章，实际: %d, %v", owner, err)
+		}
+
+		// 编辑不影响隐藏状态
+		if err := repo.EditStatus(ctx, domain.Status{Id: hiddenStatus.Id, UserId: 1, Content: "编辑"}, 1); err != nil {
+			t.Fatalf("EditStatus 失败: %v", err)
+		}
+		if _, err := repo.GetStatus(ctx, hiddenStatus.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("编辑后动态应仍然隐藏，实际: %v", err)
+		}
+		if err := repo.SetStatusHidden(ctx, hiddenStatus.Id, false); err != nil {
+			t.Fatalf("SetStatusHidden 失败: %v", err)
+		}
+		if got, err := repo.GetStatus(ctx, hiddenStatus.Id); err != nil || got.Content != "编辑" {
+			t.Fatalf("取消隐藏后应能查询到编辑后的动态: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("StatusAndPostsTrash", func(t *testing.T) {
+		repo := newRepo(t)
+		first := createStatus(t, repo, 1, "第一条")
+		second := createStatus(t, repo, 1, "第二条")
+		kept := createStatus(t, repo, 1, "未删除")
+		posts := createPosts(t, repo, 1, "文章")
+		if err := repo.DeleteStatus(ctx, first.Id, 1); err != nil {
+			t.Fatalf("DeleteStatus 失败: %v", err)
+		}
+		// 删除时间精确到毫秒，间隔开保证顺序确定
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.DeleteStatus(ctx, second.Id, 99); err != nil {
+			t.Fatalf("DeleteStatus 失败: %v", err)
+		}
+		if err := repo.DeletePosts(ctx, posts.Id, 1); err != nil {
+			t.Fatalf("DeletePosts 失败: %v", err)
+		}
+		if err := repo.DeleteStatus(ctx, first.Id, 1); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("重复删除应返回 ErrStatusNotFound，实际: %v", err)
+		}
+
+		// 已删除的内容不出现在任何读取中，也不能编辑
+		allStatus, err := repo.GetAllStatus(ctx)
+		if err != nil {
+			t.Fatalf("GetAllStatus 失败: %v", err)
+		}
+		assertStatus(t, "删除后 GetAllStatus", allStatus, kept)
+		if allPosts, err := repo.FindPostsByUser(ctx, 1); err != nil || len(allPosts) != 0 {
+			t.Fatalf("删除后 FindPostsByUser 应为空: %+v, %v", allPosts, err)
+		}
+		if entries, err := repo.TimelineEntries(ctx, []int64{1}, domain.TimelineEntry{}, 10); err != nil || len(entries) != 1 || entries[0].Id != kept.Id {
+			t.Fatalf("删除后时间线应只剩未删除的动态: %+v, %v", entries, err)
+		}
+		if err := repo.EditStatus(ctx, domain.Status{Id: first.Id, UserId: 1, Content: "删除后编辑"}, 1); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("编辑已删除的动态应返回 ErrStatusNotFound，实际: %v", err)
+		}
+
+		trash, err := repo.FindDeletedStatus(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("FindDeletedStatus 失败: %v", err)
+		}
+		if len(trash) != 2 || trash[0].Id != second.Id || trash[1].Id != first.Id {
+			t.Fatalf("FindDeletedStatus 应按删除时间倒序返回 [%d %d]，实际: %+v", second.Id, first.Id, trash)
+		}
+		if got := trash[0]; got.Type != domain.TrashStatus || got.UserId != 1 || got.DeletedBy != 99 || got.DeletedByAuthor() || got.DeletedAt.IsZero() {
+			t.Fatalf("FindDeletedStatus 返回的内容不一致: %+v", got)
+		}
+		if got, err := repo.FindDeletedPostsById(ctx, posts.Id); err != nil || got.Type != domain.TrashPost || got.Title != "文章" || !got.DeletedByAuthor() {
+			t.Fatalf("FindDeletedPostsById 返回的内容不一致: %+v, %v", got, err)
+		}
+		if _, err := repo.FindDeletedStatusById(ctx, kept.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("未删除的动态 FindDeletedStatusById 应返回 ErrStatusNotFound，实际: %v", err)
+		}
+
+		if err := repo.RestoreStatus(ctx, first.Id); err != nil {
+			t.Fatalf("RestoreStatus 失败: %v", err)
+		}
+		if got, err := repo.GetStatus(ctx, first.Id); err != nil || got.Content != "第一条" {
+			t.Fatalf("恢复后应能查询到删除前的内容: %+v, %v", got, err)
+		}
+		if err := repo.RestoreStatus(ctx, first.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("恢复未删除的动态应返回 ErrStatusNotFound，实际: %v", err)
+		}
+		if err := repo.RestorePosts(ctx, posts.Id); err != nil {
+			t.Fatalf("RestorePosts 失败: %v", err)
+		}
+		if owner, err := repo.FindPostsOwner(ctx, posts.Id); err != nil || owner != 1 {
+			t.Fatalf("恢复后 FindPostsOwner 应返回作者: %d, %v", owner, err)
+		}
+
+		if n, err := repo.PurgeDeletedStatus(ctx, time.Now().Add(-time.Hour), 10); err != nil || n != 0 {
+			t.Fatalf("没有超过保留期的动态时不应清理: %d, %v", n, err)
+		}
+		if n, err := repo.PurgeDeletedStatus(ctx, time.Now().Add(time.Second), 10); err != nil || n != 1 {
+			t.Fatalf("PurgeDeletedStatus 应清理 1 条: %d, %v", n, err)
+		}
+		if _, err := repo.FindDeletedStatusById(ctx, second.Id); !errors.Is(err, dao.ErrStatusNotFound) {
+			t.Fatalf("清理后的动态不应再出现在回收站，实际: %v", err)
+		}
+		if n, err := repo.PurgeDeletedPosts(ctx, time.Now().Add(time.Second), 10); err != nil || n != 0 {
+			t.Fatalf("已恢复的文章不应被清理: %d, %v", n, err)
+		}
+	})
+
+	t.Run("Revisions", func(t *testing.T) {
+		repo := newRepo(t)
+		status := createStatus(t, repo, 1, "第一版")
+		posts := createPosts(t, repo, 1, "标题")
+		if revisions, err := repo.FindRevisions(ctx, domain.RevisionStatus, status.Id); err != nil || len(revisions) != 0 {
+			t.Fatalf("未编辑过的动态应没有编辑历史: %+v, %v", revisions, err)
+		}
+		if got, err := repo.GetStatus(ctx, status.Id); err != nil || got.Edited || !got.EditedAt.IsZero() {
+			t.Fatalf("未编辑过的动态不应标记为已编辑: %+v, %v", got, err)
+		}
+
+		// 首次编辑时补记原始版本
+		if err := repo.EditStatus(ctx, domain.Status{Id: status.Id, Content: "第二版"}, 1); err != nil {
+			t.Fatalf("EditStatus 失败: %v", err)
+		}
+		if err := repo.EditStatus(ctx, domain.Status{Id: status.Id, Content: "第三版"}, 7); err != nil {
+			t.Fatalf("EditStatus 失败: %v", err)
+		}
+		revisions, err := repo.FindRevisions(ctx, domain.RevisionStatus, status.Id)
+		if err != nil {
+			t.Fatalf("FindRevisions 失败: %v", err)
+		}
+		if len(revisions) != 3 {
+			t.Fatalf("编辑两次后应有 3 个版本，实际: %+v", revisions)
+		}
+		for i, want := range []struct {
+			content  string
+			editorId int64
+		}{{"第一版", 1}, {"第二版", 1}, {"第三版", 7}} {
+			r := revisions[i]
+			if r.Version != i+1 || r.Content != want.content || r.EditorId != want.editorId ||
+				r.Type != domain.RevisionStatus || r.TargetId != status.Id {
+				t.Fatalf("第 %d 个版本不一致: %+v", i+1, r)
+			}
+		}
+		if !revisions[0].Ctime.Equal(status.Ctime) {
+			t.Fatalf("原始版本的时间应为创建时间: %v, 创建时间: %v", revisions[0].Ctime, status.Ctime)
+		}
+		got, err := repo.GetStatus(ctx, status.Id)
+		if err != nil || got.Content != "第三版" || got.UserId != 1 || !got.Edited || !got.EditedAt.Equal(revisions[2].Ctime) {
+			t.Fatalf("编辑后应标记为已编辑且作者不变: %+v, %v", got, err)
+		}
+		items, err := repo.FindTimelineItems(ctx, []domain.TimelineEntry{{Kind: domain.TimelineStatus, Id: status.Id}})
+		if item := items[domain.TimelineEntry{Kind: domain.TimelineStatus, Id: status.Id}]; err != nil || !item.Edited || !item.EditedAt.Equal(got.EditedAt) {
+			t.Fatalf("时间线中的动态应标记为已编辑: %+v, %v", item, err)
+		}
+
+		if r, err := repo.FindRevision(ctx, domain.RevisionStatus, status.Id, revisions[1].Id); err != nil || r != revisions[1] {
+			t.Fatalf("FindRevision 返回的版本不一致: %+v, %v", r, err)
+		}
+		// 版本只能在所属内容下查询
+		if _, err := repo.FindRevision(ctx, domain.RevisionPost, status.Id, revisions[1].Id); !errors.Is(err, dao.ErrRevisionNotFound) {
+			t.Fatalf("类型不匹配时应返回 ErrRevisionNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindRevision(ctx, domain.RevisionStatus, status.Id+100, revisions[1].Id); !errors.Is(err, dao.ErrRevisionNotFound) {
+			t.Fatalf("内容不匹配时应返回 ErrRevisionNotFound，实际: %v", err)
+		}
+
+		if err := repo.EditPosts(ctx, domain.Posts{Id: posts.Id, Title: "新标题", Content: "新正文"}, 1); err != nil {
+			t.Fatalf("EditPosts 失败: %v", err)
+		}
+		postsRevisions, err := repo.FindRevisions(ctx, domain.RevisionPost, posts.Id)
+		if err != nil || len(postsRevisions) != 2 || postsRevisions[0].Title != "标题" || postsRevisions[1].Title != "新标题" {
+			t.Fatalf("文章的编辑历史应包含标题: %+v, %v", postsRevisions, err)
+		}
+		if err := repo.EditPosts(ctx, domain.Posts{Id: posts.Id + 100, Title: "不存在"}, 1); !errors.Is(err, dao.ErrPostsNotFound) {
+			t.Fatalf("编辑不存在的文章应返回 ErrPostsNotFound，实际: %v", err)
+		}
+
+		// 永久删除时编辑历史一并清理
+		if err := repo.DeleteStatus(ctx, status.Id, 1); err != nil {
+			t.Fatalf("DeleteStatus 失败: %v", err)
+		}
+		if _, err := repo.PurgeDeletedStatus(ctx, time.Now().Add(time.Second), 10); err != nil {
+			t.Fatalf("PurgeDeletedStatus 失败: %v", err)
+		}
+		if revisions, err := repo.FindRevisions(ctx, domain.RevisionStatus, status.Id); err != nil || len(revisions) != 0 {
+			t.Fatalf("永久删除后不应保留编辑历史: %+v, %v", revisions, err)
+		}
+		if revisions, err := repo.FindRevisions(ctx, domain.RevisionPost, posts.Id); err != nil || len(revisions) != 2 {
+			t.Fatalf("其他内容的编辑历史不应受影响: %+v, %v", revisions, err)
+		}
+	})
+
+	t.Run("Timeline", func(t *testing.T) {
+		repo := newRepo(t)
+		for i := 0; i < 5; i++ {
+			for uid := int64(1); uid <= 3; uid++ {
+				createStatus(t, repo, uid, fmt.Sprintf("动态%d", i))
+				createPosts(t, repo, uid, fmt.Sprintf("文章%d", i))
+			}
+		}
+		hidden := createStatus(t, repo, 1, "会被隐藏")
+		if err := repo.SetStatusHidden(ctx, hidden.Id, true); err != nil {
+			t.Fatalf("SetStatusHidden 失败: %v", err)
+		}
+		uids := []int64{1, 2}
+
+		all, err := repo.TimelineEntries(ctx, uids, domain.TimelineEntry{}, 100)
+		if err != nil {
+			t.Fatalf("TimelineEntries 失败: %v", err)
+		}
+		if len(all) != 20 {
+			t.Fatalf("应返回 20 条，实际: %d", len(all))
+		}
+		for i := 1; i < len(all); i++ {
+			if !all[i-1].Before(all[i]) {
+				t.Fatalf("时间线未按时间倒序排列: %+v 在 %+v 之前", all[i-1], all[i])
+			}
+		}
+
+		// 按游标分页的结果应与一次读取全部相同
+		var paged []domain.TimelineEntry
+		after := domain.TimelineEntry{}
+		for {
+			page, err := repo.TimelineEntries(ctx, uids, after, 3)
+			if err != nil {
+				t.Fatalf("TimelineEntries 失败: %v", err)
+			}
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			after = page[len(page)-1]
+		}
+		if fmt.Sprint(paged) != fmt.Sprint(all) {
+			t.Fatalf("分页结果与完整结果不一致:\n%v\n%v", paged, all)
+		}
+
+		items, err := repo.FindTimelineItems(ctx, append(all[:2:2], domain.TimelineEntry{Kind: domain.TimelineStatus, Id: hidden.Id}))
+		if err != nil {
+			t.Fatalf("FindTimelineItems 失败: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("FindTimelineItems 应只返回未隐藏的 2 条，实际: %d", len(items))
+		}
+		for _, e := range all[:2] {
+			item, ok := items[domain.TimelineEntry{Kind: e.Kind, Id: e.Id}]
+			if !ok || item.Type != e.Kind || item.Id != e.Id || item.Ctime.UnixMilli() != e.Ctime || item.Content == "" {
+				t.Fatalf("FindTimelineItems 返回的内容不一致: %+v, 条目: %+v", item, e)
+			}
+		}
+
+		if empty, err := repo.TimelineEntries(ctx, nil, domain.TimelineEntry{}, 10); err != nil || len(empty) != 0 {
+			t.Fatalf("没有用户时应返回空列表: %+v, %v", empty, err)
+		}
+	})
+
+	t.Run("PostStates", func(t *testing.T) {
+		repo := newRepo(t)
+		published := createPosts(t, repo, 1, "已发布")
+		if published.State != domain.PostStatePublished || !published.PublishAt.Equal(published.Ctime) {
+			t.Fatalf("未指定状态时应直接发布，发布时间为创建时间: %+v", published)
+		}
+		draft, err := repo.CreatePosts(ctx, domain.Posts{UserId: 1, Title: "草稿", Content: "草稿正文", State: domain.PostStateDraft, CrossPost: true})
+		if err != nil {
+			t.Fatalf("创建草稿失败: %v", err)
+		}
+		if draft.State != domain.PostStateDraft || !draft.PublishAt.IsZero() || !draft.CrossPost {
+			t.Fatalf("草稿应没有发布时间并保留转发标记: %+v", draft)
+		}
+		future := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+		scheduled, err := repo.CreatePosts(ctx, domain.Posts{UserId: 1, Title: "定时", Content: "定时正文", State: domain.PostStateScheduled, PublishAt: future, CrossPost: true})
+		if err != nil {
+			t.Fatalf("创建定时文章失败: %v", err)
+		}
+		if !scheduled.PublishAt.Equal(future) {
+			t.Fatalf("定时文章的发布时间应为计划时间: %+v", scheduled)
+		}
+
+		// 任意状态都能按 id 和作者查到，列表和时间线只包含已发布的文章
+		if got, err := repo.GetPosts(ctx, draft.Id); err != nil || got != draft {
+			t.Fatalf("GetPosts 应返回草稿: %+v, %v", got, err)
+		}
+		byUser, err := repo.FindPostsByUser(ctx, 1)
+		if err != nil {
+			t.Fatalf("FindPostsByUser 失败: %v", err)
+		}
+		assertPosts(t, "FindPostsByUser", byUser, published, draft, scheduled)
+		all, err := repo.GetAllPosts(ctx)
+		if err != nil {
+			t.Fatalf("GetAllPosts 失败: %v", err)
+		}
+		assertPosts(t, "GetAllPosts", all, published)
+		entries, err := repo.TimelineEntries(ctx, []int64{1}, domain.TimelineEntry{}, 10)
+		if err != nil {
+			t.Fatalf("TimelineEntries 失败: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Id != published.Id {
+			t.Fatalf("时间线应只包含已发布的文章: %+v", entries)
+		}
+		items, err := repo.FindTimelineItems(ctx, []domain.TimelineEntry{{Kind: domain.TimelinePost, Id: draft.Id}})
+		if err != nil || len(items) != 0 {
+			t.Fatalf("FindTimelineItems 不应返回草稿: %+v, %v", items, err)
+		}
+
+		// 未到发布时间的定时文章不会被发布
+		if due, err := repo.FindDueScheduledPosts(ctx, time.Now(), 10); err != nil || len(due) != 0 {
+			t.Fatalf("没有到期的定时文章: %+v, %v", due, err)
+		}
+		if ok, err := repo.PublishScheduledPosts(ctx, scheduled.Id, time.Now()); err != nil || ok {
+			t.Fatalf("未到期的定时文章不应被发布: %v, %v", ok, err)
+		}
+		later := future.Add(time.Minute)
+		due, err := repo.FindDueScheduledPosts(ctx, later, 10)
+		if err != nil {
+			t.Fatalf("FindDueScheduledPosts 失败: %v", err)
+		}
+		assertPosts(t, "FindDueScheduledPosts", due, scheduled)
+		if ok, err := repo.PublishScheduledPosts(ctx, scheduled.Id, later); err != nil || !ok {
+			t.Fatalf("到期的定时文章应被发布: %v, %v", ok, err)
+		}
+		if ok, err := repo.PublishScheduledPosts(ctx, scheduled.Id, later); err != nil || ok {
+			t.Fatalf("已发布的文章不应再次发布: %v, %v", ok, err)
+		}
+		got, err := repo.GetPosts(ctx, scheduled.Id)
+		if err != nil {
+			t.Fatalf("GetPosts 失败: %v", err)
+		}
+		if got.State != domain.PostStatePublished || got.CrossPost || !got.PublishAt.Equal(future) {
+			t.Fatalf("发布后应为已发布、清除转发标记并保留计划时间: %+v", got)
+		}
+
+		// 发布时间晚于创建时间的文章在时间线中按发布时间排序
+		entries, err = repo.TimelineEntries(ctx, []int64{1}, domain.TimelineEntry{}, 10)
+		if err != nil {
+			t.Fatalf("TimelineEntries 失败: %v", err)
+		}
+		if len(entries) != 2 || entries[0].Id != scheduled.Id || entries[0].Ctime != future.UnixMilli() {
+			t.Fatalf("时间线应按发布时间倒序: %+v", entries)
+		}
+
+		if err := repo.ChangePostsState(ctx, published.Id, domain.PostStatePrivate, published.PublishAt, false); err != nil {
+			t.Fatalf("ChangePostsState 失败: %v", err)
+		}
+		if all, err := repo.GetAllPosts(ctx); err != nil || len(all) != 1 || all[0].Id != scheduled.Id {
+			t.Fatalf("私密文章不应出现在 GetAllPosts 中: %+v, %v", all, err)
+		}
+		if err := repo.DeletePosts(ctx, draft.Id, 1); err != nil {
+			t.Fatalf("DeletePosts 失败: %v", err)
+		}
+		if err := repo.ChangePostsState(ctx, draft.Id, domain.PostStatePublished, time.Now(), false); !errors.Is(err, dao.ErrPostsNotFound) {
+			t.Fatalf("已删除的文章 ChangePostsState 应返回 ErrPostsNotFound，实际: %v", err)
+		}
+	})
+}
+
+func assertStatus(t *testing.T, name string, got []domain.Status, want ...domain.Status) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s 应返回 %d 条，实际: %+v", name, len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s 第 %d 条应为 %+v，实际: %+v", name, i, want[i], got[i])
+		}
+	}
+}
+
+func assertPosts(t *testing.T, name string, got []domain.Posts, want ...domain.Posts) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s 应返回 %d 条，实际: %+v", name, len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s 第 %d 条应为 %+v，实际: %+v", name, i, want[i], got[i])
+		}
+	}
+}
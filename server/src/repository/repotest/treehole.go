@@ -0,0 +1,232 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: TreeHoleRepository 契约用例
+ */
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+// TreeHoleRepositoryContract newRepo 每次调用需返回一个空仓储
+func TreeHoleRepositoryContract(t *testing.T, newRepo func(t *testing.T) repository.TreeHoleRepository) {
+	ctx := context.Background()
+	create := func(t *testing.T, repo repository.TreeHoleRepository, userId int64, content string) domain.TreeHole {
+		t.Helper()
+		created, err := repo.Create(ctx, domain.TreeHole{UserId: userId, Content: content})
+		if err != nil {
+			t.Fatalf("创建树洞失败: %v", err)
+		}
+		return created
+	}
+
+	t.Run("TreeHoleCreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		created := create(t, repo, 1, "你好")
+		if created.Id == 0 || created.Ctime.IsZero() {
+			t.Fatalf("创建后未返回 Id 和时间: %+v", created)
+		}
+		got, err := repo.GetById(ctx, created.Id)
+		if err != nil {
+			t.Fatalf("GetById 失败: %v", err)
+		}
+		if got.Id != created.Id || got.UserId != 1 || got.Content != "你好" || !got.Ctime.Equal(created.Ctime) {
+			t.Fatalf("GetById 返回的树洞不一致: %+v, 创建时: %+v", got, created)
+		}
+		if owner, err := repo.FindOwner(ctx, created.Id); err != nil || owner != 1 {
+			t.Fatalf("FindOwner 应返回 1，实际: %d, %v", owner, err)
+		}
+		if _, err := repo.GetById(ctx, created.Id+100); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("GetById 应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+	})
+
+	t.Run("TreeHoleList", func(t *testing.T) {
+		repo := newRepo(t)
+		var all []domain.TreeHole
+		for i := 0; i < 6; i++ {
+			all = append(all, create(t, repo, int64(i%3+1), fmt.Sprintf("树洞%d", i)))
+		}
+
+		page, err := repo.GetList(ctx, 1, 3, nil)
+		if err != nil {
+			t.Fatalf("GetList 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "GetList 分页", page, all[1].Id, all[2].Id, all[3].Id)
+
+		page, err = repo.GetList(ctx, 0, 10, []int64{2, 3})
+		if err != nil {
+			t.Fatalf("GetList 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "GetList 排除用户", page, all[0].Id, all[3].Id)
+
+		page, err = repo.GetListByUser(ctx, 2, 0, 10)
+		if err != nil {
+			t.Fatalf("GetListByUser 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "GetListByUser", page, all[1].Id, all[4].Id)
+
+		page, err = repo.GetListByUser(ctx, 2, 1, 10)
+		if err != nil {
+			t.Fatalf("GetListByUser 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "GetListByUser 分页", page, all[4].Id)
+
+		page, err = repo.GetList(ctx, 10, 10, nil)
+		if err != nil || len(page) != 0 {
+			t.Fatalf("超出范围的页应为空: %+v, %v", page, err)
+		}
+	})
+
+	t.Run("TreeHoleHidden", func(t *testing.T) {
+		repo := newRepo(t)
+		first := create(t, repo, 1, "第一条")
+		second := create(t, repo, 1, "第二条")
+		if err := repo.SetHidden(ctx, first.Id, true); err != nil {
+			t.Fatalf("SetHidden 失败: %v", err)
+		}
+		if _, err := repo.GetById(ctx, first.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("已隐藏的树洞应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+		page, err := repo.GetList(ctx, 0, 10, nil)
+		if err != nil {
+			t.Fatalf("GetList 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "隐藏后 GetList", page, second.Id)
+		page, err = repo.GetListByUser(ctx, 1, 0, 10)
+		if err != nil {
+			t.Fatalf("GetListByUser 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "隐藏后 GetListByUser", page, second.Id)
+		if owner, err := repo.FindOwner(ctx, first.Id); err != nil || owner != 1 {
+			t.Fatalf("FindOwner 应包括已隐藏的树洞，实际: %d, %v", owner, err)
+		}
+
+		if err := repo.SetHidden(ctx, first.Id, false); err != nil {
+			t.Fatalf("SetHidden 失败: %v", err)
+		}
+		if _, err := repo.GetById(ctx, first.Id); err != nil {
+			t.Fatalf("取消隐藏后应能查询到树洞: %v", err)
+		}
+	})
+
+	t.Run("TreeHoleDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		first := create(t, repo, 1, "第一条")
+		second := create(t, repo, 1, "第二条")
+		if err := repo.Delete(ctx, first.Id, 1); err != nil {
+			t.Fatalf("Delete 失败: %v", err)
+		}
+		if _, err := repo.GetById(ctx, first.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("已删除的树洞应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindOwner(ctx, first.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("已删除的树洞 FindOwner 应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+		page, err := repo.GetList(ctx, 0, 10, nil)
+		if err != nil {
+			t.Fatalf("GetList 失败: %v", err)
+		}
+		assertTreeHoleIds(t, "删除后 GetList", page, second.Id)
+		if err := repo.Delete(ctx, first.Id, 1); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("重复删除应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+	})
+
+	t.Run("TreeHoleTrash", func(t *testing.T) {
+		repo := newRepo(t)
+		first := create(t, repo, 1, "第一条")
+		second := create(t, repo, 1, "第二条")
+		other := create(t, repo, 2, "别人的")
+		if err := repo.Delete(ctx, first.Id, 1); err != nil {
+			t.Fatalf("Delete 失败: %v", err)
+		}
+		// 删除时间精确到毫秒，间隔开保证顺序确定
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.Delete(ctx, second.Id, 99); err != nil {
+			t.Fatalf("Delete 失败: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.Delete(ctx, other.Id, 2); err != nil {
+			t.Fatalf("Delete 失败: %v", err)
+		}
+
+		trash, err := repo.FindDeleted(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("FindDeleted 失败: %v", err)
+		}
+		if len(trash) != 2 || trash[0].Id != second.Id || trash[1].Id != first.Id {
+			t.Fatalf("FindDeleted 应按删除时间倒序返回 [%d %d]，实际: %+v", second.Id, first.Id, trash)
+		}
+		if got := trash[0]; got.Type != domain.TrashTreeHole || got.UserId != 1 || got.Content != "第二条" ||
+			got.DeletedBy != 99 || got.DeletedByAuthor() || got.DeletedAt.IsZero() || !got.Ctime.Equal(second.Ctime) {
+			t.Fatalf("FindDeleted 返回的内容不一致: %+v", got)
+		}
+		if limited, err := repo.FindDeleted(ctx, 1, 1); err != nil || len(limited) != 1 || limited[0].Id != second.Id {
+			t.Fatalf("FindDeleted 应遵守 limit: %+v, %v", limited, err)
+		}
+		if got, err := repo.FindDeletedById(ctx, first.Id); err != nil || !got.DeletedByAuthor() {
+			t.Fatalf("FindDeletedById 应返回作者删除的树洞: %+v, %v", got, err)
+		}
+
+		kept := create(t, repo, 1, "未删除")
+		if _, err := repo.FindDeletedById(ctx, kept.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("未删除的树洞 FindDeletedById 应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+		if err := repo.Restore(ctx, kept.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("恢复未删除的树洞应返回 ErrTreeHoleNotFound，实际: %v", err)
+		}
+
+		if err := repo.Restore(ctx, first.Id); err != nil {
+			t.Fatalf("Restore 失败: %v", err)
+		}
+		got, err := repo.GetById(ctx, first.Id)
+		if err != nil || got.Content != "第一条" {
+			t.Fatalf("恢复后应能查询到原内容: %+v, %v", got, err)
+		}
+		if _, err := repo.FindDeletedById(ctx, first.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("恢复后不应出现在回收站，实际: %v", err)
+		}
+
+		// 保留期之前删除的才会被清理，每次最多 limit 条，先清理最早删除的
+		if n, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour), 10); err != nil || n != 0 {
+			t.Fatalf("没有超过保留期的树洞时不应清理: %d, %v", n, err)
+		}
+		n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Second), 1)
+		if err != nil || n != 1 {
+			t.Fatalf("PurgeDeleted 应清理 1 条: %d, %v", n, err)
+		}
+		if _, err := repo.FindDeletedById(ctx, second.Id); !errors.Is(err, dao.ErrTreeHoleNotFound) {
+			t.Fatalf("最早删除的树洞应已被清理，实际: %v", err)
+		}
+		if _, err := repo.FindDeletedById(ctx, other.Id); err != nil {
+			t.Fatalf("超出 limit 的树洞不应被清理: %v", err)
+		}
+		if n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Second), 10); err != nil || n != 1 {
+			t.Fatalf("PurgeDeleted 应清理剩余 1 条: %d, %v", n, err)
+		}
+		if _, err := repo.GetById(ctx, first.Id); err != nil {
+			t.Fatalf("已恢复的树洞不应被清理: %v", err)
+		}
+	})
+}
+
+func assertTreeHoleIds(t *testing.T, name string, got []domain.TreeHole, want ...int64) {
+	t.Helper()
+	ids := make([]int64, len(got))
+	for i, h := range got {
+		ids[i] = h.Id
+	}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Fatalf("%s 应返回 %v，实际: %v", name, want, ids)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 11:00:00
+ * @Description: UserRepository 契约用例
+ */
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+// UserRepositoryContract newRepo 每次调用需返回一个空仓储
+func UserRepositoryContract(t *testing.T, newRepo func(t *testing.T) repository.UserRepository) {
+	ctx := context.Background()
+	newUser := func(name string) *domain.User {
+		return &domain.User{
+			Username: name,
+			Email:    name + "@example.com",
+			Password: "hashed-" + name,
+			Nickname: "昵称" + name,
+		}
+	}
+
+	t.Run("UserCreateAndFind", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newUser("alice")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		if user.Id == 0 || user.Ctime.IsZero() {
+			t.Fatalf("创建后未回填 Id 和时间: %+v", user)
+		}
+
+		finders := map[string]func() (*domain.User, error){
+			"FindById":       func() (*domain.User, error) { return repo.FindById(ctx, user.Id) },
+			"FindByUsername": func() (*domain.User, error) { return repo.FindByUsername(ctx, "alice") },
+			"FindByEmail":    func() (*domain.User, error) { return repo.FindByEmail(ctx, "alice@example.com") },
+		}
+		for name, find := range finders {
+			got, err := find()
+			if err != nil {
+				t.Fatalf("%s 失败: %v", name, err)
+			}
+			if got.Id != user.Id || got.Username != "alice" || got.Email != "alice@example.com" ||
+				got.Password != "hashed-alice" || got.Nickname != "昵称alice" || got.EmailVerified || got.Role != domain.RoleUser {
+				t.Fatalf("%s 返回的用户不一致: %+v", name, got)
+			}
+		}
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.FindById(ctx, 404); !errors.Is(err, dao.ErrUserNotFound) {
+			t.Fatalf("FindById 应返回 ErrUserNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindByUsername(ctx, "nobody"); !errors.Is(err, dao.ErrUserNotFound) {
+			t.Fatalf("FindByUsername 应返回 ErrUserNotFound，实际: %v", err)
+		}
+		if _, err := repo.FindByEmail(ctx, "nobody@example.com"); !errors.Is(err, dao.ErrUserNotFound) {
+			t.Fatalf("FindByEmail 应返回 ErrUserNotFound，实际: %v", err)
+		}
+	})
+
+	t.Run("UserDuplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(ctx, newUser("alice")); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		sameName := newUser("alice")
+		sameName.Email = "other@example.com"
+		if err := repo.Create(ctx, sameName); !errors.Is(err, dao.ErrUserDuplicateUsername) {
+			t.Fatalf("用户名重复应返回 ErrUserDuplicateUsername，实际: %v", err)
+		}
+		sameEmail := newUser("bob")
+		sameEmail.Email = "alice@example.com"
+		if err := repo.Create(ctx, sameEmail); !errors.Is(err, dao.ErrUserDuplicateEmail) {
+			t.Fatalf("邮箱重复应返回 ErrUserDuplicateEmail，实际: %v", err)
+		}
+		if count, err := repo.GetTotalUserCount(ctx); err != nil || count != 1 {
+			t.Fatalf("冲突的用户不应写入，用户数: %d, %v", count, err)
+		}
+	})
+
+	t.Run("UserUpdate", func(t *testing.T) {
+		repo := newRepo(t)
+		alice, bob := newUser("alice"), newUser("bob")
+		for _, u := range []*domain.User{alice, bob} {
+			if err := repo.Create(ctx, u); err != nil {
+				t.Fatalf("创建用户失败: %v", err)
+			}
+		}
+
+		profile := &domain.ProfileUpdateRequest{Nickname: "新昵称", Bio: "简介", Avatar: "a.png", Phone: "123", Location: "东京", Website: "https://example.com"}
+		if err := repo.UpdateProfile(ctx, alice.Id, profile); err != nil {
+			t.Fatalf("UpdateProfile 失败: %v", err)
+		}
+		if err := repo.UpdatePassword(ctx, alice.Id, "new-hash"); err != nil {
+			t.Fatalf("UpdatePassword 失败: %v", err)
+		}
+		if err := repo.MarkEmailVerified(ctx, alice.Id); err != nil {
+			t.Fatalf("MarkEmailVerified 失败: %v", err)
+		}
+		if err := repo.UpdateRole(ctx, alice.Id, domain.RoleAdmin); err != nil {
+			t.Fatalf("UpdateRole 失败: %v", err)
+		}
+		got := mustFindUser(t, repo, alice.Id)
+		if got.Nickname != "新昵称" || got.Bio != "简介" || got.Avatar != "a.png" || got.Phone != "123" ||
+			got.Location != "东京" || got.Website != "https://example.com" || got.Password != "new-hash" || !got.EmailVerified || !got.IsAdmin() {
+			t.Fatalf("更新后的用户不一致: %+v", got)
+		}
+
+		if err := repo.UpdateEmail(ctx, alice.Id, "bob@example.com"); !errors.Is(err, dao.ErrUserDuplicateEmail) {
+			t.Fatalf("修改为已被使用的邮箱应返回 ErrUserDuplicateEmail，实际: %v", err)
+		}
+		if err := repo.UpdateEmail(ctx, alice.Id, "alice2@example.com"); err != nil {
+			t.Fatalf("UpdateEmail 失败: %v", err)
+		}
+		got = mustFindUser(t, repo, alice.Id)
+		if got.Email != "alice2@example.com" || got.EmailVerified {
+			t.Fatalf("修改邮箱后应需要重新验证: %+v", got)
+		}
+		if other := mustFindUser(t, repo, bob.Id); other.Nickname != "昵称bob" || other.Email != "bob@example.com" || other.IsAdmin() {
+			t.Fatalf("更新影响了其他用户: %+v", other)
+		}
+	})
+
+	t.Run("UserConcurrentCreate", func(t *testing.T) {
+		repo := newRepo(t)
+		const n = 20
+		var wg sync.WaitGroup
+		users := make([]*domain.User, n)
+		errs := make([]error, n)
+		for i := 0; i < n; i++ {
+			users[i] = newUser(fmt.Sprintf("user%d", i))
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = repo.Create(ctx, users[i])
+			}(i)
+		}
+		wg.Wait()
+
+		ids := make(map[int64]bool, n)
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("并发创建用户失败: %v", err)
+			}
+			ids[users[i].Id] = true
+		}
+		if len(ids) != n {
+			t.Fatalf("并发创建的用户 Id 重复: %v", ids)
+		}
+		if count, err := repo.GetTotalUserCount(ctx); err != nil || count != n {
+			t.Fatalf("用户数应为 %d，实际: %d, %v", n, count, err)
+		}
+	})
+}
+
+func mustFindUser(t *testing.T, repo repository.UserRepository, id int64) *domain.User {
+	t.Helper()
+	user, err := repo.FindById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindById 失败: %v", err)
+	}
+	return user
+}
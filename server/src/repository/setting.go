@@ -0,0 +1,40 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 站点运行时设置仓库
+ */
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"negaihoshi/server/src/repository/dao"
+)
+
+type SettingRepository struct {
+	dao *dao.SettingDAO
+}
+
+func NewSettingRepository(dao *dao.SettingDAO) *SettingRepository {
+	return &SettingRepository{
+		dao: dao,
+	}
+}
+
+// GetBool 读取布尔设置，未设置时返回 def
+func (r *SettingRepository) GetBool(ctx context.Context, key string, def bool) (bool, error) {
+	value, err := r.dao.Get(ctx, key)
+	if errors.Is(err, dao.ErrSettingNotFound) {
+		return def, nil
+	}
+	if err != nil {
+		return def, err
+	}
+	return strconv.ParseBool(value)
+}
+
+func (r *SettingRepository) SetBool(ctx context.Context, key string, value bool) error {
+	return r.dao.Set(ctx, key, strconv.FormatBool(value))
+}
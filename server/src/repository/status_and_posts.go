@@ -10,78 +10,132 @@
 package repository
 
 import (
+	"context"
+	"math"
 	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/repository/dao"
-
-	// "time"
-
-	"github.com/gin-gonic/gin"
+	"sort"
+	"time"
 )
 
-type StatusAndPostsRepository struct {
+// StatusAndPostsRepository 列表按 id 升序返回且不包含已隐藏和已删除的内容，
+// 查询不到时返回 dao.ErrStatusNotFound / dao.ErrPostsNotFound。
+// GetPosts 和 FindPostsByUser 返回任意状态的文章，由调用方按查看者过滤，
+// GetAllPosts 和时间线只包含已发布的文章
+type StatusAndPostsRepository interface {
+	CreateStatus(ctx context.Context, status domain.Status) (domain.Status, error)
+	CreatePosts(ctx context.Context, posts domain.Posts) (domain.Posts, error)
+	// EditStatus 和 EditPosts 同时追加编辑历史，editorId 为编辑人，不改变作者
+	EditStatus(ctx context.Context, status domain.Status, editorId int64) error
+	EditPosts(ctx context.Context, posts domain.Posts, editorId int64) error
+	GetPosts(ctx context.Context, id int64) (domain.Posts, error)
+	GetStatus(ctx context.Context, id int64) (domain.Status, error)
+	FindStatusByUser(ctx context.Context, uid int64) ([]domain.Status, error)
+	FindPostsByUser(ctx context.Context, uid int64) ([]domain.Posts, error)
+	GetAllStatus(ctx context.Context) ([]domain.Status, error)
+	GetAllPosts(ctx context.Context) ([]domain.Posts, error)
+	// DeleteStatus 和 DeletePosts 为软删除，deletedBy 为操作人
+	DeleteStatus(ctx context.Context, id, deletedBy int64) error
+	DeletePosts(ctx context.Context, id, deletedBy int64) error
+	FindStatusOwner(ctx context.Context, id int64) (int64, error)
+	FindPostsOwner(ctx context.Context, id int64) (int64, error)
+	SetStatusHidden(ctx context.Context, id int64, hidden bool) error
+	SetPostsHidden(ctx context.Context, id int64, hidden bool) error
+	TimelineEntries(ctx context.Context, uids []int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error)
+	FindTimelineItems(ctx context.Context, entries []domain.TimelineEntry) (map[domain.TimelineEntry]domain.TimelineItem, error)
+	// FindDeletedStatus 和 FindDeletedPosts 按删除时间倒序返回用户已删除的内容
+	FindDeletedStatus(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error)
+	FindDeletedPosts(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error)
+	FindDeletedStatusById(ctx context.Context, id int64) (domain.TrashItem, error)
+	FindDeletedPostsById(ctx context.Context, id int64) (domain.TrashItem, error)
+	RestoreStatus(ctx context.Context, id int64) error
+	RestorePosts(ctx context.Context, id int64) error
+	// PurgeDeletedStatus 和 PurgeDeletedPosts 永久删除 before 之前删除的内容，每次最多 limit 条
+	PurgeDeletedStatus(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeDeletedPosts(ctx context.Context, before time.Time, limit int) (int64, error)
+	// FindRevisions 按版本先后返回编辑历史，未编辑过的内容返回空列表，kind 为 domain.RevisionStatus 或 domain.RevisionPost
+	FindRevisions(ctx context.Context, kind string, id int64) ([]domain.ContentRevision, error)
+	// FindRevision 查询不到时返回 dao.ErrRevisionNotFound
+	FindRevision(ctx context.Context, kind string, id, revisionId int64) (domain.ContentRevision, error)
+	// ChangePostsState 修改文章状态、发布时间和是否转发，草稿的 publishAt 为零值
+	ChangePostsState(ctx context.Context, id int64, state string, publishAt time.Time, crossPost bool) error
+	// FindDueScheduledPosts 按发布时间先后返回发布时间不晚于 now 的定时文章
+	FindDueScheduledPosts(ctx context.Context, now time.Time, limit int) ([]domain.Posts, error)
+	// PublishScheduledPosts 发布到期的定时文章并清除转发标记，文章已不是待发布的定时文章时返回 false
+	PublishScheduledPosts(ctx context.Context, id int64, now time.Time) (bool, error)
+}
+
+type SQLStatusAndPostsRepository struct {
 	sdao *dao.StatusDAO
 	pdao *dao.PostsDAO
+	rdao *dao.RevisionDAO
 }
 
-func NewStatusAndPostsRepository(sdao *dao.StatusDAO, pdao *dao.PostsDAO) *StatusAndPostsRepository {
-	return &StatusAndPostsRepository{
+func NewStatusAndPostsRepository(sdao *dao.StatusDAO, pdao *dao.PostsDAO, rdao *dao.RevisionDAO) *SQLStatusAndPostsRepository {
+	return &SQLStatusAndPostsRepository{
 		sdao: sdao,
 		pdao: pdao,
+		rdao: rdao,
 	}
 }
 
-func (s *StatusAndPostsRepository) CreateStatus(ctx *gin.Context, status domain.Status) error {
-	return s.sdao.Insert(ctx, dao.Status{
+func (s *SQLStatusAndPostsRepository) CreateStatus(ctx context.Context, status domain.Status) (domain.Status, error) {
+	created, err := s.sdao.Insert(ctx, dao.Status{
 		Content: status.Content,
 		UserId:  status.UserId,
 	})
+	if err != nil {
+		return domain.Status{}, err
+	}
+	return domain.Status{
+		Id:      created.Id,
+		Content: created.Content,
+		UserId:  created.UserId,
+		Ctime:   time.UnixMilli(created.Ctime),
+	}, nil
 }
 
-func (s *StatusAndPostsRepository) CreatePosts(ctx *gin.Context, posts domain.Posts) error {
-	return s.pdao.Insert(ctx, dao.Posts{
-		Title:   posts.Title,
-		Content: posts.Content,
-		UserId:  posts.UserId,
+func (s *SQLStatusAndPostsRepository) CreatePosts(ctx context.Context, posts domain.Posts) (domain.Posts, error) {
+	created, err := s.pdao.Insert(ctx, dao.Posts{
+		Title:     posts.Title,
+		Content:   posts.Content,
+		UserId:    posts.UserId,
+		State:     posts.State,
+		PublishAt: unixMilli(posts.PublishAt),
+		CrossPost: posts.CrossPost,
 	})
+	if err != nil {
+		return domain.Posts{}, err
+	}
+	return toDomainPosts(created), nil
 }
 
-func (s *StatusAndPostsRepository) EditStatus(ctx *gin.Context, status domain.Status) error {
+func (s *SQLStatusAndPostsRepository) EditStatus(ctx context.Context, status domain.Status, editorId int64) error {
 	return s.sdao.Update(ctx, dao.Status{
 		Id:      status.Id,
 		Content: status.Content,
-		UserId:  status.UserId,
-	})
+	}, editorId)
 }
 
-func (s *StatusAndPostsRepository) EditPosts(ctx *gin.Context, posts domain.Posts) error {
+func (s *SQLStatusAndPostsRepository) EditPosts(ctx context.Context, posts domain.Posts, editorId int64) error {
 	return s.pdao.Update(ctx, dao.Posts{
 		Id:      posts.Id,
 		Title:   posts.Title,
 		Content: posts.Content,
-		UserId:  posts.UserId,
-	})
+	}, editorId)
 }
 
-func (s *StatusAndPostsRepository) GetPosts(c *gin.Context, id int64) (domain.Posts, error) {
+func (s *SQLStatusAndPostsRepository) GetPosts(c context.Context, id int64) (domain.Posts, error) {
 	res, err := s.pdao.FindById(c, id)
-	return domain.Posts{
-		Id:      res.Id,
-		Title:   res.Title,
-		Content: res.Content,
-		UserId:  res.UserId,
-	}, err
+	return toDomainPosts(res), err
 }
 
-func (s *StatusAndPostsRepository) GetStatus(c *gin.Context, id int64) (domain.Status, error) {
+func (s *SQLStatusAndPostsRepository) GetStatus(c context.Context, id int64) (domain.Status, error) {
 	res, err := s.sdao.FindById(c, id)
-	return domain.Status{
-		Id:      res.Id,
-		Content: res.Content,
-		UserId:  res.UserId,
-	}, err
+	return toDomainStatus(res), err
 }
 
-func (s *StatusAndPostsRepository) FindStatusByUser(ctx *gin.Context, uid int64) ([]domain.Status, error) {
+func (s *SQLStatusAndPostsRepository) FindStatusByUser(ctx context.Context, uid int64) ([]domain.Status, error) {
 	res, err := s.sdao.FindByUid(ctx, uid)
 	if err != nil {
 		return nil, err
@@ -90,17 +144,13 @@ func (s *StatusAndPostsRepository) FindStatusByUser(ctx *gin.Context, uid int64)
 	return func(res []dao.Status) []domain.Status {
 		var status []domain.Status
 		for _, v := range res {
-			status = append(status, domain.Status{
-				Id:      v.Id,
-				Content: v.Content,
-				UserId:  v.UserId,
-			})
+			status = append(status, toDomainStatus(v))
 		}
 		return status
 	}(res), nil
 }
 
-func (s *StatusAndPostsRepository) FindPostsByUser(ctx *gin.Context, uid int64) ([]domain.Posts, error) {
+func (s *SQLStatusAndPostsRepository) FindPostsByUser(ctx context.Context, uid int64) ([]domain.Posts, error) {
 	res, err := s.pdao.FindByUid(ctx, uid)
 	if err != nil {
 		return nil, err
@@ -109,18 +159,13 @@ func (s *StatusAndPostsRepository) FindPostsByUser(ctx *gin.Context, uid int64)
 	return func(res []dao.Posts) []domain.Posts {
 		var posts []domain.Posts
 		for _, v := range res {
-			posts = append(posts, domain.Posts{
-				Id:      v.Id,
-				Title:   v.Title,
-				Content: v.Content,
-				UserId:  v.UserId,
-			})
+			posts = append(posts, toDomainPosts(v))
 		}
 		return posts
 	}(res), nil
 }
 
-func (s *StatusAndPostsRepository) GetAllStatus(c *gin.Context) ([]domain.Status, error) {
+func (s *SQLStatusAndPostsRepository) GetAllStatus(c context.Context) ([]domain.Status, error) {
 	res, err := s.sdao.GetAllRecord(c)
 	if err != nil {
 		return nil, err
@@ -129,17 +174,13 @@ func (s *StatusAndPostsRepository) GetAllStatus(c *gin.Context) ([]domain.Status
 	return func(res []dao.Status) []domain.Status {
 		var status []domain.Status
 		for _, v := range res {
-			status = append(status, domain.Status{
-				Id:      v.Id,
-				Content: v.Content,
-				UserId:  v.UserId,
-			})
+			status = append(status, toDomainStatus(v))
 		}
 		return status
 	}(res), nil
 }
 
-func (s *StatusAndPostsRepository) GetAllPosts(c *gin.Context) ([]domain.Posts, error) {
+func (s *SQLStatusAndPostsRepository) GetAllPosts(c context.Context) ([]domain.Posts, error) {
 	res, err := s.pdao.GetAllRecord(c)
 	if err != nil {
 		return nil, err
@@ -148,21 +189,323 @@ func (s *StatusAndPostsRepository) GetAllPosts(c *gin.Context) ([]domain.Posts,
 	return func(res []dao.Posts) []domain.Posts {
 		var posts []domain.Posts
 		for _, v := range res {
-			posts = append(posts, domain.Posts{
-				Id:      v.Id,
-				Title:   v.Title,
-				Content: v.Content,
-				UserId:  v.UserId,
-			})
+			posts = append(posts, toDomainPosts(v))
 		}
 		return posts
 	}(res), nil
 }
 
-func (s *StatusAndPostsRepository) DeleteStatus(c *gin.Context, id int64) error {
-	return s.sdao.Delete(c, id)
+func (s *SQLStatusAndPostsRepository) DeleteStatus(c context.Context, id, deletedBy int64) error {
+	return s.sdao.SoftDelete(c, id, deletedBy)
+}
+
+func (s *SQLStatusAndPostsRepository) DeletePosts(c context.Context, id, deletedBy int64) error {
+	return s.pdao.SoftDelete(c, id, deletedBy)
+}
+
+// FindStatusOwner 查询动态作者，包括已隐藏的动态
+func (s *SQLStatusAndPostsRepository) FindStatusOwner(ctx context.Context, id int64) (int64, error) {
+	status, err := s.sdao.FindByIdUnscoped(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return status.UserId, nil
+}
+
+// FindPostsOwner 查询文章作者，包括已隐藏的文章
+func (s *SQLStatusAndPostsRepository) FindPostsOwner(ctx context.Context, id int64) (int64, error) {
+	posts, err := s.pdao.FindByIdUnscoped(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return posts.UserId, nil
+}
+
+func (s *SQLStatusAndPostsRepository) SetStatusHidden(ctx context.Context, id int64, hidden bool) error {
+	return s.sdao.UpdateHidden(ctx, id, hidden)
+}
+
+func (s *SQLStatusAndPostsRepository) SetPostsHidden(ctx context.Context, id int64, hidden bool) error {
+	return s.pdao.UpdateHidden(ctx, id, hidden)
+}
+
+// TimelineEntries 按时间倒序读取多个用户的动态和文章引用，after 为上一页的最后一条，为零值时从最新开始
+func (s *SQLStatusAndPostsRepository) TimelineEntries(ctx context.Context, uids []int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	statusCtime, statusId := timelineBound(domain.TimelineStatus, after)
+	status, err := s.sdao.FindTimeline(ctx, uids, statusCtime, statusId, limit)
+	if err != nil {
+		return nil, err
+	}
+	postsCtime, postsId := timelineBound(domain.TimelinePost, after)
+	posts, err := s.pdao.FindTimeline(ctx, uids, postsCtime, postsId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.TimelineEntry, 0, len(status)+len(posts))
+	for _, st := range status {
+		entries = append(entries, domain.TimelineEntry{Kind: domain.TimelineStatus, Id: st.Id, Ctime: st.Ctime})
+	}
+	// 文章按发布时间进入时间线，定时发布的文章不会排在发布前的内容之前
+	for _, p := range posts {
+		entries = append(entries, domain.TimelineEntry{Kind: domain.TimelinePost, Id: p.Id, Ctime: p.PublishAt})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Before(entries[j])
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// timelineBound 把游标换算成某一类内容的查询条件，时间相同时按 domain.TimelineEntry.Before 的顺序决定是否包含
+func timelineBound(kind string, after domain.TimelineEntry) (int64, int64) {
+	if after.IsZero() {
+		return math.MaxInt64, 0
+	}
+	switch {
+	case kind == after.Kind:
+		return after.Ctime, after.Id
+	case kind < after.Kind:
+		// 同一时间的这类内容排在游标之后，全部包含
+		return after.Ctime, math.MaxInt64
+	default:
+		return after.Ctime, 0
+	}
+}
+
+// FindTimelineItems 加载时间线条目的内容，结果以只含类型和 id 的条目为键，已删除或已隐藏的条目不会返回
+func (s *SQLStatusAndPostsRepository) FindTimelineItems(ctx context.Context, entries []domain.TimelineEntry) (map[domain.TimelineEntry]domain.TimelineItem, error) {
+	var statusIds, postsIds []int64
+	for _, e := range entries {
+		if e.Kind == domain.TimelineStatus {
+			statusIds = append(statusIds, e.Id)
+		} else {
+			postsIds = append(postsIds, e.Id)
+		}
+	}
+
+	items := make(map[domain.TimelineEntry]domain.TimelineItem, len(entries))
+	if len(statusIds) > 0 {
+		status, err := s.sdao.FindByIds(ctx, statusIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range status {
+			items[domain.TimelineEntry{Kind: domain.TimelineStatus, Id: st.Id}] = domain.TimelineItem{
+				Type:     domain.TimelineStatus,
+				Id:       st.Id,
+				UserId:   st.UserId,
+				Content:  st.Content,
+				Ctime:    time.UnixMilli(st.Ctime),
+				Edited:   st.EditedAt > 0,
+				EditedAt: editedAt(st.EditedAt),
+			}
+		}
+	}
+	if len(postsIds) > 0 {
+		posts, err := s.pdao.FindByIds(ctx, postsIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			items[domain.TimelineEntry{Kind: domain.TimelinePost, Id: p.Id}] = domain.TimelineItem{
+				Type:     domain.TimelinePost,
+				Id:       p.Id,
+				UserId:   p.UserId,
+				Title:    p.Title,
+				Content:  p.Content,
+				Ctime:    time.UnixMilli(p.PublishAt),
+				Edited:   p.EditedAt > 0,
+				EditedAt: editedAt(p.EditedAt),
+			}
+		}
+	}
+	return items, nil
+}
+
+func (s *SQLStatusAndPostsRepository) FindDeletedStatus(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	list, err := s.sdao.FindDeletedByUser(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]domain.TrashItem, 0, len(list))
+	for _, st := range list {
+		items = append(items, statusTrashItem(st))
+	}
+	return items, nil
+}
+
+func (s *SQLStatusAndPostsRepository) FindDeletedPosts(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	list, err := s.pdao.FindDeletedByUser(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]domain.TrashItem, 0, len(list))
+	for _, p := range list {
+		items = append(items, postsTrashItem(p))
+	}
+	return items, nil
 }
 
-func (s *StatusAndPostsRepository) DeletePosts(c *gin.Context, id int64) error {
-	return s.pdao.Delete(c, id)
+func (s *SQLStatusAndPostsRepository) FindDeletedStatusById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	st, err := s.sdao.FindDeletedById(ctx, id)
+	if err != nil {
+		return domain.TrashItem{}, err
+	}
+	return statusTrashItem(st), nil
+}
+
+func (s *SQLStatusAndPostsRepository) FindDeletedPostsById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	p, err := s.pdao.FindDeletedById(ctx, id)
+	if err != nil {
+		return domain.TrashItem{}, err
+	}
+	return postsTrashItem(p), nil
+}
+
+func (s *SQLStatusAndPostsRepository) RestoreStatus(ctx context.Context, id int64) error {
+	return s.sdao.Restore(ctx, id)
+}
+
+func (s *SQLStatusAndPostsRepository) RestorePosts(ctx context.Context, id int64) error {
+	return s.pdao.Restore(ctx, id)
+}
+
+func (s *SQLStatusAndPostsRepository) PurgeDeletedStatus(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return s.sdao.PurgeDeletedBefore(ctx, before.UnixMilli(), limit)
+}
+
+func (s *SQLStatusAndPostsRepository) PurgeDeletedPosts(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return s.pdao.PurgeDeletedBefore(ctx, before.UnixMilli(), limit)
+}
+
+func (s *SQLStatusAndPostsRepository) FindRevisions(ctx context.Context, kind string, id int64) ([]domain.ContentRevision, error) {
+	list, err := s.rdao.FindByTarget(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]domain.ContentRevision, 0, len(list))
+	for i, r := range list {
+		revisions = append(revisions, toDomainRevision(r, i+1))
+	}
+	return revisions, nil
+}
+
+func (s *SQLStatusAndPostsRepository) FindRevision(ctx context.Context, kind string, id, revisionId int64) (domain.ContentRevision, error) {
+	// 版本号由排在前面的版本数决定，直接取全部历史计算
+	list, err := s.FindRevisions(ctx, kind, id)
+	if err != nil {
+		return domain.ContentRevision{}, err
+	}
+	for _, r := range list {
+		if r.Id == revisionId {
+			return r, nil
+		}
+	}
+	return domain.ContentRevision{}, dao.ErrRevisionNotFound
+}
+
+func (s *SQLStatusAndPostsRepository) ChangePostsState(ctx context.Context, id int64, state string, publishAt time.Time, crossPost bool) error {
+	return s.pdao.UpdateState(ctx, id, state, unixMilli(publishAt), crossPost)
+}
+
+func (s *SQLStatusAndPostsRepository) FindDueScheduledPosts(ctx context.Context, now time.Time, limit int) ([]domain.Posts, error) {
+	list, err := s.pdao.FindDueScheduled(ctx, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	posts := make([]domain.Posts, 0, len(list))
+	for _, p := range list {
+		posts = append(posts, toDomainPosts(p))
+	}
+	return posts, nil
+}
+
+func (s *SQLStatusAndPostsRepository) PublishScheduledPosts(ctx context.Context, id int64, now time.Time) (bool, error) {
+	return s.pdao.PublishScheduled(ctx, id, now.UnixMilli())
+}
+
+func toDomainStatus(st dao.Status) domain.Status {
+	return domain.Status{
+		Id:       st.Id,
+		Content:  st.Content,
+		UserId:   st.UserId,
+		Ctime:    time.UnixMilli(st.Ctime),
+		Edited:   st.EditedAt > 0,
+		EditedAt: editedAt(st.EditedAt),
+	}
+}
+
+func toDomainPosts(p dao.Posts) domain.Posts {
+	return domain.Posts{
+		Id:        p.Id,
+		Title:     p.Title,
+		Content:   p.Content,
+		UserId:    p.UserId,
+		Ctime:     time.UnixMilli(p.Ctime),
+		Edited:    p.EditedAt > 0,
+		EditedAt:  editedAt(p.EditedAt),
+		State:     p.State,
+		PublishAt: editedAt(p.PublishAt),
+		CrossPost: p.CrossPost,
+	}
+}
+
+// editedAt 毫秒时间戳为 0 时返回零值
+func editedAt(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// unixMilli 零值时间返回 0
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func toDomainRevision(r dao.ContentRevision, version int) domain.ContentRevision {
+	return domain.ContentRevision{
+		Id:       r.Id,
+		Version:  version,
+		Type:     r.TargetType,
+		TargetId: r.TargetId,
+		EditorId: r.EditorId,
+		Title:    r.Title,
+		Content:  r.Content,
+		Ctime:    time.UnixMilli(r.Ctime),
+	}
+}
+
+func statusTrashItem(st dao.Status) domain.TrashItem {
+	return domain.TrashItem{
+		Type:      domain.TrashStatus,
+		Id:        st.Id,
+		UserId:    st.UserId,
+		Content:   st.Content,
+		Ctime:     time.UnixMilli(st.Ctime),
+		DeletedBy: st.DeletedBy,
+		DeletedAt: time.UnixMilli(st.DeletedAt),
+	}
+}
+
+func postsTrashItem(p dao.Posts) domain.TrashItem {
+	return domain.TrashItem{
+		Type:      domain.TrashPost,
+		Id:        p.Id,
+		UserId:    p.UserId,
+		Title:     p.Title,
+		Content:   p.Content,
+		Ctime:     time.UnixMilli(p.Ctime),
+		DeletedBy: p.DeletedBy,
+		DeletedAt: time.UnixMilli(p.DeletedAt),
+	}
 }
@@ -9,33 +9,59 @@
 package repository
 
 import (
+	"context"
 	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/repository/dao"
 	"time"
-
-	"github.com/gin-gonic/gin"
 )
 
-type TreeHoleRepository struct {
+// TreeHoleRepository 列表按 id 升序返回且不包含已隐藏和已删除的树洞，查询不到时返回 dao.ErrTreeHoleNotFound
+type TreeHoleRepository interface {
+	Create(ctx context.Context, treeHole domain.TreeHole) (domain.TreeHole, error)
+	GetList(ctx context.Context, offset, limit int, excludeUserIds []int64) ([]domain.TreeHole, error)
+	GetListByUser(ctx context.Context, userId int64, offset, limit int) ([]domain.TreeHole, error)
+	GetById(ctx context.Context, id int64) (domain.TreeHole, error)
+	// Delete 软删除，deletedBy 为操作人
+	Delete(ctx context.Context, id, deletedBy int64) error
+	FindOwner(ctx context.Context, id int64) (int64, error)
+	SetHidden(ctx context.Context, id int64, hidden bool) error
+	// FindDeleted 按删除时间倒序返回用户已删除的树洞
+	FindDeleted(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error)
+	FindDeletedById(ctx context.Context, id int64) (domain.TrashItem, error)
+	Restore(ctx context.Context, id int64) error
+	// PurgeDeleted 永久删除 before 之前删除的树洞，每次最多 limit 条
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type SQLTreeHoleRepository struct {
 	dao *dao.TreeHoleDAO
 }
 
-func NewTreeHoleRepository(dao *dao.TreeHoleDAO) *TreeHoleRepository {
-	return &TreeHoleRepository{
+func NewTreeHoleRepository(dao *dao.TreeHoleDAO) *SQLTreeHoleRepository {
+	return &SQLTreeHoleRepository{
 		dao: dao,
 	}
 }
 
-func (t *TreeHoleRepository) Create(ctx *gin.Context, treeHole domain.TreeHole) error {
-	return t.dao.Insert(ctx, dao.TreeHole{
+func (t *SQLTreeHoleRepository) Create(ctx context.Context, treeHole domain.TreeHole) (domain.TreeHole, error) {
+	created, err := t.dao.Insert(ctx, dao.TreeHole{
 		Content: treeHole.Content,
 		UserId:  treeHole.UserId,
 	})
+	if err != nil {
+		return domain.TreeHole{}, err
+	}
+	return domain.TreeHole{
+		Id:      created.Id,
+		Content: created.Content,
+		UserId:  created.UserId,
+		Ctime:   time.UnixMilli(created.Ctime),
+	}, nil
 }
 
-func (r *TreeHoleRepository) GetList(ctx *gin.Context, offset, limit int) ([]domain.TreeHole, error) {
+func (r *SQLTreeHoleRepository) GetList(ctx context.Context, offset, limit int, excludeUserIds []int64) ([]domain.TreeHole, error) {
 	results := []domain.TreeHole{}
-	mess, err := r.dao.FindByPage(ctx, offset, limit)
+	mess, err := r.dao.FindByPage(ctx, offset, limit, excludeUserIds)
 	if err != nil {
 		return results, err
 	}
@@ -53,7 +79,7 @@ func (r *TreeHoleRepository) GetList(ctx *gin.Context, offset, limit int) ([]dom
 	return results, nil
 }
 
-func (r *TreeHoleRepository) GetListByUser(ctx *gin.Context, userId int64, offset, limit int) ([]domain.TreeHole, error) {
+func (r *SQLTreeHoleRepository) GetListByUser(ctx context.Context, userId int64, offset, limit int) ([]domain.TreeHole, error) {
 	results := []domain.TreeHole{}
 	mess, err := r.dao.FindByUserAndPage(ctx, userId, offset, limit)
 	if err != nil {
@@ -73,7 +99,7 @@ func (r *TreeHoleRepository) GetListByUser(ctx *gin.Context, userId int64, offse
 	return results, nil
 }
 
-func (r *TreeHoleRepository) GetById(ctx *gin.Context, id int64) (domain.TreeHole, error) {
+func (r *SQLTreeHoleRepository) GetById(ctx context.Context, id int64) (domain.TreeHole, error) {
 	mess, err := r.dao.FindById(ctx, id)
 	if err != nil {
 		return domain.TreeHole{}, err
@@ -89,6 +115,59 @@ func (r *TreeHoleRepository) GetById(ctx *gin.Context, id int64) (domain.TreeHol
 	}, nil
 }
 
-func (r *TreeHoleRepository) Delete(ctx *gin.Context, id int64) error {
-	return r.dao.DeleteById(ctx, id)
+func (r *SQLTreeHoleRepository) Delete(ctx context.Context, id, deletedBy int64) error {
+	return r.dao.SoftDelete(ctx, id, deletedBy)
+}
+
+// FindOwner 查询树洞作者，包括已隐藏的树洞
+func (r *SQLTreeHoleRepository) FindOwner(ctx context.Context, id int64) (int64, error) {
+	mess, err := r.dao.FindByIdUnscoped(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return mess.UserId, nil
+}
+
+func (r *SQLTreeHoleRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	return r.dao.UpdateHidden(ctx, id, hidden)
+}
+
+func (r *SQLTreeHoleRepository) FindDeleted(ctx context.Context, userId int64, limit int) ([]domain.TrashItem, error) {
+	list, err := r.dao.FindDeletedByUser(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]domain.TrashItem, 0, len(list))
+	for _, t := range list {
+		items = append(items, treeHoleTrashItem(t))
+	}
+	return items, nil
+}
+
+func (r *SQLTreeHoleRepository) FindDeletedById(ctx context.Context, id int64) (domain.TrashItem, error) {
+	t, err := r.dao.FindDeletedById(ctx, id)
+	if err != nil {
+		return domain.TrashItem{}, err
+	}
+	return treeHoleTrashItem(t), nil
+}
+
+func (r *SQLTreeHoleRepository) Restore(ctx context.Context, id int64) error {
+	return r.dao.Restore(ctx, id)
+}
+
+func (r *SQLTreeHoleRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return r.dao.PurgeDeletedBefore(ctx, before.UnixMilli(), limit)
+}
+
+func treeHoleTrashItem(t dao.TreeHole) domain.TrashItem {
+	return domain.TrashItem{
+		Type:      domain.TrashTreeHole,
+		Id:        t.Id,
+		UserId:    t.UserId,
+		Content:   t.Content,
+		Ctime:     time.UnixMilli(t.Ctime),
+		DeletedBy: t.DeletedBy,
+		DeletedAt: time.UnixMilli(t.DeletedAt),
+	}
 }
@@ -0,0 +1,87 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 两步验证仓库，密钥加密后存储
+ */
+package repository
+
+import (
+	"context"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/util"
+)
+
+type TwoFactorRepository struct {
+	dao    *dao.TwoFactorDAO
+	crypto *util.PasswordCrypto
+	// 更换密钥前使用的加密工具，只用于解密旧数据，可为 nil
+	legacy *util.PasswordCrypto
+}
+
+func NewTwoFactorRepository(dao *dao.TwoFactorDAO, crypto, legacy *util.PasswordCrypto) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		dao:    dao,
+		crypto: crypto,
+		legacy: legacy,
+	}
+}
+
+func (r *TwoFactorRepository) FindByUser(ctx context.Context, userId int64) (domain.TwoFactor, error) {
+	tf, err := r.dao.FindByUser(ctx, userId)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	secret, stale, err := r.crypto.DecryptWithFallback(tf.Secret, r.legacy)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	if stale {
+		// 旧密钥加密的数据读取时改用当前密钥重新加密，失败时下次读取再试
+		if encrypted, err := r.crypto.EncryptPassword(secret); err == nil {
+			_ = r.dao.UpdateSecret(ctx, userId, encrypted)
+		}
+	}
+	return domain.TwoFactor{
+		UserId:   tf.UserId,
+		Secret:   secret,
+		Enabled:  tf.Enabled,
+		LastStep: tf.LastStep,
+	}, nil
+}
+
+func (r *TwoFactorRepository) SavePending(ctx context.Context, userId int64, secret string) error {
+	encrypted, err := r.crypto.EncryptPassword(secret)
+	if err != nil {
+		return err
+	}
+	return r.dao.Upsert(ctx, userId, encrypted)
+}
+
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userId, step int64) error {
+	return r.dao.UseStep(ctx, userId, step)
+}
+
+func (r *TwoFactorRepository) Enable(ctx context.Context, userId int64, recoveryHashes []string) error {
+	if err := r.dao.ReplaceRecoveryCodes(ctx, userId, recoveryHashes); err != nil {
+		return err
+	}
+	return r.dao.UpdateEnabled(ctx, userId, true)
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userId int64) error {
+	return r.dao.DeleteByUser(ctx, userId)
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userId int64, hashes []string) error {
+	return r.dao.ReplaceRecoveryCodes(ctx, userId, hashes)
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userId int64, hash string) error {
+	return r.dao.UseRecoveryCode(ctx, userId, hash)
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userId int64) (int64, error) {
+	return r.dao.CountUnusedRecoveryCodes(ctx, userId)
+}
@@ -15,17 +15,35 @@ import (
 	"negaihoshi/server/src/repository/dao"
 )
 
-type UserRepository struct {
+// UserRepository Create 成功后回填 user 的 Id 和时间。查询不到用户时返回 dao.ErrUserNotFound，
+// 用户名或邮箱冲突时返回 dao.ErrUserDuplicateUsername / dao.ErrUserDuplicateEmail
+type UserRepository interface {
+	Create(ctx context.Context, user *domain.User) error
+	FindById(ctx context.Context, id int64) (*domain.User, error)
+	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindByUsername(ctx context.Context, username string) (*domain.User, error)
+	UpdateProfile(ctx context.Context, id int64, profile *domain.ProfileUpdateRequest) error
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	UpdateEmail(ctx context.Context, id int64, email string) error
+	MarkEmailVerified(ctx context.Context, id int64) error
+	UpdateRole(ctx context.Context, id int64, role string) error
+	GetTotalUserCount(ctx context.Context) (int64, error)
+}
+
+type SQLUserRepository struct {
 	userDAO *dao.UserDAO
 }
 
-func NewUserRepository(userDAO *dao.UserDAO) *UserRepository {
-	return &UserRepository{
+func NewUserRepository(userDAO *dao.UserDAO) *SQLUserRepository {
+	return &SQLUserRepository{
 		userDAO: userDAO,
 	}
 }
 
-func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+func (r *SQLUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
 	daoUser := &dao.User{
 		Username: user.Username,
 		Email:    user.Email,
@@ -36,82 +54,81 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		Phone:    user.Phone,
 		Location: user.Location,
 		Website:  user.Website,
+		Role:     user.Role,
 	}
 
-	return r.userDAO.Insert(daoUser)
+	if err := r.userDAO.Insert(ctx, daoUser); err != nil {
+		return err
+	}
+	user.Id = daoUser.Id
+	user.Ctime = daoUser.Ctime
+	user.Utime = daoUser.Utime
+	return nil
 }
 
-func (r *UserRepository) FindById(ctx context.Context, id int64) (*domain.User, error) {
-	daoUser, err := r.userDAO.FindById(id)
+func (r *SQLUserRepository) FindById(ctx context.Context, id int64) (*domain.User, error) {
+	daoUser, err := r.userDAO.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-
-	return &domain.User{
-		Id:       daoUser.Id,
-		Username: daoUser.Username,
-		Email:    daoUser.Email,
-		Password: daoUser.Password,
-		Nickname: daoUser.Nickname,
-		Bio:      daoUser.Bio,
-		Avatar:   daoUser.Avatar,
-		Phone:    daoUser.Phone,
-		Location: daoUser.Location,
-		Website:  daoUser.Website,
-		Ctime:    daoUser.Ctime,
-		Utime:    daoUser.Utime,
-	}, nil
+	return toDomainUser(daoUser), nil
 }
 
-func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
-	daoUser, err := r.userDAO.FindByEmail(email)
+func (r *SQLUserRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	daoUser, err := r.userDAO.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
-
-	return &domain.User{
-		Id:       daoUser.Id,
-		Username: daoUser.Username,
-		Email:    daoUser.Email,
-		Password: daoUser.Password,
-		Nickname: daoUser.Nickname,
-		Bio:      daoUser.Bio,
-		Avatar:   daoUser.Avatar,
-		Phone:    daoUser.Phone,
-		Location: daoUser.Location,
-		Website:  daoUser.Website,
-		Ctime:    daoUser.Ctime,
-		Utime:    daoUser.Utime,
-	}, nil
+	return toDomainUser(daoUser), nil
 }
 
-func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
-	daoUser, err := r.userDAO.FindByUsername(username)
+func (r *SQLUserRepository) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	daoUser, err := r.userDAO.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
+	return toDomainUser(daoUser), nil
+}
 
-	return &domain.User{
-		Id:       daoUser.Id,
-		Username: daoUser.Username,
-		Email:    daoUser.Email,
-		Password: daoUser.Password,
-		Nickname: daoUser.Nickname,
-		Bio:      daoUser.Bio,
-		Avatar:   daoUser.Avatar,
-		Phone:    daoUser.Phone,
-		Location: daoUser.Location,
-		Website:  daoUser.Website,
-		Ctime:    daoUser.Ctime,
-		Utime:    daoUser.Utime,
-	}, nil
+func (r *SQLUserRepository) UpdateProfile(ctx context.Context, id int64, profile *domain.ProfileUpdateRequest) error {
+	return r.userDAO.UpdateProfile(ctx, id, profile)
 }
 
-func (r *UserRepository) UpdateProfile(ctx context.Context, id int64, profile *domain.ProfileUpdateRequest) error {
-	return r.userDAO.UpdateProfile(id, profile)
+func (r *SQLUserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return r.userDAO.UpdatePassword(ctx, id, password)
 }
 
-func (r *UserRepository) GetTotalUserCount(ctx context.Context) (int64, error) {
-	// 实现获取用户总数的逻辑
-	return 0, nil
+func (r *SQLUserRepository) UpdateEmail(ctx context.Context, id int64, email string) error {
+	return r.userDAO.UpdateEmail(ctx, id, email)
+}
+
+func (r *SQLUserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	return r.userDAO.UpdateEmailVerified(ctx, id, true)
+}
+
+func (r *SQLUserRepository) UpdateRole(ctx context.Context, id int64, role string) error {
+	return r.userDAO.UpdateRole(ctx, id, role)
+}
+
+func (r *SQLUserRepository) GetTotalUserCount(ctx context.Context) (int64, error) {
+	return r.userDAO.Count(ctx)
+}
+
+func toDomainUser(u *dao.User) *domain.User {
+	return &domain.User{
+		Id:            u.Id,
+		Username:      u.Username,
+		Email:         u.Email,
+		Password:      u.Password,
+		Nickname:      u.Nickname,
+		Bio:           u.Bio,
+		Avatar:        u.Avatar,
+		Phone:         u.Phone,
+		Location:      u.Location,
+		Website:       u.Website,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Ctime:         u.Ctime,
+		Utime:         u.Utime,
+	}
 }
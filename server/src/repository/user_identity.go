@@ -0,0 +1,68 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 18:00:00
+ * @Description: 外部登录账号绑定仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type UserIdentityRepository struct {
+	dao *dao.UserIdentityDAO
+}
+
+func NewUserIdentityRepository(dao *dao.UserIdentityDAO) *UserIdentityRepository {
+	return &UserIdentityRepository{
+		dao: dao,
+	}
+}
+
+func (r *UserIdentityRepository) Create(ctx context.Context, identity domain.UserIdentity) error {
+	return r.dao.Insert(ctx, dao.UserIdentity{
+		UserId:   identity.UserId,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
+
+func (r *UserIdentityRepository) FindBySubject(ctx context.Context, provider, subject string) (domain.UserIdentity, error) {
+	identity, err := r.dao.FindBySubject(ctx, provider, subject)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	return r.toDomain(identity), nil
+}
+
+func (r *UserIdentityRepository) FindByUser(ctx context.Context, userId int64) ([]domain.UserIdentity, error) {
+	identities, err := r.dao.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.UserIdentity, 0, len(identities))
+	for _, identity := range identities {
+		results = append(results, r.toDomain(identity))
+	}
+	return results, nil
+}
+
+func (r *UserIdentityRepository) Delete(ctx context.Context, userId int64, provider string) error {
+	return r.dao.Delete(ctx, userId, provider)
+}
+
+func (r *UserIdentityRepository) toDomain(identity dao.UserIdentity) domain.UserIdentity {
+	return domain.UserIdentity{
+		Id:       identity.Id,
+		UserId:   identity.UserId,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Ctime:    time.UnixMilli(identity.Ctime),
+	}
+}
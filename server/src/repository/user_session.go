@@ -0,0 +1,92 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 14:00:00
+ * @Description: 用户会话仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type UserSessionRepository struct {
+	dao *dao.UserSessionDAO
+}
+
+func NewUserSessionRepository(dao *dao.UserSessionDAO) *UserSessionRepository {
+	return &UserSessionRepository{
+		dao: dao,
+	}
+}
+
+func (r *UserSessionRepository) Create(ctx context.Context, sid string, session domain.UserSession, expireAt time.Time) error {
+	return r.dao.Insert(ctx, dao.UserSession{
+		Sid:       sid,
+		UserId:    session.UserId,
+		Device:    session.Device,
+		UserAgent: session.UserAgent,
+		Ip:        session.Ip,
+		Pending:   session.Pending,
+		ExpireAt:  expireAt.UnixMilli(),
+	})
+}
+
+func (r *UserSessionRepository) FindBySid(ctx context.Context, sid string) (domain.UserSession, error) {
+	session, err := r.dao.FindBySid(ctx, sid)
+	if err != nil {
+		return domain.UserSession{}, err
+	}
+	return r.toDomain(session), nil
+}
+
+// FindByUser 查询用户的全部有效会话，currentSid 对应的会话标记为当前会话
+func (r *UserSessionRepository) FindByUser(ctx context.Context, userId int64, currentSid string) ([]domain.UserSession, error) {
+	sessions, err := r.dao.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		s := r.toDomain(session)
+		s.Current = session.Sid == currentSid
+		results = append(results, s)
+	}
+	return results, nil
+}
+
+func (r *UserSessionRepository) Touch(ctx context.Context, sid, ip string) error {
+	return r.dao.UpdateLastSeen(ctx, sid, ip)
+}
+
+func (r *UserSessionRepository) Promote(ctx context.Context, sid string, expireAt time.Time) error {
+	return r.dao.Promote(ctx, sid, expireAt.UnixMilli())
+}
+
+func (r *UserSessionRepository) DeleteById(ctx context.Context, userId, id int64) error {
+	return r.dao.DeleteById(ctx, userId, id)
+}
+
+func (r *UserSessionRepository) DeleteBySid(ctx context.Context, sid string) error {
+	return r.dao.DeleteBySid(ctx, sid)
+}
+
+func (r *UserSessionRepository) DeleteByUserExcept(ctx context.Context, userId int64, exceptSid string) error {
+	return r.dao.DeleteByUserExcept(ctx, userId, exceptSid)
+}
+
+func (r *UserSessionRepository) toDomain(session dao.UserSession) domain.UserSession {
+	return domain.UserSession{
+		Id:        session.Id,
+		UserId:    session.UserId,
+		Device:    session.Device,
+		UserAgent: session.UserAgent,
+		Ip:        session.Ip,
+		Pending:   session.Pending,
+		LastSeen:  time.UnixMilli(session.LastSeen),
+		Ctime:     time.UnixMilli(session.Ctime),
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 13:00:00
+ * @Description: 一次性令牌仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/repository/dao"
+)
+
+type UserTokenRepository struct {
+	dao *dao.UserTokenDAO
+}
+
+func NewUserTokenRepository(dao *dao.UserTokenDAO) *UserTokenRepository {
+	return &UserTokenRepository{
+		dao: dao,
+	}
+}
+
+func (r *UserTokenRepository) Create(ctx context.Context, nonce string, userId int64, purpose string, expireAt time.Time) error {
+	return r.dao.Insert(ctx, dao.UserToken{
+		Nonce:    nonce,
+		UserId:   userId,
+		Purpose:  purpose,
+		ExpireAt: expireAt.UnixMilli(),
+	})
+}
+
+func (r *UserTokenRepository) Consume(ctx context.Context, nonce, purpose string) error {
+	return r.dao.Consume(ctx, nonce, purpose)
+}
+
+func (r *UserTokenRepository) InvalidateByUser(ctx context.Context, userId int64, purpose string) error {
+	return r.dao.InvalidateByUser(ctx, userId, purpose)
+}
@@ -0,0 +1,43 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 14:00:00
+ * @Description: 用户绑定的 WordPress 站点仓库
+ */
+package repository
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+type UserWordpressInfoRepository struct {
+	dao *dao.UserWordpressInfoDAO
+}
+
+func NewUserWordpressInfoRepository(dao *dao.UserWordpressInfoDAO) *UserWordpressInfoRepository {
+	return &UserWordpressInfoRepository{
+		dao: dao,
+	}
+}
+
+// FindByUid 查询用户绑定的站点和凭据，未绑定时返回 dao.ErrUserWordpressInfoNotFound
+func (r *UserWordpressInfoRepository) FindByUid(ctx context.Context, uid int64) (domain.UserWordpressInfo, error) {
+	info, err := r.dao.FindByUid(ctx, uid)
+	if err != nil {
+		return domain.UserWordpressInfo{}, err
+	}
+	return domain.UserWordpressInfo{
+		Id:       info.Id,
+		Uid:      info.Uid,
+		WPuname:  info.WPuname,
+		WPApiKey: info.WPApiKey,
+		Ctime:    time.UnixMilli(info.Ctime),
+		SiteInfo: domain.WordpressSite{
+			Id:  info.SiteWhiteList.Id,
+			Url: info.SiteWhiteList.WPSiteUrl,
+		},
+	}, nil
+}
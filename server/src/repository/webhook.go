@@ -0,0 +1,235 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: webhook 仓库，签名密钥加密保存
+ */
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/util"
+)
+
+type WebhookRepository struct {
+	dao    *dao.WebhookDAO
+	crypto *util.PasswordCrypto
+	// 更换密钥前使用的加密工具，只用于解密旧数据，可为 nil
+	legacy *util.PasswordCrypto
+}
+
+func NewWebhookRepository(dao *dao.WebhookDAO, crypto, legacy *util.PasswordCrypto) *WebhookRepository {
+	return &WebhookRepository{
+		dao:    dao,
+		crypto: crypto,
+		legacy: legacy,
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	secret, err := r.crypto.EncryptPassword(hook.Secret)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	created, err := r.dao.Insert(ctx, dao.Webhook{
+		Name:    hook.Name,
+		URL:     hook.URL,
+		Secret:  secret,
+		Events:  strings.Join(hook.Events, ","),
+		Enabled: hook.Enabled,
+	})
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	result := r.toDomain(created)
+	result.Secret = hook.Secret
+	return result, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, hook domain.Webhook) error {
+	return r.dao.Update(ctx, dao.Webhook{
+		Id:      hook.Id,
+		Name:    hook.Name,
+		URL:     hook.URL,
+		Events:  strings.Join(hook.Events, ","),
+		Enabled: hook.Enabled,
+	})
+}
+
+func (r *WebhookRepository) UpdateSecret(ctx context.Context, id int64, secret string) error {
+	encrypted, err := r.crypto.EncryptPassword(secret)
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdateSecret(ctx, id, encrypted)
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	return r.dao.Delete(ctx, id)
+}
+
+// FindById 返回的 webhook 不含密钥
+func (r *WebhookRepository) FindById(ctx context.Context, id int64) (domain.Webhook, error) {
+	hook, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	return r.toDomain(hook), nil
+}
+
+func (r *WebhookRepository) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	hooks, err := r.dao.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		results = append(results, r.toDomain(hook))
+	}
+	return results, nil
+}
+
+// FindEnabled 返回启用的 webhook
+func (r *WebhookRepository) FindEnabled(ctx context.Context) ([]domain.Webhook, error) {
+	hooks, err := r.dao.FindEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		results = append(results, r.toDomain(hook))
+	}
+	return results, nil
+}
+
+// FindSecret 解密签名密钥，只在投递时使用
+func (r *WebhookRepository) FindSecret(ctx context.Context, id int64) (string, error) {
+	hook, err := r.dao.FindById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	secret, stale, err := r.crypto.DecryptWithFallback(hook.Secret, r.legacy)
+	if err != nil {
+		return "", err
+	}
+	if stale {
+		// 旧密钥加密的数据读取时改用当前密钥重新加密，失败时下次读取再试
+		_ = r.UpdateSecret(ctx, id, secret)
+	}
+	return secret, nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	created, err := r.dao.InsertDelivery(ctx, r.toDeliveryEntity(delivery))
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return r.toDeliveryDomain(created), nil
+}
+
+func (r *WebhookRepository) FindDeliveryById(ctx context.Context, id int64) (domain.WebhookDelivery, error) {
+	delivery, err := r.dao.FindDeliveryById(ctx, id)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return r.toDeliveryDomain(delivery), nil
+}
+
+func (r *WebhookRepository) FindDeliveriesByWebhook(ctx context.Context, webhookId int64, offset, limit int) ([]domain.WebhookDelivery, int64, error) {
+	deliveries, total, err := r.dao.FindDeliveriesByWebhook(ctx, webhookId, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]domain.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		results = append(results, r.toDeliveryDomain(delivery))
+	}
+	return results, total, nil
+}
+
+func (r *WebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	deliveries, err := r.dao.FindDue(ctx, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]domain.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		results = append(results, r.toDeliveryDomain(delivery))
+	}
+	return results, nil
+}
+
+// ClaimDelivery 领取待投递记录，领取后 leaseUntil 之前其他实例不会重复投递
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, delivery domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	var expect int64
+	if delivery.NextRetryAt != nil {
+		expect = delivery.NextRetryAt.UnixMilli()
+	}
+	return r.dao.Claim(ctx, delivery.Id, expect, leaseUntil.UnixMilli())
+}
+
+func (r *WebhookRepository) UpdateDeliveryResult(ctx context.Context, delivery domain.WebhookDelivery) error {
+	return r.dao.UpdateResult(ctx, r.toDeliveryEntity(delivery))
+}
+
+func (r *WebhookRepository) toDomain(hook dao.Webhook) domain.Webhook {
+	events := []string{}
+	if hook.Events != "" {
+		events = strings.Split(hook.Events, ",")
+	}
+	return domain.Webhook{
+		Id:      hook.Id,
+		Name:    hook.Name,
+		URL:     hook.URL,
+		Events:  events,
+		Enabled: hook.Enabled,
+		Ctime:   time.UnixMilli(hook.Ctime),
+		Utime:   time.UnixMilli(hook.Utime),
+	}
+}
+
+func (r *WebhookRepository) toDeliveryEntity(delivery domain.WebhookDelivery) dao.WebhookDelivery {
+	var nextRetryAt int64
+	if delivery.NextRetryAt != nil {
+		nextRetryAt = delivery.NextRetryAt.UnixMilli()
+	}
+	return dao.WebhookDelivery{
+		Id:           delivery.Id,
+		WebhookId:    delivery.WebhookId,
+		EventId:      delivery.EventId,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		Error:        delivery.Error,
+		DurationMs:   delivery.DurationMs,
+		NextRetryAt:  nextRetryAt,
+	}
+}
+
+func (r *WebhookRepository) toDeliveryDomain(delivery dao.WebhookDelivery) domain.WebhookDelivery {
+	result := domain.WebhookDelivery{
+		Id:           delivery.Id,
+		WebhookId:    delivery.WebhookId,
+		EventId:      delivery.EventId,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		Error:        delivery.Error,
+		DurationMs:   delivery.DurationMs,
+		Ctime:        time.UnixMilli(delivery.Ctime),
+		Utime:        time.UnixMilli(delivery.Utime),
+	}
+	if delivery.NextRetryAt != 0 {
+		next := time.UnixMilli(delivery.NextRetryAt)
+		result.NextRetryAt = &next
+	}
+	return result
+}
@@ -15,6 +15,13 @@ import (
 	"strconv"
 )
 
+// WordPress 文章状态，WordPress 没有不公开状态，不公开的内容以私密状态转发
+const (
+	WpStatusPublish = "publish"
+	WpStatusPrivate = "private"
+	WpStatusDraft   = "draft"
+)
+
 type WpRequest struct{}
 
 func NewWpRequest() *WpRequest {
@@ -30,11 +37,12 @@ func (w *WpRequest) GetWpUserData(siteUrl string, uid int64) (*http.Response, er
 	return http.DefaultClient.Do(req)
 }
 
-func (w *WpRequest) TransferStatus(siteUrl string, uid int64, content string, userName string, apiKey string) (*http.Response, error) {
+// TransferStatus 转发动态，status 为 WpStatus* 之一
+func (w *WpRequest) TransferStatus(siteUrl string, uid int64, content string, userName string, apiKey string, status string) (*http.Response, error) {
 	url := siteUrl + "/wp-json/wp/v2/shuoshuo"
 	// 1. 准备JSON请求体
 	payload := map[string]interface{}{
-		"status": "publish",
+		"status": status,
 		"title": map[string]interface{}{
 			"raw": "",
 		},
@@ -65,11 +73,12 @@ func (w *WpRequest) TransferStatus(siteUrl string, uid int64, content string, us
 	return http.DefaultClient.Do(req)
 }
 
-func (w *WpRequest) TransferPosts(siteUrl string, uid int64, title string, content string, userName string, apiKey string) (*http.Response, error) {
+// TransferPosts 转发文章，status 为 WpStatus* 之一
+func (w *WpRequest) TransferPosts(siteUrl string, uid int64, title string, content string, userName string, apiKey string, status string) (*http.Response, error) {
 	url := siteUrl + "/wp-json/wp/v2/posts"
 	// 1. 准备JSON请求体
 	payload := map[string]interface{}{
-		"status": "publish",
+		"status": status,
 		"title": map[string]interface{}{
 			"raw": title,
 		},
@@ -0,0 +1,53 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 登录失败次数记录
+ */
+package security
+
+import (
+	"context"
+	"time"
+)
+
+// AttemptState 某个账户或IP的登录失败状态
+type AttemptState struct {
+	Failures    int       `json:"failures"`     // 当前窗口内连续失败次数
+	Lockouts    int       `json:"lockouts"`     // 累计锁定次数，用于计算指数退避
+	LockedUntil time.Time `json:"locked_until"` // 锁定截止时间
+}
+
+// Locked 判断当前是否处于锁定状态
+func (s AttemptState) Locked(now time.Time) bool {
+	return now.Before(s.LockedUntil)
+}
+
+// FailureRule 记录一次失败时使用的计数和锁定规则
+type FailureRule struct {
+	Threshold     int           // 连续失败多少次后锁定，0 表示不锁定
+	Window        time.Duration // 失败计数的有效期
+	BaseLock      time.Duration // 首次锁定时长
+	MaxLock       time.Duration // 最长锁定时长
+	LockoutMemory time.Duration // 锁定过后状态的保留时间，期间再次被锁定时锁定时长翻倍
+}
+
+// LockDuration 第 n 次锁定的时长：BaseLock * 2^(n-1)，不超过 MaxLock
+func (r FailureRule) LockDuration(lockouts int) time.Duration {
+	d := r.BaseLock
+	for i := 1; i < lockouts && d < r.MaxLock; i++ {
+		d *= 2
+	}
+	if r.MaxLock > 0 && d > r.MaxLock {
+		d = r.MaxLock
+	}
+	return d
+}
+
+// AttemptStore 登录失败状态存储，key 由调用方组合（如 account:1、ip:127.0.0.1）
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (AttemptState, error)
+	// RecordFailure 原子地把失败次数加一，达到阈值时锁定并清零失败次数，
+	// 返回更新后的状态以及本次是否触发了锁定，并发的失败请求不会丢失计数
+	RecordFailure(ctx context.Context, key string, rule FailureRule) (AttemptState, bool, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,92 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: HS256 JWT 访问令牌
+ */
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 预先编码的固定头部，解析时要求完全一致，避免 alg=none 等算法混淆攻击
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// AccessClaims 访问令牌载荷
+type AccessClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Id        string `json:"jti"`
+}
+
+func (c AccessClaims) UserId() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+type JWTSigner struct {
+	secret []byte
+}
+
+func NewJWTSigner(secret []byte) *JWTSigner {
+	return &JWTSigner{secret: secret}
+}
+
+// Sign 签发访问令牌
+func (s *JWTSigner) Sign(userId int64, ttl time.Duration) (string, AccessClaims, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", AccessClaims{}, err
+	}
+	now := time.Now()
+	claims := AccessClaims{
+		Subject:   strconv.FormatInt(userId, 10),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		Id:        hex.EncodeToString(jti),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", AccessClaims{}, err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(s.mac(signingInput)), claims, nil
+}
+
+// Parse 校验签名和有效期，返回载荷
+func (s *JWTSigner) Parse(token string) (AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return AccessClaims{}, ErrTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, s.mac(parts[0]+"."+parts[1])) {
+		return AccessClaims{}, ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return AccessClaims{}, ErrTokenInvalid
+	}
+	var claims AccessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return AccessClaims{}, ErrTokenInvalid
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return AccessClaims{}, ErrTokenExpired
+	}
+	return claims, nil
+}
+
+func (s *JWTSigner) mac(signingInput string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(signingInput))
+	return h.Sum(nil)
+}
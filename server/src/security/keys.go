@@ -0,0 +1,66 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 20:30:00
+ * @Description: 从配置的主密钥按用途派生签名密钥
+ */
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// MinSecretLength 主密钥的最小字节数
+const MinSecretLength = 32
+
+// 示例配置中的占位密钥，使用它签发的令牌任何人都能伪造
+const placeholderSecret = "your-jwt-secret-key"
+
+var (
+	ErrSecretMissing     = errors.New("未配置 security.jwt_secret")
+	ErrSecretPlaceholder = errors.New("security.jwt_secret 仍是示例配置中的占位值，请改为随机生成的密钥")
+	ErrSecretTooShort    = errors.New("security.jwt_secret 至少需要 32 字节，可用 openssl rand -hex 32 生成")
+)
+
+// 密钥用途，各用途使用独立派生的密钥，一种用途的签名不能被当作另一种使用
+const (
+	KeyPurposeJWT        = "jwt"
+	KeyPurposeUserToken  = "user-token"
+	KeyPurposeExportLink = "export-link"
+	KeyPurposeWebhook    = "webhook"
+	KeyPurposeTOTP       = "totp"
+)
+
+// HKDF 提取阶段使用的固定盐
+var keySalt = []byte("negaihoshi key derivation v1")
+
+type KeyRing struct {
+	// HKDF 提取得到的伪随机密钥
+	prk []byte
+}
+
+// NewKeyRing 校验主密钥：不能为空、不能是占位值、不能短于 MinSecretLength
+func NewKeyRing(secret string) (*KeyRing, error) {
+	switch {
+	case secret == "":
+		return nil, ErrSecretMissing
+	case secret == placeholderSecret:
+		return nil, ErrSecretPlaceholder
+	case len(secret) < MinSecretLength:
+		return nil, ErrSecretTooShort
+	}
+	return &KeyRing{prk: hmacSHA256(keySalt, []byte(secret))}, nil
+}
+
+// Derive 按 RFC 5869 (HKDF-SHA256) 派生 32 字节的用途密钥，purpose 作为 info
+func (k *KeyRing) Derive(purpose string) []byte {
+	// 输出长度等于一个哈希块，只需要 T(1) = HMAC(PRK, info || 0x01)
+	return hmacSHA256(k.prk, append([]byte(purpose), 0x01))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
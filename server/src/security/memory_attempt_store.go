@@ -0,0 +1,105 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 内存登录失败状态存储，适用于单实例部署
+ */
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 过期记录的清理周期
+const memoryAttemptSweepInterval = 5 * time.Minute
+
+type memoryAttempt struct {
+	state    AttemptState
+	expireAt time.Time
+}
+
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]memoryAttempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		attempts: make(map[string]memoryAttempt),
+	}
+}
+
+func (m *MemoryAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.get(key, time.Now()), nil
+}
+
+func (m *MemoryAttemptStore) RecordFailure(ctx context.Context, key string, rule FailureRule) (AttemptState, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	state := m.get(key, now)
+	state.Failures++
+	ttl := rule.Window
+	locked := rule.Threshold > 0 && state.Failures >= rule.Threshold
+	if locked {
+		state.Lockouts++
+		state.Failures = 0
+		state.LockedUntil = now.Add(rule.LockDuration(state.Lockouts))
+	}
+	if state.Lockouts > 0 {
+		ttl = rule.LockoutMemory
+	}
+	m.attempts[key] = memoryAttempt{state: state, expireAt: now.Add(ttl)}
+	return state, locked, nil
+}
+
+func (m *MemoryAttemptStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// Run 定期清理过期记录，直到 ctx 结束
+func (m *MemoryAttemptStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(memoryAttemptSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.sweep(now)
+		}
+	}
+}
+
+func (m *MemoryAttemptStore) sweep(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, attempt := range m.attempts {
+		if now.After(attempt.expireAt) {
+			delete(m.attempts, key)
+		}
+	}
+}
+
+// get 需要在持有锁时调用
+func (m *MemoryAttemptStore) get(key string, now time.Time) AttemptState {
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return AttemptState{}
+	}
+	if now.After(attempt.expireAt) {
+		delete(m.attempts, key)
+		return AttemptState{}
+	}
+	return attempt.state
+}
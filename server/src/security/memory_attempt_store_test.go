@@ -0,0 +1,106 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 11:30:00
+ * @Description: 登录失败计数和渐进式锁定的测试
+ */
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFailureRuleLockDuration(t *testing.T) {
+	rule := FailureRule{BaseLock: time.Minute, MaxLock: 10 * time.Minute}
+	tests := []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := rule.LockDuration(tt.lockouts); got != tt.want {
+			t.Errorf("第 %d 次锁定时长为 %s，期望 %s", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryAttemptStoreLockout(t *testing.T) {
+	ctx := context.Background()
+	rule := FailureRule{
+		Threshold:     3,
+		Window:        time.Minute,
+		BaseLock:      time.Minute,
+		MaxLock:       3 * time.Minute,
+		LockoutMemory: time.Hour,
+	}
+	store := NewMemoryAttemptStore()
+
+	// 每次失败后的期望状态：连续失败 3 次锁定并清零计数，锁定时长逐次翻倍且不超过 MaxLock
+	tests := []struct {
+		wantFailures int
+		wantLockouts int
+		wantLocked   bool
+		wantLock     time.Duration
+	}{
+		{1, 0, false, 0},
+		{2, 0, false, 0},
+		{0, 1, true, time.Minute},
+		{1, 1, false, 0},
+		{2, 1, false, 0},
+		{0, 2, true, 2 * time.Minute},
+		{1, 2, false, 0},
+		{2, 2, false, 0},
+		{0, 3, true, 3 * time.Minute},
+	}
+	for i, tt := range tests {
+		before := time.Now()
+		state, locked, err := store.RecordFailure(ctx, "account:1", rule)
+		after := time.Now()
+		if err != nil {
+			t.Fatalf("第 %d 次失败记录出错: %v", i+1, err)
+		}
+		if state.Failures != tt.wantFailures || state.Lockouts != tt.wantLockouts || locked != tt.wantLocked {
+			t.Fatalf("第 %d 次失败后 failures=%d lockouts=%d locked=%t，期望 failures=%d lockouts=%d locked=%t",
+				i+1, state.Failures, state.Lockouts, locked, tt.wantFailures, tt.wantLockouts, tt.wantLocked)
+		}
+		if locked && (state.LockedUntil.Before(before.Add(tt.wantLock)) || state.LockedUntil.After(after.Add(tt.wantLock))) {
+			t.Fatalf("第 %d 次失败后锁定至 %s，期望锁定 %s", i+1, state.LockedUntil, tt.wantLock)
+		}
+		if locked && !state.Locked(after) {
+			t.Fatalf("第 %d 次失败后应处于锁定状态", i+1)
+		}
+	}
+
+	got, err := store.Get(ctx, "account:1")
+	if err != nil || got.Lockouts != 3 {
+		t.Fatalf("读取到的状态为 %+v，错误 %v", got, err)
+	}
+	if got, _ := store.Get(ctx, "account:2"); got != (AttemptState{}) {
+		t.Fatalf("其他账户不应受影响，得到 %+v", got)
+	}
+	if err := store.Delete(ctx, "account:1"); err != nil {
+		t.Fatalf("删除失败记录出错: %v", err)
+	}
+	if got, _ := store.Get(ctx, "account:1"); got != (AttemptState{}) {
+		t.Fatalf("删除后应无失败记录，得到 %+v", got)
+	}
+}
+
+func TestMemoryAttemptStoreExpire(t *testing.T) {
+	store := NewMemoryAttemptStore()
+	rule := FailureRule{Threshold: 3, Window: time.Minute, LockoutMemory: time.Hour}
+	if _, _, err := store.RecordFailure(context.Background(), "ip:127.0.0.1", rule); err != nil {
+		t.Fatalf("记录失败出错: %v", err)
+	}
+	store.sweep(time.Now().Add(2 * time.Minute))
+	if got, _ := store.Get(context.Background(), "ip:127.0.0.1"); got != (AttemptState{}) {
+		t.Fatalf("超过计数有效期后应清除，得到 %+v", got)
+	}
+}
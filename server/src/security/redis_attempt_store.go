@@ -0,0 +1,116 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: Redis登录失败状态存储，多实例部署时共享
+ */
+package security
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recordFailureScript 在 Redis 中原子地完成失败计数和锁定
+// KEYS[1] 状态key
+// ARGV[1] 锁定阈值 ARGV[2] 当前毫秒时间戳 ARGV[3] 首次锁定毫秒数 ARGV[4] 最长锁定毫秒数
+// ARGV[5] 失败计数有效期毫秒数 ARGV[6] 锁定后状态保留毫秒数
+// 返回 {失败次数, 锁定次数, 锁定截止毫秒时间戳, 本次是否锁定}
+var recordFailureScript = redis.NewScript(`
+local threshold = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local base = tonumber(ARGV[3])
+local max = tonumber(ARGV[4])
+
+local failures = redis.call("HINCRBY", KEYS[1], "failures", 1)
+local state = redis.call("HMGET", KEYS[1], "lockouts", "locked_until")
+local lockouts = tonumber(state[1]) or 0
+local lockedUntil = tonumber(state[2]) or 0
+
+local locked = 0
+if threshold > 0 and failures >= threshold then
+	lockouts = lockouts + 1
+	failures = 0
+	local d = base
+	local i = 1
+	while i < lockouts and d < max do
+		d = d * 2
+		i = i + 1
+	end
+	if max > 0 and d > max then
+		d = max
+	end
+	lockedUntil = now + d
+	locked = 1
+	redis.call("HSET", KEYS[1], "failures", 0, "lockouts", lockouts, "locked_until", lockedUntil)
+end
+
+if lockouts > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[6])
+else
+	redis.call("PEXPIRE", KEYS[1], ARGV[5])
+end
+
+return {failures, lockouts, lockedUntil, locked}
+`)
+
+type RedisAttemptStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisAttemptStore(client redis.UniversalClient) *RedisAttemptStore {
+	return &RedisAttemptStore{
+		client: client,
+		prefix: "negaihoshi:login_failures:",
+	}
+}
+
+func (r *RedisAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	values, err := r.client.HMGet(ctx, r.prefix+key, "failures", "lockouts", "locked_until").Result()
+	if err != nil {
+		return AttemptState{}, err
+	}
+	return AttemptState{
+		Failures:    int(parseInt(values[0])),
+		Lockouts:    int(parseInt(values[1])),
+		LockedUntil: unixMilli(parseInt(values[2])),
+	}, nil
+}
+
+func (r *RedisAttemptStore) RecordFailure(ctx context.Context, key string, rule FailureRule) (AttemptState, bool, error) {
+	values, err := recordFailureScript.Run(ctx, r.client, []string{r.prefix + key},
+		rule.Threshold, time.Now().UnixMilli(), rule.BaseLock.Milliseconds(), rule.MaxLock.Milliseconds(),
+		rule.Window.Milliseconds(), rule.LockoutMemory.Milliseconds()).Int64Slice()
+	if err != nil {
+		return AttemptState{}, false, err
+	}
+	return AttemptState{
+		Failures:    int(values[0]),
+		Lockouts:    int(values[1]),
+		LockedUntil: unixMilli(values[2]),
+	}, values[3] == 1, nil
+}
+
+func (r *RedisAttemptStore) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+// parseInt 解析 HMGET 返回的字段，字段不存在时为 0
+func parseInt(value interface{}) int64 {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func unixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
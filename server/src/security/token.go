@@ -0,0 +1,107 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 13:00:00
+ * @Description: 带签名和有效期的一次性令牌，用于邮箱验证、找回密码等场景
+ */
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenInvalid = errors.New("令牌无效")
+	ErrTokenExpired = errors.New("令牌已过期")
+)
+
+// TokenClaims 令牌携带的信息，Nonce 用于在存储中标记令牌是否已被使用
+type TokenClaims struct {
+	Purpose  string
+	UserId   int64
+	Nonce    string
+	ExpireAt time.Time
+}
+
+type TokenSigner struct {
+	secret []byte
+}
+
+func NewTokenSigner(secret []byte) *TokenSigner {
+	return &TokenSigner{secret: secret}
+}
+
+// Sign 生成令牌：base64url(purpose|uid|expire|nonce).base64url(hmac)
+func (s *TokenSigner) Sign(purpose string, userId int64, ttl time.Duration) (string, TokenClaims, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", TokenClaims{}, err
+	}
+	claims := TokenClaims{
+		Purpose:  purpose,
+		UserId:   userId,
+		Nonce:    hex.EncodeToString(nonce),
+		ExpireAt: time.Now().Add(ttl),
+	}
+	payload := strings.Join([]string{
+		claims.Purpose,
+		strconv.FormatInt(claims.UserId, 10),
+		strconv.FormatInt(claims.ExpireAt.Unix(), 10),
+		claims.Nonce,
+	}, "|")
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac([]byte(payload)))
+	return token, claims, nil
+}
+
+// Parse 校验签名、用途和有效期
+func (s *TokenSigner) Parse(token, purpose string) (TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, s.mac(payload)) {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 4 || fields[0] != purpose {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+	userId, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+	expire, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return TokenClaims{}, ErrTokenInvalid
+	}
+	claims := TokenClaims{
+		Purpose:  fields[0],
+		UserId:   userId,
+		ExpireAt: time.Unix(expire, 0),
+		Nonce:    fields[3],
+	}
+	if time.Now().After(claims.ExpireAt) {
+		return claims, ErrTokenExpired
+	}
+	return claims, nil
+}
+
+func (s *TokenSigner) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
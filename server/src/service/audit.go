@@ -0,0 +1,59 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 安全审计日志服务
+ */
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+)
+
+// 需要同时提醒用户本人的审计事件
+var auditNotifyTitles = map[string]string{
+	domain.AuditActionAccountLocked:  "账户因多次登录失败被暂时锁定",
+	domain.AuditActionTokenReuse:     "检测到异常的登录凭证使用",
+	domain.AuditActionIdentityLink:   "已绑定新的第三方登录方式",
+	domain.AuditActionIdentityUnlink: "已解绑第三方登录方式",
+}
+
+type AuditService struct {
+	repo          *repository.AuditLogRepository
+	notifications *NotificationService
+}
+
+func NewAuditService(repo *repository.AuditLogRepository, notifications *NotificationService) *AuditService {
+	return &AuditService{repo: repo, notifications: notifications}
+}
+
+// Record 记录审计事件，写入失败只打日志，不影响业务流程
+func (s *AuditService) Record(ctx context.Context, userId int64, action, ip, message string) {
+	err := s.repo.Create(ctx, domain.AuditLog{
+		UserId:  userId,
+		Action:  action,
+		Ip:      ip,
+		Message: message,
+	})
+	if err != nil {
+		log.Printf("写入审计日志失败: %v", err)
+	}
+	if title, ok := auditNotifyTitles[action]; ok {
+		s.notifications.Security(ctx, userId, title, fmt.Sprintf("%s（IP：%s）。如果不是您本人操作，请尽快修改密码。", message, ip))
+	}
+}
+
+// 获取审计日志（管理后台）
+func (s *AuditService) GetAuditLogsForAdmin(ctx context.Context, page, size int, action string) ([]domain.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+	return s.repo.GetList(ctx, action, (page-1)*size, size)
+}
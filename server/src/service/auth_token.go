@@ -0,0 +1,174 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: JWT 访问令牌和可轮换的刷新令牌，供 API 和移动端使用
+ */
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/security"
+)
+
+var (
+	ErrAccessTokenInvalid  = errors.New("访问令牌无效")
+	ErrAccessTokenExpired  = errors.New("访问令牌已过期")
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效")
+	ErrRefreshTokenExpired = errors.New("刷新令牌已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，相关登录已全部失效")
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type AuthTokenService struct {
+	signer     *security.JWTSigner
+	repo       *repository.RefreshTokenRepository
+	audit      *AuditService
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthTokenService(signer *security.JWTSigner, repo *repository.RefreshTokenRepository, audit *AuditService, accessTTL, refreshTTL time.Duration) *AuthTokenService {
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	return &AuthTokenService{
+		signer:     signer,
+		repo:       repo,
+		audit:      audit,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Issue 登录成功后签发新的令牌对，开启一个新的刷新令牌家族
+func (s *AuthTokenService) Issue(ctx context.Context, userId int64) (domain.TokenPair, error) {
+	familyId, err := randomToken(16)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	return s.issue(ctx, userId, familyId, 0)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌立即失效；
+// 已使用过的刷新令牌再次出现说明可能被盗用，整个家族作废
+func (s *AuthTokenService) Refresh(ctx context.Context, refreshToken, ip string) (domain.TokenPair, error) {
+	token, err := s.repo.FindByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, dao.ErrRefreshTokenNotFound) {
+		return domain.TokenPair{}, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	if token.Revoked {
+		return domain.TokenPair{}, ErrRefreshTokenInvalid
+	}
+	if token.Used {
+		return domain.TokenPair{}, s.handleReuse(ctx, token, ip)
+	}
+	if time.Now().After(token.ExpireAt) {
+		return domain.TokenPair{}, ErrRefreshTokenExpired
+	}
+
+	err = s.repo.MarkUsed(ctx, token.Id)
+	if errors.Is(err, dao.ErrRefreshTokenUsed) {
+		return domain.TokenPair{}, s.handleReuse(ctx, token, ip)
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	return s.issue(ctx, token.UserId, token.FamilyId, token.Id)
+}
+
+// Revoke 注销刷新令牌所在家族，用于客户端退出登录
+func (s *AuthTokenService) Revoke(ctx context.Context, refreshToken string) error {
+	token, err := s.repo.FindByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, dao.ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeFamily(ctx, token.FamilyId)
+}
+
+// RevokeAll 注销用户的全部刷新令牌，已签发的访问令牌在过期前仍然有效
+func (s *AuthTokenService) RevokeAll(ctx context.Context, userId int64) error {
+	return s.repo.RevokeByUser(ctx, userId)
+}
+
+// VerifyAccessToken 校验访问令牌，返回请求主体
+func (s *AuthTokenService) VerifyAccessToken(ctx context.Context, accessToken string) (domain.Principal, error) {
+	claims, err := s.signer.Parse(accessToken)
+	if errors.Is(err, security.ErrTokenExpired) {
+		return domain.Principal{}, ErrAccessTokenExpired
+	}
+	if err != nil {
+		return domain.Principal{}, ErrAccessTokenInvalid
+	}
+	userId, err := claims.UserId()
+	if err != nil {
+		return domain.Principal{}, ErrAccessTokenInvalid
+	}
+	return domain.Principal{
+		UserId: userId,
+		Method: domain.AuthMethodBearer,
+	}, nil
+}
+
+func (s *AuthTokenService) issue(ctx context.Context, userId int64, familyId string, parentId int64) (domain.TokenPair, error) {
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	if err := s.repo.Create(ctx, hashToken(refreshToken), userId, familyId, parentId, time.Now().Add(s.refreshTTL)); err != nil {
+		return domain.TokenPair{}, err
+	}
+	accessToken, _, err := s.signer.Sign(userId, s.accessTTL)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	return domain.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *AuthTokenService) handleReuse(ctx context.Context, token domain.RefreshToken, ip string) error {
+	if err := s.repo.RevokeFamily(ctx, token.FamilyId); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, token.UserId, domain.AuditActionTokenReuse, ip, "刷新令牌被重复使用，已注销该令牌家族")
+	return ErrRefreshTokenReused
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
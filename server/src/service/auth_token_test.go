@@ -0,0 +1,142 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 12:00:00
+ * @Description: 刷新令牌轮换和重复使用检测的测试
+ */
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/repository/repotest"
+	"negaihoshi/server/src/security"
+	"negaihoshi/server/src/service"
+)
+
+func newAuthTokenService(t *testing.T, refreshTTL time.Duration) *service.AuthTokenService {
+	t.Helper()
+	db := repotest.OpenSQLite(t)
+	notifications := service.NewNotificationService(repository.NewNotificationRepository(dao.NewNotificationDAO(db)))
+	audit := service.NewAuditService(repository.NewAuditLogRepository(dao.NewAuditLogDAO(db)), notifications)
+	keys, err := security.NewKeyRing("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	return service.NewAuthTokenService(security.NewJWTSigner(keys.Derive(security.KeyPurposeJWT)),
+		repository.NewRefreshTokenRepository(dao.NewRefreshTokenDAO(db)), audit, time.Minute, refreshTTL)
+}
+
+func TestAuthTokenRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// run 执行令牌操作，返回最后一次刷新的结果
+		run  func(t *testing.T, s *service.AuthTokenService) error
+		want error
+	}{
+		{"Rotate", func(t *testing.T, s *service.AuthTokenService) error {
+			pair := issueTokens(t, s, 1)
+			next, err := s.Refresh(ctx, pair.RefreshToken, "127.0.0.1")
+			if err != nil {
+				return err
+			}
+			if next.RefreshToken == pair.RefreshToken {
+				t.Fatal("刷新后应签发新的刷新令牌")
+			}
+			principal, err := s.VerifyAccessToken(ctx, next.AccessToken)
+			if err != nil || principal.UserId != 1 {
+				t.Fatalf("新的访问令牌校验结果为 %+v，错误 %v", principal, err)
+			}
+			// 轮换得到的令牌可以继续刷新
+			_, err = s.Refresh(ctx, next.RefreshToken, "127.0.0.1")
+			return err
+		}, nil},
+		{"ReuseRevokesFamily", func(t *testing.T, s *service.AuthTokenService) error {
+			pair := issueTokens(t, s, 1)
+			next, err := s.Refresh(ctx, pair.RefreshToken, "127.0.0.1")
+			if err != nil {
+				t.Fatalf("刷新失败: %v", err)
+			}
+			if _, err := s.Refresh(ctx, pair.RefreshToken, "10.0.0.1"); !errors.Is(err, service.ErrRefreshTokenReused) {
+				t.Fatalf("重复使用刷新令牌应返回 ErrRefreshTokenReused，得到 %v", err)
+			}
+			// 同一家族中轮换得到的令牌随之作废
+			_, err = s.Refresh(ctx, next.RefreshToken, "127.0.0.1")
+			return err
+		}, service.ErrRefreshTokenInvalid},
+		{"ReuseKeepsOtherFamilies", func(t *testing.T, s *service.AuthTokenService) error {
+			stolen := issueTokens(t, s, 1)
+			other := issueTokens(t, s, 1)
+			if _, err := s.Refresh(ctx, stolen.RefreshToken, "127.0.0.1"); err != nil {
+				t.Fatalf("刷新失败: %v", err)
+			}
+			if _, err := s.Refresh(ctx, stolen.RefreshToken, "10.0.0.1"); !errors.Is(err, service.ErrRefreshTokenReused) {
+				t.Fatalf("重复使用刷新令牌应返回 ErrRefreshTokenReused，得到 %v", err)
+			}
+			_, err := s.Refresh(ctx, other.RefreshToken, "127.0.0.1")
+			return err
+		}, nil},
+		{"Unknown", func(t *testing.T, s *service.AuthTokenService) error {
+			_, err := s.Refresh(ctx, "not-a-refresh-token", "127.0.0.1")
+			return err
+		}, service.ErrRefreshTokenInvalid},
+		{"Revoked", func(t *testing.T, s *service.AuthTokenService) error {
+			pair := issueTokens(t, s, 1)
+			if err := s.Revoke(ctx, pair.RefreshToken); err != nil {
+				t.Fatalf("注销失败: %v", err)
+			}
+			_, err := s.Refresh(ctx, pair.RefreshToken, "127.0.0.1")
+			return err
+		}, service.ErrRefreshTokenInvalid},
+		{"RevokeAll", func(t *testing.T, s *service.AuthTokenService) error {
+			issueTokens(t, s, 1)
+			pair := issueTokens(t, s, 1)
+			if err := s.RevokeAll(ctx, 1); err != nil {
+				t.Fatalf("注销失败: %v", err)
+			}
+			_, err := s.Refresh(ctx, pair.RefreshToken, "127.0.0.1")
+			return err
+		}, service.ErrRefreshTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAuthTokenService(t, time.Hour)
+			if err := tt.run(t, s); !errors.Is(err, tt.want) {
+				t.Fatalf("刷新返回 %v，期望 %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Expired", func(t *testing.T) {
+		s := newAuthTokenService(t, time.Millisecond)
+		pair := issueTokens(t, s, 1)
+		time.Sleep(5 * time.Millisecond)
+		if _, err := s.Refresh(ctx, pair.RefreshToken, "127.0.0.1"); !errors.Is(err, service.ErrRefreshTokenExpired) {
+			t.Fatalf("过期的刷新令牌应返回 ErrRefreshTokenExpired，得到 %v", err)
+		}
+	})
+
+	t.Run("TamperedAccessToken", func(t *testing.T) {
+		s := newAuthTokenService(t, time.Hour)
+		pair := issueTokens(t, s, 1)
+		if _, err := s.VerifyAccessToken(ctx, pair.AccessToken+"x"); !errors.Is(err, service.ErrAccessTokenInvalid) {
+			t.Fatalf("被篡改的访问令牌应返回 ErrAccessTokenInvalid，得到 %v", err)
+		}
+	})
+}
+
+func issueTokens(t *testing.T, s *service.AuthTokenService, userId int64) domain.TokenPair {
+	t.Helper()
+	pair, err := s.Issue(context.Background(), userId)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return pair
+}
@@ -0,0 +1,129 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户屏蔽和静音
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/realtime"
+	"negaihoshi/server/src/repository"
+)
+
+var (
+	ErrBlockSelf = errors.New("不能屏蔽自己")
+	ErrMuteSelf  = errors.New("不能静音自己")
+)
+
+type BlockService struct {
+	repo     *repository.UserBlockRepository
+	userRepo repository.UserRepository
+	follows  *repository.FollowRepository
+	timeline *TimelineService
+	// 通知正在连接实时推送的用户重新加载屏蔽和静音列表
+	hub realtime.Hub
+}
+
+func NewBlockService(repo *repository.UserBlockRepository, userRepo repository.UserRepository, follows *repository.FollowRepository, timeline *TimelineService, hub realtime.Hub) *BlockService {
+	return &BlockService{
+		repo:     repo,
+		userRepo: userRepo,
+		follows:  follows,
+		timeline: timeline,
+		hub:      hub,
+	}
+}
+
+// Block 屏蔽用户，同时解除双方之间的关注关系
+func (s *BlockService) Block(ctx context.Context, userId, blockedId int64) error {
+	if userId == blockedId {
+		return ErrBlockSelf
+	}
+	if _, err := s.userRepo.FindById(ctx, blockedId); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.repo.Create(ctx, userId, blockedId); err != nil {
+		return err
+	}
+	for _, pair := range [][2]int64{{userId, blockedId}, {blockedId, userId}} {
+		deleted, err := s.follows.Delete(ctx, pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		if deleted {
+			s.timeline.Invalidate(ctx, pair[0])
+		}
+	}
+	s.notifyChanged(ctx, userId, blockedId)
+	return nil
+}
+
+func (s *BlockService) Unblock(ctx context.Context, userId, blockedId int64) error {
+	if err := s.repo.Delete(ctx, userId, blockedId); err != nil {
+		return err
+	}
+	s.notifyChanged(ctx, userId, blockedId)
+	return nil
+}
+
+// notifyChanged 通知相关用户的实时推送连接重新加载隐藏的作者，失败只记录日志
+func (s *BlockService) notifyChanged(ctx context.Context, userIds ...int64) {
+	for _, id := range userIds {
+		if err := s.hub.Publish(ctx, RealtimeVisibilityTopic(id), eventVisibilityChanged, struct{}{}); err != nil {
+			log.Printf("发布屏蔽关系变化通知失败: %v", err)
+		}
+	}
+}
+
+func (s *BlockService) List(ctx context.Context, userId int64, page, size int) ([]domain.UserBlock, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return s.repo.GetList(ctx, userId, (page-1)*size, size)
+}
+
+// IsBlocked 任意一方屏蔽了另一方时返回 true
+func (s *BlockService) IsBlocked(ctx context.Context, a, b int64) (bool, error) {
+	return s.repo.ExistsEither(ctx, a, b)
+}
+
+// Mute 静音用户，对方的内容不再出现在自己的信息流中，对方不会察觉
+func (s *BlockService) Mute(ctx context.Context, userId, mutedId int64) error {
+	if userId == mutedId {
+		return ErrMuteSelf
+	}
+	if _, err := s.userRepo.FindById(ctx, mutedId); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.repo.CreateMute(ctx, userId, mutedId); err != nil {
+		return err
+	}
+	s.notifyChanged(ctx, userId)
+	return nil
+}
+
+func (s *BlockService) Unmute(ctx context.Context, userId, mutedId int64) error {
+	if err := s.repo.DeleteMute(ctx, userId, mutedId); err != nil {
+		return err
+	}
+	s.notifyChanged(ctx, userId)
+	return nil
+}
+
+func (s *BlockService) ListMutes(ctx context.Context, userId int64, page, size int) ([]domain.UserMute, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return s.repo.GetMuteList(ctx, userId, (page-1)*size, size)
+}
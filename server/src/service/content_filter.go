@@ -0,0 +1,58 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 发布内容的屏蔽词过滤，树洞和私信共用
+ */
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrContentBlocked = errors.New("内容包含不允许发布的词语")
+
+type ContentFilter struct {
+	words []string
+}
+
+// NewContentFilter words 为空时不做过滤
+func NewContentFilter(words []string) *ContentFilter {
+	f := &ContentFilter{}
+	for _, word := range words {
+		if w := normalizeContent(word); w != "" {
+			f.words = append(f.words, w)
+		}
+	}
+	return f
+}
+
+// Check 内容包含屏蔽词时返回 ErrContentBlocked，忽略大小写、空白和零宽字符，避免用空格拆开屏蔽词绕过
+func (f *ContentFilter) Check(content string) error {
+	if len(f.words) == 0 {
+		return nil
+	}
+	normalized := normalizeContent(content)
+	for _, word := range f.words {
+		if strings.Contains(normalized, word) {
+			return ErrContentBlocked
+		}
+	}
+	return nil
+}
+
+func normalizeContent(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		// 全角字母数字转半角
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
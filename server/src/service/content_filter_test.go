@@ -0,0 +1,49 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 12:30:00
+ * @Description: 屏蔽词过滤的测试
+ */
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContentFilter(t *testing.T) {
+	filter := NewContentFilter([]string{"Spam", "坏 词", "  ", ""})
+
+	tests := []struct {
+		name    string
+		content string
+		want    error
+	}{
+		{"Clean", "今天天气不错", nil},
+		{"Exact", "this is spam", ErrContentBlocked},
+		{"UpperCase", "SPAM here", ErrContentBlocked},
+		{"SplitBySpaces", "s p a m", ErrContentBlocked},
+		{"SplitByNewline", "sp\nam", ErrContentBlocked},
+		{"ZeroWidth", "sp\u200bam", ErrContentBlocked},
+		{"FullWidth", "ｓｐａｍ", ErrContentBlocked},
+		{"ChineseWithSpaceInWord", "这是坏词", ErrContentBlocked},
+		{"ChineseSplit", "坏 　词", ErrContentBlocked},
+		{"Partial", "spa m", ErrContentBlocked},
+		{"NotContained", "s-p-a-m", nil},
+		{"Empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := filter.Check(tt.content); !errors.Is(err, tt.want) {
+				t.Fatalf("Check(%q) = %v，期望 %v", tt.content, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentFilterEmpty(t *testing.T) {
+	for _, words := range [][]string{nil, {}, {" ", "\u200b"}} {
+		if err := NewContentFilter(words).Check("spam"); err != nil {
+			t.Fatalf("屏蔽词为 %q 时不应过滤，得到 %v", words, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 14:00:00
+ * @Description: 转发树洞、动态和文章到作者绑定的 WordPress 站点
+ */
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/metrics"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/request"
+)
+
+var (
+	ErrWordPressNotBound = errors.New("未绑定该 WordPress 站点")
+	ErrPostNotPublished  = errors.New("草稿和定时发布的文章不能转发")
+)
+
+// WordPress 返回内容的最大读取长度
+const maxWordPressResponse = 1 << 20
+
+type WordPressCrossPoster struct {
+	treeholes     repository.TreeHoleRepository
+	content       repository.StatusAndPostsRepository
+	bindings      *repository.UserWordpressInfoRepository
+	client        *request.WpRequest
+	notifications *NotificationService
+	// 记录转发结果，可以为 nil
+	metrics *metrics.Metrics
+}
+
+func NewWordPressCrossPoster(treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, bindings *repository.UserWordpressInfoRepository, client *request.WpRequest, notifications *NotificationService, m *metrics.Metrics) *WordPressCrossPoster {
+	return &WordPressCrossPoster{
+		treeholes:     treeholes,
+		content:       content,
+		bindings:      bindings,
+		client:        client,
+		notifications: notifications,
+		metrics:       m,
+	}
+}
+
+// WordPressStatus 文章状态对应的 WordPress 状态，私密和不公开的文章在 WordPress 上都是私密的
+func WordPressStatus(state string) string {
+	switch state {
+	case domain.PostStatePrivate, domain.PostStateUnlisted:
+		return request.WpStatusPrivate
+	case domain.PostStateDraft, domain.PostStateScheduled:
+		return request.WpStatusDraft
+	default:
+		return request.WpStatusPublish
+	}
+}
+
+// Transfer 作者把自己的内容转发到绑定的站点，asPrivate 时以私密状态发布，
+// title 不为空时替换文章标题，失败的站点会通知作者
+func (w *WordPressCrossPoster) Transfer(ctx context.Context, userId int64, contentType string, contentId int64, siteIds []int64, title string, asPrivate bool) ([]domain.WordPressTransferResult, error) {
+	var send func(binding domain.UserWordpressInfo, wpStatus string) (*http.Response, error)
+	status := request.WpStatusPublish
+	switch contentType {
+	case domain.ReportTargetTreeHole:
+		th, err := w.treeholes.GetById(ctx, contentId)
+		if err != nil {
+			return nil, crossPostError(err)
+		}
+		if th.UserId != userId {
+			return nil, ErrContentNotOwner
+		}
+		send = func(b domain.UserWordpressInfo, wpStatus string) (*http.Response, error) {
+			return w.client.TransferStatus(b.SiteInfo.Url, userId, th.Content, b.WPuname, b.WPApiKey, wpStatus)
+		}
+	case domain.ReportTargetStatus:
+		st, err := w.content.GetStatus(ctx, contentId)
+		if err != nil {
+			return nil, crossPostError(err)
+		}
+		if st.UserId != userId {
+			return nil, ErrContentNotOwner
+		}
+		send = func(b domain.UserWordpressInfo, wpStatus string) (*http.Response, error) {
+			return w.client.TransferStatus(b.SiteInfo.Url, userId, st.Content, b.WPuname, b.WPApiKey, wpStatus)
+		}
+	case domain.ReportTargetPost:
+		p, err := w.content.GetPosts(ctx, contentId)
+		if err != nil {
+			return nil, crossPostError(err)
+		}
+		if p.UserId != userId {
+			return nil, ErrContentNotOwner
+		}
+		if p.State == domain.PostStateDraft || p.State == domain.PostStateScheduled {
+			return nil, ErrPostNotPublished
+		}
+		if title == "" {
+			title = p.Title
+		}
+		status = WordPressStatus(p.State)
+		send = func(b domain.UserWordpressInfo, wpStatus string) (*http.Response, error) {
+			return w.client.TransferPosts(b.SiteInfo.Url, userId, title, p.Content, b.WPuname, b.WPApiKey, wpStatus)
+		}
+	default:
+		return nil, ErrContentTypeInvalid
+	}
+	if asPrivate {
+		status = request.WpStatusPrivate
+	}
+
+	binding, err := w.bindings.FindByUid(ctx, userId)
+	if err != nil && !errors.Is(err, dao.ErrUserWordpressInfoNotFound) {
+		return nil, err
+	}
+	results := make([]domain.WordPressTransferResult, 0, len(siteIds))
+	for _, siteId := range siteIds {
+		result := domain.WordPressTransferResult{SiteId: siteId}
+		if err != nil || binding.SiteInfo.Id != siteId {
+			result.Error = ErrWordPressNotBound.Error()
+			w.metrics.WordPressDelivery(metrics.WordPressTransfer, metrics.WordPressNotBound)
+		} else if id, link, terr := readWordPressResponse(send(binding, status)); terr != nil {
+			result.Error = terr.Error()
+			w.metrics.WordPressDelivery(metrics.WordPressTransfer, metrics.WordPressFailure)
+		} else {
+			result.Success, result.WPPostId, result.WPPostURL = true, id, link
+			w.metrics.WordPressDelivery(metrics.WordPressTransfer, metrics.WordPressSuccess)
+		}
+		if !result.Success {
+			w.notifications.TransferFailed(ctx, userId, contentType, contentId, fmt.Sprintf("站点 %d", siteId), result.Error)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CrossPostPosts 文章发布时转发到作者绑定的站点，失败时通知作者
+func (w *WordPressCrossPoster) CrossPostPosts(ctx context.Context, posts domain.Posts) {
+	binding, err := w.bindings.FindByUid(ctx, posts.UserId)
+	if errors.Is(err, dao.ErrUserWordpressInfoNotFound) {
+		w.metrics.WordPressDelivery(metrics.WordPressCrossPost, metrics.WordPressNotBound)
+		w.notifications.TransferFailed(ctx, posts.UserId, domain.ReportTargetPost, posts.Id, "WordPress", "未绑定 WordPress 站点")
+		return
+	}
+	if err != nil {
+		w.metrics.WordPressDelivery(metrics.WordPressCrossPost, metrics.WordPressFailure)
+		log.Printf("查询用户 %d 绑定的 WordPress 站点失败: %v", posts.UserId, err)
+		return
+	}
+	_, _, err = readWordPressResponse(w.client.TransferPosts(binding.SiteInfo.Url, posts.UserId, posts.Title, posts.Content,
+		binding.WPuname, binding.WPApiKey, WordPressStatus(posts.State)))
+	if err != nil {
+		w.metrics.WordPressDelivery(metrics.WordPressCrossPost, metrics.WordPressFailure)
+		w.notifications.TransferFailed(ctx, posts.UserId, domain.ReportTargetPost, posts.Id, binding.SiteInfo.Url, err.Error())
+		return
+	}
+	w.metrics.WordPressDelivery(metrics.WordPressCrossPost, metrics.WordPressSuccess)
+}
+
+// readWordPressResponse 读取 WordPress 创建内容后返回的 id 和链接
+func readWordPressResponse(resp *http.Response, err error) (int64, string, error) {
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return 0, "", fmt.Errorf("WordPress 返回状态码 %d", resp.StatusCode)
+	}
+	var created struct {
+		Id   int64  `json:"id"`
+		Link string `json:"link"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxWordPressResponse)).Decode(&created); err != nil {
+		return 0, "", fmt.Errorf("解析 WordPress 返回内容失败: %w", err)
+	}
+	return created.Id, created.Link, nil
+}
+
+func crossPostError(err error) error {
+	if errors.Is(err, dao.ErrTreeHoleNotFound) || errors.Is(err, dao.ErrStatusNotFound) || errors.Is(err, dao.ErrPostsNotFound) {
+		return ErrContentNotFound
+	}
+	return err
+}
@@ -0,0 +1,540 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 15:00:00
+ * @Description: 用户数据导出：后台生成包含全部个人数据的 ZIP 文件，通过带签名的链接下载
+ */
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/security"
+)
+
+var (
+	ErrExportTooFrequent = errors.New("每天只能导出一次数据")
+	ErrExportNotFound    = errors.New("导出任务不存在")
+	ErrExportNotReady    = errors.New("导出文件尚未生成")
+	ErrExportExpired     = errors.New("下载链接已过期")
+	ErrExportLinkInvalid = errors.New("下载链接无效")
+)
+
+const (
+	exportInterval       = 30 * time.Second
+	exportBatchSize      = 10
+	exportListSize       = 10
+	exportTreeHolePage   = 500
+	defaultExportLinkTTL = 72 * time.Hour
+	defaultExportDir     = "data/exports"
+	// 执行超过该时间仍未结束的任务视为实例已退出，重新放回队列
+	exportStaleAfter   = 30 * time.Minute
+	exportTokenPurpose = "data_export:"
+)
+
+type DataExportService struct {
+	repo          *repository.DataExportRepository
+	users         repository.UserRepository
+	treeholes     repository.TreeHoleRepository
+	content       repository.StatusAndPostsRepository
+	bindings      *repository.UserWordpressInfoRepository
+	signer        *security.TokenSigner
+	notifications *NotificationService
+	dir           string
+	linkTTL       time.Duration
+	// 有新任务时唤醒后台任务，不必等到下一次定时执行
+	wake chan struct{}
+}
+
+func NewDataExportService(repo *repository.DataExportRepository, users repository.UserRepository, treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, bindings *repository.UserWordpressInfoRepository, signer *security.TokenSigner, notifications *NotificationService, dir string, linkTTLHours int) *DataExportService {
+	linkTTL := defaultExportLinkTTL
+	if linkTTLHours > 0 {
+		linkTTL = time.Duration(linkTTLHours) * time.Hour
+	}
+	if dir == "" {
+		dir = defaultExportDir
+	}
+	return &DataExportService{
+		repo:          repo,
+		users:         users,
+		treeholes:     treeholes,
+		content:       content,
+		bindings:      bindings,
+		signer:        signer,
+		notifications: notifications,
+		dir:           dir,
+		linkTTL:       linkTTL,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Request 创建导出任务，当天已有未失败的任务时返回 ErrExportTooFrequent。
+// 次数限制由数据库唯一索引保证，并发申请也只有一个能成功
+func (s *DataExportService) Request(ctx context.Context, userId int64) (domain.DataExport, error) {
+	export, err := s.repo.Create(ctx, userId)
+	if errors.Is(err, dao.ErrDataExportDuplicate) {
+		return domain.DataExport{}, ErrExportTooFrequent
+	}
+	if err != nil {
+		return domain.DataExport{}, err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return export, nil
+}
+
+// List 返回用户最近的导出任务，已生成且未过期的任务附带下载链接
+func (s *DataExportService) List(ctx context.Context, userId int64) ([]domain.DataExport, error) {
+	exports, err := s.repo.FindByUser(ctx, userId, exportListSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exports {
+		e := &exports[i]
+		ttl := time.Until(e.ExpireAt)
+		if e.Status != domain.DataExportReady || ttl <= 0 || e.DownloadToken == "" {
+			continue
+		}
+		token, _, err := s.signer.Sign(exportLinkPurpose(*e), e.UserId, ttl)
+		if err != nil {
+			return nil, err
+		}
+		e.DownloadURL = fmt.Sprintf("/api/exports/download/%d?token=%s", e.Id, token)
+	}
+	return exports, nil
+}
+
+// exportLinkPurpose 签名用途中包含导出任务保存的随机令牌，只知道签名密钥无法伪造链接，
+// 任务过期后令牌被清空，已发出的链接随之失效
+func exportLinkPurpose(e domain.DataExport) string {
+	return exportTokenPurpose + strconv.FormatInt(e.Id, 10) + ":" + e.DownloadToken
+}
+
+// Download 校验下载链接，返回导出文件的路径和下载时使用的文件名
+func (s *DataExportService) Download(ctx context.Context, id int64, token string) (string, string, error) {
+	export, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, dao.ErrDataExportNotFound) {
+		return "", "", ErrExportNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if export.Status == domain.DataExportExpired {
+		return "", "", ErrExportExpired
+	}
+	if export.DownloadToken == "" {
+		return "", "", ErrExportLinkInvalid
+	}
+	claims, err := s.signer.Parse(token, exportLinkPurpose(export))
+	if errors.Is(err, security.ErrTokenExpired) {
+		return "", "", ErrExportExpired
+	}
+	if err != nil || export.UserId != claims.UserId {
+		return "", "", ErrExportLinkInvalid
+	}
+	switch {
+	case export.Status == domain.DataExportReady && time.Now().After(export.ExpireAt):
+		return "", "", ErrExportExpired
+	case export.Status != domain.DataExportReady:
+		return "", "", ErrExportNotReady
+	}
+	path := filepath.Join(s.dir, export.FileName)
+	// 多实例部署时导出目录未共享会找不到文件
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", ErrExportNotFound
+		}
+		return "", "", err
+	}
+	name := fmt.Sprintf("negaihoshi-export-%s.zip", export.Ctime.Format("20060102"))
+	return path, name, nil
+}
+
+// Run 定期执行等待中的导出任务并删除过期的导出文件，直到 ctx 结束
+func (s *DataExportService) Run(ctx context.Context) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		log.Printf("创建数据导出目录失败: %v", err)
+	}
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.repo.ResetStale(ctx, time.Now().Add(-exportStaleAfter)); err != nil {
+			log.Printf("重置超时的导出任务失败: %v", err)
+		}
+		if n, err := s.ProcessPending(ctx); err != nil {
+			log.Printf("执行数据导出任务失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已完成数据导出任务 %d 个", n)
+		}
+		if err := s.PurgeExpired(ctx); err != nil {
+			log.Printf("删除过期的导出文件失败: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ProcessPending 执行等待中的导出任务，返回本次处理的任务数。
+// 多个实例同时执行时每个任务只会被一个实例领取
+func (s *DataExportService) ProcessPending(ctx context.Context) (int, error) {
+	var total int
+	for {
+		pending, err := s.repo.FindPending(ctx, exportBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, e := range pending {
+			ok, err := s.repo.Claim(ctx, e.Id)
+			if err != nil {
+				return total, err
+			}
+			if !ok {
+				continue
+			}
+			total++
+			s.process(ctx, e)
+		}
+		if len(pending) < exportBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// PurgeExpired 删除下载链接已过期的导出文件
+func (s *DataExportService) PurgeExpired(ctx context.Context) error {
+	for {
+		expired, err := s.repo.FindExpired(ctx, time.Now(), exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range expired {
+			if err := os.Remove(filepath.Join(s.dir, e.FileName)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := s.repo.MarkExpired(ctx, e.Id); err != nil {
+				return err
+			}
+		}
+		if len(expired) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *DataExportService) process(ctx context.Context, e domain.DataExport) {
+	fileName := fmt.Sprintf("%d-%d.zip", e.UserId, e.Id)
+	size, err := s.build(ctx, e.UserId, fileName)
+	if err != nil {
+		log.Printf("生成用户 %d 的数据导出失败: %v", e.UserId, err)
+		if ferr := s.repo.Fail(ctx, e.Id, "生成导出文件失败"); ferr != nil {
+			log.Printf("更新导出任务 %d 失败: %v", e.Id, ferr)
+		}
+		s.notifications.DataExportFinished(ctx, e.UserId, e.Id, false)
+		return
+	}
+	downloadToken, err := randomToken(32)
+	if err != nil {
+		log.Printf("生成导出任务 %d 的下载令牌失败: %v", e.Id, err)
+		return
+	}
+	if err := s.repo.Complete(ctx, e.Id, fileName, downloadToken, size, time.Now().Add(s.linkTTL)); err != nil {
+		log.Printf("更新导出任务 %d 失败: %v", e.Id, err)
+		return
+	}
+	s.notifications.DataExportFinished(ctx, e.UserId, e.Id, true)
+}
+
+// build 先写入临时文件，完成后再重命名，避免下载到不完整的文件
+func (s *DataExportService) build(ctx context.Context, userId int64, fileName string) (int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(s.dir, fileName+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := zip.NewWriter(tmp)
+	werr := s.writeArchive(ctx, zw, userId)
+	if err := zw.Close(); werr == nil {
+		werr = err
+	}
+	if err := tmp.Close(); werr == nil {
+		werr = err
+	}
+	if werr != nil {
+		return 0, werr
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, fileName)); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// 导出文件中的个人资料，不包含密码
+type exportProfile struct {
+	Id            int64     `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Nickname      string    `json:"nickname"`
+	Bio           string    `json:"bio"`
+	Avatar        string    `json:"avatar"`
+	Phone         string    `json:"phone"`
+	Location      string    `json:"location"`
+	Website       string    `json:"website"`
+	Ctime         time.Time `json:"ctime"`
+	Utime         time.Time `json:"utime"`
+}
+
+type exportTreeHole struct {
+	Id      int64     `json:"id"`
+	Content string    `json:"content"`
+	Ctime   time.Time `json:"ctime"`
+}
+
+type exportStatus struct {
+	Id       int64      `json:"id"`
+	Content  string     `json:"content"`
+	Ctime    time.Time  `json:"ctime"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+type exportPost struct {
+	Id        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	State     string     `json:"state"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	Ctime     time.Time  `json:"ctime"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// 导出文件只记录媒体的地址，不复制文件本身
+type exportMedia struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+}
+
+// 绑定的 WordPress 站点，不包含应用密码
+type exportWordPress struct {
+	SiteId   int64     `json:"site_id"`
+	SiteURL  string    `json:"site_url"`
+	Username string    `json:"username"`
+	Ctime    time.Time `json:"ctime"`
+}
+
+// 导出文件中各部分的文件名和条数，用于生成 index.html
+type exportSection struct {
+	File  string
+	Title string
+	Count int
+}
+
+func (s *DataExportService) writeArchive(ctx context.Context, zw *zip.Writer, userId int64) error {
+	user, err := s.users.FindById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	profile := exportProfile{
+		Id:            user.Id,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Nickname:      user.Nickname,
+		Bio:           user.Bio,
+		Avatar:        user.Avatar,
+		Phone:         user.Phone,
+		Location:      user.Location,
+		Website:       user.Website,
+		Ctime:         user.Ctime,
+		Utime:         user.Utime,
+	}
+
+	var treeholes []exportTreeHole
+	for offset := 0; ; offset += exportTreeHolePage {
+		page, err := s.treeholes.GetListByUser(ctx, userId, offset, exportTreeHolePage)
+		if err != nil {
+			return err
+		}
+		for _, th := range page {
+			treeholes = append(treeholes, exportTreeHole{Id: th.Id, Content: th.Content, Ctime: th.Ctime})
+		}
+		if len(page) < exportTreeHolePage {
+			break
+		}
+	}
+
+	status, err := s.content.FindStatusByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	posts, err := s.content.FindPostsByUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	statuses := make([]exportStatus, 0, len(status))
+	revisions := []domain.ContentRevision{}
+	for _, st := range status {
+		statuses = append(statuses, exportStatus{Id: st.Id, Content: st.Content, Ctime: st.Ctime, EditedAt: optionalTime(st.EditedAt)})
+		if st.Edited {
+			list, err := s.content.FindRevisions(ctx, domain.RevisionStatus, st.Id)
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, list...)
+		}
+	}
+	exportPosts := make([]exportPost, 0, len(posts))
+	for _, p := range posts {
+		exportPosts = append(exportPosts, exportPost{
+			Id:        p.Id,
+			Title:     p.Title,
+			Content:   p.Content,
+			State:     p.State,
+			PublishAt: optionalTime(p.PublishAt),
+			Ctime:     p.Ctime,
+			EditedAt:  optionalTime(p.EditedAt),
+		})
+		if p.Edited {
+			list, err := s.content.FindRevisions(ctx, domain.RevisionPost, p.Id)
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, list...)
+		}
+	}
+
+	media := []exportMedia{}
+	if user.Avatar != "" {
+		media = append(media, exportMedia{Kind: "avatar", URL: user.Avatar})
+	}
+
+	wordpress := []exportWordPress{}
+	binding, err := s.bindings.FindByUid(ctx, userId)
+	if err != nil && !errors.Is(err, dao.ErrUserWordpressInfoNotFound) {
+		return err
+	}
+	if err == nil {
+		wordpress = append(wordpress, exportWordPress{
+			SiteId:   binding.SiteInfo.Id,
+			SiteURL:  binding.SiteInfo.Url,
+			Username: binding.WPuname,
+			Ctime:    binding.Ctime,
+		})
+	}
+
+	files := []struct {
+		section exportSection
+		data    any
+	}{
+		{exportSection{"profile.json", "个人资料", 1}, profile},
+		{exportSection{"treeholes.json", "树洞", len(treeholes)}, treeholes},
+		{exportSection{"statuses.json", "动态", len(statuses)}, statuses},
+		{exportSection{"posts.json", "文章", len(exportPosts)}, exportPosts},
+		{exportSection{"revisions.json", "编辑历史", len(revisions)}, revisions},
+		{exportSection{"media.json", "媒体", len(media)}, media},
+		{exportSection{"wordpress.json", "WordPress 绑定", len(wordpress)}, wordpress},
+	}
+	sections := make([]exportSection, 0, len(files))
+	for _, f := range files {
+		w, err := zw.Create(f.section.File)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+		sections = append(sections, f.section)
+	}
+
+	w, err := zw.Create("index.html")
+	if err != nil {
+		return err
+	}
+	return exportIndexTemplate.Execute(w, map[string]any{
+		"Profile":   profile,
+		"Sections":  sections,
+		"Treeholes": treeholes,
+		"Statuses":  statuses,
+		"Posts":     exportPosts,
+		"Time":      time.Now(),
+	})
+}
+
+// optionalTime 零值时间在导出文件中省略
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+var exportIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Profile.Username}} 的数据导出</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 2em auto; padding: 0 1em; line-height: 1.6; }
+article { border-bottom: 1px solid #ddd; padding: .5em 0; white-space: pre-wrap; }
+time, .meta { color: #888; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>{{.Profile.Username}} 的数据导出</h1>
+<p class="meta">生成时间：{{.Time.Format "2006-01-02 15:04:05"}}</p>
+<h2>文件列表</h2>
+<ul>
+{{range .Sections}}<li><a href="{{.File}}">{{.File}}</a>：{{.Title}}（{{.Count}} 条）</li>
+{{end}}</ul>
+<h2>个人资料</h2>
+<ul>
+<li>用户名：{{.Profile.Username}}</li>
+<li>昵称：{{.Profile.Nickname}}</li>
+<li>邮箱：{{.Profile.Email}}</li>
+<li>简介：{{.Profile.Bio}}</li>
+<li>注册时间：{{.Profile.Ctime.Format "2006-01-02 15:04:05"}}</li>
+</ul>
+<h2>文章</h2>
+{{range .Posts}}<article><h3>{{.Title}}</h3><p class="meta">{{.State}} · {{.Ctime.Format "2006-01-02 15:04"}}</p>{{.Content}}</article>
+{{else}}<p>没有文章</p>
+{{end}}
+<h2>动态</h2>
+{{range .Statuses}}<article><time>{{.Ctime.Format "2006-01-02 15:04"}}</time>
+{{.Content}}</article>
+{{else}}<p>没有动态</p>
+{{end}}
+<h2>树洞</h2>
+{{range .Treeholes}}<article><time>{{.Ctime.Format "2006-01-02 15:04"}}</time>
+{{.Content}}</article>
+{{else}}<p>没有树洞</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,70 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 10:00:00
+ * @Description: 数据导出次数限制的测试
+ */
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/repository/repotest"
+	"negaihoshi/server/src/service"
+)
+
+func TestDataExportRequestLimit(t *testing.T) {
+	ctx := context.Background()
+	db := repotest.OpenSQLite(t)
+	repo := repository.NewDataExportRepository(dao.NewDataExportDAO(db))
+	svc := service.NewDataExportService(repo, nil, nil, nil, nil, nil, nil, t.TempDir(), 0)
+
+	// 并发申请只有一个成功，其余返回 ErrExportTooFrequent
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.Request(ctx, 1)
+		}(i)
+	}
+	wg.Wait()
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, service.ErrExportTooFrequent):
+			t.Fatalf("重复申请应返回 ErrExportTooFrequent，得到 %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("并发申请应只有一个成功，实际成功 %d 个", succeeded)
+	}
+
+	// 其他用户不受影响
+	if _, err := svc.Request(ctx, 2); err != nil {
+		t.Fatalf("其他用户申请导出失败: %v", err)
+	}
+
+	// 失败的任务不占用当天的次数
+	exports, err := repo.FindByUser(ctx, 1, 10)
+	if err != nil || len(exports) != 1 {
+		t.Fatalf("查询导出任务失败: %v, %d", err, len(exports))
+	}
+	if err := repo.Fail(ctx, exports[0].Id, "生成导出文件失败"); err != nil {
+		t.Fatalf("标记失败出错: %v", err)
+	}
+	if _, err := svc.Request(ctx, 1); err != nil {
+		t.Fatalf("任务失败后应允许重新申请，得到 %v", err)
+	}
+	if _, err := svc.Request(ctx, 1); !errors.Is(err, service.ErrExportTooFrequent) {
+		t.Fatalf("重新申请成功后再次申请应返回 ErrExportTooFrequent，得到 %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: 业务事件发布
+ */
+package service
+
+import (
+	"context"
+
+	"negaihoshi/server/src/metrics"
+)
+
+// EventPublisher 服务层在内容和账户发生变更后发布事件，订阅方（如 webhook）不能阻塞业务流程
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, data interface{})
+}
+
+// NopPublisher 不需要事件时使用
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(ctx context.Context, eventType string, data interface{}) {}
+
+// CountingPublisher 按事件类型计数后交给被包装的发布者，用作发帖、注册等业务指标
+type CountingPublisher struct {
+	EventPublisher
+	metrics *metrics.Metrics
+}
+
+func NewCountingPublisher(next EventPublisher, m *metrics.Metrics) *CountingPublisher {
+	return &CountingPublisher{EventPublisher: next, metrics: m}
+}
+
+func (p *CountingPublisher) Publish(ctx context.Context, eventType string, data interface{}) {
+	p.metrics.Event(eventType)
+	p.EventPublisher.Publish(ctx, eventType, data)
+}
+
+// 审核事件中的处理方式
+const (
+	ModerationActionApprove  = "approve"
+	ModerationActionReject   = "reject"
+	ModerationActionAutoHide = "auto_hide" // 举报数达到阈值自动隐藏
+)
+
+func moderationEventData(id int64, action, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"action": action,
+		"reason": reason,
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 用户关注
+ */
+package service
+
+import (
+	"context"
+	"errors"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+)
+
+var (
+	ErrFollowSelf    = errors.New("不能关注自己")
+	ErrFollowBlocked = errors.New("对方已屏蔽你或你已屏蔽对方，无法关注")
+)
+
+type FollowService struct {
+	repo     *repository.FollowRepository
+	userRepo repository.UserRepository
+	blocks   *BlockService
+	timeline *TimelineService
+}
+
+func NewFollowService(repo *repository.FollowRepository, userRepo repository.UserRepository, blocks *BlockService, timeline *TimelineService) *FollowService {
+	return &FollowService{
+		repo:     repo,
+		userRepo: userRepo,
+		blocks:   blocks,
+		timeline: timeline,
+	}
+}
+
+func (s *FollowService) Follow(ctx context.Context, followerId, followeeId int64) error {
+	if followerId == followeeId {
+		return ErrFollowSelf
+	}
+	if _, err := s.userRepo.FindById(ctx, followeeId); err != nil {
+		return ErrUserNotFound
+	}
+	blocked, err := s.blocks.IsBlocked(ctx, followerId, followeeId)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrFollowBlocked
+	}
+	created, err := s.repo.Create(ctx, followerId, followeeId)
+	if err != nil {
+		return err
+	}
+	if created {
+		// 时间线需要补上新关注用户之前发布的内容
+		s.timeline.Invalidate(ctx, followerId)
+	}
+	return nil
+}
+
+func (s *FollowService) Unfollow(ctx context.Context, followerId, followeeId int64) error {
+	deleted, err := s.repo.Delete(ctx, followerId, followeeId)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		s.timeline.Invalidate(ctx, followerId)
+	}
+	return nil
+}
+
+// Followers 粉丝列表，beforeId 为上一页返回的游标
+func (s *FollowService) Followers(ctx context.Context, userId, beforeId int64, limit int) ([]domain.FollowUser, int64, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	users, next, err := s.repo.GetFollowers(ctx, userId, beforeId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.fillProfiles(ctx, users)
+	return users, next, nil
+}
+
+func (s *FollowService) Following(ctx context.Context, userId, beforeId int64, limit int) ([]domain.FollowUser, int64, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	users, next, err := s.repo.GetFollowing(ctx, userId, beforeId, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.fillProfiles(ctx, users)
+	return users, next, nil
+}
+
+func (s *FollowService) Stats(ctx context.Context, userId int64) (domain.FollowStats, error) {
+	return s.repo.Stats(ctx, userId)
+}
+
+func (s *FollowService) IsFollowing(ctx context.Context, followerId, followeeId int64) (bool, error) {
+	return s.repo.Exists(ctx, followerId, followeeId)
+}
+
+func (s *FollowService) fillProfiles(ctx context.Context, users []domain.FollowUser) {
+	for i := range users {
+		user, err := s.userRepo.FindById(ctx, users[i].UserId)
+		if err != nil {
+			continue
+		}
+		users[i].Nickname = user.Nickname
+		users[i].Avatar = user.Avatar
+	}
+}
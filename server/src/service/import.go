@@ -0,0 +1,263 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 管理员批量导入内容：匹配本站用户，保留原始时间，重复执行时跳过已导入的记录
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrImportFormatInvalid = errors.New("不支持的导入格式")
+	ErrImportFileInvalid   = errors.New("无法解析导入文件")
+	ErrImportUserNotFound  = errors.New("指定的用户不存在")
+	ErrImportUserMap       = errors.New("用户映射格式错误，应为 来源用户名=本站用户名，多个映射用逗号分隔")
+)
+
+const (
+	// 每次查询已导入记录的数量
+	importLookupBatch = 500
+	// 报告中最多列出的冲突数，总数见 ConflictCount
+	maxImportConflicts = 500
+)
+
+type ImportService struct {
+	repo  *repository.ImportRepository
+	users repository.UserRepository
+	audit *AuditService
+}
+
+func NewImportService(repo *repository.ImportRepository, users repository.UserRepository, audit *AuditService) *ImportService {
+	return &ImportService{repo: repo, users: users, audit: audit}
+}
+
+// ParseImportUserMap 解析 old=new,old2=new2 形式的用户映射
+func ParseImportUserMap(raw string) (map[string]string, error) {
+	userMap := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, ErrImportUserMap
+		}
+		userMap[from] = to
+	}
+	return userMap, nil
+}
+
+// ImportForAdmin 管理员导入内容，实际写入时记录审计日志
+func (s *ImportService) ImportForAdmin(ctx context.Context, adminId int64, ip string, opts domain.ImportOptions, data []byte) (domain.ImportReport, error) {
+	report, err := s.Import(ctx, opts, data)
+	if !opts.DryRun && err == nil {
+		created := 0
+		for _, n := range report.Created {
+			created += n
+		}
+		s.audit.Record(ctx, adminId, domain.AuditActionContentImport, ip,
+			fmt.Sprintf("从 %s 导入了 %d 条内容，跳过 %d 条，冲突 %d 条", opts.Format, created, report.Skipped, report.ConflictCount))
+	}
+	return report, err
+}
+
+// Import 解析并导入文件中的内容。找不到对应用户或格式错误的记录作为冲突报告，不影响其他记录；
+// 已导入过的记录计入 Skipped，因此同一文件可以重复执行。出错时返回已完成部分的报告
+func (s *ImportService) Import(ctx context.Context, opts domain.ImportOptions, data []byte) (domain.ImportReport, error) {
+	report := domain.ImportReport{
+		Format:    opts.Format,
+		DryRun:    opts.DryRun,
+		Created:   map[string]int{},
+		Conflicts: []domain.ImportConflict{},
+	}
+	var parsed importParseResult
+	var err error
+	switch opts.Format {
+	case domain.ImportFormatNegaihoshi:
+		parsed, err = parseNegaihoshiExport(data)
+	case domain.ImportFormatCSV:
+		parsed, err = parseTreeHoleCSV(data)
+	case domain.ImportFormatTwitter:
+		parsed, err = parseTwitterArchive(data)
+	default:
+		return report, ErrImportFormatInvalid
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Total = len(parsed.items) + len(parsed.conflicts) + parsed.skipped
+	report.Skipped = parsed.skipped
+	for _, c := range parsed.conflicts {
+		addImportConflict(&report, c)
+	}
+
+	resolve, err := s.userResolver(ctx, opts)
+	if err != nil {
+		return report, err
+	}
+	imported, err := s.findImported(ctx, opts.Format, parsed.items)
+	if err != nil {
+		return report, err
+	}
+
+	defer func() {
+		if report.Created[dao.ImportTargetTreeHole] > 0 && !opts.DryRun {
+			s.repo.InvalidateTreeHoleLists(ctx)
+		}
+	}()
+	for _, it := range parsed.items {
+		if imported[it.externalId] {
+			report.Skipped++
+			continue
+		}
+		userId, err := resolve(it)
+		if err != nil {
+			return report, err
+		}
+		if userId == 0 {
+			addImportConflict(&report, domain.ImportConflict{Ref: it.ref, ExternalId: it.externalId, Reason: "找不到对应的用户 " + importAuthor(it)})
+			continue
+		}
+		// 同一文件中重复的记录只导入一次
+		imported[it.externalId] = true
+		if opts.DryRun {
+			report.Created[it.targetType]++
+			continue
+		}
+		err = s.importItem(ctx, opts.Format, it, userId)
+		if errors.Is(err, dao.ErrImportDuplicate) {
+			// 其他导入同时写入了该记录
+			report.Skipped++
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Created[it.targetType]++
+	}
+	return report, nil
+}
+
+func (s *ImportService) importItem(ctx context.Context, source string, it importItem, userId int64) error {
+	var err error
+	switch it.targetType {
+	case dao.ImportTargetTreeHole:
+		_, err = s.repo.ImportTreeHole(ctx, source, it.externalId, domain.TreeHole{
+			Content: it.content,
+			UserId:  userId,
+			Ctime:   it.ctime,
+		})
+	case dao.ImportTargetStatus:
+		_, err = s.repo.ImportStatus(ctx, source, it.externalId, domain.Status{
+			Content:  it.content,
+			UserId:   userId,
+			Ctime:    it.ctime,
+			EditedAt: it.editedAt,
+		})
+	case dao.ImportTargetPost:
+		_, err = s.repo.ImportPosts(ctx, source, it.externalId, domain.Posts{
+			Title:     it.title,
+			Content:   it.content,
+			UserId:    userId,
+			Ctime:     it.ctime,
+			EditedAt:  it.editedAt,
+			State:     it.state,
+			PublishAt: it.publishAt,
+		})
+	default:
+		err = ErrContentTypeInvalid
+	}
+	return err
+}
+
+// userResolver 返回匹配来源作者的函数，找不到用户时返回 0。
+// 指定了 Username 时所有记录都属于该用户，否则先按映射、再按同名用户、最后按邮箱匹配
+func (s *ImportService) userResolver(ctx context.Context, opts domain.ImportOptions) (func(importItem) (int64, error), error) {
+	if opts.Username != "" {
+		u, err := s.users.FindByUsername(ctx, opts.Username)
+		if errors.Is(err, dao.ErrUserNotFound) {
+			return nil, ErrImportUserNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func(importItem) (int64, error) { return u.Id, nil }, nil
+	}
+
+	byName := make(map[string]int64)
+	byEmail := make(map[string]int64)
+	lookup := func(cache map[string]int64, key string, find func(context.Context, string) (*domain.User, error)) (int64, error) {
+		if id, ok := cache[key]; ok {
+			return id, nil
+		}
+		u, err := find(ctx, key)
+		if errors.Is(err, dao.ErrUserNotFound) {
+			cache[key] = 0
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		cache[key] = u.Id
+		return u.Id, nil
+	}
+	return func(it importItem) (int64, error) {
+		if it.username != "" {
+			name := it.username
+			if mapped, ok := opts.UserMap[name]; ok {
+				name = mapped
+			}
+			if id, err := lookup(byName, name, s.users.FindByUsername); id != 0 || err != nil {
+				return id, err
+			}
+		}
+		if it.email != "" {
+			return lookup(byEmail, it.email, s.users.FindByEmail)
+		}
+		return 0, nil
+	}, nil
+}
+
+// findImported 分批查询已导入过的记录
+func (s *ImportService) findImported(ctx context.Context, source string, items []importItem) (map[string]bool, error) {
+	imported := make(map[string]bool)
+	for start := 0; start < len(items); start += importLookupBatch {
+		batch := items[start:min(start+importLookupBatch, len(items))]
+		ids := make([]string, 0, len(batch))
+		for _, it := range batch {
+			ids = append(ids, it.externalId)
+		}
+		found, err := s.repo.FindImported(ctx, source, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id := range found {
+			imported[id] = true
+		}
+	}
+	return imported, nil
+}
+
+func addImportConflict(report *domain.ImportReport, c domain.ImportConflict) {
+	report.ConflictCount++
+	if len(report.Conflicts) < maxImportConflicts {
+		report.Conflicts = append(report.Conflicts, c)
+	}
+}
+
+func importAuthor(it importItem) string {
+	if it.username != "" {
+		return it.username
+	}
+	return it.email
+}
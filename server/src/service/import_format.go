@@ -0,0 +1,342 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 16:00:00
+ * @Description: 解析本站导出文件、树洞 CSV 和 Twitter/X 存档
+ */
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+// 导入文件中单个 JSON 文件的最大解压长度
+const maxImportEntry = 256 << 20
+
+// importItem 从导入文件中解析出的一条内容
+type importItem struct {
+	ref        string
+	externalId string
+	targetType string
+	// 来源中的作者，用于匹配本站用户
+	username  string
+	email     string
+	title     string
+	content   string
+	state     string
+	ctime     time.Time
+	publishAt time.Time
+	editedAt  time.Time
+}
+
+// importParseResult 解析结果，无法解析的记录作为冲突报告，不影响其他记录
+type importParseResult struct {
+	items     []importItem
+	conflicts []domain.ImportConflict
+	// 不需要导入的记录，如转推
+	skipped int
+}
+
+func (r *importParseResult) conflict(ref, externalId, reason string) {
+	r.conflicts = append(r.conflicts, domain.ImportConflict{Ref: ref, ExternalId: externalId, Reason: reason})
+}
+
+// parseNegaihoshiExport 解析本站数据导出的 ZIP 文件，编辑历史、媒体和 WordPress 绑定不导入
+func parseNegaihoshiExport(data []byte) (importParseResult, error) {
+	var res importParseResult
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return res, ErrImportFileInvalid
+	}
+	var profile exportProfile
+	if found, err := readZipJSON(zr, "profile.json", &profile); err != nil || !found || profile.Username == "" {
+		return res, ErrImportFileInvalid
+	}
+	// 不同站点导出的 id 可能相同，外部 id 中带上来源用户名
+	externalId := func(kind string, id int64) string {
+		return importExternalId(fmt.Sprintf("%s/%s/%d", profile.Username, kind, id))
+	}
+	item := func(ref, kind string, id int64, ctime time.Time) importItem {
+		return importItem{
+			ref:        ref,
+			externalId: externalId(kind, id),
+			targetType: kind,
+			username:   profile.Username,
+			email:      profile.Email,
+			ctime:      ctime,
+		}
+	}
+
+	var treeholes []exportTreeHole
+	if _, err := readZipJSON(zr, "treeholes.json", &treeholes); err != nil {
+		return res, err
+	}
+	for i, th := range treeholes {
+		it := item(fmt.Sprintf("treeholes.json[%d]", i), dao.ImportTargetTreeHole, th.Id, th.Ctime)
+		it.content = th.Content
+		res.add(it)
+	}
+
+	var statuses []exportStatus
+	if _, err := readZipJSON(zr, "statuses.json", &statuses); err != nil {
+		return res, err
+	}
+	for i, st := range statuses {
+		it := item(fmt.Sprintf("statuses.json[%d]", i), dao.ImportTargetStatus, st.Id, st.Ctime)
+		it.content = st.Content
+		if st.EditedAt != nil {
+			it.editedAt = *st.EditedAt
+		}
+		res.add(it)
+	}
+
+	var posts []exportPost
+	if _, err := readZipJSON(zr, "posts.json", &posts); err != nil {
+		return res, err
+	}
+	for i, p := range posts {
+		it := item(fmt.Sprintf("posts.json[%d]", i), dao.ImportTargetPost, p.Id, p.Ctime)
+		it.title, it.content, it.state = p.Title, p.Content, p.State
+		if p.PublishAt != nil {
+			it.publishAt = *p.PublishAt
+		}
+		if p.EditedAt != nil {
+			it.editedAt = *p.EditedAt
+		}
+		if !domain.ValidPostState(it.state) {
+			res.conflict(it.ref, it.externalId, "不支持的文章状态 "+it.state)
+			continue
+		}
+		res.add(it)
+	}
+	return res, nil
+}
+
+// parseTreeHoleCSV 解析树洞 CSV，第一行为表头：content 必填，username 或 email 用于匹配作者，
+// ctime 为原始发布时间，id 为来源中的唯一标识，没有 id 列时按作者、时间和内容计算
+func parseTreeHoleCSV(data []byte) (importParseResult, error) {
+	var res importParseResult
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return res, ErrImportFileInvalid
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["content"]; !ok {
+		return res, fmt.Errorf("%w: 缺少 content 列", ErrImportFileInvalid)
+	}
+	if _, ok := columns["ctime"]; !ok {
+		if i, ok := columns["created_at"]; ok {
+			columns["ctime"] = i
+		}
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			res.conflict(fmt.Sprintf("第 %d 行", perr.StartLine), "", "CSV 格式错误")
+			continue
+		}
+		if err != nil {
+			return res, ErrImportFileInvalid
+		}
+		line, _ := r.FieldPos(0)
+		ref := fmt.Sprintf("第 %d 行", line)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		it := importItem{
+			ref:        ref,
+			targetType: dao.ImportTargetTreeHole,
+			username:   field("username"),
+			email:      field("email"),
+			content:    field("content"),
+		}
+		author := it.username
+		if author == "" {
+			author = it.email
+		}
+		if id := field("id"); id != "" {
+			it.externalId = importExternalId(author + "/" + id)
+		} else {
+			sum := sha256.Sum256([]byte(strings.Join([]string{author, field("ctime"), it.content}, "\x00")))
+			it.externalId = hex.EncodeToString(sum[:])
+		}
+		if raw := field("ctime"); raw == "" {
+			it.ctime = time.Now()
+		} else if it.ctime, err = parseImportTime(raw); err != nil {
+			res.conflict(ref, it.externalId, "无法识别的时间 "+raw)
+			continue
+		}
+		res.add(it)
+	}
+}
+
+// parseTwitterArchive 解析 Twitter/X 存档，可以是完整的存档 ZIP 或其中的 tweets.js，
+// 推文导入为动态，转推不导入
+func parseTwitterArchive(data []byte) (importParseResult, error) {
+	var res importParseResult
+	var account []struct {
+		Account struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		} `json:"account"`
+	}
+	var tweets []struct {
+		Tweet struct {
+			Id        string `json:"id_str"`
+			FullText  string `json:"full_text"`
+			CreatedAt string `json:"created_at"`
+			Retweeted bool   `json:"retweeted"`
+		} `json:"tweet"`
+	}
+
+	if zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		found := false
+		for _, f := range zr.File {
+			switch path.Base(f.Name) {
+			case "account.js":
+				if err := readTwitterFile(f, &account); err != nil {
+					return res, err
+				}
+			case "tweets.js", "tweet.js":
+				if err := readTwitterFile(f, &tweets); err != nil {
+					return res, err
+				}
+				found = true
+			}
+		}
+		if !found {
+			return res, fmt.Errorf("%w: 存档中没有 tweets.js", ErrImportFileInvalid)
+		}
+	} else if err := decodeTwitterJS(data, &tweets); err != nil {
+		return res, err
+	}
+
+	var username, email string
+	if len(account) > 0 {
+		username, email = account[0].Account.Username, account[0].Account.Email
+	}
+	for i, t := range tweets {
+		ref := fmt.Sprintf("tweets.js[%d]", i)
+		if t.Tweet.Id == "" {
+			res.conflict(ref, "", "缺少推文 id")
+			continue
+		}
+		if t.Tweet.Retweeted || strings.HasPrefix(t.Tweet.FullText, "RT @") {
+			res.skipped++
+			continue
+		}
+		ctime, err := time.Parse(time.RubyDate, t.Tweet.CreatedAt)
+		if err != nil {
+			res.conflict(ref, t.Tweet.Id, "无法识别的时间 "+t.Tweet.CreatedAt)
+			continue
+		}
+		res.add(importItem{
+			ref:        ref,
+			externalId: t.Tweet.Id,
+			targetType: dao.ImportTargetStatus,
+			username:   username,
+			email:      email,
+			content:    t.Tweet.FullText,
+			ctime:      ctime,
+		})
+	}
+	return res, nil
+}
+
+// add 内容为空的记录作为冲突报告
+func (r *importParseResult) add(it importItem) {
+	if strings.TrimSpace(it.content) == "" {
+		r.conflict(it.ref, it.externalId, "内容为空")
+		return
+	}
+	r.items = append(r.items, it)
+}
+
+// readZipJSON 读取 ZIP 中的 JSON 文件，文件不存在时返回 false
+func readZipJSON(zr *zip.Reader, name string, v any) (bool, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+	if err := json.NewDecoder(io.LimitReader(f, maxImportEntry)).Decode(v); err != nil {
+		return true, fmt.Errorf("%w: %s 格式错误", ErrImportFileInvalid, name)
+	}
+	return true, nil
+}
+
+func readTwitterFile(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return ErrImportFileInvalid
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxImportEntry))
+	if err != nil {
+		return ErrImportFileInvalid
+	}
+	return decodeTwitterJS(data, v)
+}
+
+// decodeTwitterJS 存档中的 .js 文件形如 window.YTD.tweets.part0 = [...]
+func decodeTwitterJS(data []byte, v any) error {
+	if i := bytes.IndexByte(data, '='); i >= 0 && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		data = data[i+1:]
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: 无法解析 Twitter 存档", ErrImportFileInvalid)
+	}
+	return nil
+}
+
+// parseImportTime 支持 RFC3339、常见的日期时间格式以及秒或毫秒时间戳
+func parseImportTime(raw string) (time.Time, error) {
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02", "2006/01/02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的时间 %s", raw)
+}
+
+// importExternalId 过长的标识取哈希，保证能存入导入记录
+func importExternalId(id string) string {
+	if len(id) <= 128 {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,219 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 12:00:00
+ * @Description: 登录暴力破解防护：失败计数、人机验证、渐进式锁定
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/mail"
+	"negaihoshi/server/src/security"
+)
+
+var (
+	ErrCaptchaRequired = errors.New("需要完成人机验证")
+	ErrCaptchaInvalid  = errors.New("人机验证失败")
+	ErrAccountLocked   = errors.New("登录失败次数过多，请稍后再试")
+)
+
+// 锁定次数的保留时间，期间再次被锁定时锁定时长翻倍
+const lockoutMemory = 24 * time.Hour
+
+// LoginProtectionPolicy 登录防护策略
+type LoginProtectionPolicy struct {
+	CaptchaThreshold int           // 连续失败多少次后需要人机验证
+	LockThreshold    int           // 账户连续失败多少次后锁定
+	IPLockThreshold  int           // 同一IP连续失败多少次后锁定该IP
+	FailureWindow    time.Duration // 失败计数的有效期
+	BaseLockDuration time.Duration // 首次锁定时长
+	MaxLockDuration  time.Duration // 最长锁定时长
+}
+
+// SecurityNotifier 账户安全事件通知
+type SecurityNotifier interface {
+	NotifyAccountLocked(ctx context.Context, user *domain.User, ip string, until time.Time) error
+}
+
+// LogSecurityNotifier 仅写日志的通知实现，未配置其他通知渠道时使用
+type LogSecurityNotifier struct{}
+
+func (LogSecurityNotifier) NotifyAccountLocked(ctx context.Context, user *domain.User, ip string, until time.Time) error {
+	log.Printf("用户 %s(%d) 因登录失败次数过多被锁定至 %s，来源IP: %s", user.Username, user.Id, until.Format("2006-01-02 15:04:05"), ip)
+	return nil
+}
+
+// MailSecurityNotifier 通过邮件通知用户账户被锁定
+type MailSecurityNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewMailSecurityNotifier(mailer mail.Mailer) *MailSecurityNotifier {
+	return &MailSecurityNotifier{mailer: mailer}
+}
+
+func (m *MailSecurityNotifier) NotifyAccountLocked(ctx context.Context, user *domain.User, ip string, until time.Time) error {
+	return m.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "账户安全提醒：登录已被临时锁定",
+		Body: fmt.Sprintf("%s，您好：\n\n您的账户因多次密码错误已被临时锁定至 %s，最近一次尝试来自 IP %s。\n如果不是您本人操作，建议尽快通过“忘记密码”重置密码。",
+			user.Nickname, until.Format("2006-01-02 15:04:05"), ip),
+	})
+}
+
+type LoginGuard struct {
+	store security.AttemptStore
+	// 为 nil 表示未配置人机验证服务
+	captcha  security.CaptchaVerifier
+	notifier SecurityNotifier
+	audit    *AuditService
+	policy   LoginProtectionPolicy
+}
+
+func NewLoginGuard(store security.AttemptStore, captcha security.CaptchaVerifier, notifier SecurityNotifier, audit *AuditService, policy LoginProtectionPolicy) *LoginGuard {
+	return &LoginGuard{
+		store:    store,
+		captcha:  captcha,
+		notifier: notifier,
+		audit:    audit,
+		policy:   policy,
+	}
+}
+
+// Check 登录前检查账户和IP是否被锁定，以及是否需要人机验证
+func (g *LoginGuard) Check(ctx context.Context, user *domain.User, identifier, ip, captchaToken string) error {
+	now := time.Now()
+	ipState, err := g.store.Get(ctx, g.ipKey(ip))
+	if err != nil {
+		return err
+	}
+	accountState, err := g.store.Get(ctx, g.accountKey(user, identifier))
+	if err != nil {
+		return err
+	}
+	if ipState.Locked(now) || accountState.Locked(now) {
+		return ErrAccountLocked
+	}
+
+	if !g.needCaptcha(ipState) && !g.needCaptcha(accountState) {
+		return nil
+	}
+	if captchaToken == "" {
+		return ErrCaptchaRequired
+	}
+	ok, err := g.captcha.Verify(ctx, captchaToken, ip)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCaptchaInvalid
+	}
+	return nil
+}
+
+// CheckLocked 只检查账户和IP是否被锁定，用于两步验证等不需要人机验证的环节
+func (g *LoginGuard) CheckLocked(ctx context.Context, user *domain.User, ip string) error {
+	now := time.Now()
+	ipState, err := g.store.Get(ctx, g.ipKey(ip))
+	if err != nil {
+		return err
+	}
+	accountState, err := g.store.Get(ctx, g.accountKey(user, ""))
+	if err != nil {
+		return err
+	}
+	if ipState.Locked(now) || accountState.Locked(now) {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// RequireCaptcha 判断下一次登录是否需要人机验证，用于提示前端
+func (g *LoginGuard) RequireCaptcha(ctx context.Context, user *domain.User, identifier, ip string) bool {
+	ipState, _ := g.store.Get(ctx, g.ipKey(ip))
+	accountState, _ := g.store.Get(ctx, g.accountKey(user, identifier))
+	return g.needCaptcha(ipState) || g.needCaptcha(accountState)
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定账户或IP并返回 ErrAccountLocked
+func (g *LoginGuard) RecordFailure(ctx context.Context, user *domain.User, identifier, ip string) error {
+	var userId int64
+	if user != nil {
+		userId = user.Id
+	}
+	g.audit.Record(ctx, userId, domain.AuditActionLoginFailed, ip, "登录失败: "+identifier)
+
+	ipLocked, _, err := g.recordFailure(ctx, g.ipKey(ip), g.policy.IPLockThreshold)
+	if err != nil {
+		return err
+	}
+	if ipLocked {
+		g.audit.Record(ctx, userId, domain.AuditActionIPLocked, ip, "IP登录失败次数过多被锁定")
+	}
+
+	accountLocked, until, err := g.recordFailure(ctx, g.accountKey(user, identifier), g.policy.LockThreshold)
+	if err != nil {
+		return err
+	}
+	if accountLocked {
+		g.audit.Record(ctx, userId, domain.AuditActionAccountLocked, ip,
+			fmt.Sprintf("账户 %s 登录失败次数过多，锁定至 %s", identifier, until.Format("2006-01-02 15:04:05")))
+		if user != nil {
+			if err := g.notifier.NotifyAccountLocked(ctx, user, ip, until); err != nil {
+				log.Printf("发送账户锁定通知失败: %v", err)
+			}
+		}
+	}
+
+	if ipLocked || accountLocked {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// RecordSuccess 登录成功后清除账户的失败记录
+func (g *LoginGuard) RecordSuccess(ctx context.Context, user *domain.User) {
+	if err := g.store.Delete(ctx, g.accountKey(user, "")); err != nil {
+		log.Printf("清除登录失败记录失败: %v", err)
+	}
+}
+
+func (g *LoginGuard) recordFailure(ctx context.Context, key string, threshold int) (bool, time.Time, error) {
+	state, locked, err := g.store.RecordFailure(ctx, key, security.FailureRule{
+		Threshold:     threshold,
+		Window:        g.policy.FailureWindow,
+		BaseLock:      g.policy.BaseLockDuration,
+		MaxLock:       g.policy.MaxLockDuration,
+		LockoutMemory: lockoutMemory,
+	})
+	return locked, state.LockedUntil, err
+}
+
+// needCaptcha 未配置人机验证服务时不要求验证，只依靠锁定防护，
+// 否则任何人都能用错误密码让某个账户永远无法登录
+func (g *LoginGuard) needCaptcha(state security.AttemptState) bool {
+	if g.captcha == nil {
+		return false
+	}
+	return state.Lockouts > 0 || (g.policy.CaptchaThreshold > 0 && state.Failures >= g.policy.CaptchaThreshold)
+}
+
+// 用户存在时按用户ID计数，避免用户名和邮箱交替尝试绕过限制
+func (g *LoginGuard) accountKey(user *domain.User, identifier string) string {
+	if user != nil {
+		return "account:" + strconv.FormatInt(user.Id, 10)
+	}
+	return "account:" + strings.ToLower(identifier)
+}
+
+func (g *LoginGuard) ipKey(ip string) string {
+	return "ip:" + ip
+}
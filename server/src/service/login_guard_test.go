@@ -0,0 +1,147 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 11:30:00
+ * @Description: 登录暴力破解防护的测试
+ */
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/repository/repotest"
+	"negaihoshi/server/src/security"
+	"negaihoshi/server/src/service"
+)
+
+// stubCaptcha 令牌为 "ok" 时验证通过
+type stubCaptcha struct{}
+
+func (stubCaptcha) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return token == "ok", nil
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	db := repotest.OpenSQLite(t)
+	notifications := service.NewNotificationService(repository.NewNotificationRepository(dao.NewNotificationDAO(db)))
+	audit := service.NewAuditService(repository.NewAuditLogRepository(dao.NewAuditLogDAO(db)), notifications)
+	policy := service.LoginProtectionPolicy{
+		CaptchaThreshold: 2,
+		LockThreshold:    3,
+		IPLockThreshold:  5,
+		FailureWindow:    time.Minute,
+		BaseLockDuration: time.Minute,
+		MaxLockDuration:  time.Hour,
+	}
+	newGuard := func(captcha security.CaptchaVerifier) *service.LoginGuard {
+		return service.NewLoginGuard(security.NewMemoryAttemptStore(), captcha, service.LogSecurityNotifier{}, audit, policy)
+	}
+	alice := &domain.User{Id: 1, Username: "alice"}
+	bob := &domain.User{Id: 2, Username: "bob"}
+
+	// fail 记录 n 次失败，返回最后一次的结果
+	fail := func(g *service.LoginGuard, user *domain.User, ip string, n int) error {
+		var err error
+		for i := 0; i < n; i++ {
+			err = g.RecordFailure(ctx, user, user.Username, ip)
+		}
+		return err
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"AccountLockedAtThreshold", func(t *testing.T) {
+			g := newGuard(nil)
+			if err := fail(g, alice, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到阈值时不应锁定，得到 %v", err)
+			}
+			if err := fail(g, alice, "10.0.0.1", 1); !errors.Is(err, service.ErrAccountLocked) {
+				t.Fatalf("达到阈值时应返回 ErrAccountLocked，得到 %v", err)
+			}
+			// 换一个 IP 仍然锁定，用户名和邮箱都按用户 ID 计数
+			if err := g.Check(ctx, alice, "alice@example.com", "10.0.0.2", ""); !errors.Is(err, service.ErrAccountLocked) {
+				t.Fatalf("锁定期间登录应返回 ErrAccountLocked，得到 %v", err)
+			}
+			if err := g.Check(ctx, bob, "bob", "10.0.0.2", ""); err != nil {
+				t.Fatalf("其他账户不应受影响，得到 %v", err)
+			}
+		}},
+		{"IPLockedAcrossAccounts", func(t *testing.T) {
+			g := newGuard(nil)
+			if err := fail(g, alice, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到阈值时不应锁定，得到 %v", err)
+			}
+			if err := fail(g, bob, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到阈值时不应锁定，得到 %v", err)
+			}
+			carol := &domain.User{Id: 3, Username: "carol"}
+			if err := fail(g, carol, "10.0.0.1", 1); !errors.Is(err, service.ErrAccountLocked) {
+				t.Fatalf("同一 IP 失败次数达到阈值时应返回 ErrAccountLocked，得到 %v", err)
+			}
+			if err := g.CheckLocked(ctx, carol, "10.0.0.1"); !errors.Is(err, service.ErrAccountLocked) {
+				t.Fatalf("IP 锁定期间应返回 ErrAccountLocked，得到 %v", err)
+			}
+			if err := g.CheckLocked(ctx, carol, "10.0.0.2"); err != nil {
+				t.Fatalf("其他 IP 不应受影响，得到 %v", err)
+			}
+		}},
+		{"SuccessResetsAccount", func(t *testing.T) {
+			g := newGuard(nil)
+			if err := fail(g, alice, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到阈值时不应锁定，得到 %v", err)
+			}
+			g.RecordSuccess(ctx, alice)
+			if err := fail(g, alice, "10.0.0.2", 2); err != nil {
+				t.Fatalf("登录成功后应重新计数，得到 %v", err)
+			}
+		}},
+		{"CaptchaAfterFailures", func(t *testing.T) {
+			g := newGuard(stubCaptcha{})
+			if err := g.Check(ctx, alice, "alice", "10.0.0.1", ""); err != nil {
+				t.Fatalf("没有失败记录时不需要人机验证，得到 %v", err)
+			}
+			if err := fail(g, alice, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到锁定阈值时不应锁定，得到 %v", err)
+			}
+			if !g.RequireCaptcha(ctx, alice, "alice", "10.0.0.1") {
+				t.Fatal("达到人机验证阈值后应提示需要人机验证")
+			}
+			checks := []struct {
+				token string
+				want  error
+			}{
+				{"", service.ErrCaptchaRequired},
+				{"bad", service.ErrCaptchaInvalid},
+				{"ok", nil},
+			}
+			for _, c := range checks {
+				if err := g.Check(ctx, alice, "alice", "10.0.0.1", c.token); !errors.Is(err, c.want) {
+					t.Fatalf("人机验证令牌为 %q 时返回 %v，期望 %v", c.token, err, c.want)
+				}
+			}
+		}},
+		{"NoCaptchaWithoutVerifier", func(t *testing.T) {
+			g := newGuard(nil)
+			if err := fail(g, alice, "10.0.0.1", 2); err != nil {
+				t.Fatalf("未达到锁定阈值时不应锁定，得到 %v", err)
+			}
+			if g.RequireCaptcha(ctx, alice, "alice", "10.0.0.1") {
+				t.Fatal("未配置人机验证服务时不应要求人机验证")
+			}
+			if err := g.Check(ctx, alice, "alice", "10.0.0.1", ""); err != nil {
+				t.Fatalf("未配置人机验证服务时不应要求人机验证，得到 %v", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户之间的私信
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/realtime"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrMessageSelf           = errors.New("不能给自己发私信")
+	ErrMessageBlocked        = errors.New("对方已屏蔽你或你已屏蔽对方，无法发送私信")
+	ErrConversationNotFound  = errors.New("会话不存在")
+	ErrMessageContentInvalid = errors.New("私信内容不能为空且不超过2000字符")
+)
+
+const (
+	messageMaxLength = 2000
+	// 会话列表中最后一条消息的预览长度
+	messagePreviewLength = 50
+)
+
+// RealtimeUserTopic 推送给单个用户的主题，用于私信等只有本人能收到的事件
+func RealtimeUserTopic(userId int64) string {
+	return "user:" + strconv.FormatInt(userId, 10)
+}
+
+type MessageService struct {
+	repo     *repository.MessageRepository
+	userRepo repository.UserRepository
+	blocks   *BlockService
+	filter   *ContentFilter
+	hub      realtime.Hub
+}
+
+func NewMessageService(repo *repository.MessageRepository, userRepo repository.UserRepository, blocks *BlockService, filter *ContentFilter, hub realtime.Hub) *MessageService {
+	return &MessageService{
+		repo:     repo,
+		userRepo: userRepo,
+		blocks:   blocks,
+		filter:   filter,
+		hub:      hub,
+	}
+}
+
+// StartConversation 发起或打开与某个用户的会话，content 不为空时同时发送第一条消息
+func (s *MessageService) StartConversation(ctx context.Context, userId, peerId int64, content string) (int64, *domain.DirectMessage, error) {
+	if userId == peerId {
+		return 0, nil, ErrMessageSelf
+	}
+	if _, err := s.userRepo.FindById(ctx, peerId); err != nil {
+		return 0, nil, ErrUserNotFound
+	}
+	if err := s.checkBlocked(ctx, userId, peerId); err != nil {
+		return 0, nil, err
+	}
+	if content != "" {
+		if err := s.checkContent(content); err != nil {
+			return 0, nil, err
+		}
+	}
+	conversationId, err := s.repo.FindOrCreateConversation(ctx, userId, peerId)
+	if err != nil {
+		return 0, nil, err
+	}
+	if content == "" {
+		return conversationId, nil, nil
+	}
+	msg, err := s.send(ctx, conversationId, userId, peerId, content)
+	if err != nil {
+		return 0, nil, err
+	}
+	return conversationId, &msg, nil
+}
+
+// Send 在已有会话中发送消息
+func (s *MessageService) Send(ctx context.Context, conversationId, userId int64, content string) (domain.DirectMessage, error) {
+	peerId, err := s.peer(ctx, conversationId, userId)
+	if err != nil {
+		return domain.DirectMessage{}, err
+	}
+	if err := s.checkBlocked(ctx, userId, peerId); err != nil {
+		return domain.DirectMessage{}, err
+	}
+	if err := s.checkContent(content); err != nil {
+		return domain.DirectMessage{}, err
+	}
+	return s.send(ctx, conversationId, userId, peerId, content)
+}
+
+func (s *MessageService) send(ctx context.Context, conversationId, senderId, peerId int64, content string) (domain.DirectMessage, error) {
+	msg, err := s.repo.Create(ctx, domain.DirectMessage{
+		ConversationId: conversationId,
+		SenderId:       senderId,
+		Content:        content,
+	}, messagePreview(content))
+	if err != nil {
+		return domain.DirectMessage{}, err
+	}
+	// 推送给双方，发送方的其他设备也能同步
+	for _, id := range []int64{peerId, senderId} {
+		if err := s.hub.Publish(ctx, RealtimeUserTopic(id), domain.EventMessageCreated, msg); err != nil {
+			log.Printf("推送私信失败: %v", err)
+		}
+	}
+	return msg, nil
+}
+
+// ListConversations 按最后一条消息时间倒序返回会话，同时附上对方的昵称和头像
+func (s *MessageService) ListConversations(ctx context.Context, userId int64, page, size int) ([]domain.Conversation, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	list, total, err := s.repo.GetConversations(ctx, userId, (page-1)*size, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range list {
+		peer, err := s.userRepo.FindById(ctx, list[i].PeerId)
+		if err != nil {
+			// 对方账户已不存在时仍显示会话
+			continue
+		}
+		list[i].PeerNickname = peer.Nickname
+		list[i].PeerAvatar = peer.Avatar
+	}
+	return list, total, nil
+}
+
+// ListMessages 按 id 倒序分页，beforeId 为上一页最早一条消息的 id
+func (s *MessageService) ListMessages(ctx context.Context, conversationId, userId, beforeId int64, limit int) ([]domain.DirectMessage, error) {
+	if _, err := s.peer(ctx, conversationId, userId); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > 100 {
+		limit = 30
+	}
+	return s.repo.GetMessages(ctx, conversationId, beforeId, limit)
+}
+
+func (s *MessageService) MarkRead(ctx context.Context, conversationId, userId int64) error {
+	if _, err := s.peer(ctx, conversationId, userId); err != nil {
+		return err
+	}
+	return s.repo.MarkRead(ctx, conversationId, userId)
+}
+
+func (s *MessageService) UnreadCount(ctx context.Context, userId int64) (int64, error) {
+	return s.repo.UnreadCount(ctx, userId)
+}
+
+// peer 返回会话的另一方，同时校验用户是会话成员
+func (s *MessageService) peer(ctx context.Context, conversationId, userId int64) (int64, error) {
+	peerId, err := s.repo.FindPeer(ctx, conversationId, userId)
+	if errors.Is(err, dao.ErrConversationNotFound) {
+		return 0, ErrConversationNotFound
+	}
+	return peerId, err
+}
+
+func (s *MessageService) checkBlocked(ctx context.Context, userId, peerId int64) error {
+	blocked, err := s.blocks.IsBlocked(ctx, userId, peerId)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMessageBlocked
+	}
+	return nil
+}
+
+func (s *MessageService) checkContent(content string) error {
+	if content == "" || len([]rune(content)) > messageMaxLength {
+		return ErrMessageContentInvalid
+	}
+	return s.filter.Check(content)
+}
+
+func messagePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= messagePreviewLength {
+		return content
+	}
+	return string(runes[:messagePreviewLength]) + "…"
+}
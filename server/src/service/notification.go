@@ -0,0 +1,207 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 20:00:00
+ * @Description: 站内通知：审核结果、转发失败和账户安全提醒
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrNotificationTypeInvalid  = errors.New("不支持的通知类型")
+	ErrNotificationTypeRequired = errors.New("账户安全提醒不能关闭")
+)
+
+// 通知中内容类型的显示名称
+var notificationTargetNames = map[string]string{
+	domain.ReportTargetTreeHole: "树洞",
+	domain.ReportTargetStatus:   "动态",
+	domain.ReportTargetPost:     "文章",
+}
+
+type NotificationService struct {
+	repo *repository.NotificationRepository
+}
+
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// Notify 按用户的偏好设置发送通知，失败只记录日志，不影响业务流程
+func (s *NotificationService) Notify(ctx context.Context, n domain.Notification) {
+	if n.UserId <= 0 {
+		return
+	}
+	// 请求结束后仍需写入通知
+	ctx = context.WithoutCancel(ctx)
+	if n.Type != domain.NotificationSecurity {
+		pref, err := s.repo.GetPreference(ctx, n.UserId, n.Type)
+		if err == nil && !pref.Enabled {
+			return
+		}
+		if err != nil && !errors.Is(err, dao.ErrNotificationPreferenceNotFound) {
+			log.Printf("查询通知偏好失败: %v", err)
+		}
+	}
+	if err := s.repo.Create(ctx, n); err != nil {
+		log.Printf("写入通知失败: %v", err)
+	}
+}
+
+// ContentModerated 通知作者内容的审核结果，action 为 ModerationAction* 之一
+func (s *NotificationService) ContentModerated(ctx context.Context, ownerId int64, targetType string, targetId int64, action, reason string) {
+	name := notificationTargetNames[targetType]
+	n := domain.Notification{
+		UserId:     ownerId,
+		TargetType: targetType,
+		TargetId:   targetId,
+	}
+	switch action {
+	case ModerationActionApprove:
+		n.Type = domain.NotificationContentApproved
+		n.Title = fmt.Sprintf("你的%s已通过审核", name)
+		n.Content = fmt.Sprintf("你的%s已通过审核并恢复显示。", name)
+	case ModerationActionReject:
+		n.Type = domain.NotificationContentRejected
+		n.Title = fmt.Sprintf("你的%s未通过审核", name)
+		n.Content = fmt.Sprintf("你的%s未通过审核，已被隐藏。", name)
+		if reason != "" {
+			n.Content += "原因：" + reason
+		}
+	case ModerationActionAutoHide:
+		n.Type = domain.NotificationContentHidden
+		n.Title = fmt.Sprintf("你的%s已被暂时隐藏", name)
+		n.Content = fmt.Sprintf("你的%s被多位用户举报，已暂时隐藏，等待管理员审核。", name)
+	default:
+		return
+	}
+	s.Notify(ctx, n)
+}
+
+// TransferFailed 通知用户转发到 WordPress 站点失败
+func (s *NotificationService) TransferFailed(ctx context.Context, userId int64, contentType string, contentId int64, site, reason string) {
+	s.Notify(ctx, domain.Notification{
+		UserId:     userId,
+		Type:       domain.NotificationTransferFailed,
+		Title:      "转发到 WordPress 失败",
+		Content:    fmt.Sprintf("%s转发到 %s 失败：%s", notificationTargetNames[contentType], site, reason),
+		TargetType: contentType,
+		TargetId:   contentId,
+	})
+}
+
+// DataExportFinished 通知用户数据导出已完成或失败
+func (s *NotificationService) DataExportFinished(ctx context.Context, userId, exportId int64, success bool) {
+	n := domain.Notification{
+		UserId:  userId,
+		Type:    domain.NotificationDataExport,
+		Title:   "数据导出已完成",
+		Content: fmt.Sprintf("数据导出 %d 已生成，请在导出记录中下载，下载链接过期后文件将被删除。", exportId),
+	}
+	if !success {
+		n.Title = "数据导出失败"
+		n.Content = fmt.Sprintf("数据导出 %d 生成失败，请稍后重新申请。", exportId)
+	}
+	s.Notify(ctx, n)
+}
+
+// Security 发送账户安全提醒，不受偏好设置影响
+func (s *NotificationService) Security(ctx context.Context, userId int64, title, content string) {
+	s.Notify(ctx, domain.Notification{
+		UserId:  userId,
+		Type:    domain.NotificationSecurity,
+		Title:   title,
+		Content: content,
+	})
+}
+
+func (s *NotificationService) List(ctx context.Context, userId int64, notificationType string, unreadOnly bool, page, size int) ([]domain.Notification, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return s.repo.GetList(ctx, userId, notificationType, unreadOnly, (page-1)*size, size)
+}
+
+// UnreadCount 返回未读总数和各类型的未读数
+func (s *NotificationService) UnreadCount(ctx context.Context, userId int64) (int64, map[string]int64, error) {
+	byType, err := s.repo.CountUnreadByType(ctx, userId)
+	if err != nil {
+		return 0, nil, err
+	}
+	var total int64
+	for _, count := range byType {
+		total += count
+	}
+	return total, byType, nil
+}
+
+// MarkRead 将指定通知标记为已读，只会修改属于该用户的通知
+func (s *NotificationService) MarkRead(ctx context.Context, userId int64, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return s.repo.MarkRead(ctx, userId, ids)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userId int64) (int64, error) {
+	return s.repo.MarkRead(ctx, userId, nil)
+}
+
+// Preferences 返回全部通知类型的开关，未设置的类型默认开启
+func (s *NotificationService) Preferences(ctx context.Context, userId int64) ([]domain.NotificationPreference, error) {
+	saved, err := s.repo.GetPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(saved))
+	for _, p := range saved {
+		enabled[p.Type] = p.Enabled
+	}
+	prefs := make([]domain.NotificationPreference, 0, len(domain.AllNotificationTypes))
+	for _, t := range domain.AllNotificationTypes {
+		on, ok := enabled[t]
+		prefs = append(prefs, domain.NotificationPreference{
+			Type:    t,
+			Enabled: !ok || on || t == domain.NotificationSecurity,
+		})
+	}
+	return prefs, nil
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userId int64, prefs []domain.NotificationPreference) error {
+	for _, p := range prefs {
+		if !isNotificationType(p.Type) {
+			return ErrNotificationTypeInvalid
+		}
+		if p.Type == domain.NotificationSecurity && !p.Enabled {
+			return ErrNotificationTypeRequired
+		}
+	}
+	for _, p := range prefs {
+		if err := s.repo.SavePreference(ctx, userId, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNotificationType(t string) bool {
+	for _, known := range domain.AllNotificationTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 18:00:00
+ * @Description: 第三方 OIDC 登录和账号绑定
+ */
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/metrics"
+	"negaihoshi/server/src/oidc"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrOIDCProviderNotFound = errors.New("不支持的登录方式")
+	ErrOIDCStateInvalid     = errors.New("登录请求已失效，请重新登录")
+	ErrOIDCAccessDenied     = errors.New("已取消第三方授权")
+	ErrOIDCIdentityTaken    = errors.New("该外部账号已绑定其他用户")
+	ErrOIDCProviderLinked   = errors.New("已绑定该登录方式的其他账号，请先解绑")
+	ErrOIDCIdentityNotFound = errors.New("未绑定该登录方式")
+	ErrOIDCEmailMissing     = errors.New("外部账号未提供邮箱，无法注册")
+	// 不按邮箱自动绑定已有账户，避免通过外部账号接管他人账户
+	ErrOIDCEmailRegistered = errors.New("该邮箱已注册，请先使用密码登录后在账户设置中绑定")
+	ErrOIDCSignupDisabled  = errors.New("未开放外部账号注册，请先注册后绑定")
+	ErrOIDCLastLoginMethod = errors.New("这是唯一的登录方式，请先通过找回密码设置密码后再解绑")
+)
+
+// 授权请求有效期
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCProvider 登录提供方，oidc.Provider 的抽象便于替换
+type OIDCProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (oidc.Claims, error)
+}
+
+type OIDCService struct {
+	providers     map[string]OIDCProvider
+	infos         []domain.OIDCProviderInfo
+	identities    *repository.UserIdentityRepository
+	userRepo      repository.UserRepository
+	userService   *UserService
+	audit         *AuditService
+	autoProvision bool
+}
+
+func NewOIDCService(identities *repository.UserIdentityRepository, userRepo repository.UserRepository, userService *UserService, audit *AuditService, autoProvision bool) *OIDCService {
+	return &OIDCService{
+		providers:     make(map[string]OIDCProvider),
+		identities:    identities,
+		userRepo:      userRepo,
+		userService:   userService,
+		audit:         audit,
+		autoProvision: autoProvision,
+	}
+}
+
+// Register 注册登录提供方，displayName 为前端按钮上的名称
+func (s *OIDCService) Register(provider OIDCProvider, displayName string) {
+	if displayName == "" {
+		displayName = provider.Name()
+	}
+	s.providers[provider.Name()] = provider
+	s.infos = append(s.infos, domain.OIDCProviderInfo{
+		Name:        provider.Name(),
+		DisplayName: displayName,
+	})
+}
+
+func (s *OIDCService) Providers() []domain.OIDCProviderInfo {
+	return s.infos
+}
+
+// Begin 生成 state、nonce 和 PKCE 校验码，返回授权地址和需要保存在会话中的授权请求；
+// linkUserId 非 0 时回调后为该用户绑定外部账号
+func (s *OIDCService) Begin(ctx context.Context, providerName string, linkUserId int64) (string, domain.OIDCAuthState, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", domain.OIDCAuthState{}, ErrOIDCProviderNotFound
+	}
+	state := domain.OIDCAuthState{
+		Provider:   providerName,
+		LinkUserId: linkUserId,
+		Ctime:      time.Now().UnixMilli(),
+	}
+	var err error
+	for _, field := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *field, err = oidc.RandomString(); err != nil {
+			return "", domain.OIDCAuthState{}, err
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return "", domain.OIDCAuthState{}, err
+	}
+	return authURL, state, nil
+}
+
+// Complete 处理回调：校验 state，换取并校验 ID 令牌，然后登录、绑定或自动注册
+func (s *OIDCService) Complete(ctx context.Context, providerName string, saved domain.OIDCAuthState, state, code, ip string) (*domain.User, error) {
+	user, err := s.complete(ctx, providerName, saved, state, code, ip)
+	// 绑定外部账号不计入登录次数
+	if saved.LinkUserId == 0 {
+		result := metrics.LoginResultSuccess
+		if err != nil {
+			result = metrics.LoginResultFailure
+		}
+		s.userService.metrics.Login(metrics.LoginMethodOIDC, result)
+	}
+	return user, err
+}
+
+func (s *OIDCService) complete(ctx context.Context, providerName string, saved domain.OIDCAuthState, state, code, ip string) (*domain.User, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	if saved.Provider != providerName || saved.State == "" ||
+		subtle.ConstantTimeCompare([]byte(saved.State), []byte(state)) != 1 ||
+		time.Since(time.UnixMilli(saved.Ctime)) > oidcStateTTL {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	claims, err := provider.Exchange(ctx, code, saved.Verifier, saved.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved.LinkUserId != 0 {
+		return s.link(ctx, saved.LinkUserId, providerName, claims, ip)
+	}
+
+	identity, err := s.identities.FindBySubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		return s.userRepo.FindById(ctx, identity.UserId)
+	}
+	if !errors.Is(err, dao.ErrUserIdentityNotFound) {
+		return nil, err
+	}
+	return s.provision(ctx, providerName, claims, ip)
+}
+
+func (s *OIDCService) link(ctx context.Context, userId int64, providerName string, claims oidc.Claims, ip string) (*domain.User, error) {
+	identity, err := s.identities.FindBySubject(ctx, providerName, claims.Subject)
+	if err == nil {
+		if identity.UserId != userId {
+			return nil, ErrOIDCIdentityTaken
+		}
+		return s.userRepo.FindById(ctx, userId)
+	}
+	if !errors.Is(err, dao.ErrUserIdentityNotFound) {
+		return nil, err
+	}
+
+	linked, err := s.identities.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range linked {
+		if l.Provider == providerName {
+			return nil, ErrOIDCProviderLinked
+		}
+	}
+
+	if err := s.identities.Create(ctx, domain.UserIdentity{
+		UserId:   userId,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, userId, domain.AuditActionIdentityLink, ip, providerName)
+	return s.userRepo.FindById(ctx, userId)
+}
+
+// provision 首次使用外部账号登录时自动注册
+func (s *OIDCService) provision(ctx context.Context, providerName string, claims oidc.Claims, ip string) (*domain.User, error) {
+	if !s.autoProvision {
+		return nil, ErrOIDCSignupDisabled
+	}
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailMissing
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, claims.Email); err == nil {
+		return nil, ErrOIDCEmailRegistered
+	}
+
+	user, err := s.userService.SignUpExternal(ctx, externalUsername(claims), claims.Email, claims.Name, claims.EmailVerified)
+	if errors.Is(err, ErrUserDuplicateEmail) {
+		return nil, ErrOIDCEmailRegistered
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.identities.Create(ctx, domain.UserIdentity{
+		UserId:   user.Id,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, user.Id, domain.AuditActionIdentityLink, ip, providerName)
+	return user, nil
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userId int64) ([]domain.UserIdentity, error) {
+	return s.identities.FindByUser(ctx, userId)
+}
+
+// Unlink 解绑外部账号，没有设置密码的用户不能解绑最后一个外部账号，否则将无法登录
+func (s *OIDCService) Unlink(ctx context.Context, userId int64, providerName, ip string) error {
+	user, err := s.userRepo.FindById(ctx, userId)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.HasPassword() {
+		identities, err := s.identities.FindByUser(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return ErrOIDCLastLoginMethod
+		}
+	}
+	err = s.identities.Delete(ctx, userId, providerName)
+	if errors.Is(err, dao.ErrUserIdentityNotFound) {
+		return ErrOIDCIdentityNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, userId, domain.AuditActionIdentityUnlink, ip, providerName)
+	return nil
+}
+
+// externalUsername 从 preferred_username 或邮箱前缀生成用户名，只保留字母、数字、下划线和连字符
+func externalUsername(claims oidc.Claims) string {
+	source := claims.PreferredUsername
+	if source == "" {
+		source, _, _ = strings.Cut(claims.Email, "@")
+	}
+	var b strings.Builder
+	for _, r := range source {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+			b.WriteRune(r)
+		}
+		if b.Len() >= 20 {
+			break
+		}
+	}
+	if b.Len() < 3 {
+		return "user_" + b.String()
+	}
+	return b.String()
+}
@@ -0,0 +1,239 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 21:00:00
+ * @Description: 外部账号登录回调的测试，使用本地模拟的 OIDC 提供方
+ */
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/mail"
+	"negaihoshi/server/src/oidc"
+	"negaihoshi/server/src/oidc/oidctest"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/repository/repotest"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/util"
+)
+
+const testProvider = "fake"
+
+type oidcFixture struct {
+	svc        *service.OIDCService
+	users      repository.UserRepository
+	identities *repository.UserIdentityRepository
+	issuer     *oidctest.Issuer
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	db := repotest.OpenSQLite(t)
+	users := repository.NewUserRepository(dao.NewUserDAO(db))
+	notifications := service.NewNotificationService(repository.NewNotificationRepository(dao.NewNotificationDAO(db)))
+	audit := service.NewAuditService(repository.NewAuditLogRepository(dao.NewAuditLogDAO(db)), notifications)
+	userService := service.NewUserService(users, util.NewPasswordCrypto([]byte("oidc-test-key")), nil, nil, nil, nil,
+		mail.LogMailer{}, "", service.NopPublisher{}, notifications, nil, 0, nil)
+	identities := repository.NewUserIdentityRepository(dao.NewUserIdentityDAO(db))
+	svc := service.NewOIDCService(identities, users, userService, audit, true)
+
+	issuer, err := oidctest.NewIssuer("client", "secret")
+	if err != nil {
+		t.Fatalf("启动模拟提供方失败: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+	svc.Register(oidc.NewProvider(issuer.Config(testProvider, "http://localhost/callback")), "")
+	return &oidcFixture{svc: svc, users: users, identities: identities, issuer: issuer}
+}
+
+// authorize 发起授权并模拟用户同意，返回会话中保存的授权请求以及回调携带的 state 和 code
+func (f *oidcFixture) authorize(t *testing.T, linkUserId int64) (domain.OIDCAuthState, string, string) {
+	t.Helper()
+	authURL, saved, err := f.svc.Begin(context.Background(), testProvider, linkUserId)
+	if err != nil {
+		t.Fatalf("Begin 失败: %v", err)
+	}
+	code, state, err := f.issuer.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("模拟授权失败: %v", err)
+	}
+	return saved, state, code
+}
+
+func TestOIDCComplete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Provision", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, state, code := f.authorize(t, 0)
+		user, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("首次登录失败: %v", err)
+		}
+		if user.Email != "fake@example.com" || !user.EmailVerified {
+			t.Fatalf("自动注册的用户不正确: %+v", user)
+		}
+
+		saved, state, code = f.authorize(t, 0)
+		again, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("再次登录失败: %v", err)
+		}
+		if again.Id != user.Id {
+			t.Fatalf("再次登录应返回同一用户，得到 %d，期望 %d", again.Id, user.Id)
+		}
+	})
+
+	t.Run("BadState", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, _, code := f.authorize(t, 0)
+		_, err := f.svc.Complete(ctx, testProvider, saved, "forged-state", code, "127.0.0.1")
+		if !errors.Is(err, service.ErrOIDCStateInvalid) {
+			t.Fatalf("state 不一致应返回 ErrOIDCStateInvalid，得到 %v", err)
+		}
+	})
+
+	t.Run("ExpiredState", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, state, code := f.authorize(t, 0)
+		saved.Ctime = time.Now().Add(-11 * time.Minute).UnixMilli()
+		_, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if !errors.Is(err, service.ErrOIDCStateInvalid) {
+			t.Fatalf("过期的授权请求应返回 ErrOIDCStateInvalid，得到 %v", err)
+		}
+	})
+
+	t.Run("NonceMismatch", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, state, code := f.authorize(t, 0)
+		saved.Nonce = "other-nonce"
+		_, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Fatalf("nonce 不一致应返回 ErrInvalidIDToken，得到 %v", err)
+		}
+	})
+
+	t.Run("VerifierMismatch", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, state, code := f.authorize(t, 0)
+		saved.Verifier = "other-verifier-other-verifier-other-verifier"
+		_, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if !errors.Is(err, oidc.ErrTokenExchange) {
+			t.Fatalf("PKCE 校验码不一致应返回 ErrTokenExchange，得到 %v", err)
+		}
+	})
+
+	t.Run("LinkIdentityOwnedByOtherUser", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		saved, state, code := f.authorize(t, 0)
+		if _, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1"); err != nil {
+			t.Fatalf("首次登录失败: %v", err)
+		}
+
+		other := &domain.User{Username: "other", Email: "other@example.com", Password: "hashed-other"}
+		if err := f.users.Create(ctx, other); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		saved, state, code = f.authorize(t, other.Id)
+		_, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if !errors.Is(err, service.ErrOIDCIdentityTaken) {
+			t.Fatalf("绑定已属于其他用户的外部账号应返回 ErrOIDCIdentityTaken，得到 %v", err)
+		}
+	})
+
+	t.Run("EmailRegistered", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		existing := &domain.User{Username: "existing", Email: "fake@example.com", Password: "hashed-existing"}
+		if err := f.users.Create(ctx, existing); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		saved, state, code := f.authorize(t, 0)
+		_, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if !errors.Is(err, service.ErrOIDCEmailRegistered) {
+			t.Fatalf("邮箱已注册时应返回 ErrOIDCEmailRegistered，得到 %v", err)
+		}
+	})
+}
+
+func TestOIDCUnlink(t *testing.T) {
+	ctx := context.Background()
+
+	// provision 通过外部账号自动注册，得到没有密码的用户
+	provision := func(t *testing.T, f *oidcFixture) *domain.User {
+		t.Helper()
+		saved, state, code := f.authorize(t, 0)
+		user, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("首次登录失败: %v", err)
+		}
+		if user.HasPassword() {
+			t.Fatal("自动注册的用户不应设置密码")
+		}
+		return user
+	}
+
+	tests := []struct {
+		name string
+		// setup 返回要解绑的用户
+		setup func(t *testing.T, f *oidcFixture) int64
+		want  error
+	}{
+		{
+			name: "OnlyIdentityWithoutPassword",
+			setup: func(t *testing.T, f *oidcFixture) int64 {
+				return provision(t, f).Id
+			},
+			want: service.ErrOIDCLastLoginMethod,
+		},
+		{
+			name: "OtherIdentityWithoutPassword",
+			setup: func(t *testing.T, f *oidcFixture) int64 {
+				user := provision(t, f)
+				if err := f.identities.Create(ctx, domain.UserIdentity{UserId: user.Id, Provider: "other", Subject: "other-subject"}); err != nil {
+					t.Fatalf("绑定外部账号失败: %v", err)
+				}
+				return user.Id
+			},
+		},
+		{
+			name: "OnlyIdentityWithPassword",
+			setup: func(t *testing.T, f *oidcFixture) int64 {
+				user := &domain.User{Username: "alice", Email: "alice@example.com", Password: "hashed-alice"}
+				if err := f.users.Create(ctx, user); err != nil {
+					t.Fatalf("创建用户失败: %v", err)
+				}
+				saved, state, code := f.authorize(t, user.Id)
+				if _, err := f.svc.Complete(ctx, testProvider, saved, state, code, "127.0.0.1"); err != nil {
+					t.Fatalf("绑定外部账号失败: %v", err)
+				}
+				return user.Id
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t)
+			userId := tt.setup(t, f)
+			err := f.svc.Unlink(ctx, userId, testProvider, "127.0.0.1")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Unlink 返回 %v，期望 %v", err, tt.want)
+			}
+			identities, err := f.identities.FindByUser(ctx, userId)
+			if err != nil {
+				t.Fatalf("查询外部账号失败: %v", err)
+			}
+			linked := false
+			for _, identity := range identities {
+				linked = linked || identity.Provider == testProvider
+			}
+			if linked != (tt.want != nil) {
+				t.Fatalf("解绑后仍绑定为 %t，期望 %t", linked, tt.want != nil)
+			}
+		})
+	}
+}
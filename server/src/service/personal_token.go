@@ -0,0 +1,180 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 17:00:00
+ * @Description: 个人访问令牌，供脚本调用 API 使用
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrPersonalTokenNotFound = errors.New("访问令牌不存在")
+	ErrPersonalTokenLimit    = errors.New("访问令牌数量已达上限")
+	ErrPersonalTokenScope    = errors.New("不支持的权限范围")
+	ErrPersonalTokenDenied   = errors.New("不能授予自己没有的权限范围")
+)
+
+const (
+	// PersonalTokenPrefix 个人访问令牌的固定前缀，用于和 JWT 区分
+	PersonalTokenPrefix   = "nhp_"
+	maxPersonalTokens     = 20
+	personalTokenTouchGap = time.Minute
+)
+
+type PersonalTokenService struct {
+	repo  *repository.PersonalTokenRepository
+	users repository.UserRepository
+}
+
+func NewPersonalTokenService(repo *repository.PersonalTokenRepository, users repository.UserRepository) *PersonalTokenService {
+	return &PersonalTokenService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// Create 创建个人访问令牌，明文令牌只在这里返回一次；ttl 为 0 表示永不过期。
+// 只能授予调用者自己拥有的权限范围
+func (s *PersonalTokenService) Create(ctx context.Context, principal domain.Principal, name string, scopes []string, ttl time.Duration) (string, domain.PersonalAccessToken, error) {
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	granted, err := s.GrantableScopes(ctx, principal)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	for _, scope := range scopes {
+		if !containsScope(granted, scope) {
+			return "", domain.PersonalAccessToken{}, ErrPersonalTokenDenied
+		}
+	}
+	userId := principal.UserId
+	count, err := s.repo.CountByUser(ctx, userId)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	if count >= maxPersonalTokens {
+		return "", domain.PersonalAccessToken{}, ErrPersonalTokenLimit
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	plain := PersonalTokenPrefix + secret
+	token := domain.PersonalAccessToken{
+		UserId: userId,
+		Name:   name,
+		Scopes: scopes,
+		Prefix: plain[:len(PersonalTokenPrefix)+4],
+	}
+	if ttl > 0 {
+		expireAt := time.Now().Add(ttl)
+		token.ExpireAt = &expireAt
+	}
+
+	created, err := s.repo.Create(ctx, hashToken(plain), token)
+	if err != nil {
+		return "", domain.PersonalAccessToken{}, err
+	}
+	return plain, created, nil
+}
+
+// GrantableScopes 调用者可以授予令牌的权限范围，管理后台权限只有管理员拥有
+func (s *PersonalTokenService) GrantableScopes(ctx context.Context, principal domain.Principal) ([]string, error) {
+	user, err := s.users.FindById(ctx, principal.UserId)
+	if err != nil {
+		return nil, err
+	}
+	scopes := make([]string, 0, len(domain.AllScopes))
+	for _, scope := range domain.AllScopes {
+		if scope == domain.ScopeAdminAll && !user.IsAdmin() {
+			continue
+		}
+		if principal.HasScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func (s *PersonalTokenService) List(ctx context.Context, userId int64) ([]domain.PersonalAccessToken, error) {
+	return s.repo.FindByUser(ctx, userId)
+}
+
+func (s *PersonalTokenService) Revoke(ctx context.Context, userId, id int64) error {
+	err := s.repo.Delete(ctx, userId, id)
+	if errors.Is(err, dao.ErrPersonalTokenNotFound) {
+		return ErrPersonalTokenNotFound
+	}
+	return err
+}
+
+// VerifyAccessToken 校验个人访问令牌，返回带权限范围的请求主体
+func (s *PersonalTokenService) VerifyAccessToken(ctx context.Context, plain string) (domain.Principal, error) {
+	token, err := s.repo.FindByHash(ctx, hashToken(plain))
+	if errors.Is(err, dao.ErrPersonalTokenNotFound) {
+		return domain.Principal{}, ErrAccessTokenInvalid
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if token.ExpireAt != nil && time.Now().After(*token.ExpireAt) {
+		return domain.Principal{}, ErrAccessTokenExpired
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > personalTokenTouchGap {
+		if err := s.repo.Touch(ctx, token.Id); err != nil {
+			log.Printf("更新访问令牌使用时间失败: %v", err)
+		}
+	}
+	return domain.Principal{
+		UserId: token.UserId,
+		Method: domain.AuthMethodToken,
+		Scopes: token.Scopes,
+	}, nil
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return nil, ErrPersonalTokenScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrPersonalTokenScope
+	}
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	return containsScope(domain.AllScopes, scope)
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
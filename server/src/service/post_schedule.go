@@ -0,0 +1,145 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 14:00:00
+ * @Description: 文章的草稿、定时发布、私密和不公开状态，以及定时发布任务
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrPostStateInvalid = errors.New("不支持的文章状态")
+	ErrPublishAtInvalid = errors.New("定时发布时间必须晚于当前时间")
+)
+
+const (
+	postScheduleInterval  = time.Minute
+	postScheduleBatchSize = 100
+)
+
+// ChangePostState 作者修改文章状态，crossPost 为 nil 时保持原来的转发设置。
+// 草稿或定时文章变为可见时按转发设置转发到绑定的 WordPress 站点，
+// 已可见的文章在公开、私密和不公开之间切换时保留原发布时间
+func (s *StatusAndPostsService) ChangePostState(ctx context.Context, userId, id int64, state string, publishAt time.Time, crossPost *bool) (domain.Posts, error) {
+	if !domain.ValidPostState(state) {
+		return domain.Posts{}, ErrPostStateInvalid
+	}
+	if err := s.checkContentOwner(ctx, userId, domain.RevisionPost, id); err != nil {
+		return domain.Posts{}, err
+	}
+	current, err := s.repo.GetPosts(ctx, id)
+	if errors.Is(err, dao.ErrPostsNotFound) {
+		return domain.Posts{}, ErrContentNotFound
+	}
+	if err != nil {
+		return domain.Posts{}, err
+	}
+
+	wasVisible := publishedNow(current.State)
+	if publishedNow(state) && wasVisible && !current.PublishAt.IsZero() {
+		publishAt = current.PublishAt
+	} else if publishAt, err = postPublishAt(state, publishAt, time.Now()); err != nil {
+		return domain.Posts{}, err
+	}
+	wantCrossPost := current.CrossPost
+	if crossPost != nil {
+		wantCrossPost = *crossPost
+	}
+	updated := current
+	updated.State = state
+	updated.PublishAt = publishAt
+	updated.CrossPost = wantCrossPost && !publishedNow(state)
+	if err := s.repo.ChangePostsState(ctx, id, state, publishAt, updated.CrossPost); err != nil {
+		return domain.Posts{}, err
+	}
+
+	if state == domain.PostStatePublished && current.State != domain.PostStatePublished {
+		s.onPostsPublished(ctx, updated)
+	}
+	if publishedNow(state) && !wasVisible && wantCrossPost {
+		s.crossPoster.CrossPostPosts(ctx, updated)
+	}
+	return updated, nil
+}
+
+// RunScheduler 定期发布到期的定时文章，直到 ctx 结束
+func (s *StatusAndPostsService) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(postScheduleInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PublishDuePosts(ctx); err != nil {
+			log.Printf("发布定时文章失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已发布到期的定时文章 %d 篇", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDuePosts 发布所有到期的定时文章，返回本次发布的篇数。
+// 多个实例同时执行时每篇文章只会被一个实例发布和转发
+func (s *StatusAndPostsService) PublishDuePosts(ctx context.Context) (int, error) {
+	now := time.Now()
+	var total int
+	for {
+		due, err := s.repo.FindDueScheduledPosts(ctx, now, postScheduleBatchSize)
+		if err != nil {
+			return total, err
+		}
+		for _, p := range due {
+			ok, err := s.repo.PublishScheduledPosts(ctx, p.Id, now)
+			if err != nil {
+				return total, err
+			}
+			if !ok {
+				// 已被其他实例发布，或作者在此期间修改了状态
+				continue
+			}
+			total++
+			crossPost := p.CrossPost
+			p.State = domain.PostStatePublished
+			p.CrossPost = false
+			s.onPostsPublished(ctx, p)
+			if crossPost {
+				s.crossPoster.CrossPostPosts(ctx, p)
+			}
+		}
+		if len(due) < postScheduleBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// postPublishAt 校验并计算文章的发布时间，草稿没有发布时间，立即可见的文章发布时间为 now
+func postPublishAt(state string, publishAt, now time.Time) (time.Time, error) {
+	switch state {
+	case domain.PostStateDraft:
+		return time.Time{}, nil
+	case domain.PostStateScheduled:
+		if !publishAt.After(now) {
+			return time.Time{}, ErrPublishAtInvalid
+		}
+		return publishAt, nil
+	case domain.PostStatePublished, domain.PostStatePrivate, domain.PostStateUnlisted:
+		return now, nil
+	default:
+		return time.Time{}, ErrPostStateInvalid
+	}
+}
+
+// publishedNow 该状态的文章是否已经发布，包括只有作者可见的私密文章
+func publishedNow(state string) bool {
+	return state == domain.PostStatePublished || state == domain.PostStatePrivate || state == domain.PostStateUnlisted
+}
@@ -0,0 +1,120 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 10:00:00
+ * @Description: 内容举报服务
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrReportDuplicate      = errors.New("已举报过该内容")
+	ErrReportTargetNotFound = errors.New("举报的内容不存在")
+	ErrReportNotFound       = errors.New("举报记录不存在")
+)
+
+// 默认自动隐藏阈值
+const defaultReportHideThreshold = 5
+
+type ReportService struct {
+	repo          *repository.ReportRepository
+	hideThreshold int64
+	events        EventPublisher
+	notifications *NotificationService
+}
+
+func NewReportService(repo *repository.ReportRepository, hideThreshold int, events EventPublisher, notifications *NotificationService) *ReportService {
+	if hideThreshold <= 0 {
+		hideThreshold = defaultReportHideThreshold
+	}
+	return &ReportService{
+		repo:          repo,
+		hideThreshold: int64(hideThreshold),
+		events:        events,
+		notifications: notifications,
+	}
+}
+
+// CreateReport 提交举报，待处理举报数达到阈值时自动隐藏内容
+func (s *ReportService) CreateReport(ctx context.Context, report domain.Report) error {
+	exists, err := s.repo.TargetExists(ctx, report.TargetType, report.TargetId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrReportTargetNotFound
+	}
+
+	err = s.repo.Create(ctx, report)
+	if errors.Is(err, dao.ErrReportDuplicate) {
+		return ErrReportDuplicate
+	}
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountPendingByTarget(ctx, report.TargetType, report.TargetId)
+	if err != nil {
+		return err
+	}
+	if count >= s.hideThreshold {
+		if err := s.repo.SetTargetHidden(ctx, report.TargetType, report.TargetId, true); err != nil {
+			return err
+		}
+		if eventType, ok := moderatedEventTypes[report.TargetType]; ok {
+			s.events.Publish(ctx, eventType, moderationEventData(report.TargetId, ModerationActionAutoHide, ""))
+		}
+		if ownerId, err := s.repo.TargetOwner(ctx, report.TargetType, report.TargetId); err == nil {
+			s.notifications.ContentModerated(ctx, ownerId, report.TargetType, report.TargetId, ModerationActionAutoHide, "")
+		} else {
+			log.Printf("查询被举报内容作者失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 举报对象对应的审核事件
+var moderatedEventTypes = map[string]string{
+	domain.ReportTargetTreeHole: domain.EventTreeholeModerated,
+	domain.ReportTargetStatus:   domain.EventStatusModerated,
+	domain.ReportTargetPost:     domain.EventPostModerated,
+}
+
+func (s *ReportService) GetReport(ctx context.Context, id int64) (domain.Report, error) {
+	report, err := s.repo.GetById(ctx, id)
+	if errors.Is(err, dao.ErrReportNotFound) {
+		return domain.Report{}, ErrReportNotFound
+	}
+	return report, err
+}
+
+// 管理后台相关方法
+
+// 获取举报列表（管理后台）
+func (s *ReportService) GetReportListForAdmin(ctx context.Context, page, size int, status string) ([]domain.Report, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+	return s.repo.GetList(ctx, status, (page-1)*size, size)
+}
+
+// 内容审核通过后驳回该内容的全部待处理举报
+func (s *ReportService) DismissTargetReports(ctx context.Context, targetType string, targetId, handlerId int64, note string) error {
+	return s.repo.ResolveByTarget(ctx, targetType, targetId, domain.ReportStatusDismissed, handlerId, note)
+}
+
+// 内容审核拒绝后将该内容的全部待处理举报标记为已处理
+func (s *ReportService) ResolveTargetReports(ctx context.Context, targetType string, targetId, handlerId int64, note string) error {
+	return s.repo.ResolveByTarget(ctx, targetType, targetId, domain.ReportStatusResolved, handlerId, note)
+}
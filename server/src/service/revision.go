@@ -0,0 +1,202 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 13:00:00
+ * @Description: 动态和文章的编辑历史：查看、比较和恢复历史版本
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrContentNotFound  = errors.New("内容不存在")
+	ErrRevisionNotFound = errors.New("历史版本不存在")
+)
+
+// 去掉首尾相同的行后，剩余行数的乘积超过该值时不再逐行比较，直接视为整体替换。
+// 比较表每格一个 int，该值对应约 2MB 内存
+const maxDiffCells = 250_000
+
+// ListRevisions 作者查看自己内容的编辑历史，按版本先后排列
+func (s *StatusAndPostsService) ListRevisions(ctx context.Context, userId int64, kind string, id int64) ([]domain.ContentRevision, error) {
+	if err := s.checkContentOwner(ctx, userId, kind, id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRevisions(ctx, kind, id)
+}
+
+// ListRevisionsForAdmin 管理员查看任意内容的编辑历史，包括已隐藏和已删除的内容
+func (s *StatusAndPostsService) ListRevisionsForAdmin(ctx context.Context, kind string, id int64) ([]domain.ContentRevision, error) {
+	if !validRevisionKind(kind) {
+		return nil, ErrContentTypeInvalid
+	}
+	return s.repo.FindRevisions(ctx, kind, id)
+}
+
+// DiffRevisions 作者比较自己内容的两个历史版本
+func (s *StatusAndPostsService) DiffRevisions(ctx context.Context, userId int64, kind string, id, fromId, toId int64) (domain.RevisionDiff, error) {
+	if err := s.checkContentOwner(ctx, userId, kind, id); err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	return s.diffRevisions(ctx, kind, id, fromId, toId)
+}
+
+func (s *StatusAndPostsService) DiffRevisionsForAdmin(ctx context.Context, kind string, id, fromId, toId int64) (domain.RevisionDiff, error) {
+	if !validRevisionKind(kind) {
+		return domain.RevisionDiff{}, ErrContentTypeInvalid
+	}
+	return s.diffRevisions(ctx, kind, id, fromId, toId)
+}
+
+// RestoreRevision 把内容恢复为某个历史版本，恢复本身作为一次新的编辑追加到历史中
+func (s *StatusAndPostsService) RestoreRevision(ctx context.Context, userId int64, kind string, id, revisionId int64) error {
+	if err := s.checkContentOwner(ctx, userId, kind, id); err != nil {
+		return err
+	}
+	revision, err := s.findRevision(ctx, kind, id, revisionId)
+	if err != nil {
+		return err
+	}
+	if kind == domain.RevisionStatus {
+		return s.EditStatusMessage(ctx, domain.Status{Id: id, UserId: userId, Content: revision.Content})
+	}
+	return s.EditPostsMessage(ctx, domain.Posts{Id: id, UserId: userId, Title: revision.Title, Content: revision.Content})
+}
+
+func (s *StatusAndPostsService) diffRevisions(ctx context.Context, kind string, id, fromId, toId int64) (domain.RevisionDiff, error) {
+	from, err := s.findRevision(ctx, kind, id, fromId)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	to, err := s.findRevision(ctx, kind, id, toId)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	diff := domain.RevisionDiff{From: from, To: to, Content: diffLines(from.Content, to.Content)}
+	if kind == domain.RevisionPost {
+		diff.Title = diffLines(from.Title, to.Title)
+	}
+	return diff, nil
+}
+
+func (s *StatusAndPostsService) findRevision(ctx context.Context, kind string, id, revisionId int64) (domain.ContentRevision, error) {
+	revision, err := s.repo.FindRevision(ctx, kind, id, revisionId)
+	if errors.Is(err, dao.ErrRevisionNotFound) {
+		return domain.ContentRevision{}, ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+func (s *StatusAndPostsService) checkContentOwner(ctx context.Context, userId int64, kind string, id int64) error {
+	var ownerId int64
+	var err error
+	switch kind {
+	case domain.RevisionStatus:
+		ownerId, err = s.repo.FindStatusOwner(ctx, id)
+	case domain.RevisionPost:
+		ownerId, err = s.repo.FindPostsOwner(ctx, id)
+	default:
+		return ErrContentTypeInvalid
+	}
+	if errors.Is(err, dao.ErrStatusNotFound) || errors.Is(err, dao.ErrPostsNotFound) {
+		return ErrContentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerId != userId {
+		return ErrContentNotOwner
+	}
+	return nil
+}
+
+func validRevisionKind(kind string) bool {
+	return kind == domain.RevisionStatus || kind == domain.RevisionPost
+}
+
+// diffLines 按最长公共子序列逐行比较 a 和 b，首尾相同的行直接输出，只比较中间变化的部分
+func diffLines(a, b string) []domain.DiffLine {
+	x, y := splitLines(a), splitLines(b)
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]domain.DiffLine, 0, max(len(x), len(y)))
+	for _, l := range x[:prefix] {
+		lines = append(lines, domain.DiffLine{Op: domain.DiffEqual, Text: l})
+	}
+	lines = diffMiddle(lines, x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])
+	for _, l := range x[len(x)-suffix:] {
+		lines = append(lines, domain.DiffLine{Op: domain.DiffEqual, Text: l})
+	}
+	return lines
+}
+
+// diffMiddle 把 x 和 y 的逐行比较结果追加到 lines，超过 maxDiffCells 时视为整体替换
+func diffMiddle(lines []domain.DiffLine, x, y []string) []domain.DiffLine {
+	if len(x)*len(y) > maxDiffCells {
+		for _, l := range x {
+			lines = append(lines, domain.DiffLine{Op: domain.DiffDelete, Text: l})
+		}
+		for _, l := range y {
+			lines = append(lines, domain.DiffLine{Op: domain.DiffInsert, Text: l})
+		}
+		return lines
+	}
+
+	// lcs[i][j] 为 x[i:] 与 y[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, domain.DiffLine{Op: domain.DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, domain.DiffLine{Op: domain.DiffDelete, Text: x[i]})
+			i++
+		default:
+			lines = append(lines, domain.DiffLine{Op: domain.DiffInsert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, domain.DiffLine{Op: domain.DiffDelete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, domain.DiffLine{Op: domain.DiffInsert, Text: y[j]})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
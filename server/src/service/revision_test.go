@@ -0,0 +1,79 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-21 10:30:00
+ * @Description: 编辑历史逐行比较的测试
+ */
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"negaihoshi/server/src/domain"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffEqual, Text: text} }
+	ins := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffInsert, Text: text} }
+	del := func(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffDelete, Text: text} }
+
+	tests := []struct {
+		name string
+		a, b string
+		want []domain.DiffLine
+	}{
+		{"BothEmpty", "", "", []domain.DiffLine{}},
+		{"FromEmpty", "", "a\nb", []domain.DiffLine{ins("a"), ins("b")}},
+		{"ToEmpty", "a\nb", "", []domain.DiffLine{del("a"), del("b")}},
+		{"Identical", "a\nb\nc", "a\nb\nc", []domain.DiffLine{eq("a"), eq("b"), eq("c")}},
+		{"CRLF", "a\r\nb", "a\nb", []domain.DiffLine{eq("a"), eq("b")}},
+		{"ChangeMiddle", "a\nb\nc", "a\nx\nc", []domain.DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"InsertAndDelete", "a\nb\nc\nd", "b\nc\ne\nd", []domain.DiffLine{del("a"), eq("b"), eq("c"), ins("e"), eq("d")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffLines(%q, %q) = %v，期望 %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesOverCap(t *testing.T) {
+	// 中间变化的部分行数乘积超过上限，首尾相同的行仍按相同输出，中间视为整体替换
+	n := 600
+	if n*n <= maxDiffCells {
+		t.Fatalf("测试数据需要超过 maxDiffCells")
+	}
+	numbered := func(prefix string) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return lines
+	}
+	x, y := numbered("old"), numbered("new")
+	a := "head\n" + strings.Join(x, "\n") + "\ntail"
+	b := "head\n" + strings.Join(y, "\n") + "\ntail"
+
+	got := diffLines(a, b)
+	if len(got) != 2*n+2 {
+		t.Fatalf("结果行数为 %d，期望 %d", len(got), 2*n+2)
+	}
+	if got[0] != (domain.DiffLine{Op: domain.DiffEqual, Text: "head"}) || got[len(got)-1] != (domain.DiffLine{Op: domain.DiffEqual, Text: "tail"}) {
+		t.Fatalf("首尾相同的行应保留为相同，得到 %v 和 %v", got[0], got[len(got)-1])
+	}
+	for i, line := range got[1 : len(got)-1] {
+		var want domain.DiffLine
+		if i < n {
+			want = domain.DiffLine{Op: domain.DiffDelete, Text: x[i]}
+		} else {
+			want = domain.DiffLine{Op: domain.DiffInsert, Text: y[i-n]}
+		}
+		if line != want {
+			t.Fatalf("第 %d 行为 %v，期望 %v", i+1, line, want)
+		}
+	}
+}
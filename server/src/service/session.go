@@ -0,0 +1,185 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 14:00:00
+ * @Description: 服务端会话登记，支持查看和注销登录设备
+ */
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrSessionInvalid  = errors.New("登录已失效，请重新登录")
+	ErrSessionNotFound = errors.New("会话不存在")
+)
+
+const (
+	sessionTTL = 30 * 24 * time.Hour
+	// 待完成两步验证的会话有效期
+	pendingSessionTTL = 10 * time.Minute
+	// 最后活跃时间的刷新间隔，避免每个请求都写库
+	sessionTouchInterval = time.Minute
+)
+
+type SessionService struct {
+	repo *repository.UserSessionRepository
+	// 注销其他设备时一并注销 API 客户端的刷新令牌
+	refreshRepo *repository.RefreshTokenRepository
+	// 修改或重置密码时一并撤销个人访问令牌
+	tokenRepo *repository.PersonalTokenRepository
+}
+
+func NewSessionService(repo *repository.UserSessionRepository, refreshRepo *repository.RefreshTokenRepository, tokenRepo *repository.PersonalTokenRepository) *SessionService {
+	return &SessionService{
+		repo:        repo,
+		refreshRepo: refreshRepo,
+		tokenRepo:   tokenRepo,
+	}
+}
+
+// Create 登记新会话，返回写入 cookie 的会话ID；pending 为 true 时会话只能用于完成两步验证
+func (s *SessionService) Create(ctx context.Context, userId int64, userAgent, ip string, pending bool) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	sid := hex.EncodeToString(buf)
+
+	ttl := sessionTTL
+	if pending {
+		ttl = pendingSessionTTL
+	}
+	err := s.repo.Create(ctx, hashSid(sid), domain.UserSession{
+		UserId:    userId,
+		Device:    describeDevice(userAgent),
+		UserAgent: userAgent,
+		Ip:        ip,
+		Pending:   pending,
+	}, time.Now().Add(ttl))
+	if err != nil {
+		return "", err
+	}
+	return sid, nil
+}
+
+// Validate 校验会话是否仍然有效
+func (s *SessionService) Validate(ctx context.Context, sid, ip string) (domain.UserSession, error) {
+	if sid == "" {
+		return domain.UserSession{}, ErrSessionInvalid
+	}
+	session, err := s.repo.FindBySid(ctx, hashSid(sid))
+	if errors.Is(err, dao.ErrUserSessionNotFound) {
+		return domain.UserSession{}, ErrSessionInvalid
+	}
+	if err != nil {
+		return domain.UserSession{}, err
+	}
+
+	if time.Since(session.LastSeen) > sessionTouchInterval || session.Ip != ip {
+		if err := s.repo.Touch(ctx, hashSid(sid), ip); err != nil {
+			log.Printf("更新会话活跃时间失败: %v", err)
+		}
+	}
+	return session, nil
+}
+
+// Promote 两步验证完成后将待验证会话转为正式会话
+func (s *SessionService) Promote(ctx context.Context, sid string) error {
+	return s.repo.Promote(ctx, hashSid(sid), time.Now().Add(sessionTTL))
+}
+
+// List 列出用户的全部有效会话
+func (s *SessionService) List(ctx context.Context, userId int64, currentSid string) ([]domain.UserSession, error) {
+	return s.repo.FindByUser(ctx, userId, hashSid(currentSid))
+}
+
+// Revoke 注销用户的指定会话
+func (s *SessionService) Revoke(ctx context.Context, userId, sessionId int64) error {
+	err := s.repo.DeleteById(ctx, userId, sessionId)
+	if errors.Is(err, dao.ErrUserSessionNotFound) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// RevokeCurrent 注销当前会话，用于退出登录
+func (s *SessionService) RevokeCurrent(ctx context.Context, sid string) error {
+	if sid == "" {
+		return nil
+	}
+	return s.repo.DeleteBySid(ctx, hashSid(sid))
+}
+
+// RevokeOthers 注销除当前会话外的全部会话和刷新令牌，currentSid 为空时注销全部会话
+func (s *SessionService) RevokeOthers(ctx context.Context, userId int64, currentSid string) error {
+	if err := s.refreshRepo.RevokeByUser(ctx, userId); err != nil {
+		return err
+	}
+	if currentSid == "" {
+		return s.repo.DeleteByUserExcept(ctx, userId, "")
+	}
+	return s.repo.DeleteByUserExcept(ctx, userId, hashSid(currentSid))
+}
+
+// RevokeCredentials 修改或重置密码后调用，在 RevokeOthers 的基础上撤销全部个人访问令牌，
+// 密码泄露时用旧密码创建的令牌同样不可信
+func (s *SessionService) RevokeCredentials(ctx context.Context, userId int64, currentSid string) error {
+	if err := s.RevokeOthers(ctx, userId, currentSid); err != nil {
+		return err
+	}
+	return s.tokenRepo.DeleteByUser(ctx, userId)
+}
+
+// 数据库中只保存会话ID的摘要，避免数据泄露后会话被直接冒用
+func hashSid(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeDevice 根据 User-Agent 粗略识别浏览器和操作系统，用于会话列表展示
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "未知浏览器"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "curl/") || strings.Contains(ua, "postman"):
+		browser = "API客户端"
+	}
+
+	os := "未知系统"
+	switch {
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		os = "iOS"
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "mac os"):
+		os = "macOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	}
+	return browser + " / " + os
+}
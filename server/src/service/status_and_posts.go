@@ -1,72 +1,219 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"log"
 	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/repository"
-
-	"github.com/gin-gonic/gin"
+	"negaihoshi/server/src/repository/dao"
+	"time"
 )
 
 type StatusAndPostsService struct {
-	repo *repository.StatusAndPostsRepository
+	repo          repository.StatusAndPostsRepository
+	events        EventPublisher
+	notifications *NotificationService
+	timeline      *TimelineService
+	visibility    *VisibilityFilter
+	crossPoster   *WordPressCrossPoster
 }
 
-func NewStatusAndPostsService(repo *repository.StatusAndPostsRepository) *StatusAndPostsService {
-	return &StatusAndPostsService{repo: repo}
+func NewStatusAndPostsService(repo repository.StatusAndPostsRepository, events EventPublisher, notifications *NotificationService, timeline *TimelineService, visibility *VisibilityFilter, crossPoster *WordPressCrossPoster) *StatusAndPostsService {
+	return &StatusAndPostsService{repo: repo, events: events, notifications: notifications, timeline: timeline, visibility: visibility, crossPoster: crossPoster}
 }
 
-func (s *StatusAndPostsService) CreateStatusMessage(c *gin.Context, status domain.Status) error {
-	err := s.repo.CreateStatus(c, status)
-	return err
+func (s *StatusAndPostsService) CreateStatusMessage(c context.Context, status domain.Status) error {
+	created, err := s.repo.CreateStatus(c, status)
+	if err != nil {
+		return err
+	}
+	s.events.Publish(c, domain.EventStatusCreated, statusEventData(created))
+	s.timeline.Publish(c, created.UserId, domain.TimelineEntry{Kind: domain.TimelineStatus, Id: created.Id, Ctime: created.Ctime.UnixMilli()})
+	return nil
 }
 
-func (s *StatusAndPostsService) CreatePostsMessage(c *gin.Context, posts domain.Posts) error {
-	err := s.repo.CreatePosts(c, posts)
-	return err
+// CreatePostsMessage 创建文章，State 为空时直接发布，定时发布的 PublishAt 必须晚于当前时间，
+// 只有草稿和定时发布的文章会保留 CrossPost，在发布时转发到作者绑定的 WordPress 站点
+func (s *StatusAndPostsService) CreatePostsMessage(c context.Context, posts domain.Posts) (domain.Posts, error) {
+	if posts.State == "" {
+		posts.State = domain.PostStatePublished
+	}
+	publishAt, err := postPublishAt(posts.State, posts.PublishAt, time.Now())
+	if err != nil {
+		return domain.Posts{}, err
+	}
+	posts.PublishAt = publishAt
+	posts.CrossPost = posts.CrossPost && !publishedNow(posts.State)
+	created, err := s.repo.CreatePosts(c, posts)
+	if err != nil {
+		return domain.Posts{}, err
+	}
+	if created.State == domain.PostStatePublished {
+		s.onPostsPublished(c, created)
+	}
+	return created, nil
 }
 
-func (s *StatusAndPostsService) EditStatusMessage(c *gin.Context, status domain.Status) error {
-	err := s.repo.EditStatus(c, status)
-	return err
+// EditStatusMessage 作者编辑自己的动态，status.UserId 为编辑人，编辑前的内容保留在编辑历史中
+func (s *StatusAndPostsService) EditStatusMessage(c context.Context, status domain.Status) error {
+	if err := s.checkContentOwner(c, status.UserId, domain.RevisionStatus, status.Id); err != nil {
+		return err
+	}
+	err := s.repo.EditStatus(c, status, status.UserId)
+	if err != nil {
+		return err
+	}
+	s.events.Publish(c, domain.EventStatusUpdated, statusEventData(status))
+	return nil
 }
 
-func (s *StatusAndPostsService) EditPostsMessage(c *gin.Context, posts domain.Posts) error {
-	err := s.repo.EditPosts(c, posts)
-	return err
+// EditPostsMessage 作者编辑自己的文章，posts.UserId 为编辑人，编辑前的内容保留在编辑历史中
+func (s *StatusAndPostsService) EditPostsMessage(c context.Context, posts domain.Posts) error {
+	if err := s.checkContentOwner(c, posts.UserId, domain.RevisionPost, posts.Id); err != nil {
+		return err
+	}
+	err := s.repo.EditPosts(c, posts, posts.UserId)
+	if err != nil {
+		return err
+	}
+	// 只有已发布的文章通知订阅方，避免泄露草稿和私密文章的内容
+	if current, err := s.repo.GetPosts(c, posts.Id); err == nil && current.State == domain.PostStatePublished {
+		s.events.Publish(c, domain.EventPostUpdated, postsEventData(posts))
+	}
+	return nil
 }
 
-func (s *StatusAndPostsService) GetPostFromThisSite(c *gin.Context, id int64) (domain.Posts, error) {
-	return s.repo.GetPosts(c, id)
+// 以下查询的 viewerId 为 0 表示未登录，登录时按屏蔽和静音关系过滤，
+// 草稿、定时发布和私密文章只有作者可见，不公开的文章只能通过 id 查看
+
+func (s *StatusAndPostsService) GetPostFromThisSite(c context.Context, viewerId, id int64) (domain.Posts, error) {
+	// 不存在的文章和无权查看的文章返回相同的错误
+	posts, err := s.repo.GetPosts(c, id)
+	if errors.Is(err, dao.ErrPostsNotFound) || err == nil && !posts.VisibleTo(viewerId) {
+		return domain.Posts{}, ErrContentNotFound
+	}
+	if err != nil {
+		return domain.Posts{}, err
+	}
+	if err := s.visibility.CheckAuthor(c, viewerId, posts.UserId); err != nil {
+		return domain.Posts{}, err
+	}
+	return posts, nil
 }
 
-func (s *StatusAndPostsService) GetStatusFromThisSite(c *gin.Context, id int64) (domain.Status, error) {
-	return s.repo.GetStatus(c, id)
+func (s *StatusAndPostsService) GetStatusFromThisSite(c context.Context, viewerId, id int64) (domain.Status, error) {
+	status, err := s.repo.GetStatus(c, id)
+	if err != nil {
+		return domain.Status{}, err
+	}
+	if err := s.visibility.CheckAuthor(c, viewerId, status.UserId); err != nil {
+		return domain.Status{}, err
+	}
+	return status, nil
 }
 
-func (s *StatusAndPostsService) GetStatusByUser(c *gin.Context, uid int64) ([]domain.Status, error) {
+func (s *StatusAndPostsService) GetStatusByUser(c context.Context, viewerId, uid int64) ([]domain.Status, error) {
+	if err := s.visibility.CheckAuthor(c, viewerId, uid); err != nil {
+		return nil, err
+	}
 	return s.repo.FindStatusByUser(c, uid)
 }
 
-func (s *StatusAndPostsService) GetPostsByUser(c *gin.Context, uid int64) ([]domain.Posts, error) {
-	return s.repo.FindPostsByUser(c, uid)
+func (s *StatusAndPostsService) GetPostsByUser(c context.Context, viewerId, uid int64) ([]domain.Posts, error) {
+	if err := s.visibility.CheckAuthor(c, viewerId, uid); err != nil {
+		return nil, err
+	}
+	posts, err := s.repo.FindPostsByUser(c, uid)
+	if err != nil {
+		return nil, err
+	}
+	listed := posts[:0]
+	for _, p := range posts {
+		if p.ListedFor(viewerId) {
+			listed = append(listed, p)
+		}
+	}
+	return listed, nil
 }
 
-func (s *StatusAndPostsService) GetStatusMessageList(c *gin.Context) ([]domain.Status, error) {
-	return s.repo.GetAllStatus(c)
+func (s *StatusAndPostsService) GetStatusMessageList(c context.Context, viewerId int64) ([]domain.Status, error) {
+	hidden, err := s.visibility.FeedHidden(c, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.repo.GetAllStatus(c)
+	if err != nil {
+		return nil, err
+	}
+	return filterByAuthor(status, hidden, func(st domain.Status) int64 { return st.UserId }), nil
 }
 
-func (s *StatusAndPostsService) GetPostsMessageList(c *gin.Context) ([]domain.Posts, error) {
-	return s.repo.GetAllPosts(c)
+func (s *StatusAndPostsService) GetPostsMessageList(c context.Context, viewerId int64) ([]domain.Posts, error) {
+	hidden, err := s.visibility.FeedHidden(c, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.repo.GetAllPosts(c)
+	if err != nil {
+		return nil, err
+	}
+	return filterByAuthor(posts, hidden, func(p domain.Posts) int64 { return p.UserId }), nil
 }
 
-func (s *StatusAndPostsService) DeleteStatus(c *gin.Context, id int64) error {
-	err := s.repo.DeleteStatus(c, id)
-	return err
+// DeleteStatus 作者删除自己的动态，删除后进入回收站
+func (s *StatusAndPostsService) DeleteStatus(c context.Context, userId, id int64) error {
+	ownerId, err := s.repo.FindStatusOwner(c, id)
+	if err != nil {
+		return err
+	}
+	if ownerId != userId {
+		return ErrContentNotOwner
+	}
+	if err := s.repo.DeleteStatus(c, id, userId); err != nil {
+		return err
+	}
+	s.events.Publish(c, domain.EventStatusDeleted, map[string]interface{}{"id": id})
+	return nil
 }
 
-func (s *StatusAndPostsService) DeletePosts(c *gin.Context, id int64) error {
-	err := s.repo.DeletePosts(c, id)
-	return err
+// DeletePosts 作者删除自己的文章，删除后进入回收站
+func (s *StatusAndPostsService) DeletePosts(c context.Context, userId, id int64) error {
+	ownerId, err := s.repo.FindPostsOwner(c, id)
+	if err != nil {
+		return err
+	}
+	if ownerId != userId {
+		return ErrContentNotOwner
+	}
+	if err := s.repo.DeletePosts(c, id, userId); err != nil {
+		return err
+	}
+	s.events.Publish(c, domain.EventPostDeleted, map[string]interface{}{"id": id})
+	return nil
+}
+
+func statusEventData(status domain.Status) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      status.Id,
+		"user_id": status.UserId,
+		"content": status.Content,
+	}
+}
+
+func postsEventData(posts domain.Posts) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      posts.Id,
+		"user_id": posts.UserId,
+		"title":   posts.Title,
+		"content": posts.Content,
+	}
+}
+
+// onPostsPublished 文章公开发布后通知订阅方并推送到粉丝的时间线
+func (s *StatusAndPostsService) onPostsPublished(c context.Context, posts domain.Posts) {
+	s.events.Publish(c, domain.EventPostCreated, postsEventData(posts))
+	s.timeline.Publish(c, posts.UserId, domain.TimelineEntry{Kind: domain.TimelinePost, Id: posts.Id, Ctime: posts.PublishAt.UnixMilli()})
 }
 
 // 管理后台相关方法
@@ -104,20 +251,69 @@ func (s *StatusAndPostsService) GetStatusListForAdmin(page, size int, status str
 	return statuses, int64(len(statuses)), nil
 }
 
-// 删除动态（管理后台）
-func (s *StatusAndPostsService) DeleteStatusForAdmin(statusID int64) error {
-	// 暂时返回nil，实际实现时需要删除数据库记录
+// 删除动态（管理后台），删除后进入作者的回收站，只有管理员可以恢复
+func (s *StatusAndPostsService) DeleteStatusForAdmin(ctx context.Context, adminId, statusID int64) error {
+	if err := s.repo.DeleteStatus(ctx, statusID, adminId); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, domain.EventStatusDeleted, map[string]interface{}{"id": statusID})
 	return nil
 }
 
-// 审核通过动态
-func (s *StatusAndPostsService) ApproveStatus(statusID int64) error {
-	// 暂时返回nil，实际实现时需要更新状态
+// 审核通过动态，恢复被举报隐藏的内容
+func (s *StatusAndPostsService) ApproveStatus(ctx context.Context, statusID int64) error {
+	if err := s.repo.SetStatusHidden(ctx, statusID, false); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, domain.EventStatusModerated, moderationEventData(statusID, ModerationActionApprove, ""))
+	s.notifyStatusModerated(ctx, statusID, ModerationActionApprove, "")
 	return nil
 }
 
-// 审核拒绝动态
-func (s *StatusAndPostsService) RejectStatus(statusID int64, reason string) error {
-	// 暂时返回nil，实际实现时需要更新状态
+// 审核拒绝动态，隐藏内容
+func (s *StatusAndPostsService) RejectStatus(ctx context.Context, statusID int64, reason string) error {
+	if err := s.repo.SetStatusHidden(ctx, statusID, true); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, domain.EventStatusModerated, moderationEventData(statusID, ModerationActionReject, reason))
+	s.notifyStatusModerated(ctx, statusID, ModerationActionReject, reason)
+	return nil
+}
+
+// 审核通过文章
+func (s *StatusAndPostsService) ApprovePosts(ctx context.Context, postsID int64) error {
+	if err := s.repo.SetPostsHidden(ctx, postsID, false); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, domain.EventPostModerated, moderationEventData(postsID, ModerationActionApprove, ""))
+	s.notifyPostsModerated(ctx, postsID, ModerationActionApprove, "")
 	return nil
 }
+
+// 审核拒绝文章
+func (s *StatusAndPostsService) RejectPosts(ctx context.Context, postsID int64, reason string) error {
+	if err := s.repo.SetPostsHidden(ctx, postsID, true); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, domain.EventPostModerated, moderationEventData(postsID, ModerationActionReject, reason))
+	s.notifyPostsModerated(ctx, postsID, ModerationActionReject, reason)
+	return nil
+}
+
+func (s *StatusAndPostsService) notifyStatusModerated(ctx context.Context, statusID int64, action, reason string) {
+	ownerId, err := s.repo.FindStatusOwner(ctx, statusID)
+	if err != nil {
+		log.Printf("查询动态作者失败: %v", err)
+		return
+	}
+	s.notifications.ContentModerated(ctx, ownerId, domain.ReportTargetStatus, statusID, action, reason)
+}
+
+func (s *StatusAndPostsService) notifyPostsModerated(ctx context.Context, postsID int64, action, reason string) {
+	ownerId, err := s.repo.FindPostsOwner(ctx, postsID)
+	if err != nil {
+		log.Printf("查询文章作者失败: %v", err)
+		return
+	}
+	s.notifications.ContentModerated(ctx, ownerId, domain.ReportTargetPost, postsID, action, reason)
+}
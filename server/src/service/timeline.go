@@ -0,0 +1,234 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 首页时间线：发布时推送到粉丝的缓存时间线，粉丝很多的用户改为读取时合并
+ */
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/timeline"
+)
+
+var ErrTimelineCursorInvalid = errors.New("时间线游标格式错误")
+
+const (
+	// 粉丝数达到该值的用户发布内容时不再逐个推送，由粉丝读取时间线时从数据库合并
+	timelineFanoutLimit = 5000
+	// 推送时每批读取的粉丝数
+	timelineFanoutBatch = 500
+)
+
+type TimelineService struct {
+	follows    *repository.FollowRepository
+	content    repository.StatusAndPostsRepository
+	store      timeline.Store
+	visibility *VisibilityFilter
+}
+
+func NewTimelineService(follows *repository.FollowRepository, content repository.StatusAndPostsRepository, store timeline.Store, visibility *VisibilityFilter) *TimelineService {
+	return &TimelineService{
+		follows:    follows,
+		content:    content,
+		store:      store,
+		visibility: visibility,
+	}
+}
+
+// Publish 在后台把新发布的内容推送到作者和粉丝已建立的时间线
+func (s *TimelineService) Publish(ctx context.Context, authorId int64, entry domain.TimelineEntry) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.fanOut(ctx, authorId, entry); err != nil {
+			log.Printf("推送时间线失败: %v", err)
+		}
+	}()
+}
+
+func (s *TimelineService) fanOut(ctx context.Context, authorId int64, entry domain.TimelineEntry) error {
+	if err := s.store.Add(ctx, []int64{authorId}, entry); err != nil {
+		return err
+	}
+	stats, err := s.follows.Stats(ctx, authorId)
+	if err != nil {
+		return err
+	}
+	if stats.Followers >= timelineFanoutLimit {
+		return nil
+	}
+	var afterId int64
+	for {
+		ids, err := s.follows.FollowerIds(ctx, authorId, afterId, timelineFanoutBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := s.store.Add(ctx, ids, entry); err != nil {
+			return err
+		}
+		afterId = ids[len(ids)-1]
+	}
+}
+
+// Home 返回关注的人和自己发布的动态和文章，cursor 为上一页返回的游标，返回下一页的游标，没有更多时为空
+func (s *TimelineService) Home(ctx context.Context, userId int64, cursor string, limit int) ([]domain.TimelineItem, string, error) {
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	after, err := decodeTimelineCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, ok, err := s.store.Range(ctx, userId, after, limit)
+	if err != nil {
+		// 缓存不可用时直接读数据库
+		log.Printf("读取时间线缓存失败: %v", err)
+		ok = false
+	}
+	if !ok {
+		entries, err = s.rebuild(ctx, userId, after, limit)
+	} else {
+		entries, err = s.fromCache(ctx, userId, after, limit, entries)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, err := s.content.FindTimelineItems(ctx, entries)
+	if err != nil {
+		return nil, "", err
+	}
+	hidden, err := s.visibility.FeedHidden(ctx, userId)
+	if err != nil {
+		return nil, "", err
+	}
+	results := make([]domain.TimelineItem, 0, len(entries))
+	for _, e := range entries {
+		// 已删除或已隐藏的内容不会加载到，直接跳过
+		if item, found := items[domain.TimelineEntry{Kind: e.Kind, Id: e.Id}]; found && !hidden[item.UserId] {
+			results = append(results, item)
+		}
+	}
+	next := ""
+	if len(entries) == limit {
+		next = encodeTimelineCursor(entries[len(entries)-1])
+	}
+	return results, next, nil
+}
+
+// Invalidate 关注关系变化后删除缓存的时间线，下次读取时重建
+func (s *TimelineService) Invalidate(ctx context.Context, userId int64) {
+	if err := s.store.Invalidate(ctx, userId); err != nil {
+		log.Printf("删除时间线缓存失败: %v", err)
+	}
+}
+
+// fromCache 在缓存的结果中合并热门用户的内容，缓存只保留最近的条目，不足一页时更早的内容从数据库读取
+func (s *TimelineService) fromCache(ctx context.Context, userId int64, after domain.TimelineEntry, limit int, cached []domain.TimelineEntry) ([]domain.TimelineEntry, error) {
+	entries, err := s.mergePopular(ctx, userId, after, limit, cached)
+	if err != nil || len(entries) == limit {
+		return entries, err
+	}
+	from := after
+	if len(entries) > 0 {
+		from = entries[len(entries)-1]
+	}
+	more, err := s.readFromDB(ctx, userId, from, limit-len(entries))
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, more...), nil
+}
+
+// rebuild 缓存未建立时从数据库读取，读取第一页时同时重建缓存
+func (s *TimelineService) rebuild(ctx context.Context, userId int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	if !after.IsZero() {
+		return s.readFromDB(ctx, userId, after, limit)
+	}
+	entries, err := s.readFromDB(ctx, userId, after, timeline.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Fill(ctx, userId, entries); err != nil {
+		log.Printf("重建时间线缓存失败: %v", err)
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// mergePopular 合并粉丝很多、发布时没有推送到缓存的用户的内容
+func (s *TimelineService) mergePopular(ctx context.Context, userId int64, after domain.TimelineEntry, limit int, cached []domain.TimelineEntry) ([]domain.TimelineEntry, error) {
+	popular, err := s.follows.PopularFolloweeIds(ctx, userId, timelineFanoutLimit)
+	if err != nil || len(popular) == 0 {
+		return cached, err
+	}
+	pulled, err := s.content.TimelineEntries(ctx, popular, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[domain.TimelineEntry]bool, len(cached)+len(pulled))
+	merged := make([]domain.TimelineEntry, 0, len(cached)+len(pulled))
+	for _, e := range append(cached, pulled...) {
+		if !seen[e] {
+			seen[e] = true
+			merged = append(merged, e)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Before(merged[j])
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+func (s *TimelineService) readFromDB(ctx context.Context, userId int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	uids, err := s.follows.FolloweeIds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.content.TimelineEntries(ctx, append(uids, userId), after, limit)
+}
+
+// 游标为最后一条的 类型:id:时间，前端只需原样传回
+func encodeTimelineCursor(e domain.TimelineEntry) string {
+	raw := fmt.Sprintf("%s:%d:%d", e.Kind, e.Id, e.Ctime)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTimelineCursor(cursor string) (domain.TimelineEntry, error) {
+	if cursor == "" {
+		return domain.TimelineEntry{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return domain.TimelineEntry{}, ErrTimelineCursorInvalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || (parts[0] != domain.TimelineStatus && parts[0] != domain.TimelinePost) {
+		return domain.TimelineEntry{}, ErrTimelineCursorInvalid
+	}
+	id, err1 := strconv.ParseInt(parts[1], 10, 64)
+	ctime, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		return domain.TimelineEntry{}, ErrTimelineCursorInvalid
+	}
+	return domain.TimelineEntry{Kind: parts[0], Id: id, Ctime: ctime}, nil
+}
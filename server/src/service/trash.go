@@ -0,0 +1,198 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 12:00:00
+ * @Description: 回收站：恢复已删除的树洞、动态和文章，超过保留期后永久删除
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrContentNotOwner    = errors.New("只能操作自己发布的内容")
+	ErrContentTypeInvalid = errors.New("不支持的内容类型")
+	ErrTrashItemNotFound  = errors.New("回收站中没有该内容")
+	ErrTrashRestoreDenied = errors.New("该内容已被管理员删除，无法自行恢复")
+)
+
+const (
+	// 未配置保留期时默认保留 30 天
+	defaultTrashRetention = 30 * 24 * time.Hour
+	trashPurgeInterval    = time.Hour
+	trashPurgeBatchSize   = 500
+)
+
+type TrashService struct {
+	treeholes repository.TreeHoleRepository
+	content   repository.StatusAndPostsRepository
+	audit     *AuditService
+	retention time.Duration
+}
+
+func NewTrashService(treeholes repository.TreeHoleRepository, content repository.StatusAndPostsRepository, audit *AuditService, retentionDays int) *TrashService {
+	retention := defaultTrashRetention
+	if retentionDays > 0 {
+		retention = time.Duration(retentionDays) * 24 * time.Hour
+	}
+	return &TrashService{treeholes: treeholes, content: content, audit: audit, retention: retention}
+}
+
+// List 按删除时间倒序返回用户回收站中的内容，包括被管理员删除的内容
+func (s *TrashService) List(ctx context.Context, userId int64, page, size int) ([]domain.TrashItem, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	// 三类内容分别取前 page*size 条，合并后再分页
+	limit := page * size
+	treeholes, err := s.treeholes.FindDeleted(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.content.FindDeletedStatus(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.content.FindDeletedPosts(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := append(append(treeholes, status...), posts...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	start := (page - 1) * size
+	if start >= len(items) {
+		return []domain.TrashItem{}, nil
+	}
+	items = items[start:min(start+size, len(items))]
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.retention)
+	}
+	return items, nil
+}
+
+// Restore 作者恢复自己删除的内容，被管理员删除的内容只能由管理员恢复
+func (s *TrashService) Restore(ctx context.Context, userId int64, itemType string, id int64) error {
+	item, err := s.findDeleted(ctx, itemType, id)
+	if err != nil {
+		return err
+	}
+	if item.UserId != userId {
+		return ErrTrashItemNotFound
+	}
+	if !item.DeletedByAuthor() {
+		return ErrTrashRestoreDenied
+	}
+	return s.restore(ctx, itemType, id)
+}
+
+// RestoreForModerator 管理员恢复任意已删除的内容并记录审计日志
+func (s *TrashService) RestoreForModerator(ctx context.Context, adminId int64, itemType string, id int64, ip string) error {
+	item, err := s.findDeleted(ctx, itemType, id)
+	if err != nil {
+		return err
+	}
+	if err := s.restore(ctx, itemType, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, adminId, domain.AuditActionContentRestore, ip,
+		fmt.Sprintf("恢复了用户 %d 的%s %d", item.UserId, notificationTargetNames[itemType], id))
+	return nil
+}
+
+// Run 定期永久删除超过保留期的内容，直到 ctx 结束
+func (s *TrashService) Run(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.Purge(ctx); err != nil {
+			log.Printf("清理回收站失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已永久删除回收站中超过保留期的内容 %d 条", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge 永久删除超过保留期的内容，分批执行避免长时间锁表，返回删除的条数
+func (s *TrashService) Purge(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-s.retention)
+	var total int64
+	for _, purge := range []func(context.Context, time.Time, int) (int64, error){
+		s.treeholes.PurgeDeleted,
+		s.content.PurgeDeletedStatus,
+		s.content.PurgeDeletedPosts,
+	} {
+		for {
+			n, err := purge(ctx, before, trashPurgeBatchSize)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if n < trashPurgeBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+func (s *TrashService) findDeleted(ctx context.Context, itemType string, id int64) (domain.TrashItem, error) {
+	var item domain.TrashItem
+	var err error
+	switch itemType {
+	case domain.TrashTreeHole:
+		item, err = s.treeholes.FindDeletedById(ctx, id)
+	case domain.TrashStatus:
+		item, err = s.content.FindDeletedStatusById(ctx, id)
+	case domain.TrashPost:
+		item, err = s.content.FindDeletedPostsById(ctx, id)
+	default:
+		return domain.TrashItem{}, ErrContentTypeInvalid
+	}
+	if err != nil {
+		return domain.TrashItem{}, trashError(err)
+	}
+	return item, nil
+}
+
+func (s *TrashService) restore(ctx context.Context, itemType string, id int64) error {
+	var err error
+	switch itemType {
+	case domain.TrashTreeHole:
+		err = s.treeholes.Restore(ctx, id)
+	case domain.TrashStatus:
+		err = s.content.RestoreStatus(ctx, id)
+	case domain.TrashPost:
+		err = s.content.RestorePosts(ctx, id)
+	default:
+		return ErrContentTypeInvalid
+	}
+	// 并发恢复时另一个请求可能已经恢复
+	return trashError(err)
+}
+
+func trashError(err error) error {
+	if errors.Is(err, dao.ErrTreeHoleNotFound) || errors.Is(err, dao.ErrStatusNotFound) || errors.Is(err, dao.ErrPostsNotFound) {
+		return ErrTrashItemNotFound
+	}
+	return err
+}
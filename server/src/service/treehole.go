@@ -9,46 +9,119 @@
 package service
 
 import (
+	"context"
+	"log"
 	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/realtime"
 	"negaihoshi/server/src/repository"
 	"time"
-
-	"github.com/gin-gonic/gin"
 )
 
+// RealtimeTopicTreehole 新树洞实时推送的主题
+const RealtimeTopicTreehole = "treehole"
+
 type TreeHoleService struct {
-	repo *repository.TreeHoleRepository
+	repo     repository.TreeHoleRepository
+	userRepo repository.UserRepository
+	// 是否允许未验证邮箱的用户发布树洞
+	allowUnverifiedPost bool
+	events              EventPublisher
+	notifications       *NotificationService
+	hub                 realtime.Hub
+	filter              *ContentFilter
+	visibility          *VisibilityFilter
 }
 
-func NewTreeHoleService(repo *repository.TreeHoleRepository) *TreeHoleService {
-	return &TreeHoleService{repo: repo}
+func NewTreeHoleService(repo repository.TreeHoleRepository, userRepo repository.UserRepository, allowUnverifiedPost bool, events EventPublisher, notifications *NotificationService, hub realtime.Hub, filter *ContentFilter, visibility *VisibilityFilter) *TreeHoleService {
+	return &TreeHoleService{
+		repo:                repo,
+		userRepo:            userRepo,
+		allowUnverifiedPost: allowUnverifiedPost,
+		events:              events,
+		notifications:       notifications,
+		hub:                 hub,
+		filter:              filter,
+		visibility:          visibility,
+	}
 }
 
-func (t *TreeHoleService) CreateTreeHoleMessage(ctx *gin.Context, treeHole domain.TreeHole) error {
-	return t.repo.Create(ctx, treeHole)
+func (t *TreeHoleService) CreateTreeHoleMessage(ctx context.Context, treeHole domain.TreeHole) error {
+	if err := t.filter.Check(treeHole.Content); err != nil {
+		return err
+	}
+	if !t.allowUnverifiedPost {
+		user, err := t.userRepo.FindById(ctx, treeHole.UserId)
+		if err != nil {
+			return ErrUserNotFound
+		}
+		if !user.EmailVerified {
+			return ErrEmailNotVerified
+		}
+	}
+	created, err := t.repo.Create(ctx, treeHole)
+	if err != nil {
+		return err
+	}
+	t.events.Publish(ctx, domain.EventTreeholeCreated, map[string]interface{}{
+		"id":      created.Id,
+		"user_id": created.UserId,
+		"content": created.Content,
+		"ctime":   created.Ctime,
+	})
+	// 推送给正在浏览树洞的用户，数据格式与列表一致
+	if err := t.hub.Publish(ctx, RealtimeTopicTreehole, domain.EventTreeholeCreated, created); err != nil {
+		log.Printf("推送新树洞失败: %v", err)
+	}
+	return nil
 }
 
-func (t *TreeHoleService) GetTreeHoleMessageList(ctx *gin.Context, pageNum, pageSize int) ([]domain.TreeHole, error) {
+// GetTreeHoleMessageList viewerId 为 0 表示未登录，登录时不返回已屏蔽或已静音用户的树洞
+func (t *TreeHoleService) GetTreeHoleMessageList(ctx context.Context, viewerId int64, pageNum, pageSize int) ([]domain.TreeHole, error) {
+	hidden, err := t.visibility.FeedHidden(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
 	// 计算偏移量
 	offset := (pageNum - 1) * pageSize
 	// 调用仓库层方法并传递偏移量和限制数量
-	return t.repo.GetList(ctx, offset, pageSize)
+	return t.repo.GetList(ctx, offset, pageSize, hiddenIds(hidden))
 }
 
-func (t *TreeHoleService) GetUserTreeHoleMessageList(ctx *gin.Context, userId int64, pageNum, pageSize int) ([]domain.TreeHole, error) {
+func (t *TreeHoleService) GetUserTreeHoleMessageList(ctx context.Context, viewerId, userId int64, pageNum, pageSize int) ([]domain.TreeHole, error) {
+	if err := t.visibility.CheckAuthor(ctx, viewerId, userId); err != nil {
+		return nil, err
+	}
 	// 计算偏移量
 	offset := (pageNum - 1) * pageSize
 	// 调用仓库层方法并传递偏移量和限制数量
 	return t.repo.GetListByUser(ctx, userId, offset, pageSize)
 }
 
-func (t *TreeHoleService) GetTreeHoleMessage(ctx *gin.Context, id int64) (domain.TreeHole, error) {
-	// 调用仓库层方法并传递偏移量和限制数量
-	return t.repo.GetById(ctx, id)
+func (t *TreeHoleService) GetTreeHoleMessage(ctx context.Context, viewerId, id int64) (domain.TreeHole, error) {
+	treeHole, err := t.repo.GetById(ctx, id)
+	if err != nil {
+		return domain.TreeHole{}, err
+	}
+	if err := t.visibility.CheckAuthor(ctx, viewerId, treeHole.UserId); err != nil {
+		return domain.TreeHole{}, err
+	}
+	return treeHole, nil
 }
 
-func (t *TreeHoleService) DeleteTreeHoleMessage(ctx *gin.Context, id int64) error {
-	return t.repo.Delete(ctx, id)
+// DeleteTreeHoleMessage 作者删除自己的树洞，删除后进入回收站
+func (t *TreeHoleService) DeleteTreeHoleMessage(ctx context.Context, userId, id int64) error {
+	ownerId, err := t.repo.FindOwner(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ownerId != userId {
+		return ErrContentNotOwner
+	}
+	if err := t.repo.Delete(ctx, id, userId); err != nil {
+		return err
+	}
+	t.events.Publish(ctx, domain.EventTreeholeDeleted, map[string]interface{}{"id": id})
+	return nil
 }
 
 // 管理后台相关方法
@@ -85,20 +158,40 @@ func (t *TreeHoleService) GetTreeholeListForAdmin(page, size int, status string)
 	return treeholes, int64(len(treeholes)), nil
 }
 
-// 删除树洞（管理后台）
-func (t *TreeHoleService) DeleteTreeholeForAdmin(treeholeID int64) error {
-	// 暂时返回nil，实际实现时需要删除数据库记录
+// 删除树洞（管理后台），删除后进入作者的回收站，只有管理员可以恢复
+func (t *TreeHoleService) DeleteTreeholeForAdmin(ctx context.Context, adminId, treeholeID int64) error {
+	if err := t.repo.Delete(ctx, treeholeID, adminId); err != nil {
+		return err
+	}
+	t.events.Publish(ctx, domain.EventTreeholeDeleted, map[string]interface{}{"id": treeholeID})
 	return nil
 }
 
-// 审核通过树洞
-func (t *TreeHoleService) ApproveTreehole(treeholeID int64) error {
-	// 暂时返回nil，实际实现时需要更新状态
+// 审核通过树洞，恢复被举报隐藏的内容
+func (t *TreeHoleService) ApproveTreehole(ctx context.Context, treeholeID int64) error {
+	if err := t.repo.SetHidden(ctx, treeholeID, false); err != nil {
+		return err
+	}
+	t.events.Publish(ctx, domain.EventTreeholeModerated, moderationEventData(treeholeID, ModerationActionApprove, ""))
+	t.notifyModerated(ctx, treeholeID, ModerationActionApprove, "")
 	return nil
 }
 
-// 审核拒绝树洞
-func (t *TreeHoleService) RejectTreehole(treeholeID int64, reason string) error {
-	// 暂时返回nil，实际实现时需要更新状态
+// 审核拒绝树洞，隐藏内容
+func (t *TreeHoleService) RejectTreehole(ctx context.Context, treeholeID int64, reason string) error {
+	if err := t.repo.SetHidden(ctx, treeholeID, true); err != nil {
+		return err
+	}
+	t.events.Publish(ctx, domain.EventTreeholeModerated, moderationEventData(treeholeID, ModerationActionReject, reason))
+	t.notifyModerated(ctx, treeholeID, ModerationActionReject, reason)
 	return nil
 }
+
+func (t *TreeHoleService) notifyModerated(ctx context.Context, treeholeID int64, action, reason string) {
+	ownerId, err := t.repo.FindOwner(ctx, treeholeID)
+	if err != nil {
+		log.Printf("查询树洞作者失败: %v", err)
+		return
+	}
+	t.notifications.ContentModerated(ctx, ownerId, domain.ReportTargetTreeHole, treeholeID, action, reason)
+}
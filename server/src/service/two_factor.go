@@ -0,0 +1,237 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: TOTP 两步验证：绑定、校验、恢复码和全站强制策略
+ */
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/security"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("未开启两步验证")
+	ErrTwoFactorAlreadyEnabled = errors.New("已开启两步验证")
+	ErrTwoFactorNotSetup       = errors.New("请先生成两步验证密钥")
+	ErrTwoFactorCodeInvalid    = errors.New("验证码错误")
+	ErrTwoFactorRequired       = errors.New("站点要求开启两步验证，无法关闭")
+)
+
+const (
+	settingRequireTwoFactor = "security.require_2fa"
+	recoveryCodeCount       = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	repo     *repository.TwoFactorRepository
+	settings *repository.SettingRepository
+	// 认证器中显示的发行方名称
+	issuer        string
+	notifications *NotificationService
+}
+
+func NewTwoFactorService(repo *repository.TwoFactorRepository, settings *repository.SettingRepository, issuer string, notifications *NotificationService) *TwoFactorService {
+	return &TwoFactorService{
+		repo:          repo,
+		settings:      settings,
+		issuer:        issuer,
+		notifications: notifications,
+	}
+}
+
+func (s *TwoFactorService) Status(ctx context.Context, userId int64) (domain.TwoFactorStatus, error) {
+	required, err := s.IsRequired(ctx)
+	if err != nil {
+		return domain.TwoFactorStatus{}, err
+	}
+	status := domain.TwoFactorStatus{Required: required}
+
+	enabled, err := s.IsEnabled(ctx, userId)
+	if err != nil || !enabled {
+		return status, err
+	}
+	status.Enabled = true
+	status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(ctx, userId)
+	return status, err
+}
+
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userId int64) (bool, error) {
+	tf, err := s.repo.FindByUser(ctx, userId)
+	if errors.Is(err, dao.ErrTwoFactorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// IsRequired 站点是否要求所有用户开启两步验证
+func (s *TwoFactorService) IsRequired(ctx context.Context) (bool, error) {
+	return s.settings.GetBool(ctx, settingRequireTwoFactor, false)
+}
+
+// SetRequired 管理员设置是否强制所有用户开启两步验证，未开启的用户下次登录时需要先完成绑定
+func (s *TwoFactorService) SetRequired(ctx context.Context, required bool) error {
+	return s.settings.SetBool(ctx, settingRequireTwoFactor, required)
+}
+
+// Setup 生成新的待启用密钥，需调用 Enable 校验验证码后才生效
+func (s *TwoFactorService) Setup(ctx context.Context, user *domain.User) (domain.TwoFactorSetup, error) {
+	enabled, err := s.IsEnabled(ctx, user.Id)
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+	if enabled {
+		return domain.TwoFactorSetup{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+	if err := s.repo.SavePending(ctx, user.Id, secret); err != nil {
+		return domain.TwoFactorSetup{}, err
+	}
+	return domain.TwoFactorSetup{
+		Secret: secret,
+		URI:    security.TOTPURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 校验认证器生成的验证码并启用两步验证，返回一次性恢复码（仅展示这一次）
+func (s *TwoFactorService) Enable(ctx context.Context, userId int64, code string) ([]string, error) {
+	tf, err := s.repo.FindByUser(ctx, userId)
+	if errors.Is(err, dao.ErrTwoFactorNotFound) {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err := s.verifyTOTP(ctx, tf, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Enable(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	s.notifications.Security(ctx, userId, "已开启两步验证", "你的账户已开启两步验证，请妥善保存恢复码。")
+	return codes, nil
+}
+
+// Verify 校验动态验证码或恢复码，恢复码使用后失效
+func (s *TwoFactorService) Verify(ctx context.Context, userId int64, code string) error {
+	tf, err := s.repo.FindByUser(ctx, userId)
+	if errors.Is(err, dao.ErrTwoFactorNotFound) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return s.verifyTOTP(ctx, tf, code)
+	}
+	err = s.repo.UseRecoveryCode(ctx, userId, hashRecoveryCode(code))
+	if errors.Is(err, dao.ErrRecoveryCodeUsed) {
+		return ErrTwoFactorCodeInvalid
+	}
+	return err
+}
+
+// Disable 校验验证码后关闭两步验证，站点强制开启时不允许关闭
+func (s *TwoFactorService) Disable(ctx context.Context, userId int64, code string) error {
+	required, err := s.IsRequired(ctx)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+	if err := s.Verify(ctx, userId, code); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userId); err != nil {
+		return err
+	}
+	s.notifications.Security(ctx, userId, "已关闭两步验证", "你的账户已关闭两步验证。如果不是您本人操作，请立即修改密码。")
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，原有恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) ([]string, error) {
+	if err := s.Verify(ctx, userId, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	s.notifications.Security(ctx, userId, "恢复码已重新生成", "你的两步验证恢复码已重新生成，原有恢复码已全部失效。")
+	return codes, nil
+}
+
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, tf domain.TwoFactor, code string) error {
+	step, ok := security.ValidateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return ErrTwoFactorCodeInvalid
+	}
+	// 同一时间窗口内的验证码只能使用一次
+	err := s.repo.UseStep(ctx, tf.UserId, step)
+	if errors.Is(err, dao.ErrTOTPStepUsed) {
+		return ErrTwoFactorCodeInvalid
+	}
+	return err
+}
+
+// generateRecoveryCodes 生成恢复码，格式 xxxxx-xxxxx，数据库只保存摘要
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// 忽略大小写、空格和连字符，方便用户手动输入
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -11,9 +11,17 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"negaihoshi/server/src/cache"
 	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/mail"
+	"negaihoshi/server/src/metrics"
 	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
 	"negaihoshi/server/src/util"
 )
 
@@ -23,17 +31,58 @@ var (
 	ErrUserNotFound          = errors.New("用户不存在")
 	ErrInvalidCredentials    = errors.New("用户名或密码错误")
 	ErrPasswordEncryption    = errors.New("密码加密失败")
+	ErrEmailAlreadyVerified  = errors.New("邮箱已验证")
+	ErrEmailNotVerified      = errors.New("邮箱未验证")
+	ErrWrongPassword         = errors.New("当前密码错误")
+	ErrSameEmail             = errors.New("新邮箱与当前邮箱相同")
+)
+
+// 登录阶段
+const (
+	LoginStageDone           = "done"
+	LoginStageTwoFactor      = "two_factor"       // 需要输入两步验证码
+	LoginStageTwoFactorSetup = "two_factor_setup" // 站点要求两步验证，需要先完成绑定
+)
+
+const (
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = 30 * time.Minute
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
-	crypto   *util.PasswordCrypto
+	userRepo  repository.UserRepository
+	crypto    *util.PasswordCrypto
+	guard     *LoginGuard
+	tokens    *UserTokenService
+	sessions  *SessionService
+	twoFactor *TwoFactorService
+	mailer    mail.Mailer
+	// 邮件中链接指向的前端地址
+	linkBaseURL   string
+	events        EventPublisher
+	notifications *NotificationService
+	// 用户资料缓存，只缓存对外返回的资料，不含密码，可以为 nil
+	profiles   *cache.Cache
+	profileTTL time.Duration
+	// 记录登录次数，可以为 nil
+	metrics *metrics.Metrics
 }
 
-func NewUserService(userRepo *repository.UserRepository, crypto *util.PasswordCrypto) *UserService {
+func NewUserService(userRepo repository.UserRepository, crypto *util.PasswordCrypto, guard *LoginGuard, tokens *UserTokenService, sessions *SessionService, twoFactor *TwoFactorService, mailer mail.Mailer, linkBaseURL string, events EventPublisher, notifications *NotificationService, profiles *cache.Cache, profileTTL time.Duration, m *metrics.Metrics) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		crypto:   crypto,
+		userRepo:      userRepo,
+		crypto:        crypto,
+		guard:         guard,
+		tokens:        tokens,
+		sessions:      sessions,
+		twoFactor:     twoFactor,
+		mailer:        mailer,
+		linkBaseURL:   linkBaseURL,
+		events:        events,
+		notifications: notifications,
+		profiles:      profiles,
+		profileTTL:    profileTTL,
+		metrics:       m,
 	}
 }
 
@@ -65,10 +114,244 @@ func (svc *UserService) SignUp(ctx context.Context, username, password, email st
 		Bio:      "欢迎来到星の海の物語！",
 	}
 
-	return svc.userRepo.Create(ctx, user)
+	if err := svc.userRepo.Create(ctx, user); err != nil {
+		return userStoreError(err)
+	}
+
+	// 发送验证邮件失败不影响注册，用户可稍后重新发送
+	created, err := svc.userRepo.FindByUsername(ctx, username)
+	if err == nil {
+		svc.events.Publish(ctx, domain.EventUserSignedUp, signedUpEventData(created, "password"))
+		err = svc.sendVerificationEmail(ctx, created)
+	}
+	if err != nil {
+		log.Printf("发送验证邮件失败: %v", err)
+	}
+	return nil
+}
+
+// SignUpExternal 通过外部账号自动注册，用户名冲突时追加随机后缀，不设置密码，之后可通过找回密码设置
+func (svc *UserService) SignUpExternal(ctx context.Context, username, email, nickname string, emailVerified bool) (*domain.User, error) {
+	if _, err := svc.userRepo.FindByEmail(ctx, email); err == nil {
+		return nil, ErrUserDuplicateEmail
+	}
+
+	candidate := username
+	for i := 0; ; i++ {
+		if _, err := svc.userRepo.FindByUsername(ctx, candidate); err != nil {
+			break
+		}
+		if i >= 5 {
+			return nil, ErrUserDuplicateUsername
+		}
+		suffix, err := randomToken(3)
+		if err != nil {
+			return nil, err
+		}
+		candidate = fmt.Sprintf("%s_%s", username, suffix)
+	}
+
+	if nickname == "" {
+		nickname = candidate
+	}
+	if err := svc.userRepo.Create(ctx, &domain.User{
+		Username: candidate,
+		// 空密码无法通过校验，用户只能用外部账号登录
+		Password: "",
+		Email:    email,
+		Nickname: nickname,
+		Bio:      "欢迎来到星の海の物語！",
+	}); err != nil {
+		return nil, userStoreError(err)
+	}
+
+	user, err := svc.userRepo.FindByUsername(ctx, candidate)
+	if err != nil {
+		return nil, err
+	}
+	if emailVerified {
+		if err := svc.userRepo.MarkEmailVerified(ctx, user.Id); err != nil {
+			return nil, err
+		}
+		svc.invalidateProfile(ctx, user.Id)
+		user.EmailVerified = true
+	} else if err := svc.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("发送验证邮件失败: %v", err)
+	}
+	svc.events.Publish(ctx, domain.EventUserSignedUp, signedUpEventData(user, "oidc"))
+	return user, nil
+}
+
+// signedUpEventData 注册事件只带公开资料，不含邮箱
+func signedUpEventData(user *domain.User, via string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       user.Id,
+		"username": user.Username,
+		"nickname": user.Nickname,
+		"via":      via,
+	}
+}
+
+// SendVerificationEmail 重新发送邮箱验证邮件
+func (svc *UserService) SendVerificationEmail(ctx context.Context, userID int64) error {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return svc.sendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail 核销邮箱验证令牌并标记邮箱已验证
+func (svc *UserService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := svc.tokens.Consume(ctx, token, TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	defer svc.invalidateProfile(ctx, userID)
+	return svc.userRepo.MarkEmailVerified(ctx, userID)
+}
+
+// ForgotPassword 发送重置密码邮件，邮箱不存在时同样返回成功，避免暴露注册信息
+func (svc *UserService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := svc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := svc.tokens.Issue(ctx, user.Id, TokenPurposeResetPassword, resetPasswordTokenTTL)
+	if err != nil {
+		return err
+	}
+	return svc.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "重置您的密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置密码的请求，请在 %d 分钟内打开以下链接设置新密码：\n%s/reset-password?token=%s\n\n如果这不是您本人的操作，请忽略本邮件。",
+			user.Nickname, int(resetPasswordTokenTTL.Minutes()), svc.linkBaseURL, token),
+	})
+}
+
+// ResetPassword 核销重置密码令牌并设置新密码
+func (svc *UserService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userID, err := svc.tokens.Consume(ctx, token, TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	encryptedPassword, err := svc.crypto.EncryptPassword(newPassword)
+	if err != nil {
+		return ErrPasswordEncryption
+	}
+	if err := svc.userRepo.UpdatePassword(ctx, userID, encryptedPassword); err != nil {
+		return err
+	}
+	svc.invalidateProfile(ctx, userID)
+	// 能收到重置邮件说明是本人，解除登录锁定
+	svc.guard.RecordSuccess(ctx, user)
+	// 密码可能已泄露，注销全部已登录的会话并撤销个人访问令牌
+	if err := svc.sessions.RevokeCredentials(ctx, userID, ""); err != nil {
+		return err
+	}
+	svc.notifications.Security(ctx, userID, "密码已重置", "你的账户密码已通过找回密码重置，所有设备上的登录已全部退出，个人访问令牌已全部撤销。")
+	return nil
+}
+
+// ChangePassword 校验当前密码后修改密码，注销除当前会话外的全部会话并撤销个人访问令牌
+func (svc *UserService) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword, currentSid string) error {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !svc.crypto.VerifyPassword(currentPassword, user.Password) {
+		return ErrWrongPassword
+	}
+
+	encryptedPassword, err := svc.crypto.EncryptPassword(newPassword)
+	if err != nil {
+		return ErrPasswordEncryption
+	}
+	if err := svc.userRepo.UpdatePassword(ctx, userID, encryptedPassword); err != nil {
+		return err
+	}
+	svc.invalidateProfile(ctx, userID)
+	if err := svc.sessions.RevokeCredentials(ctx, userID, currentSid); err != nil {
+		return err
+	}
+	svc.notifications.Security(ctx, userID, "密码已修改", "你的账户密码已修改，其他设备上的登录已全部退出，个人访问令牌已全部撤销。")
+
+	err = svc.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "账户安全提醒：密码已修改",
+		Body: fmt.Sprintf("%s，您好：\n\n您的账户密码已于 %s 修改，其他设备上的登录已全部退出，个人访问令牌已全部撤销。\n如果不是您本人操作，请立即通过“忘记密码”重置密码。",
+			user.Nickname, time.Now().Format("2006-01-02 15:04:05")),
+	})
+	if err != nil {
+		log.Printf("发送密码修改通知失败: %v", err)
+	}
+	return nil
+}
+
+// ChangeEmail 校验密码后修改邮箱，新邮箱需要重新验证，并通知原邮箱
+func (svc *UserService) ChangeEmail(ctx context.Context, userID int64, password, newEmail string) error {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !svc.crypto.VerifyPassword(password, user.Password) {
+		return ErrWrongPassword
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return ErrSameEmail
+	}
+	if _, err := svc.userRepo.FindByEmail(ctx, newEmail); err == nil {
+		return ErrUserDuplicateEmail
+	}
+
+	if err := svc.userRepo.UpdateEmail(ctx, userID, newEmail); err != nil {
+		return userStoreError(err)
+	}
+	svc.invalidateProfile(ctx, userID)
+	oldEmail := user.Email
+	user.Email = newEmail
+	user.EmailVerified = false
+	svc.notifications.Security(ctx, userID, "邮箱已修改", fmt.Sprintf("你的账户绑定邮箱已修改为 %s，请查收验证邮件。", newEmail))
+
+	if err := svc.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("发送验证邮件失败: %v", err)
+	}
+	err = svc.mailer.Send(ctx, mail.Message{
+		To:      oldEmail,
+		Subject: "账户安全提醒：邮箱已修改",
+		Body: fmt.Sprintf("%s，您好：\n\n您的账户绑定邮箱已由本邮箱修改为 %s。\n如果不是您本人操作，请尽快联系管理员。",
+			user.Nickname, newEmail),
+	})
+	if err != nil {
+		log.Printf("发送邮箱修改通知失败: %v", err)
+	}
+	return nil
 }
 
-func (svc *UserService) Login(ctx context.Context, usernameOrEmail, password string) (*domain.User, error) {
+func (svc *UserService) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	token, err := svc.tokens.Issue(ctx, user.Id, TokenPurposeVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+	return svc.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "请验证您的邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n感谢注册！请在 24 小时内打开以下链接完成邮箱验证：\n%s/verify-email?token=%s",
+			user.Nickname, svc.linkBaseURL, token),
+	})
+}
+
+// Login 校验用户名和密码，返回登录阶段；开启两步验证时需再调用 VerifyTwoFactorLogin 完成登录
+func (svc *UserService) Login(ctx context.Context, usernameOrEmail, password, ip, captchaToken string) (*domain.User, string, error) {
 	var user *domain.User
 	var err error
 
@@ -78,36 +361,145 @@ func (svc *UserService) Login(ctx context.Context, usernameOrEmail, password str
 		// 尝试通过邮箱登录
 		user, err = svc.userRepo.FindByEmail(ctx, usernameOrEmail)
 		if err != nil {
-			return nil, ErrInvalidCredentials
+			user = nil
 		}
 	}
 
+	// 检查锁定状态和人机验证
+	if err := svc.guard.Check(ctx, user, usernameOrEmail, ip, captchaToken); err != nil {
+		svc.metrics.Login(metrics.LoginMethodPassword, metrics.LoginResultBlocked)
+		return nil, "", err
+	}
+
 	// 验证密码（使用加密验证）
+	if user == nil || !svc.crypto.VerifyPassword(password, user.Password) {
+		svc.metrics.Login(metrics.LoginMethodPassword, metrics.LoginResultFailure)
+		if err := svc.guard.RecordFailure(ctx, user, usernameOrEmail, ip); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrInvalidCredentials
+	}
+
+	stage, err := svc.LoginStage(ctx, user.Id)
+	if err != nil {
+		return nil, "", err
+	}
+	// 开启两步验证时，失败计数在验证码通过后才清除，避免反复登录绕过验证码尝试次数限制
+	if stage != LoginStageTwoFactor {
+		svc.guard.RecordSuccess(ctx, user)
+		svc.metrics.Login(metrics.LoginMethodPassword, metrics.LoginResultSuccess)
+	} else {
+		svc.metrics.Login(metrics.LoginMethodPassword, metrics.LoginResultTwoFactor)
+	}
+	return user, stage, nil
+}
+
+// LoginStage 第一步认证（密码或外部账号）通过后，根据两步验证设置决定接下来的登录阶段
+func (svc *UserService) LoginStage(ctx context.Context, userID int64) (string, error) {
+	enabled, err := svc.twoFactor.IsEnabled(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if enabled {
+		return LoginStageTwoFactor, nil
+	}
+	required, err := svc.twoFactor.IsRequired(ctx)
+	if err != nil {
+		return "", err
+	}
+	if required {
+		return LoginStageTwoFactorSetup, nil
+	}
+	return LoginStageDone, nil
+}
+
+// VerifyTwoFactorLogin 登录第二步，校验动态验证码或恢复码
+func (svc *UserService) VerifyTwoFactorLogin(ctx context.Context, userID int64, code, ip string) error {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if err := svc.guard.CheckLocked(ctx, user, ip); err != nil {
+		svc.metrics.Login(metrics.LoginMethodTwoFactor, metrics.LoginResultBlocked)
+		return err
+	}
+
+	err = svc.twoFactor.Verify(ctx, userID, code)
+	if errors.Is(err, ErrTwoFactorCodeInvalid) {
+		svc.metrics.Login(metrics.LoginMethodTwoFactor, metrics.LoginResultFailure)
+		if err := svc.guard.RecordFailure(ctx, user, user.Username, ip); err != nil {
+			return err
+		}
+		return ErrTwoFactorCodeInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	svc.guard.RecordSuccess(ctx, user)
+	svc.metrics.Login(metrics.LoginMethodTwoFactor, metrics.LoginResultSuccess)
+	return nil
+}
+
+// SetupTwoFactor 为用户生成两步验证密钥和绑定地址
+func (svc *UserService) SetupTwoFactor(ctx context.Context, userID int64) (domain.TwoFactorSetup, error) {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return domain.TwoFactorSetup{}, ErrUserNotFound
+	}
+	return svc.twoFactor.Setup(ctx, user)
+}
+
+// DisableTwoFactor 校验当前密码和验证码后关闭两步验证，仅凭被盗用的会话无法关闭
+func (svc *UserService) DisableTwoFactor(ctx context.Context, userID int64, password, code string) error {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
 	if !svc.crypto.VerifyPassword(password, user.Password) {
-		return nil, ErrInvalidCredentials
+		return ErrWrongPassword
 	}
+	return svc.twoFactor.Disable(ctx, userID, code)
+}
 
-	return user, nil
+// LoginRequireCaptcha 判断该账户下一次登录是否需要人机验证
+func (svc *UserService) LoginRequireCaptcha(ctx context.Context, usernameOrEmail, ip string) bool {
+	user, err := svc.userRepo.FindByUsername(ctx, usernameOrEmail)
+	if err != nil {
+		user, err = svc.userRepo.FindByEmail(ctx, usernameOrEmail)
+		if err != nil {
+			user = nil
+		}
+	}
+	return svc.guard.RequireCaptcha(ctx, user, usernameOrEmail, ip)
 }
 
+// GetProfile 读取用户资料，优先从缓存读取，不存在的用户不缓存
 func (svc *UserService) GetProfile(ctx context.Context, userID int64) (*domain.ProfileResponse, error) {
+	return cache.Fetch(ctx, svc.profiles, profileCacheKey(userID), svc.profileTTL, func(ctx context.Context) (*domain.ProfileResponse, error) {
+		return svc.loadProfile(ctx, userID)
+	})
+}
+
+func (svc *UserService) loadProfile(ctx context.Context, userID int64) (*domain.ProfileResponse, error) {
 	user, err := svc.userRepo.FindById(ctx, userID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
 
 	return &domain.ProfileResponse{
-		Id:       user.Id,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Bio:      user.Bio,
-		Avatar:   user.Avatar,
-		Phone:    user.Phone,
-		Location: user.Location,
-		Website:  user.Website,
-		Ctime:    user.Ctime.Format("2006-01-02 15:04:05"),
-		Utime:    user.Utime.Format("2006-01-02 15:04:05"),
+		Id:            user.Id,
+		Username:      user.Username,
+		Email:         user.Email,
+		Nickname:      user.Nickname,
+		Bio:           user.Bio,
+		Avatar:        user.Avatar,
+		Phone:         user.Phone,
+		Location:      user.Location,
+		Website:       user.Website,
+		EmailVerified: user.EmailVerified,
+		Ctime:         user.Ctime.Format("2006-01-02 15:04:05"),
+		Utime:         user.Utime.Format("2006-01-02 15:04:05"),
 	}, nil
 }
 
@@ -118,9 +510,31 @@ func (svc *UserService) UpdateProfile(ctx context.Context, userID int64, profile
 		return ErrUserNotFound
 	}
 
+	defer svc.invalidateProfile(ctx, userID)
 	return svc.userRepo.UpdateProfile(ctx, userID, profile)
 }
 
+// invalidateProfile 用户信息修改后删除资料缓存
+func (svc *UserService) invalidateProfile(ctx context.Context, userID int64) {
+	svc.profiles.Delete(ctx, profileCacheKey(userID))
+}
+
+func profileCacheKey(userID int64) string {
+	return fmt.Sprintf("profile:%d", userID)
+}
+
+// IsAdmin 判断用户是否为管理员，用户不存在时返回 false
+func (svc *UserService) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := svc.userRepo.FindById(ctx, userID)
+	if errors.Is(err, dao.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin(), nil
+}
+
 func (svc *UserService) GetTotalUserCount(ctx context.Context) (int64, error) {
 	return svc.userRepo.GetTotalUserCount(ctx)
 }
@@ -263,3 +677,14 @@ func (svc *UserService) GetErrorLogs(page, size int) ([]map[string]interface{},
 
 	return logs, int64(len(logs)), nil
 }
+
+// userStoreError 预检查之后仍可能因并发写入触发唯一索引冲突，转换为对应的业务错误
+func userStoreError(err error) error {
+	switch {
+	case errors.Is(err, dao.ErrUserDuplicateUsername):
+		return ErrUserDuplicateUsername
+	case errors.Is(err, dao.ErrUserDuplicateEmail):
+		return ErrUserDuplicateEmail
+	}
+	return err
+}
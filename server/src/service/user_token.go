@@ -0,0 +1,75 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 13:00:00
+ * @Description: 一次性令牌服务，签发和核销邮箱验证、找回密码等链接中的令牌
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/security"
+)
+
+// 令牌用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+var (
+	ErrTokenInvalid = errors.New("链接无效")
+	ErrTokenExpired = errors.New("链接已过期")
+	ErrTokenUsed    = errors.New("链接已被使用")
+)
+
+type UserTokenService struct {
+	signer *security.TokenSigner
+	repo   *repository.UserTokenRepository
+}
+
+func NewUserTokenService(signer *security.TokenSigner, repo *repository.UserTokenRepository) *UserTokenService {
+	return &UserTokenService{
+		signer: signer,
+		repo:   repo,
+	}
+}
+
+// Issue 签发新令牌，同一用途之前签发的未使用令牌全部作废
+func (s *UserTokenService) Issue(ctx context.Context, userId int64, purpose string, ttl time.Duration) (string, error) {
+	if err := s.repo.InvalidateByUser(ctx, userId, purpose); err != nil {
+		return "", err
+	}
+	token, claims, err := s.signer.Sign(purpose, userId, ttl)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.Create(ctx, claims.Nonce, userId, purpose, claims.ExpireAt); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume 校验并核销令牌，返回令牌对应的用户ID
+func (s *UserTokenService) Consume(ctx context.Context, token, purpose string) (int64, error) {
+	claims, err := s.signer.Parse(token, purpose)
+	switch {
+	case errors.Is(err, security.ErrTokenExpired):
+		return 0, ErrTokenExpired
+	case err != nil:
+		return 0, ErrTokenInvalid
+	}
+
+	err = s.repo.Consume(ctx, claims.Nonce, purpose)
+	if errors.Is(err, dao.ErrUserTokenUsed) {
+		return 0, ErrTokenUsed
+	}
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserId, nil
+}
@@ -0,0 +1,169 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:30:00
+ * @Description: 按屏蔽和静音关系过滤内容，各列表统一在服务层调用
+ */
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	"negaihoshi/server/src/realtime"
+	"negaihoshi/server/src/repository"
+)
+
+var ErrContentNotVisible = errors.New("内容不存在")
+
+// 屏蔽或静音列表变化的通知，只在服务端内部使用，不推送给客户端
+const eventVisibilityChanged = "visibility.changed"
+
+// RealtimeVisibilityTopic 用户的屏蔽或静音列表变化时发布通知的主题，屏蔽是双向的，双方都会收到
+func RealtimeVisibilityTopic(userId int64) string {
+	return "visibility:" + strconv.FormatInt(userId, 10)
+}
+
+// VisibilityFilter 屏蔽是双向的，双方都看不到对方的任何内容；静音是单向的，只从静音者的信息流中隐藏
+type VisibilityFilter struct {
+	repo *repository.UserBlockRepository
+}
+
+func NewVisibilityFilter(repo *repository.UserBlockRepository) *VisibilityFilter {
+	return &VisibilityFilter{repo: repo}
+}
+
+// FeedHidden 信息流中需要对查看者隐藏的作者，未登录时不隐藏任何人
+func (f *VisibilityFilter) FeedHidden(ctx context.Context, viewerId int64) (map[int64]bool, error) {
+	if viewerId <= 0 {
+		return nil, nil
+	}
+	blocked, err := f.repo.BlockedIds(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	muted, err := f.repo.MutedIds(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[int64]bool, len(blocked)+len(muted))
+	for _, id := range append(blocked, muted...) {
+		hidden[id] = true
+	}
+	return hidden, nil
+}
+
+// CheckAuthor 查看单条内容或某个用户的内容列表时调用，存在屏蔽关系时返回 ErrContentNotVisible，静音不影响
+func (f *VisibilityFilter) CheckAuthor(ctx context.Context, viewerId, authorId int64) error {
+	if viewerId <= 0 || viewerId == authorId {
+		return nil
+	}
+	blocked, err := f.repo.ExistsEither(ctx, viewerId, authorId)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrContentNotVisible
+	}
+	return nil
+}
+
+// hiddenIds 把隐藏的作者转为列表，用于数据库查询的排除条件
+func hiddenIds(hidden map[int64]bool) []int64 {
+	ids := make([]int64, 0, len(hidden))
+	for id := range hidden {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// filterByAuthor 去掉作者被隐藏的条目
+func filterByAuthor[T any](list []T, hidden map[int64]bool, author func(T) int64) []T {
+	if len(hidden) == 0 {
+		return list
+	}
+	results := make([]T, 0, len(list))
+	for _, item := range list {
+		if !hidden[author(item)] {
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+// FeedWatcher 按查看者的屏蔽和静音关系过滤实时推送的新树洞。连接时加载一次需要隐藏的作者，
+// 之后屏蔽或静音列表变化时在下一个事件前重新加载
+type FeedWatcher struct {
+	filter   *VisibilityFilter
+	viewerId int64
+	hidden   map[int64]bool
+	sub      *realtime.Subscription
+	// 收到变化通知后置为 true
+	stale atomic.Bool
+	// 通知订阅中断后无法得知之后的变化，每个事件前都重新加载
+	broken atomic.Bool
+}
+
+// WatchFeed viewerId 为 0 表示未登录，不过滤任何事件；使用完毕后需要调用 Close
+func (f *VisibilityFilter) WatchFeed(ctx context.Context, hub realtime.Hub, viewerId int64) (*FeedWatcher, error) {
+	w := &FeedWatcher{filter: f, viewerId: viewerId}
+	if viewerId <= 0 {
+		return w, nil
+	}
+	// 先订阅再加载，加载期间发生的变化也会收到通知
+	sub, err := hub.Subscribe(ctx, RealtimeVisibilityTopic(viewerId), 0)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := f.FeedHidden(ctx, viewerId)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	w.sub = sub
+	w.hidden = hidden
+	go w.watch(ctx)
+	return w, nil
+}
+
+func (w *FeedWatcher) watch(ctx context.Context) {
+	for {
+		event, err := w.sub.Next(ctx)
+		if err != nil {
+			w.broken.Store(true)
+			return
+		}
+		if event.Type == eventVisibilityChanged {
+			w.stale.Store(true)
+		}
+	}
+}
+
+// Allow 判断事件是否推送给查看者，事件数据需包含作者的 UserId
+func (w *FeedWatcher) Allow(ctx context.Context, event realtime.Event) (bool, error) {
+	if w.viewerId <= 0 {
+		return true, nil
+	}
+	if w.stale.Swap(false) || w.broken.Load() {
+		hidden, err := w.filter.FeedHidden(ctx, w.viewerId)
+		if err != nil {
+			return false, err
+		}
+		w.hidden = hidden
+	}
+	var author struct {
+		UserId int64
+	}
+	if err := json.Unmarshal(event.Data, &author); err != nil {
+		return false, err
+	}
+	return !w.hidden[author.UserId], nil
+}
+
+func (w *FeedWatcher) Close() {
+	if w.sub != nil {
+		w.sub.Close()
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 22:00:00
+ * @Description: 实时推送按屏蔽和静音关系过滤的测试
+ */
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/realtime"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+	"negaihoshi/server/src/repository/repotest"
+	"negaihoshi/server/src/service"
+)
+
+func TestFeedWatcher(t *testing.T) {
+	ctx := context.Background()
+	db := repotest.OpenSQLite(t)
+	users := repository.NewUserRepository(dao.NewUserDAO(db))
+	blockRepo := repository.NewUserBlockRepository(dao.NewUserBlockDAO(db))
+	hub := realtime.NewMemoryHub()
+	blocks := service.NewBlockService(blockRepo, users, repository.NewFollowRepository(dao.NewFollowDAO(db)), nil, hub)
+	visibility := service.NewVisibilityFilter(blockRepo)
+
+	ids := map[string]int64{}
+	for _, name := range []string{"viewer", "blocked", "muted", "other"} {
+		user := &domain.User{Username: name, Email: name + "@example.com", Password: "hashed-" + name}
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		ids[name] = user.Id
+	}
+	if err := blocks.Block(ctx, ids["viewer"], ids["blocked"]); err != nil {
+		t.Fatalf("屏蔽用户失败: %v", err)
+	}
+
+	publish := func(author int64) {
+		t.Helper()
+		if err := hub.Publish(ctx, service.RealtimeTopicTreehole, domain.EventTreeholeCreated, domain.TreeHole{UserId: author, Content: "新树洞"}); err != nil {
+			t.Fatalf("发布失败: %v", err)
+		}
+	}
+	// allowed 读取下一个事件并返回 watcher 是否允许推送
+	allowed := func(sub *realtime.Subscription, watcher *service.FeedWatcher) bool {
+		t.Helper()
+		nextCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		event, err := sub.Next(nextCtx)
+		if err != nil {
+			t.Fatalf("读取事件失败: %v", err)
+		}
+		ok, err := watcher.Allow(ctx, event)
+		if err != nil {
+			t.Fatalf("过滤事件失败: %v", err)
+		}
+		return ok
+	}
+	watch := func(viewerId int64) *service.FeedWatcher {
+		t.Helper()
+		watcher, err := visibility.WatchFeed(ctx, hub, viewerId)
+		if err != nil {
+			t.Fatalf("WatchFeed 失败: %v", err)
+		}
+		t.Cleanup(watcher.Close)
+		return watcher
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		// 断线期间发布的事件在重连补发时同样过滤
+		publish(ids["other"])
+		publish(ids["blocked"])
+		publish(ids["other"])
+		replay, err := hub.Subscribe(ctx, service.RealtimeTopicTreehole, 1)
+		if err != nil {
+			t.Fatalf("订阅失败: %v", err)
+		}
+		defer replay.Close()
+		watcher := watch(ids["viewer"])
+		if allowed(replay, watcher) {
+			t.Fatal("补发时不应推送已屏蔽用户的树洞")
+		}
+		if !allowed(replay, watcher) {
+			t.Fatal("补发时应推送其他用户的树洞")
+		}
+	})
+
+	sub, err := hub.Subscribe(ctx, service.RealtimeTopicTreehole, 0)
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	defer sub.Close()
+
+	tests := []struct {
+		name   string
+		viewer int64
+		author int64
+		want   bool
+	}{
+		{"BlockedAuthor", ids["viewer"], ids["blocked"], false},
+		{"OtherAuthor", ids["viewer"], ids["other"], true},
+		// 屏蔽是双向的
+		{"BlockedSeesBlocker", ids["blocked"], ids["viewer"], false},
+		{"Anonymous", 0, ids["blocked"], true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := watch(tt.viewer)
+			publish(tt.author)
+			if got := allowed(sub, watcher); got != tt.want {
+				t.Fatalf("推送结果为 %t，期望 %t", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("MuteAfterConnect", func(t *testing.T) {
+		watcher := watch(ids["viewer"])
+		publish(ids["muted"])
+		if !allowed(sub, watcher) {
+			t.Fatal("静音前应推送该用户的树洞")
+		}
+		if err := blocks.Mute(ctx, ids["viewer"], ids["muted"]); err != nil {
+			t.Fatalf("静音用户失败: %v", err)
+		}
+		// 变化通知是异步处理的
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			publish(ids["muted"])
+			if !allowed(sub, watcher) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("静音后仍推送该用户的树洞")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
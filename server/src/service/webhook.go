@@ -0,0 +1,466 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: webhook 管理和投递，失败按指数退避重试
+ */
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/repository"
+	"negaihoshi/server/src/repository/dao"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook 不存在")
+	ErrWebhookDeliveryNotFound = errors.New("投递记录不存在")
+	ErrWebhookInvalidURL       = errors.New("webhook 地址必须是 http 或 https 地址")
+	ErrWebhookInvalidEvent     = errors.New("不支持的事件类型")
+	ErrWebhookBlockedAddress   = errors.New("webhook 地址不能指向本机或内网地址")
+)
+
+// 推送请求头
+const (
+	WebhookHeaderEvent     = "X-Negaihoshi-Event"
+	WebhookHeaderDelivery  = "X-Negaihoshi-Delivery"
+	WebhookHeaderTimestamp = "X-Negaihoshi-Timestamp"
+	// 值为 sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+	WebhookHeaderSignature = "X-Negaihoshi-Signature"
+)
+
+const (
+	webhookMaxAttempts  = 6
+	webhookRetryBase    = 30 * time.Second
+	webhookTimeout      = 10 * time.Second
+	webhookLease        = time.Minute
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 20
+)
+
+type WebhookService struct {
+	repo   *repository.WebhookRepository
+	client *http.Client
+	// 有新的待投递记录时唤醒投递协程
+	wake chan struct{}
+}
+
+func NewWebhookService(repo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		client: newWebhookClient(webhookDialControl),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// newWebhookClient 推送只允许连接公网地址：在建立连接时检查解析后的 IP，
+// 避免通过 DNS 重新绑定绕过；不使用环境变量中的代理，也不跟随重定向
+func newWebhookClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func webhookDialControl(network, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || webhookBlockedAddr(addrPort.Addr()) {
+		return ErrWebhookBlockedAddress
+	}
+	return nil
+}
+
+// webhookDeniedPrefixes 不允许作为推送目标的地址段：本机、私有网络、运营商 NAT（含云厂商的元数据地址）、
+// 链路本地、保留和文档地址、组播，以及可以映射到 IPv4 内网地址的 NAT64 前缀
+var webhookDeniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// webhookBlockedAddr IPv4 映射的 IPv6 地址按对应的 IPv4 地址检查
+func webhookBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range webhookDeniedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish 为订阅了该事件的 webhook 生成投递记录，由后台协程投递；失败只记录日志
+func (s *WebhookService) Publish(ctx context.Context, eventType string, data interface{}) {
+	// 请求结束后仍需写入投递记录
+	ctx = context.WithoutCancel(ctx)
+	hooks, err := s.repo.FindEnabled(ctx)
+	if err != nil {
+		log.Printf("查询 webhook 失败: %v", err)
+		return
+	}
+	var targets []domain.Webhook
+	for _, hook := range hooks {
+		if hook.Subscribes(eventType) {
+			targets = append(targets, hook)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	event, payload, err := newWebhookEvent(eventType, data)
+	if err != nil {
+		log.Printf("序列化 webhook 事件失败: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, hook := range targets {
+		_, err := s.repo.CreateDelivery(ctx, domain.WebhookDelivery{
+			WebhookId:   hook.Id,
+			EventId:     event.Id,
+			EventType:   eventType,
+			Payload:     payload,
+			Status:      domain.DeliveryStatusPending,
+			NextRetryAt: &now,
+		})
+		if err != nil {
+			log.Printf("写入 webhook 投递记录失败: %v", err)
+		}
+	}
+	s.notify()
+}
+
+// Run 后台投递协程，ctx 取消时退出
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		s.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	deliveries, err := s.repo.FindDueDeliveries(ctx, time.Now(), webhookBatchSize)
+	if err != nil {
+		log.Printf("查询待投递 webhook 失败: %v", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		ok, err := s.repo.ClaimDelivery(ctx, delivery, time.Now().Add(webhookLease))
+		if err != nil || !ok {
+			continue
+		}
+		s.deliver(ctx, delivery, true)
+	}
+}
+
+// deliver 投递一次并记录结果，retry 为 true 时失败后安排下次重试
+func (s *WebhookService) deliver(ctx context.Context, delivery domain.WebhookDelivery, retry bool) domain.WebhookDelivery {
+	hook, err := s.repo.FindById(ctx, delivery.WebhookId)
+	var secret string
+	if err == nil {
+		secret, err = s.repo.FindSecret(ctx, delivery.WebhookId)
+	}
+	if err != nil {
+		// webhook 已删除，不再重试
+		delivery.Status = domain.DeliveryStatusFailed
+		delivery.Error = "webhook 不存在或密钥无法解密"
+		delivery.NextRetryAt = nil
+		s.saveResult(ctx, delivery)
+		return delivery
+	}
+
+	start := time.Now()
+	code, err := s.send(ctx, hook.URL, secret, delivery)
+	delivery.Attempts++
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.ResponseCode = code
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		delivery.Status = domain.DeliveryStatusSuccess
+		delivery.NextRetryAt = nil
+	case !retry || delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = domain.DeliveryStatusFailed
+		delivery.NextRetryAt = nil
+	default:
+		// 30s、1m、2m、4m、8m
+		next := time.Now().Add(webhookRetryBase << (delivery.Attempts - 1))
+		delivery.Status = domain.DeliveryStatusPending
+		delivery.NextRetryAt = &next
+	}
+	s.saveResult(ctx, delivery)
+	return delivery
+}
+
+func (s *WebhookService) saveResult(ctx context.Context, delivery domain.WebhookDelivery) {
+	if err := s.repo.UpdateDeliveryResult(ctx, delivery); err != nil {
+		log.Printf("更新 webhook 投递记录失败: %v", err)
+	}
+}
+
+// send 投递一次，只返回状态码；响应体可能包含内网服务的内容，读取后丢弃不保存
+func (s *WebhookService) send(ctx context.Context, target, secret string, delivery domain.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Negaihoshi-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读完少量响应体以便复用连接
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("订阅方返回 %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload 计算推送签名，订阅方用同样的方式校验
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookEvent(eventType string, data interface{}) (domain.WebhookEvent, string, error) {
+	id, err := randomToken(16)
+	if err != nil {
+		return domain.WebhookEvent{}, "", err
+	}
+	event := domain.WebhookEvent{
+		Id:         "evt_" + id,
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return domain.WebhookEvent{}, "", err
+	}
+	return event, string(payload), nil
+}
+
+// Create 创建 webhook，返回的结果中包含只展示一次的签名密钥
+func (s *WebhookService) Create(ctx context.Context, hook domain.Webhook) (domain.Webhook, error) {
+	if err := validateWebhook(hook); err != nil {
+		return domain.Webhook{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+	hook.Secret = "whsec_" + secret
+	return s.repo.Create(ctx, hook)
+}
+
+func (s *WebhookService) Update(ctx context.Context, hook domain.Webhook) error {
+	if err := validateWebhook(hook); err != nil {
+		return err
+	}
+	return s.mapNotFound(s.repo.Update(ctx, hook))
+}
+
+// RotateSecret 重置签名密钥
+func (s *WebhookService) RotateSecret(ctx context.Context, id int64) (string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	secret = "whsec_" + secret
+	if err := s.mapNotFound(s.repo.UpdateSecret(ctx, id, secret)); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	return s.mapNotFound(s.repo.Delete(ctx, id))
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]domain.Webhook, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int64) (domain.Webhook, error) {
+	hook, err := s.repo.FindById(ctx, id)
+	return hook, s.mapNotFound(err)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookId int64, page, size int) ([]domain.WebhookDelivery, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return s.repo.FindDeliveriesByWebhook(ctx, webhookId, (page-1)*size, size)
+}
+
+// Ping 立即向指定 webhook 发送测试事件，返回投递结果
+func (s *WebhookService) Ping(ctx context.Context, id int64) (domain.WebhookDelivery, error) {
+	hook, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return domain.WebhookDelivery{}, s.mapNotFound(err)
+	}
+	event, payload, err := newWebhookEvent(domain.EventPing, map[string]interface{}{
+		"webhook_id": hook.Id,
+		"name":       hook.Name,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	delivery, err := s.repo.CreateDelivery(ctx, domain.WebhookDelivery{
+		WebhookId: hook.Id,
+		EventId:   event.Id,
+		EventType: event.Type,
+		Payload:   payload,
+		Status:    domain.DeliveryStatusPending,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	// 测试事件只投递一次，不进入重试
+	return s.deliver(ctx, delivery, false), nil
+}
+
+// Redeliver 以原始请求体重新投递，生成新的投递记录
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryId int64) (domain.WebhookDelivery, error) {
+	original, err := s.repo.FindDeliveryById(ctx, deliveryId)
+	if errors.Is(err, dao.ErrWebhookDeliveryNotFound) {
+		return domain.WebhookDelivery{}, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	now := time.Now()
+	delivery, err := s.repo.CreateDelivery(ctx, domain.WebhookDelivery{
+		WebhookId:   original.WebhookId,
+		EventId:     original.EventId,
+		EventType:   original.EventType,
+		Payload:     original.Payload,
+		Status:      domain.DeliveryStatusPending,
+		NextRetryAt: &now,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	s.notify()
+	return delivery, nil
+}
+
+func (s *WebhookService) mapNotFound(err error) error {
+	if errors.Is(err, dao.ErrWebhookNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+func validateWebhook(hook domain.Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrWebhookInvalidURL
+	}
+	// 域名在连接时检查解析结果，这里先拒绝明显的本机和内网地址
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookBlockedAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && webhookBlockedAddr(addr) {
+		return ErrWebhookBlockedAddress
+	}
+	for _, event := range hook.Events {
+		if event == "*" {
+			continue
+		}
+		known := false
+		for _, e := range domain.AllWebhookEvents {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrWebhookInvalidEvent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 22:00:00
+ * @Description: webhook 推送地址限制的测试
+ */
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"syscall"
+	"testing"
+
+	"negaihoshi/server/src/domain"
+)
+
+func TestWebhookBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"10.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.100.100.200", true},
+		{"100.127.255.255", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"172.16.0.1", true},
+		{"172.31.255.255", true},
+		{"192.0.0.8", true},
+		{"192.168.1.1", true},
+		{"198.18.0.1", true},
+		{"198.19.255.255", true},
+		{"224.0.0.1", true},
+		{"240.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:100.100.100.200", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"64:ff9b::7f00:1", true},
+		{"fc00::1", true},
+		{"fd12:3456::1", true},
+		{"fe80::1", true},
+		{"fe80::1%eth0", true},
+		{"ff02::1", true},
+		{"8.8.8.8", false},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"172.32.0.1", false},
+		{"198.20.0.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := webhookBlockedAddr(netip.MustParseAddr(tt.addr)); got != tt.blocked {
+				t.Fatalf("webhookBlockedAddr(%s) = %t，期望 %t", tt.addr, got, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookRejectsInternalHosts(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"http://localhost/hook", ErrWebhookBlockedAddress},
+		{"http://api.localhost/hook", ErrWebhookBlockedAddress},
+		{"http://127.0.0.1:8080/hook", ErrWebhookBlockedAddress},
+		{"http://100.100.100.200/latest/meta-data", ErrWebhookBlockedAddress},
+		{"http://[::ffff:169.254.169.254]/", ErrWebhookBlockedAddress},
+		{"http://[64:ff9b::a9fe:a9fe]/", ErrWebhookBlockedAddress},
+		{"ftp://example.com/hook", ErrWebhookInvalidURL},
+		{"https://example.com/hook", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateWebhook(domain.Webhook{URL: tt.url}); !errors.Is(err, tt.want) {
+				t.Fatalf("validateWebhook(%s) = %v，期望 %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookDialRejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("不应连接到本机地址")
+	}))
+	defer server.Close()
+
+	s := &WebhookService{client: newWebhookClient(webhookDialControl)}
+	_, err := s.send(context.Background(), server.URL, "secret", domain.WebhookDelivery{Payload: "{}"})
+	if !errors.Is(err, ErrWebhookBlockedAddress) {
+		t.Fatalf("连接本机地址应返回 ErrWebhookBlockedAddress，得到 %v", err)
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	targets := []string{
+		"http://127.0.0.1:1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.100.100.200/latest/meta-data/",
+		"http://[::1]/",
+	}
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			server := httptest.NewServer(http.RedirectHandler(target, http.StatusFound))
+			defer server.Close()
+
+			// 测试服务器本身在本机，只放行它的地址，其他地址仍按推送规则检查并记录
+			var mu sync.Mutex
+			var dialed []string
+			control := func(network, address string, c syscall.RawConn) error {
+				mu.Lock()
+				dialed = append(dialed, address)
+				mu.Unlock()
+				if address == server.Listener.Addr().String() {
+					return nil
+				}
+				return webhookDialControl(network, address, c)
+			}
+			s := &WebhookService{client: newWebhookClient(control)}
+			code, err := s.send(context.Background(), server.URL, "secret", domain.WebhookDelivery{Payload: "{}"})
+			if code != http.StatusFound || err == nil {
+				t.Fatalf("重定向应作为失败的响应返回，得到状态码 %d，错误 %v", code, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(dialed) != 1 {
+				t.Fatalf("不应跟随重定向连接其他地址，实际连接了 %v", dialed)
+			}
+		})
+	}
+}
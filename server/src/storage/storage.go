@@ -0,0 +1,100 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 10:00:00
+ * @Description: 按配置打开 MySQL、PostgreSQL 或 SQLite 数据库
+ */
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+var ErrUnsupportedDriver = errors.New("不支持的数据库类型")
+
+// Driver 规范化配置中的数据库类型，留空时默认为 MySQL
+func Driver(dbType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(dbType)) {
+	case "", "mysql":
+		return MySQL, nil
+	case "postgres", "postgresql", "pgsql":
+		return Postgres, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedDriver, dbType)
+}
+
+// Open 打开数据库并开启错误转换，唯一键冲突统一返回 gorm.ErrDuplicatedKey。
+// SQLite 使用 name 作为数据库文件路径，不需要 host 等参数
+func Open(dbType, host, port, user, password, name string) (*gorm.DB, string, error) {
+	driver, err := Driver(dbType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var dialector gorm.Dialector
+	switch driver {
+	case MySQL:
+		dialector = mysql.Open(user + ":" + password + "@tcp(" + host + ":" + port + ")/" + name + "?charset=utf8mb4&parseTime=True&loc=Local")
+	case Postgres:
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(user, password),
+			Host:     host + ":" + port,
+			Path:     "/" + name,
+			RawQuery: "sslmode=prefer",
+		}
+		dialector = postgres.Open(dsn.String())
+	case SQLite:
+		dialector = sqlite.Open(sqliteDSN(name))
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, "", err
+	}
+	if driver == SQLite && IsMemory(name) {
+		// 内存数据库每个连接相互独立，只能使用单个连接
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, "", err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, driver, nil
+}
+
+// sqliteDSN 开启外键约束，并在写锁冲突时等待而不是立即失败
+func sqliteDSN(name string) string {
+	if name == "" {
+		name = "negaihoshi.db"
+	}
+	sep := "?"
+	if strings.Contains(name, "?") {
+		sep = "&"
+	}
+	pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if !IsMemory(name) {
+		pragmas += "&_pragma=journal_mode(WAL)"
+	}
+	return name + sep + pragmas
+}
+
+// IsMemory 判断 SQLite 数据库是否为内存数据库
+func IsMemory(name string) bool {
+	return strings.Contains(name, ":memory:") || strings.Contains(name, "mode=memory")
+}
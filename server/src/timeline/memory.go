@@ -0,0 +1,129 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 进程内时间线缓存，单实例部署使用
+ */
+package timeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"negaihoshi/server/src/domain"
+)
+
+type memoryTimeline struct {
+	// 按时间倒序
+	entries  []domain.TimelineEntry
+	expireAt time.Time
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	timelines map[int64]*memoryTimeline
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		timelines: make(map[int64]*memoryTimeline),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, userIds []int64, entry domain.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, userId := range userIds {
+		t := s.get(userId, now)
+		if t == nil {
+			continue
+		}
+		i := sort.Search(len(t.entries), func(i int) bool {
+			return !t.entries[i].Before(entry)
+		})
+		if i < len(t.entries) && t.entries[i] == entry {
+			continue
+		}
+		t.entries = append(t.entries, domain.TimelineEntry{})
+		copy(t.entries[i+1:], t.entries[i:])
+		t.entries[i] = entry
+		if len(t.entries) > MaxEntries {
+			t.entries = t.entries[:MaxEntries]
+		}
+		t.expireAt = now.Add(ttl)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Range(ctx context.Context, userId int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.get(userId, time.Now())
+	if t == nil {
+		return nil, false, nil
+	}
+	start := 0
+	if !after.IsZero() {
+		start = sort.Search(len(t.entries), func(i int) bool {
+			return after.Before(t.entries[i])
+		})
+	}
+	end := start + limit
+	if end > len(t.entries) {
+		end = len(t.entries)
+	}
+	page := make([]domain.TimelineEntry, end-start)
+	copy(page, t.entries[start:end])
+	return page, true, nil
+}
+
+func (s *MemoryStore) Fill(ctx context.Context, userId int64, entries []domain.TimelineEntry) error {
+	if len(entries) > MaxEntries {
+		entries = entries[:MaxEntries]
+	}
+	saved := make([]domain.TimelineEntry, len(entries))
+	copy(saved, entries)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	s.timelines[userId] = &memoryTimeline{entries: saved, expireAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Invalidate(ctx context.Context, userId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.timelines, userId)
+	return nil
+}
+
+func (s *MemoryStore) get(userId int64, now time.Time) *memoryTimeline {
+	t, ok := s.timelines[userId]
+	if !ok {
+		return nil
+	}
+	if now.After(t.expireAt) {
+		delete(s.timelines, userId)
+		return nil
+	}
+	return t
+}
+
+// sweep 定期清理过期的时间线，避免不再访问的用户一直占用内存
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Hour {
+		return
+	}
+	s.lastSweep = now
+	for userId, t := range s.timelines {
+		if now.After(t.expireAt) {
+			delete(s.timelines, userId)
+		}
+	}
+}
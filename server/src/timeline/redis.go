@@ -0,0 +1,153 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 基于 Redis 有序集合的时间线缓存，多实例部署时共享
+ */
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"negaihoshi/server/src/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 每个时间线中都有一个分数为 -1 的占位成员，用来区分“时间线为空”和“时间线未建立”
+const sentinel = "~"
+
+// 只推送到已建立的时间线，推送后裁剪到保留条数（排名 0 是占位成员，不会被裁掉）
+// KEYS[1] 时间线 ARGV[1] 分数 ARGV[2] 成员 ARGV[3] 保留条数 ARGV[4] 过期秒数
+const addScript = `
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 1, -(tonumber(ARGV[3]) + 1))
+	redis.call('EXPIRE', KEYS[1], ARGV[4])
+end
+return 0
+`
+
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "negaihoshi:timeline:",
+	}
+}
+
+func (s *RedisStore) Add(ctx context.Context, userIds []int64, entry domain.TimelineEntry) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	pipe := s.client.Pipeline()
+	for _, userId := range userIds {
+		pipe.Eval(ctx, addScript, []string{s.key(userId)}, entry.Ctime, encodeMember(entry), MaxEntries, int(ttl.Seconds()))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Range(ctx context.Context, userId int64, after domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	key := s.key(userId)
+	var entries []domain.TimelineEntry
+	exists := false
+	max := "+inf"
+	if !after.IsZero() {
+		// 与游标时间相同的条目按成员倒序排列，取排在游标之后的部分
+		score := strconv.FormatInt(after.Ctime, 10)
+		ties, err := s.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: score, Max: score}).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		cursor := encodeMember(after)
+		for _, member := range ties {
+			if member < cursor && len(entries) < limit {
+				if entry, ok := decodeMember(member); ok {
+					entries = append(entries, entry)
+				}
+			}
+		}
+		exists = len(ties) > 0
+		if len(entries) == limit {
+			return entries, true, nil
+		}
+		max = "(" + score
+	}
+
+	// 多取一条，页末可能是占位成员
+	members, err := s.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   max,
+		Count: int64(limit - len(entries) + 1),
+	}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(members) == 0 && !exists {
+		return nil, false, nil
+	}
+	for _, member := range members {
+		if len(entries) == limit {
+			break
+		}
+		if entry, ok := decodeMember(member); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, true, nil
+}
+
+func (s *RedisStore) Fill(ctx context.Context, userId int64, entries []domain.TimelineEntry) error {
+	if len(entries) > MaxEntries {
+		entries = entries[:MaxEntries]
+	}
+	members := make([]redis.Z, 0, len(entries)+1)
+	members = append(members, redis.Z{Score: -1, Member: sentinel})
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: float64(entry.Ctime), Member: encodeMember(entry)})
+	}
+
+	key := s.key(userId)
+	pipe := s.client.Pipeline()
+	pipe.Del(ctx, key)
+	pipe.ZAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Invalidate(ctx context.Context, userId int64) error {
+	return s.client.Del(ctx, s.key(userId)).Err()
+}
+
+func (s *RedisStore) key(userId int64) string {
+	return s.prefix + strconv.FormatInt(userId, 10)
+}
+
+// encodeMember 成员格式为 类型:补零的 id:时间，分数相同时 Redis 按成员字典序排列，与 domain.TimelineEntry.Before 一致
+func encodeMember(entry domain.TimelineEntry) string {
+	return fmt.Sprintf("%s:%019d:%d", entry.Kind, entry.Id, entry.Ctime)
+}
+
+func decodeMember(member string) (domain.TimelineEntry, bool) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 3 {
+		return domain.TimelineEntry{}, false
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return domain.TimelineEntry{}, false
+	}
+	ctime, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return domain.TimelineEntry{}, false
+	}
+	return domain.TimelineEntry{Kind: parts[0], Id: id, Ctime: ctime}, true
+}
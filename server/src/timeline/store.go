@@ -0,0 +1,33 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 每个用户的首页时间线缓存
+ */
+package timeline
+
+import (
+	"context"
+	"time"
+
+	"negaihoshi/server/src/domain"
+)
+
+const (
+	// 每个用户缓存的条目数，更早的内容回源数据库读取
+	MaxEntries = 500
+	// 缓存过期时间，不活跃用户的时间线过期后在下次访问时重建
+	ttl = 24 * time.Hour
+)
+
+// Store 时间线缓存，有进程内和 Redis 两种实现。
+// 只有已经建立的时间线才会接收新条目，避免把只有部分内容的时间线当作完整结果返回
+type Store interface {
+	// Add 把条目推送到多个用户已建立的时间线中
+	Add(ctx context.Context, userIds []int64, entry domain.TimelineEntry) error
+	// Range 返回排在 after 之后的最多 limit 条，after 为零值时从最新开始；时间线未建立时 ok 为 false
+	Range(ctx context.Context, userId int64, after domain.TimelineEntry, limit int) (entries []domain.TimelineEntry, ok bool, err error)
+	// Fill 用完整的最新条目重建时间线，entries 按时间倒序，可以为空
+	Fill(ctx context.Context, userId int64, entries []domain.TimelineEntry) error
+	// Invalidate 删除时间线，下次读取时重建
+	Invalidate(ctx context.Context, userId int64) error
+}
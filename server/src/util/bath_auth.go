@@ -119,6 +119,19 @@ func (pc *PasswordCrypto) DecryptPassword(encryptedPassword string) (string, err
 	return string(plaintext), nil
 }
 
+// DecryptWithFallback 先用当前密钥解密，失败时再用 legacy（可为 nil）解密，用于更换密钥后读取旧数据。
+// stale 为 true 表示密文由旧密钥加密，调用方应使用当前密钥重新加密保存
+func (pc *PasswordCrypto) DecryptWithFallback(encryptedPassword string, legacy *PasswordCrypto) (plain string, stale bool, err error) {
+	plain, err = pc.DecryptPassword(encryptedPassword)
+	if err == nil || legacy == nil {
+		return plain, false, err
+	}
+	if plain, legacyErr := legacy.DecryptPassword(encryptedPassword); legacyErr == nil {
+		return plain, true, nil
+	}
+	return "", false, err
+}
+
 // VerifyPassword 验证密码
 func (pc *PasswordCrypto) VerifyPassword(plainPassword, encryptedPassword string) bool {
 	decrypted, err := pc.DecryptPassword(encryptedPassword)
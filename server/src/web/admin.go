@@ -6,23 +6,44 @@
 package web
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"negaihoshi/server/src/cache"
+	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 通过接口上传的导入文件大小上限，更大的文件使用 cmd/import 导入
+const maxImportUpload = 64 << 20
+
 type AdminHandler struct {
 	userService     *service.UserService
 	treeholeService *service.TreeHoleService
 	statusService   *service.StatusAndPostsService
+	reportService   *service.ReportService
+	auditService    *service.AuditService
+	twoFactor       *service.TwoFactorService
+	trashService    *service.TrashService
+	importService   *service.ImportService
+	cache           *cache.Cache
 }
 
-func NewAdminHandler(userService *service.UserService, treeholeService *service.TreeHoleService, statusService *service.StatusAndPostsService) *AdminHandler {
+func NewAdminHandler(userService *service.UserService, treeholeService *service.TreeHoleService, statusService *service.StatusAndPostsService, reportService *service.ReportService, auditService *service.AuditService, twoFactor *service.TwoFactorService, trashService *service.TrashService, importService *service.ImportService, c *cache.Cache) *AdminHandler {
 	return &AdminHandler{
 		userService:     userService,
 		treeholeService: treeholeService,
 		statusService:   statusService,
+		reportService:   reportService,
+		auditService:    auditService,
+		twoFactor:       twoFactor,
+		trashService:    trashService,
+		importService:   importService,
+		cache:           c,
 	}
 }
 
@@ -52,13 +73,37 @@ func (a *AdminHandler) RegisterAdminRoutes(server *gin.Engine) {
 		admin.POST("/content/status/:id/approve", a.ApproveStatus)
 		admin.POST("/content/status/:id/reject", a.RejectStatus)
 
+		// 恢复已删除的内容
+		admin.POST("/content/treehole/:id/restore", a.RestoreContent(domain.TrashTreeHole))
+		admin.POST("/content/status/:id/restore", a.RestoreContent(domain.TrashStatus))
+		admin.POST("/content/posts/:id/restore", a.RestoreContent(domain.TrashPost))
+
+		// 编辑历史
+		admin.GET("/content/status/:id/revisions", a.ListRevisions(domain.RevisionStatus))
+		admin.GET("/content/status/:id/revisions/diff", a.DiffRevisions(domain.RevisionStatus))
+		admin.GET("/content/posts/:id/revisions", a.ListRevisions(domain.RevisionPost))
+		admin.GET("/content/posts/:id/revisions/diff", a.DiffRevisions(domain.RevisionPost))
+
+		// 批量导入
+		admin.POST("/content/import", a.ImportContent)
+
+		// 举报处理
+		admin.GET("/content/reports", a.GetReportList)
+		admin.POST("/content/reports/:id/resolve", a.ResolveReport)
+
 		// 系统设置
 		admin.GET("/settings", a.GetSystemSettings)
 		admin.PUT("/settings", a.UpdateSystemSettings)
+		admin.GET("/settings/2fa", a.GetTwoFactorPolicy)
+		admin.PUT("/settings/2fa", a.UpdateTwoFactorPolicy)
 
 		// 日志查看
 		admin.GET("/logs", a.GetSystemLogs)
 		admin.GET("/logs/error", a.GetErrorLogs)
+		admin.GET("/logs/audit", a.GetAuditLogs)
+
+		// 缓存命中统计
+		admin.GET("/cache/stats", a.GetCacheStats)
 	}
 }
 
@@ -247,7 +292,7 @@ func (a *AdminHandler) DeleteTreehole(ctx *gin.Context) {
 		return
 	}
 
-	err = a.treeholeService.DeleteTreeholeForAdmin(treeholeID)
+	err = a.treeholeService.DeleteTreeholeForAdmin(ctx, a.currentAdminID(ctx), treeholeID)
 	if err != nil {
 		ErrorResponse(ctx, 500, "删除树洞失败")
 		return
@@ -264,7 +309,7 @@ func (a *AdminHandler) ApproveTreehole(ctx *gin.Context) {
 		return
 	}
 
-	err = a.treeholeService.ApproveTreehole(treeholeID)
+	err = a.approveContent(ctx, domain.ReportTargetTreeHole, treeholeID)
 	if err != nil {
 		ErrorResponse(ctx, 500, "审核通过失败")
 		return
@@ -290,7 +335,7 @@ func (a *AdminHandler) RejectTreehole(ctx *gin.Context) {
 		return
 	}
 
-	err = a.treeholeService.RejectTreehole(treeholeID, req.Reason)
+	err = a.rejectContent(ctx, domain.ReportTargetTreeHole, treeholeID, req.Reason)
 	if err != nil {
 		ErrorResponse(ctx, 500, "审核拒绝失败")
 		return
@@ -327,7 +372,7 @@ func (a *AdminHandler) DeleteStatus(ctx *gin.Context) {
 		return
 	}
 
-	err = a.statusService.DeleteStatusForAdmin(statusID)
+	err = a.statusService.DeleteStatusForAdmin(ctx, a.currentAdminID(ctx), statusID)
 	if err != nil {
 		ErrorResponse(ctx, 500, "删除动态失败")
 		return
@@ -336,6 +381,112 @@ func (a *AdminHandler) DeleteStatus(ctx *gin.Context) {
 	SuccessResponse(ctx, gin.H{"message": "动态删除成功"})
 }
 
+// 恢复已删除的树洞、动态或文章
+func (a *AdminHandler) RestoreContent(itemType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ValidationError(ctx, "内容ID格式错误")
+			return
+		}
+
+		err = a.trashService.RestoreForModerator(ctx, a.currentAdminID(ctx), itemType, id, ctx.ClientIP())
+		switch {
+		case errors.Is(err, service.ErrTrashItemNotFound):
+			NotFoundError(ctx, "已删除的内容")
+		case err != nil:
+			ErrorResponse(ctx, 500, "恢复内容失败")
+		default:
+			SuccessResponse(ctx, gin.H{"message": "内容恢复成功"})
+		}
+	}
+}
+
+// 批量导入内容，dry_run 时只报告将导入的数量和冲突
+func (a *AdminHandler) ImportContent(ctx *gin.Context) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		ValidationError(ctx, "请上传导入文件")
+		return
+	}
+	if file.Size > maxImportUpload {
+		ValidationError(ctx, "导入文件过大，请使用命令行工具导入")
+		return
+	}
+	userMap, err := service.ParseImportUserMap(ctx.PostForm("map"))
+	if err != nil {
+		ValidationError(ctx, err.Error())
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportUpload))
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+
+	opts := domain.ImportOptions{
+		Format:   ctx.PostForm("format"),
+		DryRun:   ctx.PostForm("dry_run") == "true",
+		Username: ctx.PostForm("user"),
+		UserMap:  userMap,
+	}
+	report, err := a.importService.ImportForAdmin(ctx, a.currentAdminID(ctx), ctx.ClientIP(), opts, data)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, report)
+	case errors.Is(err, service.ErrImportFormatInvalid), errors.Is(err, service.ErrImportFileInvalid):
+		ValidationError(ctx, err.Error())
+	case errors.Is(err, service.ErrImportUserNotFound):
+		NotFoundError(ctx, "用户")
+	default:
+		ErrorResponse(ctx, 500, "导入失败，已导入的内容可以重新执行导入跳过")
+	}
+}
+
+// 查看动态或文章的编辑历史
+func (a *AdminHandler) ListRevisions(kind string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := parseIdParam(ctx, "id")
+		if !ok {
+			return
+		}
+
+		revisions, err := a.statusService.ListRevisionsForAdmin(ctx, kind, id)
+		if err != nil {
+			ErrorResponse(ctx, 500, "获取编辑历史失败")
+			return
+		}
+		SuccessResponse(ctx, gin.H{"revisions": revisions})
+	}
+}
+
+// 比较动态或文章的两个历史版本
+func (a *AdminHandler) DiffRevisions(kind string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := parseIdParam(ctx, "id")
+		if !ok {
+			return
+		}
+		from, to, ok := parseRevisionRange(ctx)
+		if !ok {
+			return
+		}
+
+		diff, err := a.statusService.DiffRevisionsForAdmin(ctx, kind, id, from, to)
+		if err != nil {
+			revisionError(ctx, err)
+			return
+		}
+		SuccessResponse(ctx, diff)
+	}
+}
+
 // 审核通过动态
 func (a *AdminHandler) ApproveStatus(ctx *gin.Context) {
 	statusID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
@@ -344,7 +495,7 @@ func (a *AdminHandler) ApproveStatus(ctx *gin.Context) {
 		return
 	}
 
-	err = a.statusService.ApproveStatus(statusID)
+	err = a.approveContent(ctx, domain.ReportTargetStatus, statusID)
 	if err != nil {
 		ErrorResponse(ctx, 500, "审核通过失败")
 		return
@@ -370,7 +521,7 @@ func (a *AdminHandler) RejectStatus(ctx *gin.Context) {
 		return
 	}
 
-	err = a.statusService.RejectStatus(statusID, req.Reason)
+	err = a.rejectContent(ctx, domain.ReportTargetStatus, statusID, req.Reason)
 	if err != nil {
 		ErrorResponse(ctx, 500, "审核拒绝失败")
 		return
@@ -379,6 +530,111 @@ func (a *AdminHandler) RejectStatus(ctx *gin.Context) {
 	SuccessResponse(ctx, gin.H{"message": "审核拒绝成功"})
 }
 
+// 获取举报列表
+func (a *AdminHandler) GetReportList(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	status := ctx.DefaultQuery("status", domain.ReportStatusPending)
+
+	reports, total, err := a.reportService.GetReportListForAdmin(ctx, page, size, status)
+	if err != nil {
+		ErrorResponse(ctx, 500, "获取举报列表失败")
+		return
+	}
+
+	SuccessResponse(ctx, gin.H{
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"size":    size,
+	})
+}
+
+// 处理举报：approve 保留内容并驳回举报，reject 隐藏内容
+func (a *AdminHandler) ResolveReport(ctx *gin.Context) {
+	reportID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ValidationError(ctx, "举报ID格式错误")
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=approve reject"`
+		Reason string `json:"reason"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	report, err := a.reportService.GetReport(ctx, reportID)
+	if err == service.ErrReportNotFound {
+		NotFoundError(ctx, "举报记录")
+		return
+	}
+	if err != nil {
+		ErrorResponse(ctx, 500, "获取举报记录失败")
+		return
+	}
+
+	if req.Action == "approve" {
+		err = a.approveContent(ctx, report.TargetType, report.TargetId)
+	} else {
+		err = a.rejectContent(ctx, report.TargetType, report.TargetId, req.Reason)
+	}
+	if err != nil {
+		ErrorResponse(ctx, 500, "处理举报失败")
+		return
+	}
+
+	SuccessResponse(ctx, gin.H{"message": "举报处理成功"})
+}
+
+// approveContent 审核通过内容并驳回相关举报
+func (a *AdminHandler) approveContent(ctx *gin.Context, targetType string, targetID int64) error {
+	var err error
+	switch targetType {
+	case domain.ReportTargetTreeHole:
+		err = a.treeholeService.ApproveTreehole(ctx, targetID)
+	case domain.ReportTargetStatus:
+		err = a.statusService.ApproveStatus(ctx, targetID)
+	case domain.ReportTargetPost:
+		err = a.statusService.ApprovePosts(ctx, targetID)
+	}
+	if err != nil {
+		return err
+	}
+	return a.reportService.DismissTargetReports(ctx, targetType, targetID, a.currentAdminID(ctx), "")
+}
+
+// rejectContent 审核拒绝内容并处理相关举报
+func (a *AdminHandler) rejectContent(ctx *gin.Context, targetType string, targetID int64, reason string) error {
+	var err error
+	switch targetType {
+	case domain.ReportTargetTreeHole:
+		err = a.treeholeService.RejectTreehole(ctx, targetID, reason)
+	case domain.ReportTargetStatus:
+		err = a.statusService.RejectStatus(ctx, targetID, reason)
+	case domain.ReportTargetPost:
+		err = a.statusService.RejectPosts(ctx, targetID, reason)
+	}
+	if err != nil {
+		return err
+	}
+	return a.reportService.ResolveTargetReports(ctx, targetType, targetID, a.currentAdminID(ctx), reason)
+}
+
+// GetCacheStats 各类缓存的命中和未命中次数，重启后重新计数
+func (a *AdminHandler) GetCacheStats(ctx *gin.Context) {
+	SuccessResponse(ctx, gin.H{"caches": a.cache.Stats()})
+}
+
+func (a *AdminHandler) currentAdminID(ctx *gin.Context) int64 {
+	id, _ := middleware.CurrentUserId(ctx)
+	return id
+}
+
 // 获取系统设置
 func (a *AdminHandler) GetSystemSettings(ctx *gin.Context) {
 	settings, err := a.userService.GetSystemSettings()
@@ -414,6 +670,43 @@ func (a *AdminHandler) UpdateSystemSettings(ctx *gin.Context) {
 	SuccessResponse(ctx, gin.H{"message": "系统设置更新成功"})
 }
 
+// 获取两步验证策略
+func (a *AdminHandler) GetTwoFactorPolicy(ctx *gin.Context) {
+	required, err := a.twoFactor.IsRequired(ctx)
+	if err != nil {
+		ErrorResponse(ctx, 500, "获取两步验证策略失败")
+		return
+	}
+
+	SuccessResponse(ctx, gin.H{"require_2fa": required})
+}
+
+// 设置是否强制所有用户开启两步验证
+func (a *AdminHandler) UpdateTwoFactorPolicy(ctx *gin.Context) {
+	var req struct {
+		Require2FA *bool `json:"require_2fa" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	previous, err := a.twoFactor.IsRequired(ctx)
+	if err != nil {
+		ErrorResponse(ctx, 500, "更新两步验证策略失败")
+		return
+	}
+	if err := a.twoFactor.SetRequired(ctx, *req.Require2FA); err != nil {
+		ErrorResponse(ctx, 500, "更新两步验证策略失败")
+		return
+	}
+	a.auditService.Record(ctx, a.currentAdminID(ctx), domain.AuditActionTwoFactorPolicy, ctx.ClientIP(),
+		fmt.Sprintf("修改强制两步验证: %t -> %t", previous, *req.Require2FA))
+
+	SuccessResponse(ctx, gin.H{"require_2fa": *req.Require2FA}, "两步验证策略已更新")
+}
+
 // 获取系统日志
 func (a *AdminHandler) GetSystemLogs(ctx *gin.Context) {
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
@@ -453,3 +746,22 @@ func (a *AdminHandler) GetErrorLogs(ctx *gin.Context) {
 	})
 }
 
+// 获取安全审计日志
+func (a *AdminHandler) GetAuditLogs(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "10"))
+	action := ctx.Query("action")
+
+	logs, total, err := a.auditService.GetAuditLogsForAdmin(ctx, page, size, action)
+	if err != nil {
+		ErrorResponse(ctx, 500, "获取审计日志失败")
+		return
+	}
+
+	SuccessResponse(ctx, gin.H{
+		"logs":  logs,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
@@ -917,8 +917,9 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 				Schema: map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
-						"username": map[string]interface{}{"type": "string", "description": "用户名"},
-						"password": map[string]interface{}{"type": "string", "description": "密码"},
+						"username":      map[string]interface{}{"type": "string", "description": "用户名"},
+						"password":      map[string]interface{}{"type": "string", "description": "密码"},
+						"captcha_token": map[string]interface{}{"type": "string", "description": "人机验证令牌，连续登录失败后必填"},
 					},
 					"required": []string{"username", "password"},
 				},
@@ -936,6 +937,21 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 						"data":    map[string]interface{}{"token": "jwt_token_here"},
 					},
 				},
+				"401": {
+					Description: "登录失败，captcha_required 为 true 时下次登录需携带人机验证令牌",
+					Example: map[string]interface{}{
+						"code":    401,
+						"message": "用户名或密码错误",
+						"data":    map[string]interface{}{"captcha_required": true},
+					},
+				},
+				"429": {
+					Description: "登录失败次数过多，账户或IP被临时锁定",
+					Example: map[string]interface{}{
+						"code":    429,
+						"message": "登录失败次数过多，账户已被临时锁定，请稍后再试",
+					},
+				},
 			},
 		},
 		{
@@ -1045,7 +1061,7 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 		{
 			Method:      "DELETE",
 			Path:        "/api/treehole/{id}",
-			Description: "删除树洞消息",
+			Description: "删除自己的树洞消息，删除后进入回收站，保留期内可以恢复",
 			Tags:        []string{"treehole"},
 			Parameters: []APIParameter{
 				{Name: "id", In: "path", Type: "integer", Required: true, Description: "消息ID", Example: "1"},
@@ -1146,7 +1162,7 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 		{
 			Method:      "POST",
 			Path:        "/api/wordpress/transfer",
-			Description: "转发内容到WordPress",
+			Description: "转发自己的内容到已绑定的 WordPress 站点，未绑定的站点在结果中返回失败原因并通知用户",
 			Tags:        []string{"wordpress"},
 			RequestBody: &APIRequestBody{
 				ContentType: "application/json",
@@ -1157,7 +1173,7 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 						"content_type": map[string]interface{}{"type": "string", "enum": []string{"treehole", "status", "post"}, "description": "内容类型"},
 						"site_ids":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}, "description": "目标站点ID列表"},
 						"title":        map[string]interface{}{"type": "string", "description": "文章标题（可选）"},
-						"as_private":   map[string]interface{}{"type": "boolean", "description": "是否设为私有"},
+						"as_private":   map[string]interface{}{"type": "boolean", "description": "是否以 WordPress 私密状态发布，私密和不公开的文章总是私密发布"},
 					},
 					"required": []string{"content_id", "content_type", "site_ids"},
 				},
@@ -1190,6 +1206,1901 @@ func (a *APIDocsHandler) getAPIEndpoints() []APIEndpoint {
 						},
 					},
 				},
+				"400": {Description: "草稿和定时发布的文章不能转发"},
+				"403": {Description: "不是自己发布的内容"},
+				"404": {Description: "内容不存在"},
+			},
+		},
+
+		// 内容举报相关
+		{
+			Method:      "POST",
+			Path:        "/api/report",
+			Description: "举报树洞、动态或文章，同一用户对同一内容只能举报一次",
+			Tags:        []string{"report"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"target_type": map[string]interface{}{"type": "string", "enum": []string{"treehole", "status", "post"}, "description": "举报内容类型"},
+						"target_id":   map[string]interface{}{"type": "integer", "description": "举报内容ID"},
+						"reason":      map[string]interface{}{"type": "string", "enum": []string{"spam", "harassment", "illegal", "sexual", "other"}, "description": "举报原因"},
+						"detail":      map[string]interface{}{"type": "string", "description": "补充说明", "maxLength": 500},
+					},
+					"required": []string{"target_type", "target_id", "reason"},
+				},
+				Example: map[string]interface{}{
+					"target_type": "treehole",
+					"target_id":   1,
+					"reason":      "spam",
+					"detail":      "重复发布广告",
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "举报成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "举报成功，我们会尽快处理",
+						"data":    nil,
+					},
+				},
+				"409": {
+					Description: "重复举报",
+					Example: map[string]interface{}{
+						"code":    409,
+						"message": "您已举报过该内容",
+						"data":    nil,
+					},
+				},
+			},
+		},
+
+		// 邮箱验证与找回密码
+		{
+			Method:      "POST",
+			Path:        "/api/users/send-verification",
+			Description: "重新发送邮箱验证邮件（需要登录）",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "发送成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "验证邮件已发送",
+					},
+				},
+				"400": {
+					Description: "邮箱已验证",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "邮箱已验证，无需重复验证",
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/verify-email",
+			Description: "使用邮件中的令牌验证邮箱",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"token": map[string]interface{}{"type": "string", "description": "验证邮件中的令牌"},
+					},
+					"required": []string{"token"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "验证成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "邮箱验证成功",
+					},
+				},
+				"400": {
+					Description: "令牌无效、过期或已使用",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "链接已过期，请重新获取",
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/forgot-password",
+			Description: "发送重置密码邮件，无论邮箱是否注册都返回成功",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"email": map[string]interface{}{"type": "string", "format": "email", "description": "注册邮箱"},
+					},
+					"required": []string{"email"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已受理",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "如果该邮箱已注册，您将收到一封重置密码的邮件",
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/reset-password",
+			Description: "使用邮件中的令牌重置密码，令牌只能使用一次，成功后全部登录失效，个人访问令牌全部撤销",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"token":        map[string]interface{}{"type": "string", "description": "重置邮件中的令牌"},
+						"new_password": map[string]interface{}{"type": "string", "minLength": 6, "description": "新密码"},
+					},
+					"required": []string{"token", "new_password"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "重置成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "密码重置成功，请重新登录",
+					},
+				},
+				"400": {
+					Description: "令牌无效、过期或已使用",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "链接已被使用",
+					},
+				},
+			},
+		},
+
+		// 账户安全与会话管理
+		{
+			Method:      "PUT",
+			Path:        "/api/users/password",
+			Description: "修改密码，需要提供当前密码，成功后其他设备上的登录全部失效，个人访问令牌全部撤销",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"current_password": map[string]interface{}{"type": "string", "description": "当前密码"},
+						"new_password":     map[string]interface{}{"type": "string", "minLength": 6, "description": "新密码"},
+					},
+					"required": []string{"current_password", "new_password"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "修改成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "密码修改成功，其他设备已退出登录",
+					},
+				},
+				"400": {
+					Description: "当前密码错误",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "当前密码错误",
+					},
+				},
+			},
+		},
+		{
+			Method:      "PUT",
+			Path:        "/api/users/email",
+			Description: "修改邮箱，需要提供密码，新邮箱需要重新验证",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"password":  map[string]interface{}{"type": "string", "description": "当前密码"},
+						"new_email": map[string]interface{}{"type": "string", "format": "email", "description": "新邮箱"},
+					},
+					"required": []string{"password", "new_email"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "修改成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "邮箱修改成功，请查收验证邮件完成验证",
+					},
+				},
+				"409": {
+					Description: "邮箱已被使用",
+					Example: map[string]interface{}{
+						"code":    409,
+						"message": "邮箱已被使用",
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/users/sessions",
+			Description: "查看当前账户已登录的设备",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "获取成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "获取成功",
+						"data": []map[string]interface{}{
+							{
+								"id":         1,
+								"user_id":    1,
+								"device":     "Chrome / Windows",
+								"user_agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) ...",
+								"ip":         "127.0.0.1",
+								"current":    true,
+								"last_seen":  "2026-10-19T14:00:00+08:00",
+								"ctime":      "2026-10-19T10:00:00+08:00",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/sessions/{id}",
+			Description: "注销指定设备上的登录",
+			Tags:        []string{"auth"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "会话ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "注销成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "已注销该设备的登录",
+					},
+				},
+				"404": {
+					Description: "会话不存在",
+					Example: map[string]interface{}{
+						"code":    404,
+						"message": "会话不存在",
+					},
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/sessions",
+			Description: "注销除当前设备外的全部登录",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "注销成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "其他设备已全部退出登录",
+					},
+				},
+			},
+		},
+
+		// 两步验证
+		{
+			Method:      "GET",
+			Path:        "/api/users/2fa",
+			Description: "查询两步验证状态",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "操作成功",
+						"data":    map[string]interface{}{"enabled": true, "required": false, "recovery_codes_left": 10},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/2fa/setup",
+			Description: "生成两步验证密钥和 otpauth 绑定地址",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "请使用认证器扫描二维码，并输入验证码完成绑定",
+						"data":    map[string]interface{}{"secret": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP", "uri": "otpauth://totp/Negaihoshi:alice?algorithm=SHA1&digits=6&issuer=Negaihoshi&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"},
+					},
+				},
+				"409": {
+					Description: "已开启两步验证",
+					Example: map[string]interface{}{
+						"code":    409,
+						"message": "已开启两步验证",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/2fa/enable",
+			Description: "校验验证码并开启两步验证，返回只展示一次的恢复码",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{"type": "string", "description": "认证器中的6位验证码或恢复码"},
+					},
+					"required": []string{"code"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "两步验证已开启，请妥善保存恢复码",
+						"data":    map[string]interface{}{"recovery_codes": []string{"abcde-fghij", "klmno-pqrst"}},
+					},
+				},
+				"400": {
+					Description: "验证码错误",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "验证码错误",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/2fa/verify",
+			Description: "登录第二步：校验验证码或恢复码，通过后完成登录",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{"type": "string", "description": "认证器中的6位验证码或恢复码"},
+					},
+					"required": []string{"code"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "登录成功",
+						"data":    nil,
+					},
+				},
+				"400": {
+					Description: "验证码错误",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "验证码错误",
+						"data":    nil,
+					},
+				},
+				"429": {
+					Description: "失败次数过多",
+					Example: map[string]interface{}{
+						"code":    429,
+						"message": "验证失败次数过多，账户已被临时锁定，请稍后再试",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/2fa/disable",
+			Description: "校验当前密码和验证码后关闭两步验证",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"password": map[string]interface{}{"type": "string", "description": "当前密码"},
+						"code":     map[string]interface{}{"type": "string", "description": "认证器中的6位验证码或恢复码"},
+					},
+					"required": []string{"password", "code"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "两步验证已关闭",
+						"data":    nil,
+					},
+				},
+				"400": {
+					Description: "当前密码或验证码错误",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "验证码错误",
+						"data":    nil,
+					},
+				},
+				"403": {
+					Description: "站点强制开启",
+					Example: map[string]interface{}{
+						"code":    403,
+						"message": "站点要求开启两步验证，无法关闭",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/2fa/recovery-codes",
+			Description: "重新生成恢复码，原有恢复码全部失效",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{"type": "string", "description": "认证器中的6位验证码或恢复码"},
+					},
+					"required": []string{"code"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "恢复码已重新生成，原有恢复码已失效",
+						"data":    map[string]interface{}{"recovery_codes": []string{"abcde-fghij", "klmno-pqrst"}},
+					},
+				},
+				"400": {
+					Description: "验证码错误",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "验证码错误",
+						"data":    nil,
+					},
+				},
+			},
+		},
+
+		// API 令牌
+		{
+			Method:      "POST",
+			Path:        "/api/auth/token",
+			Description: "获取访问令牌。grant_type=password 使用用户名密码登录（开启两步验证时需提供 otp）；grant_type=refresh_token 使用刷新令牌换取新令牌，旧刷新令牌立即失效，重复使用会注销该登录的全部令牌。请求其他接口时携带 Authorization: Bearer <access_token>",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"grant_type":    map[string]interface{}{"type": "string", "enum": []string{"password", "refresh_token"}, "description": "授权方式"},
+						"username":      map[string]interface{}{"type": "string", "description": "用户名或邮箱（password）"},
+						"password":      map[string]interface{}{"type": "string", "description": "密码（password）"},
+						"captcha_token": map[string]interface{}{"type": "string", "description": "人机验证令牌（password，需要时）"},
+						"otp":           map[string]interface{}{"type": "string", "description": "两步验证码或恢复码（password，开启两步验证时）"},
+						"refresh_token": map[string]interface{}{"type": "string", "description": "刷新令牌（refresh_token）"},
+					},
+					"required": []string{"grant_type"},
+				},
+				Example: map[string]interface{}{
+					"grant_type": "password",
+					"username":   "testuser",
+					"password":   "password123",
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "登录成功",
+						"data": map[string]interface{}{
+							"access_token":  "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+							"token_type":    "Bearer",
+							"expires_in":    900,
+							"refresh_token": "Q2hhbmdlTWVQbGVhc2VJdElzQVJhbmRvbVRva2Vu",
+						},
+					},
+				},
+				"401": {
+					Description: "凭据无效、刷新令牌无效或需要两步验证码",
+					Example: map[string]interface{}{
+						"code":    401,
+						"message": "刷新令牌无效或已过期，请重新登录",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/auth/revoke",
+			Description: "注销刷新令牌及其轮换出的全部令牌",
+			Tags:        []string{"auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"refresh_token": map[string]interface{}{"type": "string", "description": "刷新令牌"},
+					},
+					"required": []string{"refresh_token"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "已退出登录",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/users/tokens",
+			Description: "获取当前用户的个人访问令牌列表及当前用户可以授予的权限范围，admin:* 只对管理员返回",
+			Tags:        []string{"users", "auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"tokens": []map[string]interface{}{
+								{"id": 1, "name": "发布脚本", "scopes": []string{"treehole:write"}, "prefix": "nhp_a1b2", "expire_at": nil, "last_used_at": nil},
+							},
+							"scopes": []string{"treehole:write", "status:read", "wordpress:transfer", "admin:*"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/tokens",
+			Description: "创建个人访问令牌，明文令牌只返回一次；使用时放在 Authorization: Bearer 头中，只能访问权限范围内的接口",
+			Tags:        []string{"users", "auth"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":            map[string]interface{}{"type": "string", "description": "令牌名称"},
+						"scopes":          map[string]interface{}{"type": "array", "description": "权限范围：treehole:write、status:read、wordpress:transfer、admin:*（仅管理员可授予）"},
+						"expires_in_days": map[string]interface{}{"type": "integer", "description": "有效天数，0 表示永不过期"},
+					},
+					"required": []string{"name", "scopes"},
+				},
+				Example: map[string]interface{}{
+					"name":            "发布脚本",
+					"scopes":          []string{"treehole:write"},
+					"expires_in_days": 90,
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "创建成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "访问令牌已创建，请立即复制保存，之后将无法再次查看",
+						"data": map[string]interface{}{
+							"token":      "nhp_a1b2c3d4...",
+							"token_info": map[string]interface{}{"id": 1, "name": "发布脚本", "scopes": []string{"treehole:write"}, "prefix": "nhp_a1b2"},
+						},
+					},
+				},
+				"403": {
+					Description: "申请了自己没有的权限范围，如非管理员申请 admin:*",
+					Example: map[string]interface{}{
+						"code":    403,
+						"message": "不能授予自己没有的权限范围",
+					},
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/tokens/:id",
+			Description: "撤销个人访问令牌",
+			Tags:        []string{"users", "auth"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "令牌ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "访问令牌已撤销",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/auth/oidc/providers",
+			Description: "获取已配置的第三方登录方式",
+			Tags:        []string{"auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"providers": []map[string]interface{}{
+								{"name": "google", "display_name": "Google"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/auth/oidc/:provider/login",
+			Description: "跳转到第三方授权页（授权码 + PKCE）；已登录用户带 link=1 时为当前账户绑定外部账号",
+			Tags:        []string{"auth"},
+			Parameters: []APIParameter{
+				{Name: "provider", In: "path", Type: "string", Required: true, Description: "登录方式标识", Example: "google"},
+				{Name: "link", In: "query", Type: "string", Required: false, Description: "为 1 时绑定到当前登录的账户", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"302": {
+					Description: "跳转到第三方授权页",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/auth/oidc/:provider/callback",
+			Description: "第三方授权回调，校验 state、nonce 和 ID 令牌后登录、绑定或自动注册；配置了 frontend-redirect 时带上 result/login_stage 或 error 跳转回前端",
+			Tags:        []string{"auth"},
+			Parameters: []APIParameter{
+				{Name: "provider", In: "path", Type: "string", Required: true, Description: "登录方式标识", Example: "google"},
+				{Name: "code", In: "query", Type: "string", Required: true, Description: "授权码", Example: ""},
+				{Name: "state", In: "query", Type: "string", Required: true, Description: "授权请求时生成的 state", Example: ""},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "登录成功（未配置前端跳转地址时）",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "登录成功",
+						"data": map[string]interface{}{
+							"result":      "login",
+							"login_stage": "done",
+						},
+					},
+				},
+				"400": {
+					Description: "邮箱已被注册等",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "该邮箱已注册，请先使用密码登录后在账户设置中绑定",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/users/identities",
+			Description: "获取当前用户绑定的外部登录账号",
+			Tags:        []string{"users", "auth"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"identities": []map[string]interface{}{
+								{"id": 1, "user_id": 1, "provider": "google", "email": "user@example.com", "ctime": "2026-10-19T18:00:00+08:00"},
+							},
+							"providers": []map[string]interface{}{
+								{"name": "google", "display_name": "Google"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/identities/:provider",
+			Description: "解绑外部登录账号",
+			Tags:        []string{"users", "auth"},
+			Parameters: []APIParameter{
+				{Name: "provider", In: "path", Type: "string", Required: true, Description: "登录方式标识", Example: "google"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "已解绑",
+						"data":    nil,
+					},
+				},
+				"400": {
+					Description: "未设置密码且这是唯一的登录方式",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "这是唯一的登录方式，请先通过找回密码设置密码后再解绑",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/admin/webhooks",
+			Description: "获取 webhook 列表和可订阅的事件类型",
+			Tags:        []string{"webhook"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"webhooks": []map[string]interface{}{
+								{"id": 1, "name": "归档", "url": "https://example.com/hook", "events": []string{"treehole.created"}, "enabled": true},
+							},
+							"events": []string{"treehole.created", "treehole.deleted", "treehole.moderated", "status.created", "user.signed_up"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/webhooks",
+			Description: "创建 webhook，events 为订阅的事件类型，\"*\" 表示全部；签名密钥只在本次返回。推送请求带 X-Negaihoshi-Event、X-Negaihoshi-Delivery、X-Negaihoshi-Timestamp 和 X-Negaihoshi-Signature（sha256=hex(HMAC-SHA256(secret, timestamp + \".\" + body))），非 2xx 响应按 30 秒起的指数退避重试，最多 6 次",
+			Tags:        []string{"webhook"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"name", "url", "events"},
+					"properties": map[string]interface{}{
+						"name":    map[string]interface{}{"type": "string", "description": "名称"},
+						"url":     map[string]interface{}{"type": "string", "description": "推送地址，http 或 https，不能指向本机或内网地址；推送不跟随重定向"},
+						"events":  map[string]interface{}{"type": "array", "description": "订阅的事件类型，\"*\" 表示全部"},
+						"enabled": map[string]interface{}{"type": "boolean", "description": "是否启用，默认启用"},
+					},
+				},
+				Example: map[string]interface{}{
+					"name":    "归档",
+					"url":     "https://example.com/hook",
+					"events":  []string{"treehole.created", "treehole.deleted"},
+					"enabled": true,
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "创建成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "创建成功，请妥善保存签名密钥",
+						"data": map[string]interface{}{
+							"webhook": map[string]interface{}{"id": 1, "name": "归档", "secret": "whsec_..."},
+						},
+					},
+				},
+				"400": {
+					Description: "地址或事件类型无效，或地址指向本机或内网",
+					Example: map[string]interface{}{
+						"code":    400,
+						"message": "不支持的事件类型",
+						"data":    nil,
+					},
+				},
+			},
+		},
+		{
+			Method:      "PUT",
+			Path:        "/api/admin/webhooks/:id",
+			Description: "修改 webhook 的名称、地址、订阅事件和启用状态",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "webhook ID", Example: "1"},
+			},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"name", "url", "events"},
+					"properties": map[string]interface{}{
+						"name":    map[string]interface{}{"type": "string", "description": "名称"},
+						"url":     map[string]interface{}{"type": "string", "description": "推送地址，http 或 https，不能指向本机或内网地址；推送不跟随重定向"},
+						"events":  map[string]interface{}{"type": "array", "description": "订阅的事件类型，\"*\" 表示全部"},
+						"enabled": map[string]interface{}{"type": "boolean", "description": "是否启用，默认启用"},
+					},
+				},
+				Example: map[string]interface{}{
+					"name":    "归档",
+					"url":     "https://example.com/hook",
+					"events":  []string{"*"},
+					"enabled": false,
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已更新"},
+				"404": {Description: "webhook 不存在"},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/admin/webhooks/:id",
+			Description: "删除 webhook",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "webhook ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已删除"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/webhooks/:id/rotate-secret",
+			Description: "重置签名密钥，旧密钥立即失效",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "webhook ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已重置",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "签名密钥已重置",
+						"data":    map[string]interface{}{"secret": "whsec_..."},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/admin/webhooks/:id/deliveries",
+			Description: "分页获取投递记录，含响应码、耗时、重试次数和下次重试时间",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "webhook ID", Example: "1"},
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"deliveries": []map[string]interface{}{
+								{"id": 10, "event_type": "treehole.created", "status": "failed", "attempts": 6, "response_code": 500},
+							},
+							"total": 1,
+							"page":  1,
+							"size":  20,
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/webhooks/:id/ping",
+			Description: "立即发送一次 ping 测试事件并返回投递结果，失败不重试",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "webhook ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已发送，结果见 delivery.status"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/webhooks/deliveries/:deliveryId/redeliver",
+			Description: "以原始请求体重新投递，生成新的投递记录",
+			Tags:        []string{"webhook"},
+			Parameters: []APIParameter{
+				{Name: "deliveryId", In: "path", Type: "integer", Required: true, Description: "投递记录 ID", Example: "10"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已加入投递队列"},
+				"404": {Description: "投递记录不存在"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/notifications",
+			Description: "分页获取当前用户的站内通知，同时返回未读总数和各类型未读数",
+			Tags:        []string{"notification"},
+			Parameters: []APIParameter{
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+				{Name: "type", In: "query", Type: "string", Required: false, Description: "通知类型：content_approved/content_rejected/content_hidden/transfer_failed/data_export/security", Example: "content_rejected"},
+				{Name: "unread", In: "query", Type: "string", Required: false, Description: "为 1 时只返回未读", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"notifications": []map[string]interface{}{
+								{"id": 1, "type": "content_rejected", "title": "你的树洞未通过审核", "content": "你的树洞未通过审核，已被隐藏。原因：广告", "target_type": "treehole", "target_id": 12, "read": false},
+							},
+							"total":          1,
+							"unread":         1,
+							"unread_by_type": map[string]interface{}{"content_rejected": 1},
+							"page":           1,
+							"size":           20,
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/notifications/unread-count",
+			Description: "获取未读通知数",
+			Tags:        []string{"notification"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"unread":         3,
+							"unread_by_type": map[string]interface{}{"security": 1, "content_approved": 2},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/notifications/:id/read",
+			Description: "将一条通知标记为已读",
+			Tags:        []string{"notification"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "通知 ID", Example: "1"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已标记为已读"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/notifications/read",
+			Description: "批量标记已读，一次最多 100 条",
+			Tags:        []string{"notification"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"ids"},
+					"properties": map[string]interface{}{
+						"ids": map[string]interface{}{"type": "array", "description": "通知 ID 列表"},
+					},
+				},
+				Example: map[string]interface{}{
+					"ids": []int64{1, 2, 3},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "已标记为已读",
+						"data":    map[string]interface{}{"updated": 3},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/notifications/read-all",
+			Description: "将全部通知标记为已读",
+			Tags:        []string{"notification"},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已全部标记为已读"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/notifications/preferences",
+			Description: "获取各类型通知的开关，未设置的类型默认开启",
+			Tags:        []string{"notification"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"preferences": []map[string]interface{}{
+								{"type": "content_approved", "enabled": false},
+								{"type": "security", "enabled": true},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "PUT",
+			Path:        "/api/notifications/preferences",
+			Description: "修改通知开关，账户安全提醒（security）不能关闭",
+			Tags:        []string{"notification"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"preferences"},
+					"properties": map[string]interface{}{
+						"preferences": map[string]interface{}{"type": "array", "description": "[{type, enabled}]"},
+					},
+				},
+				Example: map[string]interface{}{
+					"preferences": []map[string]interface{}{
+						{"type": "content_approved", "enabled": false},
+					},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已保存，返回全部开关"},
+				"400": {Description: "不支持的通知类型，或尝试关闭账户安全提醒"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/treehole/stream",
+			Description: "通过 Server-Sent Events 推送新树洞（event: treehole.created，data 与树洞列表中的条目一致）。断线重连时浏览器自动带上 Last-Event-ID 补发期间的事件；缺失的事件超出保留范围时先推送 event: resync，前端应重新拉取列表。登录时不推送已屏蔽或已静音用户的树洞，补发的事件同样过滤，连接期间修改屏蔽或静音列表立即生效。消费太慢的连接会被断开，重连后补发。每个用户或 IP 最多同时 5 个连接",
+			Tags:        []string{"treehole"},
+			Parameters: []APIParameter{
+				{Name: "Last-Event-ID", In: "header", Type: "string", Required: false, Description: "上次收到的事件 id，浏览器重连时自动携带", Example: "42"},
+				{Name: "last_event_id", In: "query", Type: "string", Required: false, Description: "首次连接时指定从哪个事件之后开始补发", Example: "42"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "text/event-stream，示例：id: 43\\nevent: treehole.created\\ndata: {\"Id\":12,\"Content\":\"...\",\"UserId\":3,\"Ctime\":\"...\"}",
+				},
+				"429": {
+					Description: "连接数过多，响应头带 Retry-After",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/treehole/ws",
+			Description: "通过 WebSocket 推送新树洞，每条消息为 {id, topic, type, data}；缺失事件超出保留范围时先发送 {type: \"resync\"}。消费太慢时以 1013 关闭，重连时用 last_event_id 参数补发；屏蔽和静音的过滤规则与 /api/treehole/stream 相同",
+			Tags:        []string{"treehole"},
+			Parameters: []APIParameter{
+				{Name: "last_event_id", In: "query", Type: "string", Required: false, Description: "上次收到的事件 id", Example: "42"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"101": {
+					Description: "切换到 WebSocket 协议",
+				},
+				"429": {
+					Description: "连接数过多，响应头带 Retry-After",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/messages/conversations",
+			Description: "发起或打开与某个用户的私信会话，可同时发送第一条消息。任意一方屏蔽了另一方时返回 403，内容包含屏蔽词时返回 400",
+			Tags:        []string{"message"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"user_id"},
+					"properties": map[string]interface{}{
+						"user_id": map[string]interface{}{"type": "integer", "description": "对方用户 ID"},
+						"content": map[string]interface{}{"type": "string", "description": "第一条消息，可选，不超过 2000 字符"},
+					},
+				},
+				Example: map[string]interface{}{
+					"user_id": 5,
+					"content": "你好",
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"conversation_id": 3,
+							"message":         map[string]interface{}{"id": 10, "conversation_id": 3, "sender_id": 1, "content": "你好"},
+						},
+					},
+				},
+				"403": {
+					Description: "对方已屏蔽你或你已屏蔽对方",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/messages/conversations",
+			Description: "按最后一条消息时间倒序获取会话列表，同时返回私信未读总数",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"conversations": []map[string]interface{}{
+								{"id": 3, "peer_id": 5, "peer_nickname": "小樱", "peer_avatar": "", "last_message": "你好", "unread": 1},
+							},
+							"total":  1,
+							"unread": 1,
+							"page":   1,
+							"size":   20,
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/messages/conversations/:id/messages",
+			Description: "按时间倒序分页获取会话中的消息，下一页传入返回的 next_before_id",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "会话 ID", Example: "3"},
+				{Name: "before_id", In: "query", Type: "integer", Required: false, Description: "只返回 id 小于该值的消息", Example: "10"},
+				{Name: "limit", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "30"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"messages": []map[string]interface{}{
+								{"id": 10, "conversation_id": 3, "sender_id": 1, "content": "你好"},
+							},
+							"next_before_id": 10,
+						},
+					},
+				},
+				"404": {
+					Description: "会话不存在或不是会话成员",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/messages/conversations/:id/messages",
+			Description: "在会话中发送消息，双方通过 /api/messages/stream 或 /api/messages/ws 实时收到 message.created 事件",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "会话 ID", Example: "3"},
+			},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type":     "object",
+					"required": []string{"content"},
+					"properties": map[string]interface{}{
+						"content": map[string]interface{}{"type": "string", "description": "消息内容，不超过 2000 字符"},
+					},
+				},
+				Example: map[string]interface{}{
+					"content": "最近好吗",
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "发送成功",
+				},
+				"400": {
+					Description: "内容为空、过长或包含屏蔽词",
+				},
+				"403": {
+					Description: "对方已屏蔽你或你已屏蔽对方",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/messages/conversations/:id/read",
+			Description: "将会话标记为已读，清空未读数",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "会话 ID", Example: "3"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已标记为已读",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/messages/unread-count",
+			Description: "获取私信未读总数",
+			Tags:        []string{"message"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data":    map[string]interface{}{"unread": 2},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/messages/stream",
+			Description: "通过 Server-Sent Events 推送当前用户收发的私信（event: message.created），断线重连和补发规则与 /api/treehole/stream 相同，需要登录",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "Last-Event-ID", In: "header", Type: "string", Required: false, Description: "上次收到的事件 id，浏览器重连时自动携带", Example: "7"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "text/event-stream",
+				},
+				"401": {
+					Description: "未登录",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/messages/ws",
+			Description: "通过 WebSocket 推送当前用户收发的私信，消息格式与 /api/treehole/ws 相同，需要登录",
+			Tags:        []string{"message"},
+			Parameters: []APIParameter{
+				{Name: "last_event_id", In: "query", Type: "string", Required: false, Description: "上次收到的事件 id", Example: "7"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"101": {
+					Description: "切换到 WebSocket 协议",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/users/blocks",
+			Description: "获取当前用户屏蔽的用户列表",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/blocks/:id",
+			Description: "屏蔽用户，屏蔽后双方互相看不到对方的树洞、动态和文章，无法私信，同时解除双方的关注关系",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "要屏蔽的用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已屏蔽",
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/blocks/:id",
+			Description: "取消屏蔽用户",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已取消屏蔽",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/follow/:id",
+			Description: "关注用户，重复关注不会报错。任意一方屏蔽了另一方时返回 403",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "要关注的用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已关注",
+				},
+				"403": {
+					Description: "对方已屏蔽你或你已屏蔽对方",
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/follow/:id",
+			Description: "取消关注用户",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已取消关注",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/follow/:id/followers",
+			Description: "按关注时间倒序获取用户的粉丝，下一页传入返回的 next_before_id，为 0 时没有更多",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+				{Name: "before_id", In: "query", Type: "integer", Required: false, Description: "上一页返回的 next_before_id", Example: "120"},
+				{Name: "limit", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"users": []map[string]interface{}{
+								{"user_id": 3, "nickname": "小樱", "avatar": "", "ctime": "2026-10-19T23:00:00+08:00"},
+							},
+							"next_before_id": 0,
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/follow/:id/following",
+			Description: "按关注时间倒序获取用户关注的人，分页方式与粉丝列表相同",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+				{Name: "before_id", In: "query", Type: "integer", Required: false, Description: "上一页返回的 next_before_id", Example: "120"},
+				{Name: "limit", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/follow/:id/stats",
+			Description: "获取用户的粉丝数和关注数，以及当前用户是否已关注",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data":    map[string]interface{}{"followers": 12, "following": 3, "is_following": true},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/timeline/home",
+			Description: "首页时间线，按时间倒序合并关注的人和自己发布的动态和文章。下一页传入返回的 next_cursor，为空时没有更多。个人令牌需要 status:read 权限",
+			Tags:        []string{"follow"},
+			Parameters: []APIParameter{
+				{Name: "cursor", In: "query", Type: "string", Required: false, Description: "上一页返回的 next_cursor", Example: "c3RhdHVzOjEyOjE3NjA4ODYwMDAwMDA"},
+				{Name: "limit", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"items": []map[string]interface{}{
+								{"type": "status", "id": 12, "user_id": 5, "content": "今天天气很好", "ctime": "2026-10-19T23:00:00+08:00"},
+								{"type": "post", "id": 4, "user_id": 3, "title": "旅行记录", "content": "...", "ctime": "2026-10-19T22:00:00+08:00"},
+							},
+							"next_cursor": "cG9zdDo0OjE3NjA4ODI0MDAwMDA",
+						},
+					},
+				},
+				"400": {
+					Description: "游标格式错误",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/users/mutes",
+			Description: "获取当前用户静音的用户列表",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/users/mutes/:id",
+			Description: "静音用户，对方的内容不再出现在自己的树洞列表、动态列表和首页时间线中，对方不会察觉，仍可访问对方主页",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "要静音的用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已静音",
+				},
+			},
+		},
+		{
+			Method:      "DELETE",
+			Path:        "/api/users/mutes/:id",
+			Description: "取消静音用户",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "用户 ID", Example: "5"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已取消静音",
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/trash",
+			Description: "按删除时间倒序获取当前用户回收站中的树洞、动态和文章，包括被管理员删除的内容，purge_at 之后内容会被永久删除",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "page", In: "query", Type: "integer", Required: false, Description: "页码", Example: "1"},
+				{Name: "size", In: "query", Type: "integer", Required: false, Description: "每页数量，最大 100", Example: "20"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"items": []map[string]interface{}{
+								{"type": "treehole", "id": 12, "user_id": 3, "content": "删掉的树洞", "ctime": "2026-10-01T08:00:00Z", "deleted_by": 3, "deleted_at": "2026-10-20T08:00:00Z", "purge_at": "2026-11-19T08:00:00Z"},
+							},
+							"page": 1,
+							"size": 20,
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/trash/:type/:id/restore",
+			Description: "恢复回收站中自己删除的内容，被管理员删除的内容不能自行恢复",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：treehole/status/post", Example: "treehole"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "内容 ID", Example: "12"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已恢复"},
+				"403": {Description: "内容被管理员删除，无法自行恢复"},
+				"404": {Description: "回收站中没有该内容"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/content/:type/:id/restore",
+			Description: "管理员恢复任意已删除的树洞、动态或文章并记录审计日志，type 为 treehole、status 或 posts",
+			Tags:        []string{"report"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：treehole/status/posts", Example: "status"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "内容 ID", Example: "8"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "内容恢复成功"},
+				"404": {Description: "已删除的内容不存在"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/revisions/:type/:id",
+			Description: "按版本先后获取自己的动态或文章的编辑历史，version 1 为首次编辑前的原始版本，未编辑过的内容返回空列表",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：status/post", Example: "post"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "动态或文章 ID", Example: "8"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "操作成功",
+						"data": map[string]interface{}{
+							"revisions": []map[string]interface{}{
+								{"id": 31, "version": 1, "type": "post", "target_id": 8, "editor_id": 3, "title": "标题", "content": "原文", "ctime": "2026-10-01T08:00:00Z"},
+								{"id": 32, "version": 2, "type": "post", "target_id": 8, "editor_id": 3, "title": "标题", "content": "改过的正文", "ctime": "2026-10-20T08:00:00Z"},
+							},
+						},
+					},
+				},
+				"403": {Description: "不是自己发布的内容"},
+				"404": {Description: "内容不存在"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/revisions/:type/:id/diff",
+			Description: "逐行比较两个历史版本，op 为 equal/insert/delete，文章同时比较标题",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：status/post", Example: "post"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "动态或文章 ID", Example: "8"},
+				{Name: "from", In: "query", Type: "integer", Required: true, Description: "旧版本 id", Example: "31"},
+				{Name: "to", In: "query", Type: "integer", Required: true, Description: "新版本 id", Example: "32"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "操作成功",
+						"data": map[string]interface{}{
+							"from":  map[string]interface{}{"id": 31, "version": 1},
+							"to":    map[string]interface{}{"id": 32, "version": 2},
+							"title": []map[string]interface{}{{"op": "equal", "text": "标题"}},
+							"content": []map[string]interface{}{
+								{"op": "delete", "text": "原文"},
+								{"op": "insert", "text": "改过的正文"},
+							},
+						},
+					},
+				},
+				"404": {Description: "内容或历史版本不存在"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/revisions/:type/:id/restore",
+			Description: "把自己的动态或文章恢复为某个历史版本，恢复会作为一次新的编辑追加到历史中",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：status/post", Example: "post"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "动态或文章 ID", Example: "8"},
+			},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"revision_id": map[string]interface{}{"type": "integer", "description": "要恢复的版本 id"},
+					},
+					"required": []string{"revision_id"},
+				},
+				Example: map[string]interface{}{"revision_id": 31},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "已恢复"},
+				"404": {Description: "内容或历史版本不存在"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/admin/content/:type/:id/revisions",
+			Description: "管理员查看任意动态或文章的编辑历史，包括已隐藏和已删除的内容，type 为 status 或 posts；比较版本使用 /revisions/diff?from=&to=",
+			Tags:        []string{"report"},
+			Parameters: []APIParameter{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "内容类型：status/posts", Example: "status"},
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "内容 ID", Example: "8"},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "成功"},
+			},
+		},
+		{
+			Method:      "PATCH",
+			Path:        "/api/posts/state",
+			Description: "修改自己的文章状态：draft 草稿、scheduled 定时发布、published 公开、private 私密、unlisted 不公开（持有链接可查看，不出现在列表和时间线中）。草稿或定时文章变为可见时，crossPost 为 true 则转发到已绑定的 WordPress 站点，私密和不公开的文章以 WordPress 私密状态发布",
+			Tags:        []string{"users"},
+			RequestBody: &APIRequestBody{
+				ContentType: "application/json",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":        map[string]interface{}{"type": "integer", "description": "文章 ID"},
+						"state":     map[string]interface{}{"type": "string", "enum": []string{"draft", "scheduled", "published", "private", "unlisted"}, "description": "文章状态"},
+						"publishAt": map[string]interface{}{"type": "string", "format": "date-time", "description": "定时发布的时间，必须晚于当前时间，其他状态忽略"},
+						"crossPost": map[string]interface{}{"type": "boolean", "description": "发布时是否转发到绑定的 WordPress 站点，不传时保持原来的设置"},
+					},
+					"required": []string{"id", "state"},
+				},
+				Example: map[string]interface{}{
+					"id":        8,
+					"state":     "scheduled",
+					"publishAt": "2026-10-21T08:00:00+08:00",
+					"crossPost": true,
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "文章状态已更新",
+						"data": map[string]interface{}{
+							"Id":        8,
+							"Title":     "标题",
+							"Content":   "正文",
+							"UserId":    3,
+							"State":     "scheduled",
+							"PublishAt": "2026-10-21T08:00:00+08:00",
+							"CrossPost": true,
+						},
+					},
+				},
+				"400": {Description: "不支持的文章状态或定时发布时间早于当前时间"},
+				"403": {Description: "不是自己发布的文章"},
+				"404": {Description: "文章不存在"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/exports",
+			Description: "申请导出当前用户的全部数据，后台生成包含个人资料、树洞、动态、文章、编辑历史、媒体和 WordPress 绑定信息的 ZIP 文件，完成后发送通知。每 24 小时只能申请一次，失败的任务不计入",
+			Tags:        []string{"users"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "已开始导出",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "已开始导出，完成后会发送通知",
+						"data":    map[string]interface{}{"id": 5, "user_id": 3, "status": "pending", "size": 0, "expire_at": "1970-01-01T00:00:00Z", "ctime": "2026-10-20T08:00:00Z"},
+					},
+				},
+				"429": {Description: "24 小时内已经申请过导出"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/exports",
+			Description: "获取当前用户最近的导出任务，已生成且未过期的任务返回带签名的下载链接，状态为 pending/running/ready/failed/expired",
+			Tags:        []string{"users"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": []map[string]interface{}{
+							{"id": 5, "user_id": 3, "status": "ready", "size": 20480, "expire_at": "2026-10-23T08:00:00Z", "ctime": "2026-10-20T08:00:00Z", "download_url": "/api/exports/download/5?token=..."},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/exports/download/:id",
+			Description: "通过带签名的链接下载导出的 ZIP 文件，不需要登录。链接绑定导出任务生成时的随机令牌，过期后文件和令牌一并删除",
+			Tags:        []string{"users"},
+			Parameters: []APIParameter{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "导出任务 ID", Example: "5"},
+				{Name: "token", In: "query", Type: "string", Required: true, Description: "下载链接中的签名令牌", Example: "..."},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "ZIP 文件"},
+				"403": {Description: "下载链接无效"},
+				"404": {Description: "导出文件不存在"},
+				"409": {Description: "导出文件尚未生成"},
+				"410": {Description: "下载链接已过期"},
+			},
+		},
+		{
+			Method:      "POST",
+			Path:        "/api/admin/content/import",
+			Description: "批量导入内容：本站数据导出的 ZIP（树洞、动态和文章）、树洞 CSV 或 Twitter/X 存档（推文导入为动态）。按用户名或邮箱匹配本站用户，保留原始发布时间，已导入过的记录会跳过，可以重复执行。dry_run 时只报告将导入的数量和冲突。文件上限 64MB，更大的文件使用 cmd/import 导入",
+			Tags:        []string{"system"},
+			RequestBody: &APIRequestBody{
+				ContentType: "multipart/form-data",
+				Schema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file":    map[string]interface{}{"type": "string", "format": "binary", "description": "导入文件"},
+						"format":  map[string]interface{}{"type": "string", "enum": []string{"negaihoshi", "csv", "twitter"}, "description": "导入格式"},
+						"user":    map[string]interface{}{"type": "string", "description": "所有记录都导入到该用户名下，可选"},
+						"map":     map[string]interface{}{"type": "string", "description": "来源用户名到本站用户名的映射，如 old=new,old2=new2，可选"},
+						"dry_run": map[string]interface{}{"type": "boolean", "description": "只检查不写入"},
+					},
+					"required": []string{"file", "format"},
+				},
+			},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "导入完成",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"format":         "csv",
+							"dry_run":        true,
+							"total":          3,
+							"created":        map[string]interface{}{"treehole": 1},
+							"skipped":        1,
+							"conflict_count": 1,
+							"conflicts": []map[string]interface{}{
+								{"ref": "第 4 行", "external_id": "carol/3", "reason": "找不到对应的用户 carol"},
+							},
+						},
+					},
+				},
+				"400": {Description: "格式不支持或文件无法解析"},
+				"404": {Description: "指定的用户不存在"},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/admin/cache/stats",
+			Description: "各类缓存的命中统计，name 为缓存键的类别（treehole、treehole_list、status、posts、profile）。shared 为未命中但与其他并发请求合并回源的次数，计入 misses；errors 为缓存后端出错后直接回源的次数。统计从服务启动开始计数，多实例部署时各实例分别统计",
+			Tags:        []string{"system"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "获取成功",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "success",
+						"data": map[string]interface{}{
+							"caches": []map[string]interface{}{
+								{"name": "treehole", "hits": 1520, "misses": 210, "shared": 12, "errors": 0},
+								{"name": "treehole_list", "hits": 860, "misses": 95, "shared": 30, "errors": 0},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/health",
+			Description: "存活检查，进程能处理请求即返回成功，不检查数据库和 Redis。供容器健康检查使用",
+			Tags:        []string{"system"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "服务存活",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "操作成功",
+						"data":    map[string]interface{}{"status": "ok"},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/api/ready",
+			Description: "就绪检查，检查数据库和已配置的 Redis，每项检查超时 2 秒。任一依赖不可用时返回 HTTP 503，失败原因只记录在服务日志中。供负载均衡判断是否转发流量",
+			Tags:        []string{"system"},
+			Responses: map[string]APIResponseDoc{
+				"200": {
+					Description: "服务就绪",
+					Example: map[string]interface{}{
+						"code":    200,
+						"message": "操作成功",
+						"data": map[string]interface{}{
+							"status": "ok",
+							"checks": map[string]interface{}{"database": "ok", "redis": "ok"},
+						},
+					},
+				},
+				"503": {
+					Description: "有依赖不可用",
+					Example: map[string]interface{}{
+						"code":    503,
+						"message": "服务未就绪",
+						"data": map[string]interface{}{
+							"status": "unavailable",
+							"checks": map[string]interface{}{"database": "ok", "redis": "unavailable"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Method:      "GET",
+			Path:        "/metrics",
+			Description: "Prometheus 文本格式的监控指标，包括按路由的 HTTP 请求耗时（negaihoshi_http_request_duration_seconds）、数据库连接池（go_sql_*）、缓存命中（negaihoshi_cache_*）、WordPress 转发结果（negaihoshi_wordpress_deliveries_total）、业务事件（negaihoshi_events_total）和登录次数（negaihoshi_logins_total），实时推送的长连接路由不计入请求耗时。需要携带 Authorization: Bearer <metrics.token>，未配置 metrics.token 时不开放该接口",
+			Tags:        []string{"system"},
+			Responses: map[string]APIResponseDoc{
+				"200": {Description: "Prometheus 文本格式的指标"},
+				"401": {Description: "未携带或携带了错误的采集令牌"},
 			},
 		},
 	}
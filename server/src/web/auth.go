@@ -0,0 +1,160 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: API 和移动端使用的令牌接口
+ */
+package web
+
+import (
+	"net/http"
+
+	"negaihoshi/server/src/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 令牌接口支持的授权方式
+const (
+	GrantTypePassword     = "password"
+	GrantTypeRefreshToken = "refresh_token"
+)
+
+type AuthHandler struct {
+	userService *service.UserService
+	authTokens  *service.AuthTokenService
+}
+
+func NewAuthHandler(userService *service.UserService, authTokens *service.AuthTokenService) *AuthHandler {
+	return &AuthHandler{
+		userService: userService,
+		authTokens:  authTokens,
+	}
+}
+
+type TokenReq struct {
+	GrantType string `json:"grant_type" binding:"required,oneof=password refresh_token"`
+	// grant_type=password
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	CaptchaToken string `json:"captcha_token"`
+	// 开启两步验证的账户需要同时提供动态验证码或恢复码
+	OTP string `json:"otp"`
+	// grant_type=refresh_token
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) RegisterAuthRoutes(server *gin.Engine) {
+	ag := server.Group("/api/auth")
+	ag.POST("/token", h.Token)
+	ag.POST("/revoke", h.Revoke)
+}
+
+// Token 使用密码或刷新令牌换取访问令牌
+func (h *AuthHandler) Token(ctx *gin.Context) {
+	var req TokenReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	switch req.GrantType {
+	case GrantTypePassword:
+		h.passwordGrant(ctx, req)
+	case GrantTypeRefreshToken:
+		h.refreshGrant(ctx, req)
+	}
+}
+
+func (h *AuthHandler) passwordGrant(ctx *gin.Context, req TokenReq) {
+	if req.Username == "" || req.Password == "" {
+		ValidationError(ctx, "用户名和密码不能为空")
+		return
+	}
+
+	user, stage, err := h.userService.Login(ctx, req.Username, req.Password, ctx.ClientIP(), req.CaptchaToken)
+	if err != nil {
+		h.loginError(ctx, err)
+		return
+	}
+
+	switch stage {
+	case service.LoginStageTwoFactor:
+		if req.OTP == "" {
+			ctx.JSON(http.StatusOK, APIResponse{
+				Code:    401,
+				Message: "请提供两步验证码",
+				Data:    gin.H{"two_factor_required": true},
+			})
+			return
+		}
+		if err := h.userService.VerifyTwoFactorLogin(ctx, user.Id, req.OTP, ctx.ClientIP()); err != nil {
+			h.loginError(ctx, err)
+			return
+		}
+	case service.LoginStageTwoFactorSetup:
+		ErrorResponse(ctx, 403, "站点要求开启两步验证，请先在网页端完成绑定")
+		return
+	}
+
+	pair, err := h.authTokens.Issue(ctx, user.Id)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, pair, "登录成功")
+}
+
+func (h *AuthHandler) refreshGrant(ctx *gin.Context, req TokenReq) {
+	if req.RefreshToken == "" {
+		ValidationError(ctx, "刷新令牌不能为空")
+		return
+	}
+
+	pair, err := h.authTokens.Refresh(ctx, req.RefreshToken, ctx.ClientIP())
+	switch err {
+	case nil:
+		SuccessResponse(ctx, pair, "刷新成功")
+	case service.ErrRefreshTokenInvalid, service.ErrRefreshTokenExpired:
+		ErrorResponse(ctx, 401, "刷新令牌无效或已过期，请重新登录")
+	case service.ErrRefreshTokenReused:
+		ErrorResponse(ctx, 401, "刷新令牌已被使用，为保护账户安全相关登录已全部失效，请重新登录")
+	default:
+		SystemError(ctx)
+	}
+}
+
+// Revoke 注销刷新令牌，客户端退出登录时调用
+func (h *AuthHandler) Revoke(ctx *gin.Context) {
+	var req RevokeTokenReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	if err := h.authTokens.Revoke(ctx, req.RefreshToken); err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, nil, "已退出登录")
+}
+
+func (h *AuthHandler) loginError(ctx *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidCredentials, service.ErrUserNotFound:
+		ErrorResponse(ctx, 401, "用户名或密码错误")
+	case service.ErrTwoFactorCodeInvalid:
+		ErrorResponse(ctx, 401, "两步验证码错误")
+	case service.ErrCaptchaRequired:
+		ErrorResponse(ctx, 401, "请完成人机验证")
+	case service.ErrCaptchaInvalid:
+		ErrorResponse(ctx, 401, "人机验证失败，请重试")
+	case service.ErrAccountLocked:
+		ErrorResponse(ctx, 429, "登录失败次数过多，账户已被临时锁定，请稍后再试")
+	default:
+		SystemError(ctx)
+	}
+}
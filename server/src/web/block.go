@@ -0,0 +1,167 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 用户屏蔽和静音接口
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BlockHandler struct {
+	svc *service.BlockService
+}
+
+func NewBlockHandler(svc *service.BlockService) *BlockHandler {
+	return &BlockHandler{
+		svc: svc,
+	}
+}
+
+func (h *BlockHandler) RegisterBlockRoutes(server *gin.Engine) {
+	bg := server.Group("/api/users/blocks")
+	bg.GET("", h.List)
+	bg.POST("/:id", h.Block)
+	bg.DELETE("/:id", h.Unblock)
+
+	mg := server.Group("/api/users/mutes")
+	mg.GET("", h.ListMutes)
+	mg.POST("/:id", h.Mute)
+	mg.DELETE("/:id", h.Unmute)
+}
+
+func (h *BlockHandler) List(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+
+	blocks, total, err := h.svc.List(ctx, userId, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"blocks": blocks,
+		"total":  total,
+		"page":   page,
+		"size":   size,
+	})
+}
+
+func (h *BlockHandler) Block(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	blockedId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	err := h.svc.Block(ctx, userId, blockedId)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, nil, "已屏蔽")
+	case errors.Is(err, service.ErrBlockSelf):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFoundError(ctx, "用户")
+	default:
+		SystemError(ctx)
+	}
+}
+
+func (h *BlockHandler) Unblock(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	blockedId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unblock(ctx, userId, blockedId); err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, nil, "已取消屏蔽")
+}
+
+func (h *BlockHandler) ListMutes(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+
+	mutes, total, err := h.svc.ListMutes(ctx, userId, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"mutes": mutes,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *BlockHandler) Mute(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	mutedId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	err := h.svc.Mute(ctx, userId, mutedId)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, nil, "已静音")
+	case errors.Is(err, service.ErrMuteSelf):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFoundError(ctx, "用户")
+	default:
+		SystemError(ctx)
+	}
+}
+
+func (h *BlockHandler) Unmute(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	mutedId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unmute(ctx, userId, mutedId); err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, nil, "已取消静音")
+}
@@ -0,0 +1,89 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 15:00:00
+ * @Description: 用户数据导出接口
+ */
+package web
+
+import (
+	"errors"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	svc *service.DataExportService
+}
+
+func NewDataExportHandler(svc *service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{
+		svc: svc,
+	}
+}
+
+func (h *DataExportHandler) RegisterDataExportRoutes(server *gin.Engine) {
+	eg := server.Group("/api/exports")
+	eg.POST("", h.Request)
+	eg.GET("", h.List)
+	// 下载链接本身带签名，不需要登录，可以直接在浏览器中打开
+	eg.GET("/download/:id", h.Download)
+}
+
+func (h *DataExportHandler) Request(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	export, err := h.svc.Request(ctx, userId)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, export, "已开始导出，完成后会发送通知")
+	case errors.Is(err, service.ErrExportTooFrequent):
+		ErrorResponse(ctx, 429, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
+
+func (h *DataExportHandler) List(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	exports, err := h.svc.List(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, exports)
+}
+
+func (h *DataExportHandler) Download(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	path, name, err := h.svc.Download(ctx, id, ctx.Query("token"))
+	switch {
+	case err == nil:
+		ctx.FileAttachment(path, name)
+	case errors.Is(err, service.ErrExportLinkInvalid):
+		ErrorResponse(ctx, 403, err.Error())
+	case errors.Is(err, service.ErrExportExpired):
+		ErrorResponse(ctx, 410, err.Error())
+	case errors.Is(err, service.ErrExportNotReady):
+		ErrorResponse(ctx, 409, err.Error())
+	case errors.Is(err, service.ErrExportNotFound):
+		NotFoundError(ctx, "导出文件")
+	default:
+		SystemError(ctx)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 23:00:00
+ * @Description: 用户关注和首页时间线接口
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowHandler struct {
+	svc      *service.FollowService
+	timeline *service.TimelineService
+}
+
+func NewFollowHandler(svc *service.FollowService, timeline *service.TimelineService) *FollowHandler {
+	return &FollowHandler{
+		svc:      svc,
+		timeline: timeline,
+	}
+}
+
+func (h *FollowHandler) RegisterFollowRoutes(server *gin.Engine) {
+	fg := server.Group("/api/follow")
+	fg.POST("/:id", h.Follow)
+	fg.DELETE("/:id", h.Unfollow)
+	fg.GET("/:id/followers", h.Followers)
+	fg.GET("/:id/following", h.Following)
+	fg.GET("/:id/stats", h.Stats)
+
+	server.GET("/api/timeline/home", h.Home)
+}
+
+func (h *FollowHandler) Follow(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	followeeId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	err := h.svc.Follow(ctx, userId, followeeId)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, nil, "已关注")
+	case errors.Is(err, service.ErrFollowSelf):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrFollowBlocked):
+		ErrorResponse(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFoundError(ctx, "用户")
+	default:
+		SystemError(ctx)
+	}
+}
+
+func (h *FollowHandler) Unfollow(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	followeeId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Unfollow(ctx, userId, followeeId); err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, nil, "已取消关注")
+}
+
+// Followers 粉丝列表，下一页传入返回的 next_before_id，为 0 时没有更多
+func (h *FollowHandler) Followers(ctx *gin.Context) {
+	userId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	beforeId, _ := strconv.ParseInt(ctx.Query("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	users, next, err := h.svc.Followers(ctx, userId, beforeId, limit)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"users":          users,
+		"next_before_id": next,
+	})
+}
+
+func (h *FollowHandler) Following(ctx *gin.Context) {
+	userId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	beforeId, _ := strconv.ParseInt(ctx.Query("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	users, next, err := h.svc.Following(ctx, userId, beforeId, limit)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"users":          users,
+		"next_before_id": next,
+	})
+}
+
+// Stats 关注数和粉丝数，同时返回当前用户是否已关注
+func (h *FollowHandler) Stats(ctx *gin.Context) {
+	userId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	stats, err := h.svc.Stats(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	following := false
+	if currentId, ok := middleware.CurrentUserId(ctx); ok && currentId != userId {
+		following, err = h.svc.IsFollowing(ctx, currentId, userId)
+		if err != nil {
+			SystemError(ctx)
+			return
+		}
+	}
+	SuccessResponse(ctx, gin.H{
+		"followers":    stats.Followers,
+		"following":    stats.Following,
+		"is_following": following,
+	})
+}
+
+// Home 首页时间线，包含关注的人和自己发布的动态和文章，下一页传入返回的 next_cursor，为空时没有更多
+func (h *FollowHandler) Home(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	items, next, err := h.timeline.Home(ctx, userId, ctx.Query("cursor"), limit)
+	if errors.Is(err, service.ErrTimelineCursorInvalid) {
+		ValidationError(ctx, err.Error())
+		return
+	}
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"items":       items,
+		"next_cursor": next,
+	})
+}
@@ -0,0 +1,82 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 18:00:00
+ * @Description: 存活和就绪检查接口，供容器编排和负载均衡探测
+ */
+package web
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每项依赖检查的超时时间，探测方的超时通常为数秒
+const readyCheckTimeout = 2 * time.Second
+
+// ReadinessCheck 就绪检查的一项依赖，Check 返回错误表示该依赖不可用
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	checks []ReadinessCheck
+}
+
+func NewHealthHandler(checks ...ReadinessCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+func (h *HealthHandler) RegisterHealthRoutes(server *gin.Engine) {
+	server.GET("/api/health", h.Health)
+	server.GET("/api/ready", h.Ready)
+}
+
+// Health 存活检查，进程能处理请求即返回成功，不检查依赖，避免依赖故障时容器被反复重启
+func (h *HealthHandler) Health(ctx *gin.Context) {
+	SuccessResponse(ctx, gin.H{"status": "ok"})
+}
+
+// Ready 就绪检查，并发检查全部依赖，任一依赖不可用时返回 HTTP 503。
+// 返回内容不包含错误详情，避免暴露内部地址，详情记录在日志中
+func (h *HealthHandler) Ready(ctx *gin.Context) {
+	results := make(map[string]string, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check ReadinessCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readyCheckTimeout)
+			defer cancel()
+			status := "ok"
+			if err := check.Check(checkCtx); err != nil {
+				log.Printf("就绪检查 %s 失败: %v", check.Name, err)
+				status = "unavailable"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = status
+			if status != "ok" {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, APIResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "服务未就绪",
+			Data:    gin.H{"status": "unavailable", "checks": results},
+		})
+		return
+	}
+	SuccessResponse(ctx, gin.H{"status": "ok", "checks": results})
+}
@@ -0,0 +1,197 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 22:00:00
+ * @Description: 私信接口
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+	svc *service.MessageService
+}
+
+func NewMessageHandler(svc *service.MessageService) *MessageHandler {
+	return &MessageHandler{
+		svc: svc,
+	}
+}
+
+func (h *MessageHandler) RegisterMessageRoutes(server *gin.Engine) {
+	mg := server.Group("/api/messages")
+	mg.GET("/unread-count", h.UnreadCount)
+	mg.POST("/conversations", h.StartConversation)
+	mg.GET("/conversations", h.ListConversations)
+	mg.GET("/conversations/:id/messages", h.ListMessages)
+	mg.POST("/conversations/:id/messages", h.Send)
+	mg.POST("/conversations/:id/read", h.MarkRead)
+}
+
+// StartConversation 发起与某个用户的会话，可同时发送第一条消息
+func (h *MessageHandler) StartConversation(ctx *gin.Context) {
+	type StartConversationReq struct {
+		UserId  int64  `json:"user_id" binding:"required,min=1"`
+		Content string `json:"content"`
+	}
+	var req StartConversationReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	conversationId, msg, err := h.svc.StartConversation(ctx, userId, req.UserId, req.Content)
+	if err != nil {
+		h.messageError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"conversation_id": conversationId,
+		"message":         msg,
+	})
+}
+
+func (h *MessageHandler) ListConversations(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+
+	list, total, err := h.svc.ListConversations(ctx, userId, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	unread, err := h.svc.UnreadCount(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"conversations": list,
+		"total":         total,
+		"unread":        unread,
+		"page":          page,
+		"size":          size,
+	})
+}
+
+// ListMessages 按时间倒序分页，下一页传入本页最早一条消息的 id 作为 before_id
+func (h *MessageHandler) ListMessages(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	conversationId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	beforeId, _ := strconv.ParseInt(ctx.Query("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "30"))
+
+	messages, err := h.svc.ListMessages(ctx, conversationId, userId, beforeId, limit)
+	if err != nil {
+		h.messageError(ctx, err)
+		return
+	}
+	var nextBeforeId int64
+	if len(messages) > 0 {
+		nextBeforeId = messages[len(messages)-1].Id
+	}
+	SuccessResponse(ctx, gin.H{
+		"messages":       messages,
+		"next_before_id": nextBeforeId,
+	})
+}
+
+func (h *MessageHandler) Send(ctx *gin.Context) {
+	type SendMessageReq struct {
+		Content string `json:"content" binding:"required"`
+	}
+	var req SendMessageReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "私信内容不能为空")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	conversationId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	msg, err := h.svc.Send(ctx, conversationId, userId, req.Content)
+	if err != nil {
+		h.messageError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"message": msg}, "发送成功")
+}
+
+func (h *MessageHandler) MarkRead(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	conversationId, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.MarkRead(ctx, conversationId, userId); err != nil {
+		h.messageError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "已标记为已读")
+}
+
+func (h *MessageHandler) UnreadCount(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	unread, err := h.svc.UnreadCount(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"unread": unread})
+}
+
+func (h *MessageHandler) messageError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound):
+		NotFoundError(ctx, "会话")
+	case errors.Is(err, service.ErrUserNotFound):
+		NotFoundError(ctx, "用户")
+	case errors.Is(err, service.ErrMessageBlocked):
+		ErrorResponse(ctx, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrMessageSelf), errors.Is(err, service.ErrMessageContentInvalid), errors.Is(err, service.ErrContentBlocked):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
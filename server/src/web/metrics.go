@@ -0,0 +1,44 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 18:00:00
+ * @Description: Prometheus 指标采集接口
+ */
+package web
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"negaihoshi/server/src/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	handler http.Handler
+	// 采集请求需要携带 Authorization: Bearer <token>，为空时不开放采集接口
+	token string
+}
+
+func NewMetricsHandler(m *metrics.Metrics, token string) *MetricsHandler {
+	return &MetricsHandler{handler: m.Handler(), token: token}
+}
+
+// RegisterMetricsRoutes 未配置 metrics.token 时不注册采集接口，避免指标对外公开
+func (h *MetricsHandler) RegisterMetricsRoutes(server *gin.Engine) {
+	if h.token == "" {
+		log.Println("未配置 metrics.token，不开放 /metrics")
+		return
+	}
+	server.GET("/metrics", h.Metrics)
+}
+
+func (h *MetricsHandler) Metrics(ctx *gin.Context) {
+	want := "Bearer " + h.token
+	if subtle.ConstantTimeCompare([]byte(ctx.GetHeader("Authorization")), []byte(want)) != 1 {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	h.handler.ServeHTTP(ctx.Writer, ctx.Request)
+}
@@ -0,0 +1,51 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 20:00:00
+ * @Description: 管理后台接口的管理员角色校验
+ */
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminChecker 判断用户是否为管理员
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userId int64) (bool, error)
+}
+
+// NewAdminMiddleware 以 prefix 开头的路径只允许管理员访问，需要注册在登录中间件之后。
+// 每次请求都重新查询角色，撤销管理员后立即生效
+func NewAdminMiddleware(prefix string, checker AdminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return
+		}
+		userId, ok := CurrentUserId(c)
+		if !ok {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		admin, err := checker.IsAdmin(c.Request.Context(), userId)
+		if err != nil {
+			log.Printf("查询用户 %d 的角色失败: %v", userId, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "系统错误，请稍后重试",
+			})
+			return
+		}
+		if !admin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "需要管理员权限",
+			})
+			return
+		}
+	}
+}
@@ -9,18 +9,54 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
+
+	"negaihoshi/server/src/domain"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
+var errNotLoggedIn = errors.New("未登录")
+
+// SessionValidator 校验服务端登记的会话是否仍然有效
+type SessionValidator interface {
+	Validate(ctx context.Context, sid, ip string) (domain.UserSession, error)
+}
+
+// TokenVerifier 校验 Authorization: Bearer 访问令牌
+type TokenVerifier interface {
+	VerifyAccessToken(ctx context.Context, token string) (domain.Principal, error)
+}
+
 type LoginMiddlewareBuilder struct {
 	paths []string
+	// 待完成两步验证的会话可以访问的路径
+	pendingPaths []string
+	validator    SessionValidator
+	tokens       TokenVerifier
+	// 按令牌前缀分派到其他校验器，例如个人访问令牌
+	prefixedTokens map[string]TokenVerifier
+	// 受限令牌访问各路由所需的权限范围，键为 "METHOD 路由"
+	routeScopes  map[string]string
+	prefixScopes []prefixScope
 }
 
-func NewLoginMiddlewareBuilder() *LoginMiddlewareBuilder {
-	return &LoginMiddlewareBuilder{}
+type prefixScope struct {
+	prefix string
+	scope  string
+}
+
+func NewLoginMiddlewareBuilder(validator SessionValidator, tokens TokenVerifier) *LoginMiddlewareBuilder {
+	return &LoginMiddlewareBuilder{
+		validator:      validator,
+		tokens:         tokens,
+		prefixedTokens: make(map[string]TokenVerifier),
+		routeScopes:    make(map[string]string),
+	}
 }
 
 func (l *LoginMiddlewareBuilder) IgnorePaths(path string) *LoginMiddlewareBuilder {
@@ -28,23 +64,159 @@ func (l *LoginMiddlewareBuilder) IgnorePaths(path string) *LoginMiddlewareBuilde
 	return l
 }
 
+// AllowPending 允许密码已验证、两步验证尚未完成的会话访问该路径
+func (l *LoginMiddlewareBuilder) AllowPending(path string) *LoginMiddlewareBuilder {
+	l.pendingPaths = append(l.pendingPaths, path)
+	return l
+}
+
+// BearerVerifier 以 prefix 开头的 Bearer 令牌交给 verifier 校验
+func (l *LoginMiddlewareBuilder) BearerVerifier(prefix string, verifier TokenVerifier) *LoginMiddlewareBuilder {
+	l.prefixedTokens[prefix] = verifier
+	return l
+}
+
+// RouteScope 声明路由所需的权限范围，path 为注册路由时的路径（如 /api/treehole/:id）；
+// 带权限范围的令牌只能访问声明过的路由
+func (l *LoginMiddlewareBuilder) RouteScope(method, path, scope string) *LoginMiddlewareBuilder {
+	l.routeScopes[method+" "+path] = scope
+	return l
+}
+
+// PrefixScope 为某一路径前缀下的全部路由声明权限范围
+func (l *LoginMiddlewareBuilder) PrefixScope(prefix, scope string) *LoginMiddlewareBuilder {
+	l.prefixScopes = append(l.prefixScopes, prefixScope{prefix: prefix, scope: scope})
+	return l
+}
+
+// Build 支持 Cookie 会话和 Bearer 访问令牌两种方式，认证通过后把请求主体放入上下文
 func (l *LoginMiddlewareBuilder) Build() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 不需要登录校验的
-		for _, path := range l.paths {
-			if c.Request.URL.Path == path {
-				return
+		path := c.Request.URL.Path
+		principal, err := l.authenticate(c)
+
+		// 不需要登录校验的，已登录时同样放入请求主体，供可选登录的接口使用
+		if l.ignored(path) {
+			if err == nil && !principal.Pending {
+				SetPrincipal(c, principal)
 			}
-		}
-		if c.Request.URL.Path == "/api/users/login" || c.Request.URL.Path == "/api/users/signup" {
 			return
 		}
-		sess := sessions.Default(c)
-		id := sess.Get("userId")
-		if id == nil {
+
+		if errors.Is(err, errNotLoggedIn) {
 			// 没有登录
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		if err != nil {
+			if principal.Method == domain.AuthMethodBearer {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "登录已失效，请重新登录",
+			})
+			return
+		}
+		if principal.Pending && !l.pendingAllowed(path) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "请先完成两步验证",
+				"data": gin.H{
+					"two_factor_pending": true,
+				},
+			})
+			return
+		}
+		if principal.Scopes != nil {
+			scope, ok := l.requiredScope(c.Request.Method, c.FullPath())
+			if !ok || !principal.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"code":    403,
+					"message": "访问令牌没有该接口的权限",
+					"data": gin.H{
+						"required_scope": scope,
+					},
+				})
+				return
+			}
+		}
+		SetPrincipal(c, principal)
+	}
+}
+
+func (l *LoginMiddlewareBuilder) authenticate(c *gin.Context) (domain.Principal, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if !strings.HasPrefix(header, "Bearer ") {
+			return domain.Principal{Method: domain.AuthMethodBearer}, errNotLoggedIn
+		}
+		token := strings.TrimPrefix(header, "Bearer ")
+		verifier := l.tokens
+		for prefix, v := range l.prefixedTokens {
+			if strings.HasPrefix(token, prefix) {
+				verifier = v
+				break
+			}
+		}
+		principal, err := verifier.VerifyAccessToken(c.Request.Context(), token)
+		if err != nil {
+			return domain.Principal{Method: domain.AuthMethodBearer}, err
+		}
+		return principal, nil
+	}
+
+	sess := sessions.Default(c)
+	id, ok := sess.Get("userId").(int64)
+	if !ok {
+		return domain.Principal{}, errNotLoggedIn
+	}
+
+	// 会话已被注销或过期时清除cookie
+	sid, _ := sess.Get("sid").(string)
+	session, err := l.validator.Validate(c.Request.Context(), sid, c.ClientIP())
+	if err != nil || session.UserId != id {
+		sess.Clear()
+		_ = sess.Save()
+		return domain.Principal{Method: domain.AuthMethodSession}, errors.New("会话已失效")
+	}
+	return domain.Principal{
+		UserId:    id,
+		Method:    domain.AuthMethodSession,
+		SessionId: sid,
+		Pending:   session.Pending,
+	}, nil
+}
+
+func (l *LoginMiddlewareBuilder) requiredScope(method, path string) (string, bool) {
+	if scope, ok := l.routeScopes[method+" "+path]; ok {
+		return scope, true
+	}
+	for _, ps := range l.prefixScopes {
+		if strings.HasPrefix(path, ps.prefix) {
+			return ps.scope, true
+		}
+	}
+	return "", false
+}
+
+func (l *LoginMiddlewareBuilder) ignored(path string) bool {
+	for _, p := range l.paths {
+		if p == path {
+			return true
+		}
+		// 以 /* 结尾的规则匹配该前缀下的全部路径
+		if strings.HasSuffix(p, "/*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return path == "/api/users/login" || path == "/api/users/signup"
+}
+
+func (l *LoginMiddlewareBuilder) pendingAllowed(path string) bool {
+	for _, p := range l.pendingPaths {
+		if p == path {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,36 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 18:00:00
+ * @Description: 按路由记录 HTTP 请求耗时
+ */
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewMetricsMiddleware 按注册的路由而不是实际路径记录，避免路径参数产生过多的标签值；
+// skipRoutes 中的长连接路由不记录，连接时长会把耗时分布拉偏
+func NewMetricsMiddleware(m *metrics.Metrics, skipRoutes ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipRoutes))
+	for _, route := range skipRoutes {
+		skip[route] = true
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if skip[route] {
+			return
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveHTTP(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
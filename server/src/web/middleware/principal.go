@@ -0,0 +1,36 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 16:00:00
+ * @Description: 在 gin 上下文中存取已认证的请求主体
+ */
+package middleware
+
+import (
+	"negaihoshi/server/src/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+func SetPrincipal(c *gin.Context, principal domain.Principal) {
+	c.Set(principalKey, principal)
+}
+
+func GetPrincipal(c *gin.Context) (domain.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return domain.Principal{}, false
+	}
+	principal, ok := value.(domain.Principal)
+	return principal, ok
+}
+
+// CurrentUserId 获取当前登录用户ID，未登录时返回 false
+func CurrentUserId(c *gin.Context) (int64, bool) {
+	principal, ok := GetPrincipal(c)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserId, true
+}
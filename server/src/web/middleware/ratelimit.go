@@ -0,0 +1,93 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 11:00:00
+ * @Description: 请求限流中间件，按IP和用户ID分别限流
+ */
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitMiddlewareBuilder struct {
+	limiter ratelimit.Limiter
+	rate    ratelimit.Rate
+	routes  map[string]ratelimit.Rate
+}
+
+func NewRateLimitMiddlewareBuilder(limiter ratelimit.Limiter, rate ratelimit.Rate) *RateLimitMiddlewareBuilder {
+	return &RateLimitMiddlewareBuilder{
+		limiter: limiter,
+		rate:    rate,
+		routes:  make(map[string]ratelimit.Rate),
+	}
+}
+
+// RouteRate 为指定路径设置单独的限流规则，该路径使用独立的令牌桶
+func (r *RateLimitMiddlewareBuilder) RouteRate(path string, rate ratelimit.Rate) *RateLimitMiddlewareBuilder {
+	r.routes[path] = rate
+	return r
+}
+
+// Build 需要注册在登录中间件之后，已登录的请求才能按用户限流
+func (r *RateLimitMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rate, scope := r.rate, "global"
+		if routeRate, ok := r.routes[c.Request.URL.Path]; ok {
+			rate, scope = routeRate, c.Request.URL.Path
+		}
+
+		keys := []string{"ip:" + scope + ":" + c.ClientIP()}
+		// 用户维度取自登录中间件认证过的请求主体，不信任会话中未经校验的字段
+		if id, ok := CurrentUserId(c); ok {
+			keys = append(keys, "user:"+scope+":"+strconv.FormatInt(id, 10))
+		}
+
+		// 取所有维度中最严格的结果
+		var result *ratelimit.Result
+		for _, key := range keys {
+			res, err := r.limiter.Allow(c, key, rate)
+			if err != nil {
+				// 限流后端异常时放行，避免影响正常访问
+				log.Printf("限流检查失败: %v", err)
+				return
+			}
+			if result == nil || stricter(res, *result) {
+				result = &res
+			}
+		}
+		if result == nil || result.Limit == 0 {
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    429,
+				"message": "请求过于频繁，请稍后再试",
+			})
+			return
+		}
+	}
+}
+
+func stricter(a, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
@@ -0,0 +1,191 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 20:00:00
+ * @Description: 站内通知接口
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	svc *service.NotificationService
+}
+
+func NewNotificationHandler(svc *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		svc: svc,
+	}
+}
+
+func (h *NotificationHandler) RegisterNotificationRoutes(server *gin.Engine) {
+	ng := server.Group("/api/notifications")
+	ng.GET("", h.List)
+	ng.GET("/unread-count", h.UnreadCount)
+	ng.POST("/read", h.MarkRead)
+	ng.POST("/read-all", h.MarkAllRead)
+	ng.POST("/:id/read", h.MarkOneRead)
+	ng.GET("/preferences", h.GetPreferences)
+	ng.PUT("/preferences", h.UpdatePreferences)
+}
+
+// List 分页获取通知，可按类型和未读筛选，同时返回未读数
+func (h *NotificationHandler) List(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	unreadOnly := ctx.Query("unread") == "1"
+
+	list, total, err := h.svc.List(ctx, userId, ctx.Query("type"), unreadOnly, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	unread, byType, err := h.svc.UnreadCount(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"notifications":  list,
+		"total":          total,
+		"unread":         unread,
+		"unread_by_type": byType,
+		"page":           page,
+		"size":           size,
+	})
+}
+
+func (h *NotificationHandler) UnreadCount(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	unread, byType, err := h.svc.UnreadCount(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"unread":         unread,
+		"unread_by_type": byType,
+	})
+}
+
+func (h *NotificationHandler) MarkOneRead(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.MarkRead(ctx, userId, []int64{id}); err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, nil, "已标记为已读")
+}
+
+// MarkRead 批量标记已读
+func (h *NotificationHandler) MarkRead(ctx *gin.Context) {
+	type MarkReadReq struct {
+		Ids []int64 `json:"ids" binding:"required,min=1,max=100"`
+	}
+	var req MarkReadReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	updated, err := h.svc.MarkRead(ctx, userId, req.Ids)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"updated": updated}, "已标记为已读")
+}
+
+func (h *NotificationHandler) MarkAllRead(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	updated, err := h.svc.MarkAllRead(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"updated": updated}, "已全部标记为已读")
+}
+
+func (h *NotificationHandler) GetPreferences(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	prefs, err := h.svc.Preferences(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"preferences": prefs})
+}
+
+func (h *NotificationHandler) UpdatePreferences(ctx *gin.Context) {
+	type UpdatePreferencesReq struct {
+		Preferences []domain.NotificationPreference `json:"preferences" binding:"required,min=1"`
+	}
+	var req UpdatePreferencesReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	err := h.svc.UpdatePreferences(ctx, userId, req.Preferences)
+	switch {
+	case err == nil:
+		prefs, err := h.svc.Preferences(ctx, userId)
+		if err != nil {
+			SystemError(ctx)
+			return
+		}
+		SuccessResponse(ctx, gin.H{"preferences": prefs}, "已保存")
+	case errors.Is(err, service.ErrNotificationTypeInvalid), errors.Is(err, service.ErrNotificationTypeRequired):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
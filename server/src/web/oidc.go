@@ -0,0 +1,238 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 18:00:00
+ * @Description: 第三方 OIDC 登录和外部账号绑定接口
+ */
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/oidc"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// 跳转到提供方前，授权请求保存在 session 的这个键下
+const oidcStateKey = "oidc_state"
+
+type OIDCHandler struct {
+	oidcService    *service.OIDCService
+	userService    *service.UserService
+	sessionService *service.SessionService
+	// 回调完成后跳转的前端地址，为空时返回 JSON
+	frontendRedirect string
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, userService *service.UserService, sessionService *service.SessionService, frontendRedirect string) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:      oidcService,
+		userService:      userService,
+		sessionService:   sessionService,
+		frontendRedirect: frontendRedirect,
+	}
+}
+
+func (h *OIDCHandler) RegisterOIDCRoutes(server *gin.Engine) {
+	og := server.Group("/api/auth/oidc")
+	og.GET("/providers", h.Providers)
+	og.GET("/:provider/login", h.Login)
+	og.GET("/:provider/callback", h.Callback)
+
+	ig := server.Group("/api/users/identities")
+	ig.GET("", h.ListIdentities)
+	ig.DELETE("/:provider", h.Unlink)
+}
+
+func (h *OIDCHandler) Providers(ctx *gin.Context) {
+	SuccessResponse(ctx, gin.H{"providers": h.oidcService.Providers()})
+}
+
+// Login 跳转到提供方授权页；已登录用户带 link=1 时为当前账户绑定外部账号
+func (h *OIDCHandler) Login(ctx *gin.Context) {
+	var linkUserId int64
+	if ctx.Query("link") == "1" {
+		principal, ok := middleware.GetPrincipal(ctx)
+		if !ok || principal.Method == domain.AuthMethodToken {
+			UnauthorizedError(ctx)
+			return
+		}
+		linkUserId = principal.UserId
+	}
+
+	authURL, state, err := h.oidcService.Begin(ctx, ctx.Param("provider"), linkUserId)
+	if err != nil {
+		h.oidcError(ctx, err)
+		return
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	sess := sessions.Default(ctx)
+	sess.Set(oidcStateKey, string(data))
+	if err := sess.Save(); err != nil {
+		SystemError(ctx)
+		return
+	}
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 提供方回调，完成登录、绑定或自动注册
+func (h *OIDCHandler) Callback(ctx *gin.Context) {
+	// 授权请求只能使用一次
+	sess := sessions.Default(ctx)
+	raw, _ := sess.Get(oidcStateKey).(string)
+	sess.Delete(oidcStateKey)
+	if err := sess.Save(); err != nil {
+		SystemError(ctx)
+		return
+	}
+
+	if ctx.Query("error") != "" {
+		h.finish(ctx, "", "", service.ErrOIDCAccessDenied)
+		return
+	}
+	var saved domain.OIDCAuthState
+	if raw == "" || json.Unmarshal([]byte(raw), &saved) != nil {
+		h.finish(ctx, "", "", service.ErrOIDCStateInvalid)
+		return
+	}
+	// 绑定要求回调时仍是发起绑定的用户
+	if saved.LinkUserId != 0 {
+		if userId, ok := middleware.CurrentUserId(ctx); !ok || userId != saved.LinkUserId {
+			h.finish(ctx, "", "", service.ErrOIDCStateInvalid)
+			return
+		}
+	}
+
+	user, err := h.oidcService.Complete(ctx, ctx.Param("provider"), saved, ctx.Query("state"), ctx.Query("code"), ctx.ClientIP())
+	if err != nil {
+		h.finish(ctx, "", "", err)
+		return
+	}
+	if saved.LinkUserId != 0 {
+		h.finish(ctx, "linked", "", nil)
+		return
+	}
+
+	stage, err := h.userService.LoginStage(ctx, user.Id)
+	if err == nil {
+		err = startSession(ctx, h.sessionService, user.Id, stage)
+	}
+	if err != nil {
+		h.finish(ctx, "", "", err)
+		return
+	}
+	h.finish(ctx, "login", stage, nil)
+}
+
+func (h *OIDCHandler) ListIdentities(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	identities, err := h.oidcService.ListIdentities(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"identities": identities,
+		"providers":  h.oidcService.Providers(),
+	})
+}
+
+func (h *OIDCHandler) Unlink(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	if err := h.oidcService.Unlink(ctx, userId, ctx.Param("provider"), ctx.ClientIP()); err != nil {
+		h.oidcError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "已解绑")
+}
+
+// finish 配置了前端地址时带上结果跳转回前端，否则返回 JSON
+func (h *OIDCHandler) finish(ctx *gin.Context, result, stage string, err error) {
+	if err != nil && !isOIDCUserError(err) {
+		log.Printf("OIDC 登录失败: %v", err)
+	}
+
+	if h.frontendRedirect != "" {
+		params := url.Values{}
+		if err != nil {
+			params.Set("error", oidcErrorMessage(err))
+		} else {
+			params.Set("result", result)
+			if stage != "" {
+				params.Set("login_stage", stage)
+			}
+		}
+		ctx.Redirect(http.StatusFound, h.frontendRedirect+"?"+params.Encode())
+		return
+	}
+
+	if err != nil {
+		h.oidcError(ctx, err)
+		return
+	}
+	if result == "linked" {
+		SuccessResponse(ctx, gin.H{"result": result}, "绑定成功")
+		return
+	}
+	SuccessResponse(ctx, gin.H{"result": result, "login_stage": stage}, loginStageMessage(stage))
+}
+
+func (h *OIDCHandler) oidcError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOIDCProviderNotFound), errors.Is(err, service.ErrOIDCIdentityNotFound):
+		NotFoundError(ctx, "登录方式")
+	case isOIDCUserError(err):
+		ErrorResponse(ctx, http.StatusBadRequest, oidcErrorMessage(err))
+	case errors.Is(err, oidc.ErrDiscovery), errors.Is(err, oidc.ErrTokenExchange), errors.Is(err, oidc.ErrInvalidIDToken):
+		ErrorResponse(ctx, http.StatusBadGateway, oidcErrorMessage(err))
+	default:
+		SystemError(ctx)
+	}
+}
+
+func isOIDCUserError(err error) bool {
+	for _, target := range []error{
+		service.ErrOIDCProviderNotFound, service.ErrOIDCStateInvalid, service.ErrOIDCAccessDenied, service.ErrOIDCIdentityTaken,
+		service.ErrOIDCProviderLinked, service.ErrOIDCIdentityNotFound, service.ErrOIDCEmailMissing,
+		service.ErrOIDCEmailRegistered, service.ErrOIDCSignupDisabled, service.ErrOIDCLastLoginMethod,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// oidcErrorMessage 提供方返回的错误细节只写日志，不透出给前端
+func oidcErrorMessage(err error) string {
+	switch {
+	case isOIDCUserError(err):
+		return err.Error()
+	case errors.Is(err, oidc.ErrDiscovery), errors.Is(err, oidc.ErrTokenExchange), errors.Is(err, oidc.ErrInvalidIDToken):
+		return "第三方登录验证失败，请重试"
+	default:
+		return "登录失败，请稍后重试"
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 17:00:00
+ * @Description: 个人访问令牌管理接口
+ */
+package web
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalTokenHandler struct {
+	tokenService *service.PersonalTokenService
+}
+
+func NewPersonalTokenHandler(tokenService *service.PersonalTokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+type CreatePersonalTokenReq struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// 有效天数，0 表示永不过期
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0,max=3650"`
+}
+
+func (h *PersonalTokenHandler) RegisterPersonalTokenRoutes(server *gin.Engine) {
+	pg := server.Group("/api/users/tokens")
+	pg.GET("", h.List)
+	pg.POST("", h.Create)
+	pg.DELETE("/:id", h.Revoke)
+}
+
+func (h *PersonalTokenHandler) List(ctx *gin.Context) {
+	principal, ok := h.interactivePrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.tokenService.List(ctx, principal.UserId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	scopes, err := h.tokenService.GrantableScopes(ctx, principal)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"tokens": tokens,
+		"scopes": scopes,
+	})
+}
+
+// Create 创建访问令牌，明文令牌只在本次响应中返回
+func (h *PersonalTokenHandler) Create(ctx *gin.Context) {
+	var req CreatePersonalTokenReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	principal, ok := h.interactivePrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	plain, token, err := h.tokenService.Create(ctx, principal, req.Name, req.Scopes, ttl)
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"token":      plain,
+		"token_info": token,
+	}, "访问令牌已创建，请立即复制保存，之后将无法再次查看")
+}
+
+func (h *PersonalTokenHandler) Revoke(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ValidationError(ctx, "无效的令牌ID")
+		return
+	}
+	principal, ok := h.interactivePrincipal(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.tokenService.Revoke(ctx, principal.UserId, id); err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "访问令牌已撤销")
+}
+
+// interactivePrincipal 令牌只能通过登录会话或 JWT 管理，不能用个人访问令牌自己签发
+func (h *PersonalTokenHandler) interactivePrincipal(ctx *gin.Context) (domain.Principal, bool) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return domain.Principal{}, false
+	}
+	if principal.Method == domain.AuthMethodToken {
+		ForbiddenError(ctx)
+		return domain.Principal{}, false
+	}
+	return principal, true
+}
+
+func (h *PersonalTokenHandler) tokenError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPersonalTokenNotFound):
+		NotFoundError(ctx, "访问令牌")
+	case errors.Is(err, service.ErrPersonalTokenLimit), errors.Is(err, service.ErrPersonalTokenScope):
+		ValidationError(ctx, err.Error())
+	case errors.Is(err, service.ErrPersonalTokenDenied):
+		ErrorResponse(ctx, 403, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
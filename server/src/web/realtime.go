@@ -0,0 +1,295 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 21:00:00
+ * @Description: 实时推送接口，支持 SSE 和 WebSocket，包括新树洞和私信
+ */
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"negaihoshi/server/src/realtime"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// 单个用户或 IP 同时保持的连接数
+	realtimeConnsPerClient = 5
+	// 单个实例的连接总数
+	realtimeConnsTotal = 10000
+	// 没有事件时发送心跳的间隔，避免被代理断开
+	realtimeHeartbeat = 25 * time.Second
+	// WebSocket 单次写超时，超时说明客户端接收太慢
+	realtimeWriteWait = 10 * time.Second
+	// SSE 断开后浏览器重连的等待时间（毫秒）
+	sseRetryMs = 3000
+)
+
+type RealtimeHandler struct {
+	hub        realtime.Hub
+	visibility *service.VisibilityFilter
+	limiter    *realtime.ConnLimiter
+	upgrader   websocket.Upgrader
+}
+
+func NewRealtimeHandler(hub realtime.Hub, visibility *service.VisibilityFilter, allowOrigin func(origin string) bool) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:        hub,
+		visibility: visibility,
+		limiter:    realtime.NewConnLimiter(realtimeConnsPerClient, realtimeConnsTotal),
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: 10 * time.Second,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || allowOrigin(origin)
+			},
+		},
+	}
+}
+
+// 实时推送的长连接路由，耗时是连接时长而不是请求耗时，不计入请求耗时指标
+const (
+	routeTreeholeStream = "/api/treehole/stream"
+	routeTreeholeWS     = "/api/treehole/ws"
+	routeMessageStream  = "/api/messages/stream"
+	routeMessageWS      = "/api/messages/ws"
+)
+
+// RealtimeRoutes 返回实时推送的长连接路由
+func RealtimeRoutes() []string {
+	return []string{routeTreeholeStream, routeTreeholeWS, routeMessageStream, routeMessageWS}
+}
+
+func (h *RealtimeHandler) RegisterRealtimeRoutes(server *gin.Engine) {
+	server.GET(routeTreeholeStream, h.Stream)
+	server.GET(routeTreeholeWS, h.WebSocket)
+	server.GET(routeMessageStream, h.MessageStream)
+	server.GET(routeMessageWS, h.MessageWebSocket)
+}
+
+// Stream 通过 SSE 推送新树洞，断线重连时浏览器会带上 Last-Event-ID 补发期间的事件。
+// 登录用户不会收到已屏蔽或已静音用户的树洞，与列表接口一致
+func (h *RealtimeHandler) Stream(ctx *gin.Context) {
+	h.serveSSE(ctx, service.RealtimeTopicTreehole, true)
+}
+
+// WebSocket 通过 WebSocket 推送新树洞，重连时用 last_event_id 参数补发，过滤规则与 Stream 相同
+func (h *RealtimeHandler) WebSocket(ctx *gin.Context) {
+	h.serveWebSocket(ctx, service.RealtimeTopicTreehole, true)
+}
+
+// MessageStream 通过 SSE 推送当前用户收发的私信
+func (h *RealtimeHandler) MessageStream(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	h.serveSSE(ctx, service.RealtimeUserTopic(userId), false)
+}
+
+// MessageWebSocket 通过 WebSocket 推送当前用户收发的私信
+func (h *RealtimeHandler) MessageWebSocket(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	h.serveWebSocket(ctx, service.RealtimeUserTopic(userId), false)
+}
+
+// serveSSE filterFeed 为 true 时按当前用户的屏蔽和静音关系过滤事件，补发的历史事件同样过滤
+func (h *RealtimeHandler) serveSSE(ctx *gin.Context, topic string, filterFeed bool) {
+	release, ok := h.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	reqCtx := ctx.Request.Context()
+	watcher, err := h.watchFeed(ctx, filterFeed)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	defer watcher.Close()
+	sub, err := h.hub.Subscribe(reqCtx, topic, lastEventId(ctx))
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	defer sub.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	// 关闭 nginx 的响应缓冲
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	w := ctx.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMs)
+	if sub.Resync {
+		fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+	}
+	w.Flush()
+
+	for {
+		event, err := nextEvent(reqCtx, sub)
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+			continue
+		}
+		// 客户端断开或消费太慢被断开，浏览器会自动带上 Last-Event-ID 重连
+		if err != nil {
+			return
+		}
+		allowed, err := watcher.Allow(reqCtx, event)
+		if err != nil {
+			return
+		}
+		if !allowed {
+			continue
+		}
+		fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.IdString(), event.Type, event.Data)
+		w.Flush()
+	}
+}
+
+func (h *RealtimeHandler) serveWebSocket(ctx *gin.Context, topic string, filterFeed bool) {
+	release, ok := h.acquire(ctx)
+	if !ok {
+		return
+	}
+	defer release()
+
+	conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// Upgrade 已经写入了错误响应
+		return
+	}
+	defer conn.Close()
+
+	reqCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	watcher, err := h.watchFeed(ctx, filterFeed)
+	if err != nil {
+		closeWebSocket(conn, websocket.CloseInternalServerErr, "subscribe failed")
+		return
+	}
+	defer watcher.Close()
+	sub, err := h.hub.Subscribe(reqCtx, topic, lastEventId(ctx))
+	if err != nil {
+		closeWebSocket(conn, websocket.CloseInternalServerErr, "subscribe failed")
+		return
+	}
+	defer sub.Close()
+
+	// 只读取控制帧，客户端断开或超过两个心跳周期没有回应时结束推送
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * realtimeHeartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * realtimeHeartbeat))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if sub.Resync {
+		conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+		if err := conn.WriteJSON(gin.H{"type": "resync"}); err != nil {
+			return
+		}
+	}
+	for {
+		event, err := nextEvent(reqCtx, sub)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(realtimeWriteWait)); err != nil {
+				return
+			}
+			continue
+		case errors.Is(err, realtime.ErrSlowConsumer):
+			closeWebSocket(conn, websocket.CloseTryAgainLater, "slow consumer")
+			return
+		case err != nil:
+			return
+		}
+		allowed, err := watcher.Allow(reqCtx, event)
+		if err != nil {
+			closeWebSocket(conn, websocket.CloseInternalServerErr, "filter failed")
+			return
+		}
+		if !allowed {
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+}
+
+// watchFeed 不需要过滤时返回不过滤任何事件的 watcher，未登录时同样不过滤
+func (h *RealtimeHandler) watchFeed(ctx *gin.Context, filterFeed bool) (*service.FeedWatcher, error) {
+	var viewerId int64
+	if filterFeed {
+		viewerId, _ = middleware.CurrentUserId(ctx)
+	}
+	return h.visibility.WatchFeed(ctx.Request.Context(), h.hub, viewerId)
+}
+
+// acquire 检查连接数限制，超出时直接返回 429
+func (h *RealtimeHandler) acquire(ctx *gin.Context) (func(), bool) {
+	key := "ip:" + ctx.ClientIP()
+	if userId, ok := middleware.CurrentUserId(ctx); ok {
+		key = "user:" + strconv.FormatInt(userId, 10)
+	}
+	release, ok := h.limiter.Acquire(key)
+	if !ok {
+		ctx.Header("Retry-After", "10")
+		ErrorResponse(ctx, http.StatusTooManyRequests, "连接数过多，请稍后重试")
+		return nil, false
+	}
+	return release, true
+}
+
+// nextEvent 等待下一个事件，超过心跳间隔没有事件时返回 context.DeadlineExceeded
+func nextEvent(ctx context.Context, sub *realtime.Subscription) (realtime.Event, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, realtimeHeartbeat)
+	defer cancel()
+	event, err := sub.Next(waitCtx)
+	if err != nil && ctx.Err() != nil {
+		return event, ctx.Err()
+	}
+	return event, err
+}
+
+// lastEventId 从 Last-Event-ID 请求头或 last_event_id 参数读取上次收到的事件 id
+func lastEventId(ctx *gin.Context) uint64 {
+	raw := ctx.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = ctx.Query("last_event_id")
+	}
+	id, _ := strconv.ParseUint(raw, 10, 64)
+	return id
+}
+
+func closeWebSocket(conn *websocket.Conn, code int, text string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(realtimeWriteWait))
+}
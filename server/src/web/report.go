@@ -0,0 +1,67 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 10:00:00
+ * @Description: 内容举报接口
+ */
+package web
+
+import (
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	svc *service.ReportService
+}
+
+func NewReportHandler(svc *service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		svc: svc,
+	}
+}
+
+func (r *ReportHandler) RegisterReportRoutes(server *gin.Engine) {
+	server.POST("/api/report", r.CreateReport)
+}
+
+// 举报树洞、动态或文章
+func (r *ReportHandler) CreateReport(ctx *gin.Context) {
+	type CreateReportReq struct {
+		TargetType string `json:"target_type" binding:"required,oneof=treehole status post"`
+		TargetId   int64  `json:"target_id" binding:"required,min=1"`
+		Reason     string `json:"reason" binding:"required,oneof=spam harassment illegal sexual other"`
+		Detail     string `json:"detail" binding:"max=500"`
+	}
+
+	var req CreateReportReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	err := r.svc.CreateReport(ctx, domain.Report{
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		ReporterId: userId,
+		Reason:     req.Reason,
+		Detail:     req.Detail,
+	})
+	switch err {
+	case nil:
+		SuccessResponse(ctx, nil, "举报成功，我们会尽快处理")
+	case service.ErrReportDuplicate:
+		ErrorResponse(ctx, 409, "您已举报过该内容")
+	case service.ErrReportTargetNotFound:
+		NotFoundError(ctx, "举报的内容")
+	default:
+		SystemError(ctx)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 13:00:00
+ * @Description: 动态和文章的编辑历史接口
+ */
+package web
+
+import (
+	"errors"
+	"strconv"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRevisionRoutes :type 为 status 或 post，只有作者本人可以访问
+func (t *StatusAndPostsHandler) RegisterRevisionRoutes(server *gin.Engine) {
+	rg := server.Group("/api/revisions/:type/:id")
+	rg.GET("", t.ListRevisions)
+	rg.GET("/diff", t.DiffRevisions)
+	rg.POST("/restore", t.RestoreRevision)
+}
+
+func (t *StatusAndPostsHandler) ListRevisions(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	revisions, err := t.svc.ListRevisions(ctx, userId, ctx.Param("type"), id)
+	if err != nil {
+		revisionError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"revisions": revisions})
+}
+
+func (t *StatusAndPostsHandler) DiffRevisions(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	from, to, ok := parseRevisionRange(ctx)
+	if !ok {
+		return
+	}
+
+	diff, err := t.svc.DiffRevisions(ctx, userId, ctx.Param("type"), id, from, to)
+	if err != nil {
+		revisionError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, diff)
+}
+
+func (t *StatusAndPostsHandler) RestoreRevision(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		RevisionId int64 `json:"revision_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请指定要恢复的版本")
+		return
+	}
+
+	if err := t.svc.RestoreRevision(ctx, userId, ctx.Param("type"), id, req.RevisionId); err != nil {
+		revisionError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "已恢复")
+}
+
+// parseRevisionRange 解析 from 和 to 两个版本 id
+func parseRevisionRange(ctx *gin.Context) (int64, int64, bool) {
+	from, err := strconv.ParseInt(ctx.Query("from"), 10, 64)
+	if err != nil {
+		ValidationError(ctx, "from 版本格式错误")
+		return 0, 0, false
+	}
+	to, err := strconv.ParseInt(ctx.Query("to"), 10, 64)
+	if err != nil {
+		ValidationError(ctx, "to 版本格式错误")
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+func revisionError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrContentTypeInvalid):
+		ValidationError(ctx, err.Error())
+	case errors.Is(err, service.ErrContentNotOwner):
+		ForbiddenError(ctx)
+	case errors.Is(err, service.ErrContentNotFound):
+		NotFoundError(ctx, "内容")
+	case errors.Is(err, service.ErrRevisionNotFound):
+		NotFoundError(ctx, "历史版本")
+	default:
+		SystemError(ctx)
+	}
+}
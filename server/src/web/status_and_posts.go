@@ -10,24 +10,29 @@ package web
 
 // 导入 gin 包以解决 undefined: gin 问题
 import (
+	"errors"
 	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/metrics"
 	"negaihoshi/server/src/request"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
 	"net/http"
+	"time"
 
 	// "strconv"
 
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
 type StatusAndPostsHandler struct {
-	svc *service.StatusAndPostsService
+	svc     *service.StatusAndPostsService
+	metrics *metrics.Metrics
 }
 
-func NewStatusAndPostsHandler(svc *service.StatusAndPostsService) *StatusAndPostsHandler {
+func NewStatusAndPostsHandler(svc *service.StatusAndPostsService, m *metrics.Metrics) *StatusAndPostsHandler {
 	return &StatusAndPostsHandler{
-		svc: svc,
+		svc:     svc,
+		metrics: m,
 	}
 }
 
@@ -35,6 +40,7 @@ func (t *StatusAndPostsHandler) RegisterStatusAndPostsRoutes(server *gin.Engine)
 	tg := server.Group("/api/posts")
 	tg.POST("/create", t.CreateStatusAndPostsMessage)
 	tg.PATCH("/edit", t.EditStatusAndPostsMessage)
+	tg.PATCH("/state", t.ChangePostState)
 	tg.GET("/view/:id", t.GetStatusAndPostsMessage)
 	tg.GET("/:uid", t.GetUserStatusAndPostsMessageList)
 	tg.GET("/listAll", t.GetStatusAndPostsMessageList)
@@ -50,6 +56,9 @@ func (t *StatusAndPostsHandler) CreateStatusAndPostsMessage(ctx *gin.Context) {
 		SiteUrl               string `json:"siteurl"`
 		WPApiKey              string `json:"wpapikey"`
 		WPuname               string `json:"wpuname"`
+		// 文章状态，为空时直接发布，定时发布时 publishAt 为计划发布时间
+		State     string    `json:"state"`
+		PublishAt time.Time `json:"publishAt"`
 	}
 	var req StatusMessageReq
 	var err error
@@ -57,31 +66,38 @@ func (t *StatusAndPostsHandler) CreateStatusAndPostsMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	sess := sessions.Default(ctx)
-	userId := sess.Get("userId").(int64)
+	userId, _ := middleware.CurrentUserId(ctx)
 
 	if req.IsPost {
-		if req.IsTransferToWordPress {
-			// 调用 WordPress API 发布文章
-			_, err = request.NewWpRequest().TransferPosts(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey, req.Title)
-			if err != nil {
-				ctx.String(http.StatusOK, "转发至 WordPress 失败")
-			}
-		}
-		err = t.svc.CreatePostsMessage(ctx, domain.Posts{
-			Title:   req.Title,
-			Content: req.Content,
-			UserId:  userId,
+		posts, err := t.svc.CreatePostsMessage(ctx, domain.Posts{
+			Title:     req.Title,
+			Content:   req.Content,
+			UserId:    userId,
+			State:     req.State,
+			PublishAt: req.PublishAt,
+			CrossPost: req.IsTransferToWordPress,
 		})
+		if errors.Is(err, service.ErrPostStateInvalid) || errors.Is(err, service.ErrPublishAtInvalid) {
+			ValidationError(ctx, err.Error())
+			return
+		}
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
 		}
+		// 草稿和定时发布的文章在发布时转发到绑定的站点，其余按请求中的站点立即转发
+		if req.IsTransferToWordPress && !posts.CrossPost {
+			err = t.recordTransfer(closeTransfer(request.NewWpRequest().TransferPosts(req.SiteUrl, userId, req.Title, req.Content, req.WPuname, req.WPApiKey, service.WordPressStatus(posts.State))))
+			if err != nil {
+				ctx.String(http.StatusOK, "添加成功，但转发至 WordPress 失败")
+				return
+			}
+		}
 		ctx.String(http.StatusOK, "添加成功")
 	} else {
 		if req.IsTransferToWordPress {
 			// 调用 WordPress API 发布文章
-			_, err = request.NewWpRequest().TransferStatus(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey)
+			err = t.recordTransfer(closeTransfer(request.NewWpRequest().TransferStatus(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey, request.WpStatusPublish)))
 			if err != nil {
 				ctx.String(http.StatusOK, "转发至 WordPress 失败")
 			}
@@ -116,15 +132,17 @@ func (t *StatusAndPostsHandler) EditStatusAndPostsMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	sess := sessions.Default(ctx)
-	userId := sess.Get("userId").(int64)
+	userId, _ := middleware.CurrentUserId(ctx)
 
 	if req.IsPost {
 		if req.IsTransferToWordPress {
-			// 调用 WordPress API 发布文章
-			_, err = request.NewWpRequest().TransferPosts(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey, req.Title)
-			if err != nil {
-				ctx.String(http.StatusOK, "转发至 WordPress 失败")
+			// 草稿和定时发布的文章不转发，私密和不公开的文章以私密状态转发
+			posts, err := t.svc.GetPostFromThisSite(ctx, userId, req.Id)
+			if err == nil && posts.State != domain.PostStateDraft && posts.State != domain.PostStateScheduled {
+				err = t.recordTransfer(closeTransfer(request.NewWpRequest().TransferPosts(req.SiteUrl, userId, req.Title, req.Content, req.WPuname, req.WPApiKey, service.WordPressStatus(posts.State))))
+				if err != nil {
+					ctx.String(http.StatusOK, "转发至 WordPress 失败")
+				}
 			}
 		}
 		err = t.svc.EditPostsMessage(ctx, domain.Posts{
@@ -133,6 +151,10 @@ func (t *StatusAndPostsHandler) EditStatusAndPostsMessage(ctx *gin.Context) {
 			Content: req.Content,
 			UserId:  userId,
 		})
+		if err == service.ErrContentNotOwner {
+			ForbiddenError(ctx)
+			return
+		}
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
@@ -141,7 +163,7 @@ func (t *StatusAndPostsHandler) EditStatusAndPostsMessage(ctx *gin.Context) {
 	} else {
 		if req.IsTransferToWordPress {
 			// 调用 WordPress API 发布文章
-			_, err = request.NewWpRequest().TransferStatus(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey)
+			err = t.recordTransfer(closeTransfer(request.NewWpRequest().TransferStatus(req.SiteUrl, userId, req.Content, req.WPuname, req.WPApiKey, request.WpStatusPublish)))
 			if err != nil {
 				ctx.String(http.StatusOK, "转发至 WordPress 失败")
 			}
@@ -151,6 +173,10 @@ func (t *StatusAndPostsHandler) EditStatusAndPostsMessage(ctx *gin.Context) {
 			Content: req.Content,
 			UserId:  userId,
 		})
+		if err == service.ErrContentNotOwner {
+			ForbiddenError(ctx)
+			return
+		}
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
@@ -170,15 +196,20 @@ func (t *StatusAndPostsHandler) GetStatusAndPostsMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
+	viewerId, _ := middleware.CurrentUserId(ctx)
 	if req.IsPost {
-		post, err := t.svc.GetPostFromThisSite(ctx, req.Id)
+		post, err := t.svc.GetPostFromThisSite(ctx, viewerId, req.Id)
+		if errors.Is(err, service.ErrContentNotFound) {
+			NotFoundError(ctx, "文章")
+			return
+		}
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
 		}
 		ctx.JSON(http.StatusOK, post)
 	} else {
-		status, err := t.svc.GetStatusFromThisSite(ctx, req.Id)
+		status, err := t.svc.GetStatusFromThisSite(ctx, viewerId, req.Id)
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
@@ -197,14 +228,15 @@ func (t *StatusAndPostsHandler) GetUserStatusAndPostsMessageList(ctx *gin.Contex
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
+	viewerId, _ := middleware.CurrentUserId(ctx)
 	if req.IsPost {
-		posts, err := t.svc.GetPostsByUser(ctx, req.UserId)
+		posts, err := t.svc.GetPostsByUser(ctx, viewerId, req.UserId)
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 		}
 		ctx.JSON(http.StatusOK, posts)
 	} else {
-		status, err := t.svc.GetStatusByUser(ctx, req.UserId)
+		status, err := t.svc.GetStatusByUser(ctx, viewerId, req.UserId)
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 		}
@@ -222,15 +254,16 @@ func (t *StatusAndPostsHandler) GetStatusAndPostsMessageList(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
+	viewerId, _ := middleware.CurrentUserId(ctx)
 	if req.IsPost {
-		posts, err := t.svc.GetPostsMessageList(ctx)
+		posts, err := t.svc.GetPostsMessageList(ctx, viewerId)
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
 		}
 		ctx.JSON(http.StatusOK, posts)
 	} else {
-		status, err := t.svc.GetStatusMessageList(ctx)
+		status, err := t.svc.GetStatusMessageList(ctx, viewerId)
 		if err != nil {
 			ctx.String(http.StatusOK, "系统错误")
 			return
@@ -250,19 +283,75 @@ func (t *StatusAndPostsHandler) DeleteStatusAndPostsMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
 	if req.IsPost {
-		err = t.svc.DeletePosts(ctx, req.Id)
-		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
-		}
+		err = t.svc.DeletePosts(ctx, userId, req.Id)
 	} else {
-		err = t.svc.DeleteStatus(ctx, req.Id)
-		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
-		}
+		err = t.svc.DeleteStatus(ctx, userId, req.Id)
+	}
+	if err == service.ErrContentNotOwner {
+		ForbiddenError(ctx)
+		return
+	}
+	if err != nil {
+		ctx.String(http.StatusOK, "系统错误")
+		return
 	}
 	ctx.String(http.StatusOK, "删除成功")
 	return
 }
+
+// ChangePostState 修改文章状态，定时发布时 publishAt 为计划发布时间，crossPost 不传时保持原来的转发设置
+func (t *StatusAndPostsHandler) ChangePostState(ctx *gin.Context) {
+	type ChangeStateReq struct {
+		Id        int64     `json:"id" binding:"required"`
+		State     string    `json:"state" binding:"required"`
+		PublishAt time.Time `json:"publishAt"`
+		CrossPost *bool     `json:"crossPost"`
+	}
+	var req ChangeStateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	posts, err := t.svc.ChangePostState(ctx, userId, req.Id, req.State, req.PublishAt, req.CrossPost)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, posts, "文章状态已更新")
+	case errors.Is(err, service.ErrPostStateInvalid), errors.Is(err, service.ErrPublishAtInvalid):
+		ValidationError(ctx, err.Error())
+	case errors.Is(err, service.ErrContentNotFound):
+		NotFoundError(ctx, "文章")
+	case errors.Is(err, service.ErrContentNotOwner):
+		ForbiddenError(ctx)
+	default:
+		SystemError(ctx)
+	}
+}
+
+// closeTransfer 关闭 WordPress 的响应，只返回请求错误
+// recordTransfer 记录按请求中的站点直接转发的结果
+func (t *StatusAndPostsHandler) recordTransfer(err error) error {
+	result := metrics.WordPressSuccess
+	if err != nil {
+		result = metrics.WordPressFailure
+	}
+	t.metrics.WordPressDelivery(metrics.WordPressInline, result)
+	return err
+}
+
+func closeTransfer(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,79 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-20 12:00:00
+ * @Description: 回收站接口
+ */
+package web
+
+import (
+	"errors"
+	"strconv"
+
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrashHandler struct {
+	svc *service.TrashService
+}
+
+func NewTrashHandler(svc *service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		svc: svc,
+	}
+}
+
+func (h *TrashHandler) RegisterTrashRoutes(server *gin.Engine) {
+	tg := server.Group("/api/trash")
+	tg.GET("", h.List)
+	tg.POST("/:type/:id/restore", h.Restore)
+}
+
+func (h *TrashHandler) List(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+
+	items, err := h.svc.List(ctx, userId, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"items": items,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+func (h *TrashHandler) Restore(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	err := h.svc.Restore(ctx, userId, ctx.Param("type"), id)
+	switch {
+	case err == nil:
+		SuccessResponse(ctx, nil, "已恢复")
+	case errors.Is(err, service.ErrContentTypeInvalid):
+		ValidationError(ctx, err.Error())
+	case errors.Is(err, service.ErrTrashItemNotFound):
+		NotFoundError(ctx, "回收站内容")
+	case errors.Is(err, service.ErrTrashRestoreDenied):
+		ErrorResponse(ctx, 403, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
@@ -11,10 +11,10 @@ package web
 import (
 	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
 	"net/http"
 	"strconv"
 
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
@@ -48,20 +48,25 @@ func (t *TreeHoleHandler) CreateTreeHoleMessage(ctx *gin.Context) {
 		return
 	}
 
-	sess := sessions.Default(ctx)
-	userIdInterface := sess.Get("userId")
-	if userIdInterface == nil {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
 		UnauthorizedError(ctx)
 		return
 	}
-
-	userId := userIdInterface.(int64)
 	treeholeData := domain.TreeHole{
 		Content: req.Content,
 		UserId:  userId,
 	}
 
 	err := t.svc.CreateTreeHoleMessage(ctx, treeholeData)
+	if err == service.ErrEmailNotVerified {
+		ErrorResponse(ctx, 403, "请先验证邮箱后再发布")
+		return
+	}
+	if err == service.ErrContentBlocked {
+		ErrorResponse(ctx, 400, err.Error())
+		return
+	}
 	if err != nil {
 		SystemError(ctx)
 		return
@@ -89,7 +94,8 @@ func (t *TreeHoleHandler) GetTreeHoleMessageList(ctx *gin.Context) {
 		pageSize = size
 	}
 
-	messages, err := t.svc.GetTreeHoleMessageList(ctx, pageNum, pageSize)
+	viewerId, _ := middleware.CurrentUserId(ctx)
+	messages, err := t.svc.GetTreeHoleMessageList(ctx, viewerId, pageNum, pageSize)
 	if err != nil {
 		SystemError(ctx)
 		return
@@ -112,10 +118,9 @@ func (t *TreeHoleHandler) GetUserTreeHoleMessageList(ctx *gin.Context) {
 		// ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	sess := sessions.Default(ctx)
-	userId := sess.Get("userId").(int64)
+	userId, _ := middleware.CurrentUserId(ctx)
 	println(userId)
-	mess, err := t.svc.GetUserTreeHoleMessageList(ctx, userId, req.PageNum, req.PageSize)
+	mess, err := t.svc.GetUserTreeHoleMessageList(ctx, userId, userId, req.PageNum, req.PageSize)
 	if err != nil {
 		ctx.String(http.StatusOK, "系统错误")
 		return
@@ -130,7 +135,8 @@ func (t *TreeHoleHandler) GetTreeHoleMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	mess, err := t.svc.GetTreeHoleMessage(ctx, id)
+	viewerId, _ := middleware.CurrentUserId(ctx)
+	mess, err := t.svc.GetTreeHoleMessage(ctx, viewerId, id)
 	if err != nil {
 		ctx.String(http.StatusOK, "系统错误")
 		return
@@ -145,7 +151,16 @@ func (t *TreeHoleHandler) DeleteTreeHoleMessage(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	err = t.svc.DeleteTreeHoleMessage(ctx, id)
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+	err = t.svc.DeleteTreeHoleMessage(ctx, userId, id)
+	if err == service.ErrContentNotOwner {
+		ForbiddenError(ctx)
+		return
+	}
 	if err != nil {
 		ctx.String(http.StatusOK, "系统错误")
 		return
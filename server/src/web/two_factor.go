@@ -0,0 +1,201 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 15:00:00
+ * @Description: 两步验证接口
+ */
+package web
+
+import (
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	userService      *service.UserService
+	twoFactorService *service.TwoFactorService
+	sessionService   *service.SessionService
+}
+
+func NewTwoFactorHandler(userService *service.UserService, twoFactorService *service.TwoFactorService, sessionService *service.SessionService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		sessionService:   sessionService,
+	}
+}
+
+type TwoFactorCodeReq struct {
+	// 认证器中的6位动态验证码，或恢复码
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableReq struct {
+	Password string `json:"password" binding:"required"`
+	// 认证器中的6位动态验证码，或恢复码
+	Code string `json:"code" binding:"required"`
+}
+
+func (h *TwoFactorHandler) RegisterTwoFactorRoutes(server *gin.Engine) {
+	tg := server.Group("/api/users/2fa")
+	tg.GET("", h.GetStatus)
+	tg.POST("/setup", h.Setup)
+	tg.POST("/enable", h.Enable)
+	tg.POST("/verify", h.Verify)
+	tg.POST("/disable", h.Disable)
+	tg.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+}
+
+func (h *TwoFactorHandler) GetStatus(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	status, err := h.twoFactorService.Status(ctx, userId)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, status)
+}
+
+// Setup 生成密钥和 otpauth 地址，前端据此展示二维码
+func (h *TwoFactorHandler) Setup(ctx *gin.Context) {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	setup, err := h.userService.SetupTwoFactor(ctx, userId)
+	if err != nil {
+		h.twoFactorError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, setup, "请使用认证器扫描二维码，并输入验证码完成绑定")
+}
+
+// Enable 校验验证码后启用两步验证，返回只展示一次的恢复码
+func (h *TwoFactorHandler) Enable(ctx *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(ctx, principal.UserId, req.Code)
+	if err != nil {
+		h.twoFactorError(ctx, err)
+		return
+	}
+	// 站点强制两步验证时，绑定完成即视为完成登录
+	if !h.promote(ctx, principal) {
+		return
+	}
+	SuccessResponse(ctx, gin.H{"recovery_codes": codes}, "两步验证已开启，请妥善保存恢复码")
+}
+
+// Verify 登录第二步，校验通过后会话转为正式会话
+func (h *TwoFactorHandler) Verify(ctx *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	if err := h.userService.VerifyTwoFactorLogin(ctx, principal.UserId, req.Code, ctx.ClientIP()); err != nil {
+		h.twoFactorError(ctx, err)
+		return
+	}
+	if !h.promote(ctx, principal) {
+		return
+	}
+	SuccessResponse(ctx, nil, "登录成功")
+}
+
+func (h *TwoFactorHandler) Disable(ctx *gin.Context) {
+	var req TwoFactorDisableReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(ctx, userId, req.Password, req.Code); err != nil {
+		h.twoFactorError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "两步验证已关闭")
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
+		UnauthorizedError(ctx)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(ctx, userId, req.Code)
+	if err != nil {
+		h.twoFactorError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"recovery_codes": codes}, "恢复码已重新生成，原有恢复码已失效")
+}
+
+func (h *TwoFactorHandler) promote(ctx *gin.Context, principal domain.Principal) bool {
+	if principal.SessionId == "" {
+		return true
+	}
+	if err := h.sessionService.Promote(ctx, principal.SessionId); err != nil {
+		SystemError(ctx)
+		return false
+	}
+	return true
+}
+
+func (h *TwoFactorHandler) twoFactorError(ctx *gin.Context, err error) {
+	switch err {
+	case service.ErrTwoFactorCodeInvalid:
+		ErrorResponse(ctx, 400, "验证码错误")
+	case service.ErrWrongPassword:
+		ErrorResponse(ctx, 400, "当前密码错误")
+	case service.ErrTwoFactorNotSetup:
+		ErrorResponse(ctx, 400, "请先生成两步验证密钥")
+	case service.ErrTwoFactorAlreadyEnabled:
+		ErrorResponse(ctx, 409, "已开启两步验证")
+	case service.ErrTwoFactorNotEnabled:
+		ErrorResponse(ctx, 400, "未开启两步验证")
+	case service.ErrTwoFactorRequired:
+		ErrorResponse(ctx, 403, "站点要求开启两步验证，无法关闭")
+	case service.ErrAccountLocked:
+		ErrorResponse(ctx, 429, "验证失败次数过多，账户已被临时锁定，请稍后再试")
+	case service.ErrUserNotFound:
+		NotFoundError(ctx, "用户")
+	default:
+		SystemError(ctx)
+	}
+}
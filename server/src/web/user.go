@@ -6,17 +6,21 @@ import (
 
 	"negaihoshi/server/src/domain"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
 
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService    *service.UserService
+	sessionService *service.SessionService
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService *service.UserService, sessionService *service.SessionService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		sessionService: sessionService,
 	}
 }
 
@@ -27,8 +31,32 @@ type SignupReq struct {
 }
 
 type LoginReq struct {
-	Username string `json:"username" binding:"required"`
+	Username     string `json:"username" binding:"required"`
+	Password     string `json:"password" binding:"required"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangeEmailReq struct {
 	Password string `json:"password" binding:"required"`
+	NewEmail string `json:"new_email" binding:"required,email"`
 }
 
 type ProfileUpdateReq struct {
@@ -47,6 +75,15 @@ func (h *UserHandler) RegisterUserRoutes(server *gin.Engine) {
 	ug.POST("/logout", h.Logout)
 	ug.GET("/profile", h.GetProfile)
 	ug.PUT("/profile", h.UpdateProfile)
+	ug.POST("/send-verification", h.SendVerificationEmail)
+	ug.POST("/verify-email", h.VerifyEmail)
+	ug.POST("/forgot-password", h.ForgotPassword)
+	ug.POST("/reset-password", h.ResetPassword)
+	ug.PUT("/password", h.ChangePassword)
+	ug.PUT("/email", h.ChangeEmail)
+	ug.GET("/sessions", h.ListSessions)
+	ug.DELETE("/sessions", h.RevokeOtherSessions)
+	ug.DELETE("/sessions/:id", h.RevokeSession)
 
 	// 管理后台相关路由
 	adminGroup := server.Group("/api/admin")
@@ -98,7 +135,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Login(c.Request.Context(), req.Username, req.Password)
+	user, stage, err := h.userService.Login(c.Request.Context(), req.Username, req.Password, c.ClientIP(), req.CaptchaToken)
 	if err != nil {
 		var message string
 		switch err {
@@ -106,35 +143,88 @@ func (h *UserHandler) Login(c *gin.Context) {
 			message = "用户名或密码错误"
 		case service.ErrUserNotFound:
 			message = "用户不存在"
+		case service.ErrCaptchaRequired:
+			message = "请完成人机验证"
+		case service.ErrCaptchaInvalid:
+			message = "人机验证失败，请重试"
+		case service.ErrAccountLocked:
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code":    429,
+				"message": "登录失败次数过多，账户已被临时锁定，请稍后再试",
+			})
+			return
 		default:
 			message = "登录失败: " + err.Error()
 		}
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    401,
 			"message": message,
+			"data": gin.H{
+				"captcha_required": h.userService.LoginRequireCaptcha(c.Request.Context(), req.Username, c.ClientIP()),
+			},
 		})
 		return
 	}
 
-	// 设置session
-	c.Set("user_id", user.Id)
-	c.Set("username", user.Username)
+	if err := startSession(c, h.sessionService, user.Id, stage); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "登录失败: " + err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
-		"message": "登录成功",
+		"message": loginStageMessage(stage),
 		"data": gin.H{
-			"user_id":  user.Id,
-			"username": user.Username,
-			"email":    user.Email,
+			"user_id":     user.Id,
+			"username":    user.Username,
+			"email":       user.Email,
+			"login_stage": stage,
 		},
 	})
 }
 
+// startSession 登记服务端会话并写入session，需要两步验证时先登记为待验证会话
+func startSession(c *gin.Context, sessionService *service.SessionService, userId int64, stage string) error {
+	pending := stage != service.LoginStageDone
+	sid, err := sessionService.Create(c.Request.Context(), userId, c.Request.UserAgent(), c.ClientIP(), pending)
+	if err != nil {
+		return err
+	}
+	sess := sessions.Default(c)
+	sess.Set("userId", userId)
+	sess.Set("sid", sid)
+	return sess.Save()
+}
+
+func loginStageMessage(stage string) string {
+	switch stage {
+	case service.LoginStageTwoFactor:
+		return "请输入两步验证码"
+	case service.LoginStageTwoFactorSetup:
+		return "站点要求开启两步验证，请先完成绑定"
+	default:
+		return "登录成功"
+	}
+}
+
 func (h *UserHandler) Logout(c *gin.Context) {
-	// 清除session
-	c.Set("user_id", nil)
-	c.Set("username", nil)
+	// 注销服务端会话并清除session，访问令牌客户端通过 /api/auth/revoke 注销
+	if principal, ok := middleware.GetPrincipal(c); ok && principal.SessionId != "" {
+		if err := h.sessionService.RevokeCurrent(c.Request.Context(), principal.SessionId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "登出失败: " + err.Error(),
+			})
+			return
+		}
+	}
+	sess := sessions.Default(c)
+	sess.Clear()
+	sess.Options(sessions.Options{MaxAge: -1})
+	_ = sess.Save()
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -143,9 +233,9 @@ func (h *UserHandler) Logout(c *gin.Context) {
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	// 从session获取用户ID
-	userIDInterface, exists := c.Get("user_id")
-	if !exists {
+	// 从请求主体获取用户ID
+	userID, ok := middleware.CurrentUserId(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    401,
 			"message": "请先登录",
@@ -153,15 +243,6 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	userID, ok := userIDInterface.(int64)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "用户ID类型错误",
-		})
-		return
-	}
-
 	profile, err := h.userService.GetProfile(c.Request.Context(), userID)
 	if err != nil {
 		var message string
@@ -186,9 +267,9 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 }
 
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	// 从session获取用户ID
-	userIDInterface, exists := c.Get("user_id")
-	if !exists {
+	// 从请求主体获取用户ID
+	userID, ok := middleware.CurrentUserId(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"code":    401,
 			"message": "请先登录",
@@ -196,15 +277,6 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	userID, ok := userIDInterface.(int64)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "用户ID类型错误",
-		})
-		return
-	}
-
 	var req ProfileUpdateReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -245,6 +317,309 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	})
 }
 
+func (h *UserHandler) SendVerificationEmail(c *gin.Context) {
+	userID, ok := middleware.CurrentUserId(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "请先登录",
+		})
+		return
+	}
+
+	err := h.userService.SendVerificationEmail(c.Request.Context(), userID)
+	if err != nil {
+		var message string
+		switch err {
+		case service.ErrEmailAlreadyVerified:
+			message = "邮箱已验证，无需重复验证"
+		case service.ErrUserNotFound:
+			message = "用户不存在"
+		default:
+			message = "发送验证邮件失败: " + err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "验证邮件已发送",
+	})
+}
+
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		h.tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "邮箱验证成功",
+	})
+}
+
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "发送重置邮件失败，请稍后重试",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "如果该邮箱已注册，您将收到一封重置密码的邮件",
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		h.tokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "密码重置成功，请重新登录",
+	})
+}
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, sid, ok := h.currentSession(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	err := h.userService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword, sid)
+	if err != nil {
+		h.accountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "密码修改成功，其他设备已退出登录",
+	})
+}
+
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	userID, _, ok := h.currentSession(c)
+	if !ok {
+		return
+	}
+
+	var req ChangeEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.userService.ChangeEmail(c.Request.Context(), userID, req.Password, req.NewEmail); err != nil {
+		h.accountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "邮箱修改成功，请查收验证邮件完成验证",
+	})
+}
+
+// ListSessions 查看当前用户已登录的设备
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, sid, ok := h.currentSession(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.sessionService.List(c.Request.Context(), userID, sid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取会话列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "获取成功",
+		"data":    list,
+	})
+}
+
+// RevokeSession 注销指定设备上的登录
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, _, ok := h.currentSession(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的会话ID",
+		})
+		return
+	}
+
+	err = h.sessionService.Revoke(c.Request.Context(), userID, sessionID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "已注销该设备的登录",
+		})
+	case service.ErrSessionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "会话不存在",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "注销失败: " + err.Error(),
+		})
+	}
+}
+
+// RevokeOtherSessions 注销除当前设备外的全部登录
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, sid, ok := h.currentSession(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sessionService.RevokeOthers(c.Request.Context(), userID, sid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "注销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "其他设备已全部退出登录",
+	})
+}
+
+// currentSession 从请求主体中取出用户ID和会话ID，未登录时直接写入401响应；
+// 使用访问令牌时会话ID为空
+func (h *UserHandler) currentSession(c *gin.Context) (int64, string, bool) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "请先登录",
+		})
+		return 0, "", false
+	}
+	return principal.UserId, principal.SessionId, true
+}
+
+// accountError 修改密码、邮箱相关错误响应
+func (h *UserHandler) accountError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrWrongPassword:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "当前密码错误",
+		})
+	case service.ErrSameEmail:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "新邮箱与当前邮箱相同",
+		})
+	case service.ErrUserDuplicateEmail:
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    409,
+			"message": "邮箱已被使用",
+		})
+	case service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404,
+			"message": "用户不存在",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "操作失败: " + err.Error(),
+		})
+	}
+}
+
+// tokenError 邮件链接令牌相关错误响应
+func (h *UserHandler) tokenError(c *gin.Context, err error) {
+	var message string
+	switch err {
+	case service.ErrTokenInvalid:
+		message = "链接无效"
+	case service.ErrTokenExpired:
+		message = "链接已过期，请重新获取"
+	case service.ErrTokenUsed:
+		message = "链接已被使用"
+	case service.ErrUserNotFound:
+		message = "用户不存在"
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "操作失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":    400,
+		"message": message,
+	})
+}
+
 // 管理后台相关方法
 func (h *UserHandler) GetUserStats(c *gin.Context) {
 	// 获取用户统计信息
@@ -0,0 +1,224 @@
+/*
+ * @Author: Aii如樱如月 morikawa@kimisui56.work
+ * @Date: 2026-10-19 19:00:00
+ * @Description: webhook 管理接口
+ */
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"negaihoshi/server/src/domain"
+	"negaihoshi/server/src/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	svc *service.WebhookService
+}
+
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		svc: svc,
+	}
+}
+
+// RegisterWebhookRoutes 路由位于 /api/admin/ 下，只有管理员可以访问
+func (h *WebhookHandler) RegisterWebhookRoutes(server *gin.Engine) {
+	wg := server.Group("/api/admin/webhooks")
+	wg.GET("", h.List)
+	wg.POST("", h.Create)
+	wg.GET("/:id", h.Get)
+	wg.PUT("/:id", h.Update)
+	wg.DELETE("/:id", h.Delete)
+	wg.POST("/:id/rotate-secret", h.RotateSecret)
+	wg.GET("/:id/deliveries", h.ListDeliveries)
+	wg.POST("/:id/ping", h.Ping)
+	wg.POST("/deliveries/:deliveryId/redeliver", h.Redeliver)
+}
+
+type webhookReq struct {
+	Name    string   `json:"name" binding:"required,max=64"`
+	URL     string   `json:"url" binding:"required,max=512"`
+	Events  []string `json:"events" binding:"required,min=1"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func (r webhookReq) toDomain() domain.Webhook {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return domain.Webhook{
+		Name:    r.Name,
+		URL:     r.URL,
+		Events:  r.Events,
+		Enabled: enabled,
+	}
+}
+
+func (h *WebhookHandler) List(ctx *gin.Context) {
+	hooks, err := h.svc.List(ctx)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"webhooks": hooks,
+		"events":   domain.AllWebhookEvents,
+	})
+}
+
+// Create 创建 webhook，签名密钥只在创建和重置时返回
+func (h *WebhookHandler) Create(ctx *gin.Context) {
+	var req webhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	hook, err := h.svc.Create(ctx, req.toDomain())
+	if err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"webhook": hook}, "创建成功，请妥善保存签名密钥")
+}
+
+func (h *WebhookHandler) Get(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	hook, err := h.svc.Get(ctx, id)
+	if err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"webhook": hook})
+}
+
+func (h *WebhookHandler) Update(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req webhookReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ValidationError(ctx, "请求参数错误")
+		return
+	}
+
+	hook := req.toDomain()
+	hook.Id = id
+	if err := h.svc.Update(ctx, hook); err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "已更新")
+}
+
+func (h *WebhookHandler) Delete(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(ctx, id); err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, nil, "已删除")
+}
+
+func (h *WebhookHandler) RotateSecret(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	secret, err := h.svc.RotateSecret(ctx, id)
+	if err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"secret": secret}, "签名密钥已重置")
+}
+
+func (h *WebhookHandler) ListDeliveries(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+
+	deliveries, total, err := h.svc.ListDeliveries(ctx, id, page, size)
+	if err != nil {
+		SystemError(ctx)
+		return
+	}
+	SuccessResponse(ctx, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"size":       size,
+	})
+}
+
+// Ping 同步发送测试事件，返回本次投递结果
+func (h *WebhookHandler) Ping(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.Ping(ctx, id)
+	if err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"delivery": delivery})
+}
+
+func (h *WebhookHandler) Redeliver(ctx *gin.Context) {
+	id, ok := parseIdParam(ctx, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.Redeliver(ctx, id)
+	if err != nil {
+		h.webhookError(ctx, err)
+		return
+	}
+	SuccessResponse(ctx, gin.H{"delivery": delivery}, "已加入投递队列")
+}
+
+func (h *WebhookHandler) webhookError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		NotFoundError(ctx, "webhook")
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		NotFoundError(ctx, "投递记录")
+	case errors.Is(err, service.ErrWebhookInvalidURL), errors.Is(err, service.ErrWebhookInvalidEvent),
+		errors.Is(err, service.ErrWebhookBlockedAddress):
+		ErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		SystemError(ctx)
+	}
+}
+
+// parseIdParam 解析路径中的数字 ID，格式错误时直接返回参数错误
+func parseIdParam(ctx *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		ValidationError(ctx, "ID格式错误")
+		return 0, false
+	}
+	return id, true
+}
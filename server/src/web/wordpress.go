@@ -6,21 +6,26 @@
 package web
 
 import (
+	"errors"
 	"negaihoshi/server/src/service"
+	"negaihoshi/server/src/web/middleware"
 	"strconv"
 
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
 type WordPressHandler struct {
 	userSvc *service.UserService
 	// 后续可以添加专门的WordPress服务
+	notifications *service.NotificationService
+	crossPoster   *service.WordPressCrossPoster
 }
 
-func NewWordPressHandler(userSvc *service.UserService) *WordPressHandler {
+func NewWordPressHandler(userSvc *service.UserService, notifications *service.NotificationService, crossPoster *service.WordPressCrossPoster) *WordPressHandler {
 	return &WordPressHandler{
-		userSvc: userSvc,
+		userSvc:       userSvc,
+		notifications: notifications,
+		crossPoster:   crossPoster,
 	}
 }
 
@@ -48,15 +53,12 @@ func (w *WordPressHandler) BindWordPressSite(ctx *gin.Context) {
 		return
 	}
 
-	sess := sessions.Default(ctx)
-	userIdInterface := sess.Get("userId")
-	if userIdInterface == nil {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
 		UnauthorizedError(ctx)
 		return
 	}
 
-	userId := userIdInterface.(int64)
-
 	// 这里应该调用service层来保存WordPress绑定信息
 	// 暂时简化处理
 	SuccessResponse(ctx, map[string]interface{}{
@@ -68,9 +70,7 @@ func (w *WordPressHandler) BindWordPressSite(ctx *gin.Context) {
 
 // 获取已绑定的站点
 func (w *WordPressHandler) GetBindSites(ctx *gin.Context) {
-	sess := sessions.Default(ctx)
-	userIdInterface := sess.Get("userId")
-	if userIdInterface == nil {
+	if _, ok := middleware.CurrentUserId(ctx); !ok {
 		UnauthorizedError(ctx)
 		return
 	}
@@ -101,9 +101,7 @@ func (w *WordPressHandler) UnbindSite(ctx *gin.Context) {
 		return
 	}
 
-	sess := sessions.Default(ctx)
-	userIdInterface := sess.Get("userId")
-	if userIdInterface == nil {
+	if _, ok := middleware.CurrentUserId(ctx); !ok {
 		UnauthorizedError(ctx)
 		return
 	}
@@ -132,32 +130,40 @@ func (w *WordPressHandler) TransferContent(ctx *gin.Context) {
 		return
 	}
 
-	sess := sessions.Default(ctx)
-	userIdInterface := sess.Get("userId")
-	if userIdInterface == nil {
+	userId, ok := middleware.CurrentUserId(ctx)
+	if !ok {
 		UnauthorizedError(ctx)
 		return
 	}
 
-	// 这里应该：
-	// 1. 获取原始内容
-	// 2. 验证用户权限
-	// 3. 遍历目标站点进行转发
-	// 4. 记录转发结果
+	// 私密转发时 WordPress 上的内容只有站点作者可见，文章本身为私密或不公开时总是私密转发
+	results, err := w.crossPoster.Transfer(ctx, userId, req.ContentType, req.ContentID, req.SiteIDs, req.Title, req.AsPrivate)
+	switch {
+	case errors.Is(err, service.ErrContentNotFound):
+		NotFoundError(ctx, "内容")
+		return
+	case errors.Is(err, service.ErrContentNotOwner):
+		ForbiddenError(ctx)
+		return
+	case errors.Is(err, service.ErrPostNotPublished):
+		ValidationError(ctx, err.Error())
+		return
+	case err != nil:
+		SystemError(ctx)
+		return
+	}
+	transferred := 0
+	for _, result := range results {
+		if result.Success {
+			transferred++
+		}
+	}
 
-	// 暂时返回成功响应
 	SuccessResponse(ctx, map[string]interface{}{
 		"message":        "内容转发成功",
 		"content_id":     req.ContentID,
 		"content_type":   req.ContentType,
-		"transferred_to": len(req.SiteIDs),
-		"results": []map[string]interface{}{
-			{
-				"site_id":     req.SiteIDs[0],
-				"success":     true,
-				"wp_post_id":  123,
-				"wp_post_url": "https://example.com/post/123",
-			},
-		},
+		"transferred_to": transferred,
+		"results":        results,
 	})
 }